@@ -13,6 +13,7 @@ import (
 	"github.com/ByteMirror/hivemind/log"
 	"github.com/ByteMirror/hivemind/memory"
 	"github.com/ByteMirror/hivemind/session"
+	"github.com/ByteMirror/hivemind/session/approval"
 	"github.com/ByteMirror/hivemind/ui"
 	"github.com/ByteMirror/hivemind/ui/overlay"
 
@@ -86,6 +87,16 @@ const (
 	stateNewAutomation
 	// stateMemoryBrowser is the state when the memory file browser is open.
 	stateMemoryBrowser
+	// stateWorkflows is the state when the workflow DAG view is open.
+	stateWorkflows
+	// stateWorkflowPicker is the state when the user is choosing a workflow definition to run.
+	stateWorkflowPicker
+	// stateWorkflowParam is the state when the user is entering a workflow parameter.
+	stateWorkflowParam
+	// stateInbox is the state when the needs-attention queue is open.
+	stateInbox
+	// stateInboxAnswer is the state when the user is typing an answer to a question.
+	stateInboxAnswer
 )
 
 type home struct {
@@ -180,6 +191,22 @@ type home struct {
 	settingsOverlay *overlay.SettingsOverlay
 	// memoryBrowser is the memory file browser screen.
 	memoryBrowser *ui.MemoryBrowser
+	// workflowView is the workflow DAG screen.
+	workflowView *ui.WorkflowView
+	// workflowDefs maps picker labels to workflow definitions while the picker is open.
+	workflowDefs map[string]workflowDefEntry
+	// pendingWorkflowRun collects parameters for the workflow being started.
+	pendingWorkflowRun *workflowRun
+	// inbox is the needs-attention queue of agent questions and permission prompts.
+	inbox *overlay.Inbox
+	// inboxAnswering is the question being answered in textInputOverlay.
+	inboxAnswering *inboxTarget
+	// permissionNotified records, per instance title, the permission prompt
+	// already announced with a toast.
+	permissionNotified map[string]time.Time
+	// budgetUnpriced records the instances already warned about that their
+	// cost budget leaves out models with no known price.
+	budgetUnpriced map[string]bool
 
 	// Layout dimensions for mouse hit-testing
 	sidebarWidth  int
@@ -214,6 +241,8 @@ type home struct {
 
 	// brainServer is the IPC server for coordinating brain state between MCP agents
 	brainServer *brain.Server
+	// approvals decides permission prompts with the auto-approve rules.
+	approvals *approval.Engine
 }
 
 func newHome(ctx context.Context, program string, autoYes bool) *home {
@@ -234,6 +263,7 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		}
 		session.SetMemoryManager(memMgr, injectCount, sysBudget)
 		session.SetMemoryFactory(buildRepoMemoryFactory(appConfig))
+		startMemoryReindex(memMgr)
 		if stop, err := memMgr.StartWatcher(); err != nil {
 			log.WarningLog.Printf("memory watcher: %v", err)
 		} else {
@@ -329,12 +359,16 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 	// Start brain IPC server for multi-agent coordination
 	configDir, err := config.GetConfigDir()
 	if err == nil {
+		h.approvals = approval.NewEngine(configDir)
 		socketPath := filepath.Join(configDir, "hivemind.sock")
 		h.brainServer = brain.NewServer(socketPath)
 		if err := h.brainServer.Start(); err != nil {
 			log.WarningLog.Printf("failed to start brain server: %v", err)
 			h.brainServer = nil
+		} else {
+			session.SetTokenIssuer(h.brainServer)
 		}
+		h.syncBrainInstances()
 	}
 
 	return h
@@ -403,6 +437,9 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	if m.memoryBrowser != nil {
 		m.memoryBrowser.SetSize(msg.Width, m.contentHeight)
 	}
+	if m.workflowView != nil {
+		m.workflowView.SetSize(msg.Width, m.contentHeight)
+	}
 
 	previewWidth, previewHeight := m.tabbedWindow.GetPreviewSize()
 	if err := m.list.SetSessionPreviewSize(previewWidth, previewHeight); err != nil {
@@ -428,6 +465,7 @@ func (m *home) Init() tea.Cmd {
 	// Start polling the brain server's action channel for Tier 3 requests.
 	if m.brainServer != nil {
 		cmds = append(cmds, m.pollBrainActions())
+		m.notifyPendingQuestions()
 	}
 
 	return tea.Batch(cmds...)
@@ -527,13 +565,20 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case brainActionMsg:
 		return m.handleBrainAction(msg.action)
+	case workflowActionDoneMsg:
+		return m.handleWorkflowActionDone(msg)
 	case tickUpdateMetadataMessage:
+		m.syncBrainInstances()
+		m.refreshSidebarLeases()
+		m.refreshWorkflowView()
+		m.refreshInbox()
 		if m.metadataFetching {
 			return m, nil // previous tick still running, skip
 		}
 		m.metadataFetching = true
 		instances := m.list.GetInstances()
 		brainSrv := m.brainServer
+		approvals := m.approvals
 		return m, func() tea.Msg {
 			for _, instance := range instances {
 				if !instance.Started() || instance.Paused() || instance.Status == session.Loading {
@@ -549,16 +594,16 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					continue
 				}
 
+				instance.UpdateUsage()
 				if updated {
 					instance.SetStatus(session.Running)
-					// Parse activity from pane content.
-					if content, err := instance.GetPaneContent(); err == nil && content != "" {
-						instance.LastActivity = session.ParseActivity(content, instance.Program)
-					}
+					instance.UpdateActivity()
 				} else {
 					if prompt {
 						instance.PromptDetected = true
-						instance.TapEnter()
+						if decision, isNew := instance.ApplyApprovalRules(approvals); decision == approval.Ask && isNew {
+							pushPermissionEvent(brainSrv, instance)
+						}
 					} else {
 						instance.SetStatus(session.Ready)
 					}
@@ -574,11 +619,19 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				instance.UpdateResourceUsage()
 			}
+			updateFileOverlaps(brainSrv, instances)
 			return metadataFetchedMsg{}
 		}
 	case metadataFetchedMsg:
 		m.metadataFetching = false
 		m.updateSidebarItems()
+		paused, budgetNotified := m.enforceBudgets()
+		if m.notifyPermissionRequests() || budgetNotified {
+			if paused {
+				return m, tea.Batch(tickUpdateMetadataCmd, m.toastTickCmd(), m.instanceChanged())
+			}
+			return m, tea.Batch(tickUpdateMetadataCmd, m.toastTickCmd())
+		}
 		return m, tickUpdateMetadataCmd
 	case automationCheckMsg:
 		cmds := m.checkDueAutomations()
@@ -610,6 +663,7 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Instance started successfully — add to master list, save and finalize
 		m.allInstances = append(m.allInstances, msg.instance)
+		m.syncBrainInstances()
 		if err := m.saveAllInstances(); err != nil {
 			return m, m.handleError(err)
 		}
@@ -624,6 +678,7 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case brainInstanceStartedMsg:
 		// Brain-spawned instance started — add to master list with its own finalizer.
 		m.allInstances = append(m.allInstances, msg.instance)
+		m.syncBrainInstances()
 		if err := m.saveAllInstances(); err != nil {
 			return m, m.handleError(err)
 		}
@@ -754,6 +809,15 @@ func (m *home) View() string {
 		result = overlay.PlaceOverlay(0, 0, m.commandPalette.Render(), mainView, true, true)
 	case m.state == stateSettings && m.settingsOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.settingsOverlay.Render(), mainView, true, true)
+	case m.state == stateWorkflowPicker && m.pickerOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
+	case m.state == stateWorkflowParam && m.textInputOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.textInputOverlay.Render(), mainView, true, true)
+	case m.state == stateInbox && m.inbox != nil:
+		result = overlay.PlaceOverlay(0, 0, m.inbox.Render(), mainView, true, true)
+	case m.state == stateInboxAnswer && m.inbox != nil && m.textInputOverlay != nil:
+		inboxView := overlay.PlaceOverlay(0, 0, m.inbox.Render(), mainView, true, true)
+		result = overlay.PlaceOverlay(0, 0, m.textInputOverlay.Render(), inboxView, true, true)
 	case m.state == stateSkillPicker && m.pickerOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
 	case m.state == stateAutomations || m.state == stateNewAutomation:
@@ -774,6 +838,8 @@ func (m *home) View() string {
 		result = overlay.PlaceOverlay(0, 0, autoView, mainView, true, true)
 	case m.state == stateMemoryBrowser && m.memoryBrowser != nil:
 		result = m.memoryBrowser.Render()
+	case m.state == stateWorkflows && m.workflowView != nil:
+		result = m.workflowView.Render()
 	case m.state == stateContextMenu && m.contextMenu != nil:
 		cx, cy := m.contextMenu.GetPosition()
 		result = overlay.PlaceOverlay(cx, cy, m.contextMenu.Render(), mainView, true, false)
@@ -988,6 +1054,24 @@ func pushStatusEvent(brainSrv *brain.Server, instance *session.Instance, prevSta
 	})
 }
 
+// pushPermissionEvent emits an EventPermissionRequested for the instance's
+// pending permission prompt. No-op if brainSrv is nil.
+func pushPermissionEvent(brainSrv *brain.Server, instance *session.Instance) {
+	if brainSrv == nil {
+		return
+	}
+	brainSrv.PushEvent(brain.Event{
+		Type:     brain.EventPermissionRequested,
+		Source:   instance.Title,
+		RepoPath: instance.GetRepoPath(),
+		Data: map[string]any{
+			"prompt":       instance.PermissionPrompt,
+			"program":      instance.Program,
+			"parent_title": instance.ParentTitle,
+		},
+	})
+}
+
 // asyncUpdatePreview handles preview content fetching. Cheap instance states
 // (nil, Loading, Paused) are handled synchronously on the main thread.
 // Running instances spawn a background goroutine for the expensive tmux capture-pane
@@ -33,6 +33,8 @@ func (m *home) handleBrainAction(action brain.ActionRequest) (tea.Model, tea.Cmd
 		return m.handleActionResumeInstance(action)
 	case brain.ActionKillInstance:
 		return m.handleActionKillInstance(action)
+	case brain.ActionAskUser:
+		return m.handleActionAskUser(action)
 	default:
 		action.ResponseCh <- brain.ActionResponse{
 			Error: fmt.Sprintf("unknown action type: %s", action.Type),
@@ -142,6 +144,7 @@ func (m *home) handleActionCreateInstance(action brain.ActionRequest) (tea.Model
 			Data: map[string]any{
 				"title":  instance.Title,
 				"status": "created",
+				"branch": instance.Branch,
 			},
 		}
 
@@ -163,6 +166,70 @@ func (m *home) handleActionCreateInstance(action brain.ActionRequest) (tea.Model
 	return m, tea.Batch(startCmd, m.pollBrainActions())
 }
 
+// syncBrainInstances tells the brain server which instances exist, with the
+// roles and parents its permission policy is enforced against.
+func (m *home) syncBrainInstances() {
+	if m.brainServer == nil {
+		return
+	}
+	m.brainServer.SetInstances(brainInstanceInfos(m.allInstances))
+}
+
+// brainInstanceInfos describes instances for the brain server's policy checks.
+func brainInstanceInfos(instances []*session.Instance) []brain.InstanceInfo {
+	infos := make([]brain.InstanceInfo, 0, len(instances))
+	for _, inst := range instances {
+		infos = append(infos, brain.InstanceInfo{
+			Title:       inst.Title,
+			RepoPath:    inst.GetRepoPath(),
+			Role:        inst.Role,
+			ParentTitle: inst.ParentTitle,
+		})
+	}
+	return infos
+}
+
+// updateFileOverlaps compares the changed files of running instances in each
+// repo, marks instances whose changes overlap, and records the overlaps in the
+// brain so agents are told about them. It runs on the metadata goroutine after
+// diff stats have been refreshed.
+func updateFileOverlaps(brainSrv *brain.Server, instances []*session.Instance) {
+	changes := make(map[string][]brain.ChangeSet)
+	for _, inst := range instances {
+		inst.OverlapsWith = nil
+		repo := inst.GetRepoPath()
+		if repo == "" {
+			continue
+		}
+		if inst.Paused() {
+			// Keep the repo so overlaps involving the paused instance are cleared.
+			changes[repo] = changes[repo]
+			continue
+		}
+		cs := brain.ChangeSet{Instance: inst.Title, Worktree: inst.GetWorkingPath()}
+		if stats := inst.GetDiffStats(); stats != nil {
+			cs.Files = stats.Files
+		}
+		changes[repo] = append(changes[repo], cs)
+	}
+
+	byTitle := make(map[string]*session.Instance, len(instances))
+	for _, inst := range instances {
+		byTitle[inst.Title] = inst
+	}
+	for repo, sets := range changes {
+		overlaps := brain.DetectFileOverlaps(sets)
+		for _, o := range overlaps {
+			a, b := byTitle[o.Instances[0]], byTitle[o.Instances[1]]
+			a.OverlapsWith = append(a.OverlapsWith, b.Title)
+			b.OverlapsWith = append(b.OverlapsWith, a.Title)
+		}
+		if brainSrv != nil {
+			brainSrv.Manager().SetFileOverlaps(repo, overlaps)
+		}
+	}
+}
+
 // findInstanceByTitle returns the instance with the given title, or nil.
 func (m *home) findInstanceByTitle(title string) *session.Instance {
 	for _, inst := range m.allInstances {
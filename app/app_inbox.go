@@ -0,0 +1,272 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ByteMirror/hivemind/brain"
+	"github.com/ByteMirror/hivemind/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inboxTarget identifies the question being answered in the free-form answer box.
+type inboxTarget struct {
+	repoPath string
+	id       string
+}
+
+// inboxItemID encodes a question's repo and ID as an inbox item ID.
+func inboxItemID(repoPath, id string) string {
+	return repoPath + "\x00" + id
+}
+
+// parseInboxItemID reverses inboxItemID.
+func parseInboxItemID(itemID string) inboxTarget {
+	repoPath, id, _ := strings.Cut(itemID, "\x00")
+	return inboxTarget{repoPath: repoPath, id: id}
+}
+
+// inboxRepoPaths returns every repo that can have questions: the active repos
+// and the repos of all known instances.
+func (m *home) inboxRepoPaths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(rp string) {
+		if rp != "" && !seen[rp] {
+			seen[rp] = true
+			paths = append(paths, rp)
+		}
+	}
+	for _, rp := range m.activeRepoPaths {
+		add(rp)
+	}
+	for _, inst := range m.allInstances {
+		add(inst.GetRepoPath())
+	}
+	return paths
+}
+
+// inboxItems collects pending permission prompts and unanswered questions
+// across repos, oldest first.
+func (m *home) inboxItems() []overlay.InboxItem {
+	var items []overlay.InboxItem
+	for _, inst := range m.allInstances {
+		if inst.PermissionPrompt == "" {
+			continue
+		}
+		items = append(items, overlay.InboxItem{
+			ID:    inst.Title,
+			Kind:  overlay.InboxPermission,
+			From:  inst.Title,
+			Text:  inst.PermissionPrompt,
+			Since: inst.PermissionPromptAt,
+		})
+	}
+	if m.brainServer == nil {
+		return items
+	}
+	mgr := m.brainServer.Manager()
+	for _, rp := range m.inboxRepoPaths() {
+		for _, q := range mgr.PendingQuestions(rp) {
+			items = append(items, overlay.InboxItem{
+				ID:      inboxItemID(rp, q.ID),
+				From:    q.From,
+				Text:    q.Text,
+				Choices: q.Choices,
+				Since:   q.AskedAt,
+			})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Since.Before(items[j].Since) })
+	return items
+}
+
+// openInbox opens the queue of questions and permission prompts agents are
+// waiting on.
+func (m *home) openInbox() (tea.Model, tea.Cmd) {
+	items := m.inboxItems()
+	if len(items) == 0 {
+		m.toastManager.Info("Nothing needs attention.")
+		return m, m.toastTickCmd()
+	}
+	m.inbox = overlay.NewInbox(items)
+	m.inbox.SetWidth(min(90, max(50, m.width*3/5)))
+	m.state = stateInbox
+	return m, nil
+}
+
+// refreshInbox reloads pending questions while the inbox is open.
+func (m *home) refreshInbox() {
+	if m.state != stateInbox || m.inbox == nil {
+		return
+	}
+	m.inbox.SetItems(m.inboxItems())
+}
+
+func (m *home) closeInbox() {
+	m.inbox = nil
+	m.inboxAnswering = nil
+	m.state = stateDefault
+}
+
+func (m *home) handleInboxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.inbox == nil {
+		m.state = stateDefault
+		return m, nil
+	}
+	switch m.inbox.HandleKeyPress(msg) {
+	case overlay.InboxClose:
+		m.closeInbox()
+		return m, tea.WindowSize()
+	case overlay.InboxAnswer:
+		return m.answerQuestion(parseInboxItemID(m.inbox.Selected().ID), m.inbox.Answer())
+	case overlay.InboxApprove:
+		return m.answerPermission(m.inbox.Selected().ID, true)
+	case overlay.InboxDeny:
+		return m.answerPermission(m.inbox.Selected().ID, false)
+	case overlay.InboxCompose:
+		sel := m.inbox.Selected()
+		target := parseInboxItemID(sel.ID)
+		m.inboxAnswering = &target
+		m.textInputOverlay = overlay.NewTextInputOverlay(fmt.Sprintf("Answer %s: %s", sel.From, firstInboxLine(sel.Text)), "")
+		m.textInputOverlay.SetSize(60, 5)
+		m.state = stateInboxAnswer
+	}
+	return m, nil
+}
+
+func (m *home) handleInboxAnswerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.textInputOverlay == nil || m.inboxAnswering == nil {
+		m.closeInbox()
+		return m, nil
+	}
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	submitted := m.textInputOverlay.IsSubmitted()
+	value := strings.TrimSpace(m.textInputOverlay.GetValue())
+	target := *m.inboxAnswering
+	m.textInputOverlay = nil
+	m.inboxAnswering = nil
+	m.state = stateInbox
+	if !submitted || value == "" {
+		return m, nil
+	}
+	return m.answerQuestion(target, value)
+}
+
+// answerQuestion delivers the user's answer to the waiting agent.
+func (m *home) answerQuestion(target inboxTarget, answer string) (tea.Model, tea.Cmd) {
+	if err := m.brainServer.Manager().AnswerQuestion(target.repoPath, target.id, answer); err != nil {
+		m.toastManager.Error("Answer failed: " + err.Error())
+		m.refreshInbox()
+		return m, m.toastTickCmd()
+	}
+	m.toastManager.Success("Answer sent.")
+	return m.afterInboxAnswer()
+}
+
+// answerPermission approves or denies the permission prompt of the instance
+// with the given title.
+func (m *home) answerPermission(title string, approve bool) (tea.Model, tea.Cmd) {
+	inst := m.findInstanceByTitle(title)
+	if inst == nil {
+		m.refreshInbox()
+		return m, nil
+	}
+	if err := inst.AnswerPermission(approve); err != nil {
+		m.toastManager.Error("Permission answer failed: " + err.Error())
+		m.refreshInbox()
+		return m, m.toastTickCmd()
+	}
+	if approve {
+		m.toastManager.Success(fmt.Sprintf("Approved %s.", title))
+	} else {
+		m.toastManager.Info(fmt.Sprintf("Denied %s.", title))
+	}
+	return m.afterInboxAnswer()
+}
+
+// afterInboxAnswer refreshes the queue and closes it once nothing else is
+// pending.
+func (m *home) afterInboxAnswer() (tea.Model, tea.Cmd) {
+	m.refreshInbox()
+	if m.inbox != nil && m.inbox.Len() == 0 {
+		m.closeInbox()
+		return m, tea.Batch(tea.WindowSize(), m.toastTickCmd())
+	}
+	return m, m.toastTickCmd()
+}
+
+// notifyPermissionRequests shows a toast for each permission prompt that
+// appeared since the last metadata tick and reports whether any was shown.
+func (m *home) notifyPermissionRequests() bool {
+	shown := false
+	for _, inst := range m.allInstances {
+		if inst.PermissionPrompt == "" {
+			delete(m.permissionNotified, inst.Title)
+			continue
+		}
+		if m.permissionNotified[inst.Title].Equal(inst.PermissionPromptAt) {
+			continue
+		}
+		if m.permissionNotified == nil {
+			m.permissionNotified = make(map[string]time.Time)
+		}
+		m.permissionNotified[inst.Title] = inst.PermissionPromptAt
+		m.toastManager.Info(fmt.Sprintf("%s needs permission: %s (press I)", inst.Title, firstInboxLine(inst.PermissionPrompt)))
+		shown = true
+	}
+	if shown {
+		m.refreshInbox()
+	}
+	return shown
+}
+
+// handleActionAskUser shows a toast for a question an agent just asked. The
+// server does not wait for this action; the answer is delivered through
+// Manager.AnswerQuestion.
+func (m *home) handleActionAskUser(action brain.ActionRequest) (tea.Model, tea.Cmd) {
+	repoPath, _ := action.Params["repo_path"].(string)
+	id, _ := action.Params["id"].(string)
+	action.ResponseCh <- brain.ActionResponse{OK: true}
+
+	q := m.brainServer.Manager().GetQuestion(repoPath, id)
+	if q == nil || q.Answered() {
+		return m, m.pollBrainActions()
+	}
+	m.toastManager.Info(fmt.Sprintf("%s asks: %s (press I to answer)", q.From, firstInboxLine(q.Text)))
+	m.refreshInbox()
+	return m, tea.Batch(m.pollBrainActions(), m.toastTickCmd())
+}
+
+// notifyPendingQuestions reminds the user of questions left unanswered
+// before a restart.
+func (m *home) notifyPendingQuestions() {
+	n := len(m.inboxItems())
+	if n == 0 {
+		return
+	}
+	noun := "question"
+	if n > 1 {
+		noun = "questions"
+	}
+	m.toastManager.Info(fmt.Sprintf("%d %s from agents waiting in the inbox (press I).", n, noun))
+}
+
+// firstInboxLine shortens a question to its first line for toasts and titles.
+func firstInboxLine(s string) string {
+	line, _, cut := strings.Cut(s, "\n")
+	if r := []rune(line); len(r) > 80 {
+		line = string(r[:77]) + "..."
+		cut = false
+	}
+	if cut {
+		line += "…"
+	}
+	return line
+}
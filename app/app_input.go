@@ -23,7 +23,7 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateNewTopic || m.state == stateNewTopicConfirm || m.state == stateSearch || m.state == stateMoveTo || m.state == stateContextMenu || m.state == statePRTitle || m.state == statePRBody || m.state == stateRenameInstance || m.state == stateRenameTopic || m.state == stateSendPrompt || m.state == stateFocusAgent || m.state == stateRepoSwitch || m.state == stateNewTopicRepo || m.state == stateCommandPalette || m.state == stateSettings || m.state == stateSkillPicker || m.state == stateInlineComment || m.state == stateAutomations || m.state == stateNewAutomation || m.state == stateMemoryBrowser {
+	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateNewTopic || m.state == stateNewTopicConfirm || m.state == stateSearch || m.state == stateMoveTo || m.state == stateContextMenu || m.state == statePRTitle || m.state == statePRBody || m.state == stateRenameInstance || m.state == stateRenameTopic || m.state == stateSendPrompt || m.state == stateFocusAgent || m.state == stateRepoSwitch || m.state == stateNewTopicRepo || m.state == stateCommandPalette || m.state == stateSettings || m.state == stateSkillPicker || m.state == stateInlineComment || m.state == stateAutomations || m.state == stateNewAutomation || m.state == stateMemoryBrowser || m.state == stateWorkflows || m.state == stateWorkflowPicker || m.state == stateWorkflowParam || m.state == stateInbox || m.state == stateInboxAnswer {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -112,6 +112,15 @@ func (m *home) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
+		// Route to workflow view when it is open.
+		if m.state == stateWorkflows && m.workflowView != nil {
+			if msg.Button == tea.MouseButtonWheelUp {
+				m.workflowView.ScrollUp(3)
+			} else {
+				m.workflowView.ScrollDown(3)
+			}
+			return m, nil
+		}
 		// Scroll the instance list when mouse is over it.
 		if msg.X >= m.sidebarWidth && msg.X < m.sidebarWidth+m.listWidth {
 			switch msg.Button {
@@ -379,6 +388,16 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m.handleNewAutomationKeys(msg)
 	case stateMemoryBrowser:
 		return m.handleMemoryBrowserKeys(msg)
+	case stateWorkflows:
+		return m.handleWorkflowViewKeys(msg)
+	case stateWorkflowPicker:
+		return m.handleWorkflowPickerKeys(msg)
+	case stateWorkflowParam:
+		return m.handleWorkflowParamKeys(msg)
+	case stateInbox:
+		return m.handleInboxKeys(msg)
+	case stateInboxAnswer:
+		return m.handleInboxAnswerKeys(msg)
 	default:
 		return m.handleDefaultKeys(msg)
 	}
@@ -1661,6 +1680,8 @@ func (m *home) handleDefaultKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case keys.KeyMemoryBrowser:
 		return m.openMemoryBrowser()
+	case keys.KeyInbox:
+		return m.openInbox()
 	case keys.KeyAutomations:
 		m.state = stateAutomations
 		return m, nil
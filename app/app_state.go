@@ -567,6 +567,43 @@ func (m *home) filterInstancesByTopic() {
 	m.list.SetFilterByRepoAndTopic(topicFilter, selectedRepoPath)
 }
 
+// refreshSidebarLeases lists the file leases held by agents in the selected
+// topic, or in every visible repo when no topic is selected.
+func (m *home) refreshSidebarLeases() {
+	if m.brainServer == nil {
+		return
+	}
+	selectedID := m.sidebar.GetSelectedID()
+	selectedRepoPath := m.sidebar.GetSelectedRepoPath()
+	byTopic := selectedID != ui.SidebarAll && selectedID != ui.SidebarAutomations && !ui.IsUngroupedID(selectedID)
+
+	var holders map[string]bool
+	if byTopic {
+		holders = make(map[string]bool)
+		for _, inst := range m.list.GetInstances() {
+			if inst.TopicName == selectedID && (selectedRepoPath == "" || inst.GetRepoPath() == selectedRepoPath) {
+				holders[inst.Title] = true
+			}
+		}
+	}
+
+	var entries []ui.FileLeaseEntry
+	seen := make(map[string]bool, len(m.activeRepoPaths))
+	for _, rp := range m.activeRepoPaths {
+		if seen[rp] || (selectedRepoPath != "" && rp != selectedRepoPath) {
+			continue
+		}
+		seen[rp] = true
+		for _, l := range m.brainServer.Manager().ListLeases(rp) {
+			if holders != nil && !holders[l.Holder] {
+				continue
+			}
+			entries = append(entries, ui.FileLeaseEntry{Pattern: l.Pattern, Holder: l.Holder, Exclusive: l.Exclusive})
+		}
+	}
+	m.sidebar.SetFileLeases(entries)
+}
+
 // filterSearchWithTopic applies the search query scoped to the currently selected topic.
 func (m *home) filterSearchWithTopic() {
 	query := strings.ToLower(m.sidebar.GetSearchQuery())
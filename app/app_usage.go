@@ -0,0 +1,112 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session"
+	"github.com/ByteMirror/hivemind/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// enforceBudgets pauses the running instances whose usage exceeds the
+// configured budget, and warns about instances whose cost can't be checked
+// against it because a model has no known price. It reports whether any
+// instance was paused and whether a toast was shown.
+func (m *home) enforceBudgets() (paused, notified bool) {
+	if m.appConfig == nil || m.appConfig.Budget == nil {
+		return false, false
+	}
+	budget := m.appConfig.Budget
+	for _, inst := range m.allInstances {
+		if budget.MaxCostUSD > 0 && len(inst.Usage.Unpriced) > 0 && !m.budgetUnpriced[inst.Title] {
+			if m.budgetUnpriced == nil {
+				m.budgetUnpriced = make(map[string]bool)
+			}
+			m.budgetUnpriced[inst.Title] = true
+			log.WarningLog.Printf("cost budget of %s leaves out %s: no known price", inst.Title, strings.Join(inst.Usage.Unpriced, ", "))
+			m.toastManager.Error(fmt.Sprintf("%s uses %s, whose price is unknown; its cost budget can't be fully enforced",
+				inst.Title, strings.Join(inst.Usage.Unpriced, ", ")))
+			notified = true
+		}
+		ok, err := inst.EnforceBudget(budget)
+		if err != nil {
+			log.ErrorLog.Printf("failed to pause %s over budget: %v", inst.Title, err)
+			m.toastManager.Error(fmt.Sprintf("%s is over budget but could not be paused: %v", inst.Title, err))
+			notified = true
+			continue
+		}
+		if !ok {
+			continue
+		}
+		m.removeAgentFromBrain(inst)
+		m.toastManager.Info(fmt.Sprintf("%s paused: over budget at %s (%s tokens)", inst.Title,
+			inst.Usage.CostText(), session.FormatTokens(inst.Usage.Tokens.Total())))
+		paused, notified = true, true
+	}
+	if paused {
+		if err := m.saveAllInstances(); err != nil {
+			log.ErrorLog.Printf("failed to save instances: %v", err)
+		}
+	}
+	return paused, notified
+}
+
+// usageReport rolls up the usage of all instances.
+func (m *home) usageReport() session.UsageReport {
+	data := make([]session.InstanceData, 0, len(m.allInstances))
+	for _, inst := range m.allInstances {
+		data = append(data, session.InstanceData{
+			Title:        inst.Title,
+			Program:      inst.Program,
+			TopicName:    inst.TopicName,
+			AutomationID: inst.AutomationID,
+			Usage:        inst.Usage,
+		})
+	}
+
+	workflows := make(map[string][]string)
+	for _, entry := range m.workflowEntries() {
+		for _, task := range entry.Workflow.Tasks {
+			if task.AssignedTo != "" {
+				workflows[entry.Workflow.ID] = append(workflows[entry.Workflow.ID], task.AssignedTo)
+			}
+		}
+	}
+
+	automations := make(map[string]string, len(m.automations))
+	for _, a := range m.automations {
+		automations[a.ID] = a.Name
+	}
+	return session.NewUsageReport(data, workflows, automations)
+}
+
+// showUsageReport opens the token and cost report.
+func (m *home) showUsageReport() (tea.Model, tea.Cmd) {
+	report := m.usageReport()
+	content := headerStyle.Render("Model Usage") + "\n\n"
+	if report.Total.Tokens.Total() == 0 {
+		content += descStyle.Render("No token usage recorded yet.") + "\n"
+	} else {
+		content += report.String()
+	}
+	if b := m.appConfig.Budget; b != nil {
+		var limits []string
+		if b.MaxCostUSD > 0 {
+			limits = append(limits, session.FormatCost(b.MaxCostUSD))
+		}
+		if b.MaxTokens > 0 {
+			limits = append(limits, session.FormatTokens(b.MaxTokens)+" tokens")
+		}
+		if len(limits) > 0 {
+			content += "\n" + descStyle.Render("Budget per instance: "+strings.Join(limits, ", ")) + "\n"
+		}
+	}
+	content += "\n" + descStyle.Render("Costs are estimates from list prices.")
+
+	m.textOverlay = overlay.NewTextOverlay(content)
+	m.state = stateHelp
+	return m, nil
+}
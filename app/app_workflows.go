@@ -0,0 +1,267 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ByteMirror/hivemind/brain"
+	"github.com/ByteMirror/hivemind/ui"
+	"github.com/ByteMirror/hivemind/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// workflowActionDoneMsg reports the result of starting a workflow, or of a
+// retry or cancel issued from the workflow view.
+type workflowActionDoneMsg struct {
+	text string
+	err  error
+}
+
+// workflowDefEntry is a workflow definition together with the repo it was loaded from.
+type workflowDefEntry struct {
+	repoPath string
+	def      *brain.WorkflowDefinition
+}
+
+// workflowRun tracks a workflow definition while its parameters are entered.
+type workflowRun struct {
+	workflowDefEntry
+	params map[string]string
+	// next is the index of the parameter being prompted for.
+	next int
+}
+
+// workflowEntries collects the workflows of every visible repo.
+func (m *home) workflowEntries() []ui.WorkflowEntry {
+	if m.brainServer == nil {
+		return nil
+	}
+	mgr := m.brainServer.Manager()
+	var entries []ui.WorkflowEntry
+	seen := make(map[string]bool, len(m.activeRepoPaths))
+	for _, rp := range m.activeRepoPaths {
+		if seen[rp] {
+			continue
+		}
+		seen[rp] = true
+		for _, wf := range mgr.ListWorkflows(rp) {
+			entries = append(entries, ui.WorkflowEntry{RepoPath: rp, Workflow: wf})
+		}
+	}
+	return entries
+}
+
+// openWorkflowView opens the full-screen workflow DAG view.
+func (m *home) openWorkflowView() (tea.Model, tea.Cmd) {
+	if m.brainServer == nil {
+		m.toastManager.Info("Workflows require the brain server.")
+		return m, m.toastTickCmd()
+	}
+	view := ui.NewWorkflowView(m.workflowEntries())
+	view.SetSize(m.width, m.contentHeight)
+	m.workflowView = view
+	m.state = stateWorkflows
+	return m, nil
+}
+
+// refreshWorkflowView reloads workflow state while the view is open.
+func (m *home) refreshWorkflowView() {
+	if m.state != stateWorkflows || m.workflowView == nil {
+		return
+	}
+	m.workflowView.SetWorkflows(m.workflowEntries())
+}
+
+func (m *home) closeWorkflowView() {
+	m.workflowView = nil
+	m.state = stateDefault
+}
+
+func (m *home) handleWorkflowViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.workflowView == nil {
+		m.state = stateDefault
+		return m, nil
+	}
+
+	switch m.workflowView.HandleKeyPress(msg) {
+	case ui.WorkflowViewClose:
+		m.closeWorkflowView()
+		return m, tea.WindowSize()
+
+	case ui.WorkflowViewJump:
+		task := m.workflowView.SelectedTask()
+		inst := m.findInstanceByTitle(task.AssignedTo)
+		if inst == nil {
+			m.toastManager.Info(fmt.Sprintf("Instance %q no longer exists", task.AssignedTo))
+			return m, m.toastTickCmd()
+		}
+		m.closeWorkflowView()
+		m.sidebar.SelectFirst()
+		m.filterInstancesByTopic()
+		m.list.RevealInstance(inst)
+		return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
+
+	case ui.WorkflowViewRetry:
+		entry := m.workflowView.SelectedWorkflow()
+		task := m.workflowView.SelectedTask()
+		repoPath, wfID, taskID := entry.RepoPath, entry.Workflow.ID, task.ID
+		srv := m.brainServer
+		// The brain server relays instance creation back through the TUI's
+		// action channel, so it must not be called from the update loop.
+		return m, func() tea.Msg {
+			_, err := srv.RetryWorkflowTask(repoPath, wfID, taskID)
+			return workflowActionDoneMsg{text: fmt.Sprintf("Retrying task %q", taskID), err: err}
+		}
+
+	case ui.WorkflowViewCancel:
+		entry := m.workflowView.SelectedWorkflow()
+		repoPath, wfID := entry.RepoPath, entry.Workflow.ID
+		srv := m.brainServer
+		return m, func() tea.Msg {
+			err := srv.CancelWorkflow(repoPath, wfID)
+			return workflowActionDoneMsg{text: fmt.Sprintf("Cancelled workflow %s", wfID), err: err}
+		}
+	}
+	return m, nil
+}
+
+// handleWorkflowActionDone shows the outcome of a retry or cancel and refreshes the view.
+func (m *home) handleWorkflowActionDone(msg workflowActionDoneMsg) (tea.Model, tea.Cmd) {
+	m.refreshWorkflowView()
+	if msg.err != nil {
+		m.toastManager.Error(msg.err.Error())
+	} else {
+		m.toastManager.Success(msg.text)
+	}
+	return m, m.toastTickCmd()
+}
+
+// openWorkflowPicker lists the workflow definitions of every visible repo.
+func (m *home) openWorkflowPicker() (tea.Model, tea.Cmd) {
+	if m.brainServer == nil {
+		m.toastManager.Info("Workflows require the brain server.")
+		return m, m.toastTickCmd()
+	}
+
+	m.workflowDefs = make(map[string]workflowDefEntry)
+	var labels []string
+	seen := make(map[string]bool, len(m.activeRepoPaths))
+	for _, rp := range m.activeRepoPaths {
+		if seen[rp] {
+			continue
+		}
+		seen[rp] = true
+		defs, err := brain.LoadWorkflowDefinitions(rp)
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		for _, def := range defs {
+			label := def.Name
+			if def.Description != "" {
+				label += " — " + def.Description
+			}
+			if m.isMultiRepoView() {
+				label += " (" + filepath.Base(rp) + ")"
+			}
+			m.workflowDefs[label] = workflowDefEntry{repoPath: rp, def: def}
+			labels = append(labels, label)
+		}
+	}
+	if len(labels) == 0 {
+		m.workflowDefs = nil
+		m.toastManager.Info(fmt.Sprintf("No workflows found. Add YAML or JSON files to %s/.", brain.WorkflowsDir))
+		return m, m.toastTickCmd()
+	}
+
+	m.pickerOverlay = overlay.NewPickerOverlay("Run workflow", labels)
+	m.state = stateWorkflowPicker
+	return m, nil
+}
+
+func (m *home) handleWorkflowPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pickerOverlay == nil {
+		m.state = stateDefault
+		return m, nil
+	}
+	if !m.pickerOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	entry, ok := m.workflowDefs[m.pickerOverlay.Value()]
+	submitted := m.pickerOverlay.IsSubmitted()
+	m.pickerOverlay = nil
+	m.workflowDefs = nil
+	m.state = stateDefault
+	if !submitted || !ok {
+		return m, tea.WindowSize()
+	}
+
+	m.pendingWorkflowRun = &workflowRun{workflowDefEntry: entry, params: make(map[string]string)}
+	return m.promptNextWorkflowParam()
+}
+
+// promptNextWorkflowParam asks for the next parameter of the pending workflow,
+// or starts it once every parameter has a value.
+func (m *home) promptNextWorkflowParam() (tea.Model, tea.Cmd) {
+	run := m.pendingWorkflowRun
+	if run.next >= len(run.def.Params) {
+		m.pendingWorkflowRun = nil
+		m.state = stateDefault
+		return m, tea.Batch(tea.WindowSize(), m.startWorkflowCmd(run))
+	}
+
+	p := run.def.Params[run.next]
+	title := fmt.Sprintf("%s: %s", run.def.Name, p.Name)
+	if p.Description != "" {
+		title += " — " + p.Description
+	}
+	if p.Required {
+		title += " (required)"
+	}
+	m.textInputOverlay = overlay.NewTextInputOverlay(title, p.Default)
+	m.textInputOverlay.SetSize(60, 3)
+	m.state = stateWorkflowParam
+	return m, nil
+}
+
+func (m *home) handleWorkflowParamKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.textInputOverlay == nil || m.pendingWorkflowRun == nil {
+		m.state = stateDefault
+		return m, nil
+	}
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	submitted := m.textInputOverlay.IsSubmitted()
+	value := m.textInputOverlay.GetValue()
+	m.textInputOverlay = nil
+	if !submitted {
+		m.pendingWorkflowRun = nil
+		m.state = stateDefault
+		return m, tea.WindowSize()
+	}
+
+	run := m.pendingWorkflowRun
+	run.params[run.def.Params[run.next].Name] = value
+	run.next++
+	return m.promptNextWorkflowParam()
+}
+
+// startWorkflowCmd instantiates a workflow definition and submits it to the
+// brain server off the update loop.
+func (m *home) startWorkflowCmd(run *workflowRun) tea.Cmd {
+	srv := m.brainServer
+	return func() tea.Msg {
+		tasks, err := run.def.Instantiate(run.params)
+		if err != nil {
+			return workflowActionDoneMsg{err: err}
+		}
+		result, err := srv.StartWorkflow(run.repoPath, "", tasks)
+		if err != nil {
+			return workflowActionDoneMsg{err: err}
+		}
+		return workflowActionDoneMsg{text: fmt.Sprintf("Started workflow %s (%s)", run.def.Name, result.WorkflowID)}
+	}
+}
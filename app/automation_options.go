@@ -5,21 +5,21 @@ import (
 	"strings"
 
 	"github.com/ByteMirror/hivemind/config"
+	"github.com/ByteMirror/hivemind/session/agent"
 	"github.com/ByteMirror/hivemind/ui"
 )
 
-var defaultAutomationAgents = []string{"claude", "codex", "aider", "gemini", "amp"}
-
 func buildAutomationAgentOptions(currentProgram string) []string {
 	seen := make(map[string]struct{})
-	options := make([]string, 0, len(defaultAutomationAgents)+1)
+	agents := agent.Names()
+	options := make([]string, 0, len(agents)+1)
 
 	if current := normalizeProgramCommand(currentProgram); current != "" {
 		options = append(options, current)
 		seen[current] = struct{}{}
 	}
 
-	for _, opt := range defaultAutomationAgents {
+	for _, opt := range agents {
 		if _, ok := seen[opt]; ok {
 			continue
 		}
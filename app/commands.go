@@ -8,6 +8,7 @@ import (
 	"github.com/ByteMirror/hivemind/log"
 	"github.com/ByteMirror/hivemind/memory"
 	"github.com/ByteMirror/hivemind/session"
+	"github.com/ByteMirror/hivemind/session/agent"
 	"github.com/ByteMirror/hivemind/ui"
 	"github.com/ByteMirror/hivemind/ui/overlay"
 
@@ -48,6 +49,10 @@ func (m *home) buildCommandItems() []overlay.CommandItem {
 
 		// System
 		{Label: "Settings", Description: "Configure application settings", Shortcut: "", Category: "System", Action: "cmd_settings"},
+		{Label: "Run Workflow", Description: "Start a workflow from .hivemind/workflows/", Shortcut: "", Category: "System", Action: "cmd_run_workflow", Disabled: m.brainServer == nil},
+		{Label: "Workflows", Description: "View workflow DAGs and manage their tasks", Shortcut: "", Category: "System", Action: "cmd_workflows", Disabled: m.brainServer == nil},
+		{Label: "Needs Attention", Description: "Answer agent questions and approve or deny permission prompts", Shortcut: "I", Category: "System", Action: "cmd_inbox"},
+		{Label: "Usage Report", Description: "Show tokens and cost per instance, topic, workflow and automation", Shortcut: "", Category: "System", Action: "cmd_usage"},
 		{Label: "Memory Browser", Description: "Browse, edit and delete memory files", Shortcut: "M", Category: "System", Action: "cmd_memory_browser"},
 		{Label: "Help", Description: "Show keyboard shortcuts", Shortcut: "?", Category: "System", Action: "cmd_help"},
 	}
@@ -107,6 +112,14 @@ func (m *home) executeCommandPaletteAction(action string) (tea.Model, tea.Cmd) {
 		return m.openSettings()
 	case "cmd_memory_browser":
 		return m.openMemoryBrowser()
+	case "cmd_usage":
+		return m.showUsageReport()
+	case "cmd_workflows":
+		return m.openWorkflowView()
+	case "cmd_inbox":
+		return m.openInbox()
+	case "cmd_run_workflow":
+		return m.openWorkflowPicker()
 	case "cmd_help":
 		return m.showHelpScreen(helpTypeGeneral{}, nil)
 	}
@@ -170,10 +183,10 @@ func buildMemorySettingItems(cfg *config.Config) []overlay.SettingItem {
 		},
 		{
 			Label:       "Search provider",
-			Description: "claude = re-rank via local claude CLI (works with Max), openai/ollama = embeddings",
+			Description: "claude = re-rank via local claude CLI (works with Max), openai/ollama = embeddings, local = offline embeddings",
 			Type:        overlay.SettingPicker,
 			Value:       provider,
-			Options:     []string{"none", "claude", "openai", "ollama"},
+			Options:     []string{"none", "claude", "openai", "ollama", "local"},
 			Key:         "memory.embedding_provider",
 		},
 	}
@@ -267,7 +280,7 @@ func (m *home) openSettings() (tea.Model, tea.Cmd) {
 			Description: "Program to run in new instances",
 			Type:        overlay.SettingPicker,
 			Value:       m.appConfig.DefaultProgram,
-			Options:     []string{"claude", "aider", "gemini", "codex", "amp"},
+			Options:     agent.Names(),
 			Key:         "default_program",
 		},
 		{
@@ -387,6 +400,10 @@ func (m *home) restartMemoryManager() {
 		}
 		return
 	}
+	// The old manager's re-index job would store vectors of the old provider.
+	if old := session.GetMemoryManager(); old != nil {
+		old.StopReindex()
+	}
 	injectCount := 5
 	if m.appConfig.Memory != nil && m.appConfig.Memory.StartupInjectCount > 0 {
 		injectCount = m.appConfig.Memory.StartupInjectCount
@@ -397,6 +414,21 @@ func (m *home) restartMemoryManager() {
 	}
 	session.SetMemoryManager(mgr, injectCount, sysBudget)
 	session.SetMemoryFactory(buildRepoMemoryFactory(m.appConfig))
+	if mgr != nil {
+		startMemoryReindex(mgr)
+	}
+}
+
+// startMemoryReindex re-embeds the memory store in the background when its
+// vectors come from another embedding provider than the configured one.
+func startMemoryReindex(mgr *memory.Manager) {
+	if !mgr.NeedsReindex() {
+		return
+	}
+	if log.InfoLog != nil {
+		log.InfoLog.Printf("memory: re-embedding %d chunks for the new embedding provider", mgr.ReindexProgress().Total)
+	}
+	mgr.StartReindex()
 }
 
 func buildRepoMemoryFactory(cfg *config.Config) func(dir string) (*memory.Manager, error) {
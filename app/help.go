@@ -64,6 +64,7 @@ func (h helpTypeGeneral) toContent() string {
 		keyStyle.Render("R")+descStyle.Render("         - Switch repository"),
 		keyStyle.Render("e")+descStyle.Render("         - Expand/collapse sub-agent tree"),
 		keyStyle.Render("ctrl+p")+descStyle.Render("    - Command palette"),
+		keyStyle.Render("I")+descStyle.Render("         - Needs attention: answer agent questions, approve/deny permissions"),
 		"",
 		headerStyle.Render("\uf07b Topics:"),
 		keyStyle.Render("T")+descStyle.Render("         - Create a new topic"),
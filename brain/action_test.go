@@ -2,7 +2,10 @@ package brain
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServerActionChannel(t *testing.T) {
@@ -29,7 +32,7 @@ func TestServerActionChannel(t *testing.T) {
 		}
 	}()
 
-	client := NewClient(srv.SocketPath())
+	client := newTestClient(t, srv)
 
 	// Test create_instance via socket.
 	result, err := client.CreateInstance("/repo", "agent-1", CreateInstanceParams{
@@ -65,7 +68,7 @@ func TestServerInjectMessageAlsoStoresInBrain(t *testing.T) {
 		}
 	}()
 
-	client := NewClient(srv.SocketPath())
+	client := newTestClient(t, srv)
 
 	// First register agents.
 	client.UpdateStatus("/repo", "agent-1", "work", nil)
@@ -103,7 +106,7 @@ func TestServerDefineWorkflowAndComplete(t *testing.T) {
 		}
 	}()
 
-	client := NewClient(srv.SocketPath())
+	client := newTestClient(t, srv)
 
 	// Define a workflow.
 	tasks := []*WorkflowTask{
@@ -124,7 +127,7 @@ func TestServerDefineWorkflowAndComplete(t *testing.T) {
 	}
 
 	// Get the workflow state.
-	wf, err := client.GetWorkflow("/repo", "architect")
+	wf, err := client.GetWorkflow("/repo", "architect", result.WorkflowID)
 	if err != nil {
 		t.Fatalf("GetWorkflow error: %v", err)
 	}
@@ -132,8 +135,13 @@ func TestServerDefineWorkflowAndComplete(t *testing.T) {
 		t.Fatalf("expected 3 tasks, got %d", len(wf.Tasks))
 	}
 
+	// Only the instance a task runs in may complete it.
+	if _, err := client.CompleteTask("/repo", "agent-1", "", "task-1", "done", "", nil); err == nil {
+		t.Error("expected error completing a task assigned to another instance")
+	}
+
 	// Complete task-1 and task-2.
-	completeResult, err := client.CompleteTask("/repo", "agent-1", "task-1", "done", "")
+	completeResult, err := client.CompleteTask("/repo", "task-1", "", "task-1", "done", "", nil)
 	if err != nil {
 		t.Fatalf("CompleteTask error: %v", err)
 	}
@@ -142,7 +150,7 @@ func TestServerDefineWorkflowAndComplete(t *testing.T) {
 		t.Errorf("expected 0 triggered (task-2 not done), got %d", len(completeResult.Triggered))
 	}
 
-	completeResult, err = client.CompleteTask("/repo", "agent-2", "task-2", "done", "")
+	completeResult, err = client.CompleteTask("/repo", "task-2", "", "task-2", "done", "", nil)
 	if err != nil {
 		t.Fatalf("CompleteTask error: %v", err)
 	}
@@ -155,9 +163,9 @@ func TestServerDefineWorkflowAndComplete(t *testing.T) {
 func TestServerGetWorkflowEmpty(t *testing.T) {
 	srv := startTestServer(t)
 
-	client := NewClient(srv.SocketPath())
+	client := newTestClient(t, srv)
 
-	wf, err := client.GetWorkflow("/repo", "agent-1")
+	wf, err := client.GetWorkflow("/repo", "agent-1", "")
 	if err != nil {
 		t.Fatalf("GetWorkflow error: %v", err)
 	}
@@ -166,10 +174,65 @@ func TestServerGetWorkflowEmpty(t *testing.T) {
 	}
 }
 
+func TestServerListWorkflows(t *testing.T) {
+	srv := startTestServer(t)
+
+	go func() {
+		for action := range srv.Actions() {
+			action.ResponseCh <- ActionResponse{OK: true}
+		}
+	}()
+
+	client := newTestClient(t, srv)
+	first, err := client.DefineWorkflow("/repo", "architect", []*WorkflowTask{{ID: "task-1"}})
+	if err != nil {
+		t.Fatalf("DefineWorkflow error: %v", err)
+	}
+	second, err := client.DefineWorkflow("/repo", "architect", []*WorkflowTask{{ID: "task-1"}})
+	if err != nil {
+		t.Fatalf("DefineWorkflow error: %v", err)
+	}
+
+	workflows, err := client.ListWorkflows("/repo", "architect")
+	if err != nil {
+		t.Fatalf("ListWorkflows error: %v", err)
+	}
+	if len(workflows) != 2 {
+		t.Fatalf("expected 2 workflows, got %d", len(workflows))
+	}
+
+	// task-1 exists in both workflows, so the ID alone is ambiguous.
+	if _, err := client.CompleteTask("/repo", "task-1", "", "task-1", "done", "", nil); err == nil {
+		t.Error("expected error completing ambiguous task without workflow_id")
+	}
+	secondWf, err := client.GetWorkflow("/repo", "architect", second.WorkflowID)
+	if err != nil {
+		t.Fatalf("GetWorkflow error: %v", err)
+	}
+	result, err := client.CompleteTask("/repo", secondWf.Tasks[0].AssignedTo, second.WorkflowID, "task-1", "done", "", nil)
+	if err != nil {
+		t.Fatalf("CompleteTask error: %v", err)
+	}
+	if result.WorkflowID != second.WorkflowID {
+		t.Errorf("expected result for %s, got %s", second.WorkflowID, result.WorkflowID)
+	}
+
+	wf, err := client.GetWorkflow("/repo", "architect", first.WorkflowID)
+	if err != nil {
+		t.Fatalf("GetWorkflow error: %v", err)
+	}
+	if wf.Tasks[0].Status != TaskRunning {
+		t.Errorf("first workflow's task should still be running, got %s", wf.Tasks[0].Status)
+	}
+	if _, err := client.GetWorkflow("/repo", "architect", "wf-missing"); err == nil {
+		t.Error("expected error for unknown workflow_id")
+	}
+}
+
 func TestClientUpdateStatusWithRole(t *testing.T) {
 	srv := startTestServer(t)
 
-	client := NewClient(srv.SocketPath())
+	client := newTestClient(t, srv)
 
 	_, err := client.UpdateStatusWithRole("/repo", "agent-1", "implement auth", []string{"auth.go"}, "coder")
 	if err != nil {
@@ -205,3 +268,255 @@ func TestCreateInstanceResultJSON(t *testing.T) {
 		t.Errorf("Title = %q, want %q", decoded.Title, "test-agent")
 	}
 }
+
+func TestServerKilledInstanceRetriesTask(t *testing.T) {
+	srv := startTestServer(t)
+
+	created := make(chan string, 4)
+	go func() {
+		for action := range srv.Actions() {
+			if action.Type == ActionCreateInstance {
+				title, _ := action.Params["title"].(string)
+				created <- title
+			}
+			action.ResponseCh <- ActionResponse{OK: true}
+		}
+	}()
+
+	client := newTestClient(t, srv)
+	wf, err := client.DefineWorkflow("/repo", "architect", []*WorkflowTask{
+		{ID: "task-1", Title: "work", MaxRetries: 1},
+	})
+	if err != nil {
+		t.Fatalf("DefineWorkflow error: %v", err)
+	}
+	if got := <-created; got != "task-1" {
+		t.Fatalf("expected task-1 to be spawned, got %q", got)
+	}
+
+	// The TUI reports the task's instance was killed.
+	srv.PushEvent(Event{Type: EventInstanceKilled, Source: "task-1", RepoPath: "/repo"})
+
+	select {
+	case got := <-created:
+		if got != "task-1-retry1" {
+			t.Errorf("expected retry instance task-1-retry1, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the killed task to be re-spawned")
+	}
+
+	task := srv.Manager().GetWorkflowTask("/repo", wf.WorkflowID, "task-1")
+	if task.Status != TaskRunning || task.Attempts != 2 {
+		t.Errorf("expected running second attempt, got status=%s attempts=%d", task.Status, task.Attempts)
+	}
+}
+
+func TestServerDefineWorkflowRejectsInvalidPolicy(t *testing.T) {
+	srv := startTestServer(t)
+
+	resp := roundTrip(t, srv, Request{
+		Method:   MethodDefineWorkflow,
+		RepoPath: "/repo",
+		Params: map[string]any{
+			"tasks": []any{
+				map[string]any{"id": "task-1", "on_failure": "explode"},
+			},
+		},
+	})
+	if resp.OK {
+		t.Fatal("expected invalid on_failure to be rejected")
+	}
+
+	resp = roundTrip(t, srv, Request{
+		Method:   MethodDefineWorkflow,
+		RepoPath: "/repo",
+		Params: map[string]any{
+			"tasks": []any{
+				map[string]any{"id": "task-1", "timeout": "soon"},
+			},
+		},
+	})
+	if resp.OK {
+		t.Fatal("expected invalid timeout to be rejected")
+	}
+}
+
+func TestServerDefineWorkflowRejectsCycle(t *testing.T) {
+	srv := startTestServer(t)
+
+	resp := roundTrip(t, srv, Request{
+		Method:   MethodDefineWorkflow,
+		RepoPath: "/repo",
+		Params: map[string]any{
+			"tasks": []any{
+				map[string]any{"id": "build", "depends_on": []any{"test"}},
+				map[string]any{"id": "test", "depends_on": []any{"build"}},
+			},
+		},
+	})
+	if resp.OK {
+		t.Fatal("expected cyclic workflow to be rejected")
+	}
+	if !strings.Contains(resp.Error, "build -> test -> build") {
+		t.Errorf("expected error to name the cycle, got %q", resp.Error)
+	}
+	var verr WorkflowValidationError
+	if err := json.Unmarshal(resp.Data, &verr); err != nil {
+		t.Fatalf("expected validation details in data, got %q: %v", resp.Data, err)
+	}
+	if len(verr.Cycle) != 3 {
+		t.Errorf("expected a cycle of 2 tasks, got %v", verr.Cycle)
+	}
+	if wfs := srv.Manager().ListWorkflows("/repo"); len(wfs) != 0 {
+		t.Errorf("expected no workflows to be stored, got %d", len(wfs))
+	}
+
+	// The client hands the details back as the error.
+	client := newTestClient(t, srv)
+	_, err := client.DefineWorkflow("/repo", "", []*WorkflowTask{
+		{ID: "build"},
+		{ID: "test", DependsOn: []string{"lint"}},
+	})
+	var clientErr *WorkflowValidationError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("expected *WorkflowValidationError, got %v", err)
+	}
+	if deps := clientErr.UnknownDeps["test"]; len(deps) != 1 || deps[0] != "lint" {
+		t.Errorf("expected unknown dependency lint of test, got %v", clientErr.UnknownDeps)
+	}
+}
+
+func TestServerWorkflowPassesOutputsDownstream(t *testing.T) {
+	srv := startTestServer(t)
+
+	prompts := make(chan string, 4)
+	go func() {
+		for action := range srv.Actions() {
+			title, _ := action.Params["title"].(string)
+			prompt, _ := action.Params["prompt"].(string)
+			prompts <- prompt
+			action.ResponseCh <- ActionResponse{
+				OK:   true,
+				Data: map[string]any{"title": title, "status": "created", "branch": "hivemind/" + title},
+			}
+		}
+	}()
+
+	client := newTestClient(t, srv)
+	result, err := client.DefineWorkflow("/repo", "architect", []*WorkflowTask{
+		{ID: "design", Prompt: "design it"},
+		{ID: "impl", DependsOn: []string{"design"}, Prompt: "Implement: {{tasks.design.outputs.summary}}"},
+	})
+	if err != nil {
+		t.Fatalf("DefineWorkflow error: %v", err)
+	}
+	if got := <-prompts; got != "design it" {
+		t.Errorf("design prompt = %q", got)
+	}
+	if got := srv.Manager().GetWorkflowTask("/repo", result.WorkflowID, "design").Branch; got != "hivemind/design" {
+		t.Errorf("design branch = %q, want hivemind/design", got)
+	}
+
+	_, err = client.CompleteTask("/repo", "design", "", "design", "done", "", map[string]any{"summary": "token bucket"})
+	if err != nil {
+		t.Fatalf("CompleteTask error: %v", err)
+	}
+	if got := <-prompts; got != "Implement: token bucket" {
+		t.Errorf("impl prompt = %q, want rendered summary", got)
+	}
+}
+
+func TestServerRetryAndCancelWorkflow(t *testing.T) {
+	srv := startTestServer(t)
+
+	created := make(chan string, 4)
+	killed := make(chan string, 4)
+	go func() {
+		for action := range srv.Actions() {
+			switch action.Type {
+			case ActionCreateInstance:
+				title, _ := action.Params["title"].(string)
+				created <- title
+			case ActionKillInstance:
+				target, _ := action.Params["target"].(string)
+				killed <- target
+			}
+			action.ResponseCh <- ActionResponse{OK: true}
+		}
+	}()
+
+	client := newTestClient(t, srv)
+	wf, err := client.DefineWorkflow("/repo", "architect", []*WorkflowTask{
+		{ID: "build", Title: "build"},
+		{ID: "deploy", Title: "deploy", DependsOn: []string{"build"}},
+	})
+	if err != nil {
+		t.Fatalf("DefineWorkflow error: %v", err)
+	}
+	if got := <-created; got != "build" {
+		t.Fatalf("expected build to be spawned, got %q", got)
+	}
+	if _, err := client.CompleteTask("/repo", "build", wf.WorkflowID, "build", "failed", "compile error", nil); err != nil {
+		t.Fatalf("CompleteTask error: %v", err)
+	}
+
+	result, err := srv.RetryWorkflowTask("/repo", wf.WorkflowID, "build")
+	if err != nil {
+		t.Fatalf("RetryWorkflowTask: %v", err)
+	}
+	if len(result.Triggered) != 1 || result.Triggered[0] != "build" {
+		t.Errorf("expected build triggered, got %v", result.Triggered)
+	}
+	select {
+	case got := <-created:
+		if got != "build-retry1" {
+			t.Errorf("expected retry instance build-retry1, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the retried task to be spawned")
+	}
+
+	if err := srv.CancelWorkflow("/repo", wf.WorkflowID); err != nil {
+		t.Fatalf("CancelWorkflow: %v", err)
+	}
+	select {
+	case got := <-killed:
+		if got != "build-retry1" {
+			t.Errorf("expected the retry instance to be killed, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the running task's instance to be killed")
+	}
+	if got := srv.Manager().GetWorkflow("/repo", wf.WorkflowID).Status; got != WorkflowCancelled {
+		t.Errorf("workflow status = %s, want cancelled", got)
+	}
+}
+
+func TestServerStartWorkflowPassesProgram(t *testing.T) {
+	srv := startTestServer(t)
+
+	programs := make(chan string, 2)
+	go func() {
+		for action := range srv.Actions() {
+			if action.Type == ActionCreateInstance {
+				program, _ := action.Params["program"].(string)
+				programs <- program
+			}
+			action.ResponseCh <- ActionResponse{OK: true}
+		}
+	}()
+
+	result, err := srv.StartWorkflow("/repo", "", []*WorkflowTask{
+		{ID: "fix", Title: "fix", Program: "aider"},
+	})
+	if err != nil {
+		t.Fatalf("StartWorkflow: %v", err)
+	}
+	if len(result.Triggered) != 1 {
+		t.Fatalf("expected fix triggered, got %v", result.Triggered)
+	}
+	if got := <-programs; got != "aider" {
+		t.Errorf("expected program aider, got %q", got)
+	}
+}
@@ -0,0 +1,261 @@
+package brain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenFile is the name of the file, in the brain state directory, holding
+// the hashes of the tokens issued to instances.
+const TokenFile = "tokens.json"
+
+// OperatorKeyFile is the name of the file, in the brain state directory,
+// holding the key the hivemind CLI authenticates with. Only the user may
+// read it.
+const OperatorKeyFile = "operator.key"
+
+const tokenSize = 32
+
+// newToken returns a random token.
+func newToken() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// tokenTable holds the tokens issued to instances. Only SHA-256 hashes are
+// written to disk, so reading the file doesn't let an agent act as another
+// instance. The file lets a daemon accept tokens issued by the TUI, and the
+// other way around; it is reloaded whenever it changes.
+type tokenTable struct {
+	path string
+
+	mu      sync.Mutex
+	hashes  map[string]string // instance ID -> hex SHA-256 of its token
+	modTime time.Time
+}
+
+func newTokenTable(path string) *tokenTable {
+	return &tokenTable{path: path, hashes: make(map[string]string)}
+}
+
+// issue returns a fresh random token for instanceID, replacing any token it
+// was issued before.
+func (t *tokenTable) issue(instanceID string) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reload()
+	t.hashes[instanceID] = hashToken(token)
+	if err := t.save(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// revoke invalidates the token issued to instanceID.
+func (t *tokenTable) revoke(instanceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reload()
+	if _, ok := t.hashes[instanceID]; !ok {
+		return
+	}
+	delete(t.hashes, instanceID)
+	if err := t.save(); err != nil {
+		logWarn("brain: %v", err)
+	}
+}
+
+// valid reports whether token was issued to instanceID.
+func (t *tokenTable) valid(instanceID, token string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reload()
+	want, ok := t.hashes[instanceID]
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(want)) == 1
+}
+
+// has reports whether instanceID holds a token.
+func (t *tokenTable) has(instanceID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reload()
+	_, ok := t.hashes[instanceID]
+	return ok
+}
+
+// reload re-reads the file if it changed since it was last read or written.
+// The caller must hold mu.
+func (t *tokenTable) reload() {
+	info, err := os.Stat(t.path)
+	if err != nil || info.ModTime().Equal(t.modTime) {
+		return
+	}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return
+	}
+	hashes := make(map[string]string)
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		logWarn("brain: ignoring invalid %s: %v", t.path, err)
+		return
+	}
+	t.hashes = hashes
+	t.modTime = info.ModTime()
+}
+
+// save writes the table atomically. The caller must hold mu.
+func (t *tokenTable) save() error {
+	data, err := json.MarshalIndent(t.hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0700); err != nil {
+		return fmt.Errorf("write tokens: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), TokenFile+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write tokens: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), t.path)
+	}
+	if err != nil {
+		return fmt.Errorf("write tokens: %w", err)
+	}
+	if info, err := os.Stat(t.path); err == nil {
+		t.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureOperatorKey returns the operator key saved at path, creating it if
+// there is none yet. The TUI and the daemon share the file, so the CLI works
+// with either.
+func ensureOperatorKey(path string) (string, error) {
+	if key, err := readOperatorKey(path); err == nil {
+		return key, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	key, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("write operator key: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		// Another server created it first.
+		return readOperatorKey(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("write operator key: %w", err)
+	}
+	_, err = f.WriteString(key + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("write operator key: %w", err)
+	}
+	return key, nil
+}
+
+func readOperatorKey(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("operator key %s is empty", path)
+	}
+	return key, nil
+}
+
+// LoadOperatorKey reads the key the hivemind CLI authenticates with, which
+// the brain server of a running TUI or daemon saves in configDir.
+func LoadOperatorKey(configDir string) (string, error) {
+	key, err := readOperatorKey(filepath.Join(configDir, "brain", OperatorKeyFile))
+	if err != nil {
+		return "", fmt.Errorf("read operator key: %w", err)
+	}
+	return key, nil
+}
+
+// isOperator reports whether token is the operator key.
+func (s *Server) isOperator(token string) bool {
+	return token != "" && s.operatorHash != "" &&
+		subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(s.operatorHash)) == 1
+}
+
+// IssueToken returns a new token for the instance described by info, to be
+// handed to its agent at spawn, and starts enforcing the permission policy
+// for it right away. A token issued before to the same instance stops working.
+func (s *Server) IssueToken(info InstanceInfo) (string, error) {
+	token, err := s.tokens.issue(info.Title)
+	if err != nil {
+		return "", err
+	}
+	s.instMu.Lock()
+	defer s.instMu.Unlock()
+	if s.instances == nil {
+		s.instances = make(map[string]InstanceInfo)
+	}
+	if s.issued == nil {
+		s.issued = make(map[string]InstanceInfo)
+	}
+	s.instances[info.Title] = info
+	s.issued[info.Title] = info
+	return token, nil
+}
+
+// RevokeToken invalidates the token of a killed instance.
+func (s *Server) RevokeToken(instanceID string) {
+	s.tokens.revoke(instanceID)
+	s.instMu.Lock()
+	defer s.instMu.Unlock()
+	delete(s.instances, instanceID)
+	delete(s.issued, instanceID)
+}
+
+// isInstance reports whether instanceID names an instance, which must
+// authenticate with its token.
+func (s *Server) isInstance(instanceID string) bool {
+	s.instMu.Lock()
+	_, ok := s.instances[instanceID]
+	s.instMu.Unlock()
+	return ok || s.tokens.has(instanceID)
+}
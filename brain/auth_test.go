@@ -0,0 +1,97 @@
+package brain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TokenFile)
+	table := newTokenTable(path)
+
+	token, err := table.issue("agent-1")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if !table.valid("agent-1", token) {
+		t.Error("token should be valid for its own instance")
+	}
+	if table.valid("agent-2", token) {
+		t.Error("token should not be valid for another instance")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Error("the token itself should not be written to disk")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+
+	// Another process reading the same file accepts the token.
+	other := newTokenTable(path)
+	if !other.valid("agent-1", token) {
+		t.Error("token should be valid for a table loaded from the same file")
+	}
+
+	reissued, err := table.issue("agent-1")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if table.valid("agent-1", token) {
+		t.Error("reissuing should invalidate the previous token")
+	}
+
+	other.revoke("agent-1")
+	if table.valid("agent-1", reissued) {
+		t.Error("a token revoked by another process should be rejected")
+	}
+}
+
+func TestServerCLIRequests(t *testing.T) {
+	s := startTestServer(t)
+	if _, err := s.IssueToken(InstanceInfo{Title: "agent-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The CLI sends the operator key and may use any name that isn't an
+	// instance's.
+	key, err := LoadOperatorKey(filepath.Dir(s.SocketPath()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient(s.SocketPath())
+	cli.SetToken("", key)
+	if err := cli.SendMessage("/repo", "cli", "agent-1", "hi"); err != nil {
+		t.Fatalf("the CLI should be able to act as the user: %v", err)
+	}
+	if err := cli.SendMessage("/repo", "agent-1", "agent-2", "hi"); err == nil {
+		t.Error("a request naming an instance should need its token")
+	}
+
+	// A restarted server keeps the key.
+	if again, err := ensureOperatorKey(s.operatorPath); err != nil || again != key {
+		t.Errorf("expected the saved operator key, got %q, %v", again, err)
+	}
+	info, err := os.Stat(s.operatorPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("the operator key should only be readable by the user, got %v", info.Mode())
+	}
+
+	s.RevokeToken("agent-1")
+	if s.isInstance("agent-1") {
+		t.Error("a revoked instance should be forgotten")
+	}
+}
@@ -2,6 +2,7 @@ package brain
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -17,6 +18,9 @@ const actionTimeout = 35 * time.Second
 // Client connects to a brain server over a Unix domain socket.
 type Client struct {
 	socketPath string
+	// instanceID and token authenticate requests as the client's instance.
+	instanceID string
+	token      string
 }
 
 // NewClient creates a new socket client.
@@ -24,6 +28,25 @@ func NewClient(socketPath string) *Client {
 	return &Client{socketPath: socketPath}
 }
 
+// SetToken sets the instance ID and token sent with every request. Agents
+// use the token they were given at spawn, which is only valid for their own
+// instance ID. The hivemind CLI uses the operator key (see LoadOperatorKey)
+// and no instance ID. Without a token, the server only accepts read-only
+// requests such as listing workflows and reading events.
+func (c *Client) SetToken(instanceID, token string) {
+	c.instanceID = instanceID
+	c.token = token
+}
+
+// sign attaches the client's token, and its instance ID unless the request
+// names one.
+func (c *Client) sign(req *Request) {
+	req.Token = c.token
+	if req.InstanceID == "" {
+		req.InstanceID = c.instanceID
+	}
+}
+
 // Ping checks connectivity to the brain server.
 func (c *Client) Ping() error {
 	_, err := c.send(Request{Method: MethodPing})
@@ -81,16 +104,58 @@ func (c *Client) UpdateStatusWithRole(repoPath, instanceID, feature string, file
 
 // SendMessage sends a message from one agent to another (or broadcast if to is empty).
 func (c *Client) SendMessage(repoPath, from, to, content string) error {
-	_, err := c.send(Request{
+	_, err := c.PostMessage(repoPath, from, to, content, 0)
+	return err
+}
+
+// PostMessage sends a message, optionally as a reply to message replyTo, and
+// returns it with its ID.
+func (c *Client) PostMessage(repoPath, from, to, content string, replyTo int64) (*BrainMessage, error) {
+	params := map[string]any{
+		"to":      to,
+		"content": content,
+	}
+	if replyTo != 0 {
+		params["reply_to"] = replyTo
+	}
+	resp, err := c.send(Request{
 		Method:     MethodSendMessage,
 		InstanceID: from,
 		RepoPath:   repoPath,
+		Params:     params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var msg BrainMessage
+	if err := json.Unmarshal(resp.Data, &msg); err != nil {
+		return nil, fmt.Errorf("unmarshal message: %w", err)
+	}
+	return &msg, nil
+}
+
+// GetMessages returns messages for instanceID newer than the message ID since,
+// marking them read. With unreadOnly, already-read messages are skipped.
+func (c *Client) GetMessages(repoPath, instanceID string, since int64, unreadOnly bool) ([]BrainMessage, error) {
+	resp, err := c.send(Request{
+		Method:     MethodGetMessages,
+		InstanceID: instanceID,
+		RepoPath:   repoPath,
 		Params: map[string]any{
-			"to":      to,
-			"content": content,
+			"since":       since,
+			"unread_only": unreadOnly,
 		},
 	})
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	var result GetMessagesResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal messages: %w", err)
+	}
+	return result.Messages, nil
 }
 
 // RemoveAgent removes an agent from the brain state.
@@ -103,6 +168,84 @@ func (c *Client) RemoveAgent(repoPath, instanceID string) error {
 	return err
 }
 
+// ClaimFiles takes advisory leases on files or glob patterns for instanceID.
+func (c *Client) ClaimFiles(repoPath, instanceID string, files []string, opts ClaimOptions) (*ClaimFilesResult, error) {
+	params := map[string]any{
+		"files":     files,
+		"exclusive": opts.Exclusive,
+	}
+	if opts.TTL > 0 {
+		params["ttl"] = opts.TTL.String()
+	}
+	resp, err := c.send(Request{
+		Method:     MethodClaimFiles,
+		InstanceID: instanceID,
+		RepoPath:   repoPath,
+		Params:     params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result ClaimFilesResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal claim result: %w", err)
+	}
+	return &result, nil
+}
+
+// ReleaseFiles drops instanceID's leases on files, or all of them if files is empty.
+func (c *Client) ReleaseFiles(repoPath, instanceID string, files []string) (int, error) {
+	resp, err := c.send(Request{
+		Method:     MethodReleaseFiles,
+		InstanceID: instanceID,
+		RepoPath:   repoPath,
+		Params:     map[string]any{"files": files},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var result ReleaseFilesResult
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return 0, fmt.Errorf("unmarshal release result: %w", err)
+	}
+	return result.Released, nil
+}
+
+// AskUser asks the user a question, or resumes waiting on params.QuestionID,
+// and blocks until it is answered or the wait elapses. The returned question
+// has no answer if the wait elapsed first.
+func (c *Client) AskUser(repoPath, instanceID string, params AskUserParams) (*Question, error) {
+	wait := params.Wait
+	if wait <= 0 {
+		wait = DefaultAskWait
+	}
+	wait = min(wait, maxAskWait)
+	p := map[string]any{"wait": int(wait.Seconds())}
+	if params.QuestionID != "" {
+		p["question_id"] = params.QuestionID
+	} else {
+		p["question"] = params.Question
+		p["choices"] = params.Choices
+	}
+	resp, err := c.sendWithTimeout(Request{
+		Method:     MethodAskUser,
+		InstanceID: instanceID,
+		RepoPath:   repoPath,
+		Params:     p,
+	}, wait+10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	var q Question
+	if err := json.Unmarshal(resp.Data, &q); err != nil {
+		return nil, fmt.Errorf("unmarshal question: %w", err)
+	}
+	return &q, nil
+}
+
 // CreateInstance requests the TUI to spawn a new agent instance.
 func (c *Client) CreateInstance(repoPath, instanceID string, params CreateInstanceParams) (*CreateInstanceResult, error) {
 	p := map[string]any{
@@ -190,6 +333,10 @@ func (c *Client) DefineWorkflow(repoPath, instanceID string, tasks []*WorkflowTa
 		Params:     map[string]any{"tasks": tasks},
 	}, 3*time.Minute+5*time.Second)
 	if err != nil {
+		var verr WorkflowValidationError
+		if resp != nil && len(resp.Data) > 0 && json.Unmarshal(resp.Data, &verr) == nil {
+			return nil, fmt.Errorf("brain server error: %w", &verr)
+		}
 		return nil, err
 	}
 
@@ -200,17 +347,21 @@ func (c *Client) DefineWorkflow(repoPath, instanceID string, tasks []*WorkflowTa
 	return &result, nil
 }
 
-// CompleteTask marks a workflow task as done or failed.
+// CompleteTask marks a workflow task as done or failed, recording any outputs
+// for downstream tasks. workflowID may be empty when the task ID is unique
+// across the repo's workflows.
 // Uses a longer timeout because the server may trigger dependent task instances.
-func (c *Client) CompleteTask(repoPath, instanceID, taskID, status, errMsg string) (*WorkflowResult, error) {
+func (c *Client) CompleteTask(repoPath, instanceID, workflowID, taskID, status, errMsg string, outputs map[string]any) (*WorkflowResult, error) {
 	resp, err := c.sendWithTimeout(Request{
 		Method:     MethodCompleteTask,
 		InstanceID: instanceID,
 		RepoPath:   repoPath,
 		Params: map[string]any{
-			"task_id": taskID,
-			"status":  status,
-			"error":   errMsg,
+			"workflow_id": workflowID,
+			"task_id":     taskID,
+			"status":      status,
+			"error":       errMsg,
+			"outputs":     outputs,
 		},
 	}, 90*time.Second+5*time.Second)
 	if err != nil {
@@ -224,12 +375,14 @@ func (c *Client) CompleteTask(repoPath, instanceID, taskID, status, errMsg strin
 	return &result, nil
 }
 
-// GetWorkflow retrieves the current workflow DAG for a repo.
-func (c *Client) GetWorkflow(repoPath, instanceID string) (*Workflow, error) {
+// GetWorkflow retrieves a workflow DAG by ID, or the most recently defined
+// workflow for the repo when workflowID is empty.
+func (c *Client) GetWorkflow(repoPath, instanceID, workflowID string) (*Workflow, error) {
 	resp, err := c.send(Request{
 		Method:     MethodGetWorkflow,
 		InstanceID: instanceID,
 		RepoPath:   repoPath,
+		Params:     map[string]any{"workflow_id": workflowID},
 	})
 	if err != nil {
 		return nil, err
@@ -242,23 +395,32 @@ func (c *Client) GetWorkflow(repoPath, instanceID string) (*Workflow, error) {
 	return &workflow, nil
 }
 
-// Subscribe creates an event subscription with the given filter.
-func (c *Client) Subscribe(repoPath string, filter EventFilter) (string, error) {
-	params := make(map[string]any)
-	if len(filter.Types) > 0 {
-		params["types"] = toAnySlice(filter.Types)
+// ListWorkflows retrieves every workflow DAG for a repo, oldest first.
+func (c *Client) ListWorkflows(repoPath, instanceID string) ([]*Workflow, error) {
+	resp, err := c.send(Request{
+		Method:     MethodListWorkflows,
+		InstanceID: instanceID,
+		RepoPath:   repoPath,
+	})
+	if err != nil {
+		return nil, err
 	}
-	if len(filter.Instances) > 0 {
-		params["instances"] = toAnySlice(filter.Instances)
+
+	var result struct {
+		Workflows []*Workflow `json:"workflows"`
 	}
-	if filter.ParentTitle != "" {
-		params["parent_title"] = filter.ParentTitle
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal workflows: %w", err)
 	}
+	return result.Workflows, nil
+}
 
+// Subscribe creates an event subscription with the given filter.
+func (c *Client) Subscribe(repoPath string, filter EventFilter) (string, error) {
 	resp, err := c.send(Request{
 		Method:   MethodSubscribe,
 		RepoPath: repoPath,
-		Params:   params,
+		Params:   filterParams(filter),
 	})
 	if err != nil {
 		return "", err
@@ -271,6 +433,90 @@ func (c *Client) Subscribe(repoPath string, filter EventFilter) (string, error)
 	return result.SubscriberID, nil
 }
 
+// filterParams encodes an event filter as request params.
+func filterParams(filter EventFilter) map[string]any {
+	params := make(map[string]any)
+	if len(filter.Types) > 0 {
+		params["types"] = toAnySlice(filter.Types)
+	}
+	if len(filter.Instances) > 0 {
+		params["instances"] = toAnySlice(filter.Instances)
+	}
+	if filter.ParentTitle != "" {
+		params["parent_title"] = filter.ParentTitle
+	}
+	return params
+}
+
+// StreamEvents holds one connection open and calls fn for each matching event
+// as it is emitted, until ctx is cancelled (returning nil), fn returns an
+// error, or the connection drops. from resumes after that event, replaying
+// buffered history; the zero cursor streams new events only. Events the
+// server could not deliver are reported as an EventGap marker.
+func (c *Client) StreamEvents(ctx context.Context, filter EventFilter, from EventCursor, fn func(Event) error) error {
+	var d net.Dialer
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	conn, err := d.DialContext(dialCtx, "unix", c.socketPath)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("connect to brain server: %w", err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	params := filterParams(filter)
+	if from.Sequence > 0 {
+		params["since"] = from.Sequence
+	}
+	if from.Epoch != "" {
+		params["epoch"] = from.Epoch
+	}
+	req := Request{Method: MethodStreamEvents, Params: params}
+	c.sign(&req)
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write request: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	if !scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("no response from brain server")
+	}
+	var resp Response
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("brain server error: %s", resp.Error)
+	}
+
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("unmarshal event: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read event stream: %w", err)
+	}
+	return fmt.Errorf("event stream closed by brain server")
+}
+
 // toAnySlice converts a typed slice to []any for JSON-based IPC params.
 func toAnySlice[T ~string](src []T) []any {
 	out := make([]any, len(src))
@@ -316,7 +562,8 @@ func (c *Client) send(req Request) (*Response, error) {
 	return c.sendWithTimeout(req, dialTimeout)
 }
 
-// sendWithTimeout is like send but with a custom dial and read deadline.
+// sendWithTimeout is like send but with a custom dial and read deadline. When
+// the server reports an error, the response is returned along with it.
 func (c *Client) sendWithTimeout(req Request, timeout time.Duration) (*Response, error) {
 	conn, err := net.DialTimeout("unix", c.socketPath, timeout)
 	if err != nil {
@@ -326,6 +573,7 @@ func (c *Client) sendWithTimeout(req Request, timeout time.Duration) (*Response,
 
 	conn.SetDeadline(time.Now().Add(timeout))
 
+	c.sign(&req)
 	data, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
@@ -349,7 +597,8 @@ func (c *Client) sendWithTimeout(req Request, timeout time.Duration) (*Response,
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 	if !resp.OK {
-		return nil, fmt.Errorf("brain server error: %s", resp.Error)
+		// The response still goes back so callers can read error details.
+		return &resp, fmt.Errorf("brain server error: %s", resp.Error)
 	}
 	return &resp, nil
 }
@@ -1,13 +1,15 @@
 package brain
 
 import (
+	"context"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestClientPing(t *testing.T) {
 	s := startTestServer(t)
-	c := NewClient(s.SocketPath())
+	c := newTestClient(t, s)
 
 	if err := c.Ping(); err != nil {
 		t.Fatalf("Ping failed: %v", err)
@@ -23,7 +25,7 @@ func TestClientPingNoServer(t *testing.T) {
 
 func TestClientUpdateAndGetBrain(t *testing.T) {
 	s := startTestServer(t)
-	c := NewClient(s.SocketPath())
+	c := newTestClient(t, s)
 
 	result, err := c.UpdateStatus("/repo", "agent-1", "implement auth", []string{"auth.go"})
 	if err != nil {
@@ -45,9 +47,35 @@ func TestClientUpdateAndGetBrain(t *testing.T) {
 	}
 }
 
+func TestClientClaimAndReleaseFiles(t *testing.T) {
+	s := startTestServer(t)
+	c := newTestClient(t, s)
+
+	res, err := c.ClaimFiles("/repo", "agent-1", []string{"api/**"}, ClaimOptions{Exclusive: true, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("ClaimFiles: %v", err)
+	}
+	if !res.Granted || len(res.Leases) != 1 || time.Until(res.Leases[0].ExpiresAt) < 59*time.Minute {
+		t.Fatalf("unexpected claim result: %+v", res)
+	}
+
+	res, err = c.ClaimFiles("/repo", "agent-2", []string{"api/server.go"}, ClaimOptions{})
+	if err != nil {
+		t.Fatalf("ClaimFiles: %v", err)
+	}
+	if res.Granted || len(res.Conflicts) != 1 {
+		t.Errorf("expected the claim to be refused, got %+v", res)
+	}
+
+	released, err := c.ReleaseFiles("/repo", "agent-1", nil)
+	if err != nil || released != 1 {
+		t.Errorf("ReleaseFiles = %d, %v; want 1", released, err)
+	}
+}
+
 func TestClientSendMessage(t *testing.T) {
 	s := startTestServer(t)
-	c := NewClient(s.SocketPath())
+	c := newTestClient(t, s)
 
 	if err := c.SendMessage("/repo", "agent-1", "agent-2", "heads up"); err != nil {
 		t.Fatalf("SendMessage: %v", err)
@@ -62,9 +90,33 @@ func TestClientSendMessage(t *testing.T) {
 	}
 }
 
+func TestClientMessageReplies(t *testing.T) {
+	s := startTestServer(t)
+	lead := newTestClient(t, s)
+
+	q, err := lead.PostMessage("/repo", "lead", "coder", "ready for review?", 0)
+	if err != nil {
+		t.Fatalf("PostMessage: %v", err)
+	}
+	if _, err := lead.PostMessage("/repo", "coder", "", "yes", q.ID); err != nil {
+		t.Fatalf("PostMessage reply: %v", err)
+	}
+
+	msgs, err := lead.GetMessages("/repo", "lead", 0, true)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].ReplyTo != q.ID || msgs[0].From != "coder" {
+		t.Fatalf("expected the reply, got %+v", msgs)
+	}
+	if msgs, _ := lead.GetMessages("/repo", "lead", 0, true); len(msgs) != 0 {
+		t.Errorf("expected no unread messages, got %+v", msgs)
+	}
+}
+
 func TestClientRemoveAgent(t *testing.T) {
 	s := startTestServer(t)
-	c := NewClient(s.SocketPath())
+	c := newTestClient(t, s)
 
 	c.UpdateStatus("/repo", "agent-1", "work", nil)
 	if err := c.RemoveAgent("/repo", "agent-1"); err != nil {
@@ -82,7 +134,7 @@ func TestClientRemoveAgent(t *testing.T) {
 
 func TestClientConflictDetection(t *testing.T) {
 	s := startTestServer(t)
-	c := NewClient(s.SocketPath())
+	c := newTestClient(t, s)
 
 	c.UpdateStatus("/repo", "agent-1", "auth", []string{"auth.go"})
 	result, err := c.UpdateStatus("/repo", "agent-2", "auth fix", []string{"auth.go"})
@@ -93,3 +145,54 @@ func TestClientConflictDetection(t *testing.T) {
 		t.Fatalf("expected 1 conflict, got %d", len(result.Conflicts))
 	}
 }
+
+func TestClientStreamEvents(t *testing.T) {
+	srv := startTestServer(t)
+	client := newTestClient(t, srv)
+
+	// Events emitted before the stream opens are replayed when resuming.
+	srv.PushEvent(Event{Type: EventStatusChanged, Source: "a", RepoPath: "/repo"})
+	srv.PushEvent(Event{Type: EventMessageReceived, Source: "b", RepoPath: "/repo"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan Event, 10)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.StreamEvents(ctx, EventFilter{Types: []EventType{EventMessageReceived}}, EventCursor{Sequence: 1}, func(e Event) error {
+			received <- e
+			return nil
+		})
+	}()
+
+	next := func() Event {
+		t.Helper()
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for streamed event")
+			return Event{}
+		}
+	}
+
+	if e := next(); e.Sequence != 2 || e.Source != "b" {
+		t.Fatalf("expected replayed event 2 from b, got %+v", e)
+	}
+	srv.PushEvent(Event{Type: EventStatusChanged, Source: "c", RepoPath: "/repo"})
+	srv.PushEvent(Event{Type: EventMessageReceived, Source: "d", RepoPath: "/repo"})
+	if e := next(); e.Sequence != 4 || e.Source != "d" {
+		t.Fatalf("expected live event 4 from d, got %+v", e)
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("expected nil error after cancel, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StreamEvents did not return after cancel")
+	}
+}
@@ -0,0 +1,231 @@
+package brain
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// WorkflowValidationError describes why a task list is not a runnable DAG.
+// Each field names the offending tasks so the caller can fix its plan.
+type WorkflowValidationError struct {
+	// MissingIDs holds the positions of tasks defined without an ID.
+	MissingIDs []int `json:"missing_ids,omitempty"`
+	// DuplicateIDs lists task IDs that are defined more than once.
+	DuplicateIDs []string `json:"duplicate_ids,omitempty"`
+	// UnknownDeps maps a task ID to the depends_on entries that name no task.
+	UnknownDeps map[string][]string `json:"unknown_deps,omitempty"`
+	// Cycle is a dependency cycle, starting and ending with the same task.
+	Cycle []string `json:"cycle,omitempty"`
+	// InvalidRefs maps a task ID to prompt template references that name an
+	// unknown field or a task that is not one of its upstream dependencies.
+	InvalidRefs map[string][]string `json:"invalid_refs,omitempty"`
+}
+
+func (e *WorkflowValidationError) Error() string {
+	var parts []string
+	if len(e.MissingIDs) > 0 {
+		positions := make([]string, len(e.MissingIDs))
+		for i, p := range e.MissingIDs {
+			positions[i] = fmt.Sprintf("%d", p)
+		}
+		parts = append(parts, "tasks without an id at positions "+strings.Join(positions, ", "))
+	}
+	if len(e.DuplicateIDs) > 0 {
+		parts = append(parts, "duplicate task ids: "+strings.Join(e.DuplicateIDs, ", "))
+	}
+	if len(e.UnknownDeps) > 0 {
+		parts = append(parts, "unknown dependencies: "+formatTaskMap(e.UnknownDeps))
+	}
+	if len(e.Cycle) > 0 {
+		parts = append(parts, "dependency cycle: "+strings.Join(e.Cycle, " -> "))
+	}
+	if len(e.InvalidRefs) > 0 {
+		parts = append(parts, "invalid prompt references (must name an upstream task): "+formatTaskMap(e.InvalidRefs))
+	}
+	return "invalid workflow: " + strings.Join(parts, "; ")
+}
+
+// formatTaskMap renders a task ID -> values map as "a -> x, y; b -> z", sorted by task ID.
+func formatTaskMap(m map[string][]string) string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	entries := make([]string, len(ids))
+	for i, id := range ids {
+		entries[i] = fmt.Sprintf("%s -> %s", id, strings.Join(m[id], ", "))
+	}
+	return strings.Join(entries, "; ")
+}
+
+// ValidateWorkflow checks that tasks form a DAG: every task has a unique ID,
+// every dependency names a defined task, there are no cycles, and prompt
+// templates only reference upstream tasks (see renderTaskTemplate). On success it
+// returns the tasks grouped into topological levels: level 0 has no
+// dependencies, and each later level depends only on earlier ones. Task order
+// within a level follows the definition order.
+func ValidateWorkflow(tasks []*WorkflowTask) ([][]string, error) {
+	verr := &WorkflowValidationError{}
+
+	byID := make(map[string]*WorkflowTask, len(tasks))
+	for i, t := range tasks {
+		if t.ID == "" {
+			verr.MissingIDs = append(verr.MissingIDs, i)
+			continue
+		}
+		if _, dup := byID[t.ID]; dup {
+			if !slices.Contains(verr.DuplicateIDs, t.ID) {
+				verr.DuplicateIDs = append(verr.DuplicateIDs, t.ID)
+			}
+			continue
+		}
+		byID[t.ID] = t
+	}
+
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := byID[dep]; !ok {
+				if verr.UnknownDeps == nil {
+					verr.UnknownDeps = make(map[string][]string)
+				}
+				verr.UnknownDeps[t.ID] = append(verr.UnknownDeps[t.ID], dep)
+			}
+		}
+	}
+
+	// Levels are only meaningful once every ID resolves to a single task.
+	if len(verr.MissingIDs) > 0 || len(verr.DuplicateIDs) > 0 {
+		return nil, verr
+	}
+
+	levels, unresolved := topoLevels(tasks, byID)
+	if len(unresolved) > 0 {
+		verr.Cycle = findCycle(unresolved, byID)
+	} else {
+		verr.InvalidRefs = invalidTaskRefs(tasks, byID)
+	}
+	if len(verr.UnknownDeps) > 0 || len(verr.Cycle) > 0 || len(verr.InvalidRefs) > 0 {
+		return nil, verr
+	}
+	return levels, nil
+}
+
+// topoLevels groups tasks by dependency depth using Kahn's algorithm, ignoring
+// dependencies on unknown tasks. Tasks that can never be scheduled (those on
+// or downstream of a cycle) are returned as unresolved, in definition order.
+func topoLevels(tasks []*WorkflowTask, byID map[string]*WorkflowTask) ([][]string, []string) {
+	level := make(map[string]int, len(tasks))
+	var levels [][]string
+
+	remaining := tasks
+	for depth := 0; len(remaining) > 0; depth++ {
+		var current []string
+		var next []*WorkflowTask
+		for _, t := range remaining {
+			ready := true
+			for _, dep := range t.DependsOn {
+				if _, known := byID[dep]; !known {
+					continue
+				}
+				if l, done := level[dep]; !done || l >= depth {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				current = append(current, t.ID)
+			} else {
+				next = append(next, t)
+			}
+		}
+		if len(current) == 0 {
+			unresolved := make([]string, len(next))
+			for i, t := range next {
+				unresolved[i] = t.ID
+			}
+			return levels, unresolved
+		}
+		for _, id := range current {
+			level[id] = depth
+		}
+		levels = append(levels, current)
+		remaining = next
+	}
+	return levels, nil
+}
+
+// findCycle returns one dependency cycle among the unresolved tasks, as a path
+// that starts and ends with the same task ID.
+func findCycle(unresolved []string, byID map[string]*WorkflowTask) []string {
+	inSet := make(map[string]bool, len(unresolved))
+	for _, id := range unresolved {
+		inSet[id] = true
+	}
+
+	// Every unresolved task depends on at least one other unresolved task,
+	// so following those edges must eventually revisit a task.
+	var path []string
+	pos := make(map[string]int)
+	for id := unresolved[0]; ; {
+		if i, seen := pos[id]; seen {
+			return append(path[i:], id)
+		}
+		pos[id] = len(path)
+		path = append(path, id)
+
+		next := ""
+		for _, dep := range byID[id].DependsOn {
+			if inSet[dep] {
+				next = dep
+				break
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		id = next
+	}
+}
+
+// invalidTaskRefs returns, per task, the prompt references that name an unknown
+// field or a task outside the task's transitive dependencies. A task may only
+// read results that are guaranteed to exist when it starts. The graph must be
+// acyclic.
+func invalidTaskRefs(tasks []*WorkflowTask, byID map[string]*WorkflowTask) map[string][]string {
+	ancestors := make(map[string]map[string]bool, len(tasks))
+	var collect func(id string) map[string]bool
+	collect = func(id string) map[string]bool {
+		if a, ok := ancestors[id]; ok {
+			return a
+		}
+		a := make(map[string]bool)
+		for _, dep := range byID[id].DependsOn {
+			if _, known := byID[dep]; !known {
+				continue
+			}
+			a[dep] = true
+			for anc := range collect(dep) {
+				a[anc] = true
+			}
+		}
+		ancestors[id] = a
+		return a
+	}
+
+	var invalid map[string][]string
+	for _, t := range tasks {
+		for _, ref := range parseTaskRefs(t.Prompt) {
+			if validTaskField(ref.Field) && collect(t.ID)[ref.TaskID] {
+				continue
+			}
+			if invalid == nil {
+				invalid = make(map[string][]string)
+			}
+			invalid[t.ID] = append(invalid[t.ID], ref.String())
+		}
+	}
+	return invalid
+}
@@ -0,0 +1,98 @@
+package brain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateWorkflowLevels(t *testing.T) {
+	levels, err := ValidateWorkflow([]*WorkflowTask{
+		{ID: "design"},
+		{ID: "api", DependsOn: []string{"design"}},
+		{ID: "ui", DependsOn: []string{"design"}},
+		{ID: "docs"},
+		{ID: "review", DependsOn: []string{"api", "ui", "docs"}},
+	})
+	if err != nil {
+		t.Fatalf("ValidateWorkflow: %v", err)
+	}
+	want := [][]string{{"design", "docs"}, {"api", "ui"}, {"review"}}
+	if !reflect.DeepEqual(levels, want) {
+		t.Errorf("levels = %v, want %v", levels, want)
+	}
+}
+
+func TestValidateWorkflowErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*WorkflowTask
+		want  WorkflowValidationError
+	}{
+		{
+			name:  "missing id",
+			tasks: []*WorkflowTask{{ID: "a"}, {Title: "no id"}},
+			want:  WorkflowValidationError{MissingIDs: []int{1}},
+		},
+		{
+			name:  "duplicate ids",
+			tasks: []*WorkflowTask{{ID: "a"}, {ID: "b"}, {ID: "a"}, {ID: "a"}},
+			want:  WorkflowValidationError{DuplicateIDs: []string{"a"}},
+		},
+		{
+			name: "unknown dependency",
+			tasks: []*WorkflowTask{
+				{ID: "a"},
+				{ID: "b", DependsOn: []string{"a", "nope"}},
+			},
+			want: WorkflowValidationError{UnknownDeps: map[string][]string{"b": {"nope"}}},
+		},
+		{
+			name:  "self dependency",
+			tasks: []*WorkflowTask{{ID: "a", DependsOn: []string{"a"}}},
+			want:  WorkflowValidationError{Cycle: []string{"a", "a"}},
+		},
+		{
+			name: "cycle downstream of valid tasks",
+			tasks: []*WorkflowTask{
+				{ID: "root"},
+				{ID: "leaf", DependsOn: []string{"x"}},
+				{ID: "x", DependsOn: []string{"root", "z"}},
+				{ID: "y", DependsOn: []string{"x"}},
+				{ID: "z", DependsOn: []string{"y"}},
+			},
+			want: WorkflowValidationError{Cycle: []string{"x", "z", "y", "x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels, err := ValidateWorkflow(tt.tasks)
+			if levels != nil {
+				t.Errorf("expected no levels, got %v", levels)
+			}
+			var verr *WorkflowValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *WorkflowValidationError, got %v", err)
+			}
+			if !reflect.DeepEqual(*verr, tt.want) {
+				t.Errorf("error = %+v, want %+v", *verr, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowValidationErrorMessage(t *testing.T) {
+	err := &WorkflowValidationError{
+		DuplicateIDs: []string{"a"},
+		UnknownDeps:  map[string][]string{"b": {"c", "d"}},
+		Cycle:        []string{"e", "f", "e"},
+	}
+	msg := err.Error()
+	for _, want := range []string{"duplicate task ids: a", "b -> c, d", "dependency cycle: e -> f -> e"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q missing %q", msg, want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package brain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WorkflowsDir is the repo-relative directory holding workflow definition files.
+const WorkflowsDir = ".hivemind/workflows"
+
+// paramRefPattern matches parameter references in workflow definitions, e.g.
+// {{params.feature}}.
+var paramRefPattern = regexp.MustCompile(`\{\{\s*params\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// WorkflowParam declares a parameter of a workflow definition.
+type WorkflowParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	// Required params must be given a value when the workflow is run, unless
+	// they have a default.
+	Required bool `json:"required,omitempty"`
+}
+
+// WorkflowDefinition is a reusable workflow checked into a repo under
+// .hivemind/workflows/ as YAML or JSON. Task titles, prompts, roles and programs
+// may reference parameters as {{params.<name>}}; they are filled in by
+// Instantiate. Prompts may also use {{tasks.<id>...}} references, which are
+// resolved when each task is spawned.
+type WorkflowDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Params      []WorkflowParam `json:"params,omitempty"`
+	Tasks       []*WorkflowTask `json:"tasks"`
+	// Path is the file the definition was loaded from.
+	Path string `json:"path"`
+}
+
+// workflowFile is the on-disk layout of a workflow definition.
+type workflowFile struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Params      []WorkflowParam  `json:"params"`
+	Tasks       []map[string]any `json:"tasks"`
+}
+
+// LoadWorkflowDefinitions loads every definition in the repo's workflows
+// directory, sorted by name. Files that fail to parse are skipped with a
+// warning; use LoadWorkflowDefinition to see the error for a single file.
+func LoadWorkflowDefinitions(repoPath string) ([]*WorkflowDefinition, error) {
+	dir := filepath.Join(repoPath, WorkflowsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read workflows dir: %w", err)
+	}
+
+	var defs []*WorkflowDefinition
+	for _, e := range entries {
+		if e.IsDir() || !isWorkflowFile(e.Name()) {
+			continue
+		}
+		def, err := ParseWorkflowDefinitionFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			logWarn("brain: skipping workflow definition %s: %v", e.Name(), err)
+			continue
+		}
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs, nil
+}
+
+// LoadWorkflowDefinition loads the definition with the given name from the
+// repo's workflows directory. The name matches either the definition's name
+// field or its file name without extension.
+func LoadWorkflowDefinition(repoPath, name string) (*WorkflowDefinition, error) {
+	dir := filepath.Join(repoPath, WorkflowsDir)
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return ParseWorkflowDefinitionFile(path)
+		}
+	}
+
+	defs, err := LoadWorkflowDefinitions(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, def := range defs {
+		if def.Name == name {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("workflow %q not found in %s", name, dir)
+}
+
+// ParseWorkflowDefinitionFile reads and validates a single definition file.
+func ParseWorkflowDefinitionFile(path string) (*WorkflowDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def, err := ParseWorkflowDefinition(data, filepath.Ext(path) == ".json")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if def.Name == "" {
+		def.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	def.Path = path
+	return def, nil
+}
+
+// ParseWorkflowDefinition parses a YAML or JSON definition. Tasks accept the
+// same fields as define_workflow, plus "program". The task graph is validated
+// with ValidateWorkflow and every parameter reference must name a declared
+// parameter.
+func ParseWorkflowDefinition(data []byte, isJSON bool) (*WorkflowDefinition, error) {
+	// YAML is decoded generically and re-encoded as JSON so both formats share
+	// the JSON field names and number handling used by define_workflow.
+	if !isJSON {
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+	}
+
+	var file workflowFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse workflow: %w", err)
+	}
+	if len(file.Tasks) == 0 {
+		return nil, fmt.Errorf("workflow defines no tasks")
+	}
+
+	def := &WorkflowDefinition{
+		Name:        file.Name,
+		Description: file.Description,
+		Params:      file.Params,
+	}
+
+	declared := make(map[string]bool, len(file.Params))
+	for _, p := range file.Params {
+		if p.Name == "" {
+			return nil, fmt.Errorf("parameter without a name")
+		}
+		if declared[p.Name] {
+			return nil, fmt.Errorf("duplicate parameter %q", p.Name)
+		}
+		declared[p.Name] = true
+	}
+
+	for _, taskMap := range file.Tasks {
+		task, err := parseWorkflowTask(taskMap)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range []string{task.Title, task.Prompt, task.Role, task.Program} {
+			for _, m := range paramRefPattern.FindAllStringSubmatch(field, -1) {
+				if !declared[m[1]] {
+					return nil, fmt.Errorf("task %q: unknown parameter %q", task.ID, m[1])
+				}
+			}
+		}
+		def.Tasks = append(def.Tasks, task)
+	}
+
+	if _, err := ValidateWorkflow(def.Tasks); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Instantiate returns fresh tasks with parameter references replaced by the
+// given values, falling back to each parameter's default. It fails if a
+// required parameter has no value or an undeclared parameter is given.
+func (d *WorkflowDefinition) Instantiate(params map[string]string) ([]*WorkflowTask, error) {
+	values := make(map[string]string, len(d.Params))
+	var missing []string
+	for _, p := range d.Params {
+		v, ok := params[p.Name]
+		if !ok || v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			missing = append(missing, p.Name)
+		}
+		values[p.Name] = v
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("workflow %q has no parameter %q", d.Name, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("workflow %q: missing required parameters: %s", d.Name, strings.Join(missing, ", "))
+	}
+
+	fill := func(s string) string {
+		return paramRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+			return values[paramRefPattern.FindStringSubmatch(match)[1]]
+		})
+	}
+	tasks := make([]*WorkflowTask, len(d.Tasks))
+	for i, t := range d.Tasks {
+		c := cloneTask(t)
+		c.Title = fill(c.Title)
+		c.Prompt = fill(c.Prompt)
+		c.Role = fill(c.Role)
+		c.Program = fill(c.Program)
+		tasks[i] = c
+	}
+	return tasks, nil
+}
+
+func isWorkflowFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
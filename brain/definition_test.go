@@ -0,0 +1,160 @@
+package brain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const featureWorkflowYAML = `name: feature
+description: spec, implement, test and review a feature
+params:
+  - name: feature
+    description: What to build
+    required: true
+  - name: reviewer
+    default: claude
+tasks:
+  - id: spec
+    title: Spec {{params.feature}}
+    role: architect
+    prompt: Write a spec for {{params.feature}}.
+  - id: impl
+    title: Implement {{params.feature}}
+    role: coder
+    prompt: "Implement the spec: {{tasks.spec.outputs.spec}}"
+    depends_on: [spec]
+    max_retries: 2
+    timeout: 30m
+  - id: review
+    title: Review
+    program: "{{params.reviewer}}"
+    prompt: Review branch {{tasks.impl.branch}}
+    depends_on: [impl]
+    on_failure: continue
+`
+
+func writeWorkflowFile(t *testing.T, repo, name, content string) {
+	t.Helper()
+	dir := filepath.Join(repo, WorkflowsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseWorkflowDefinitionYAML(t *testing.T) {
+	def, err := ParseWorkflowDefinition([]byte(featureWorkflowYAML), false)
+	if err != nil {
+		t.Fatalf("ParseWorkflowDefinition: %v", err)
+	}
+	if def.Name != "feature" || len(def.Params) != 2 || len(def.Tasks) != 3 {
+		t.Fatalf("unexpected definition: %+v", def)
+	}
+	impl := def.Tasks[1]
+	if impl.MaxRetries != 2 || impl.Timeout != "30m" || len(impl.DependsOn) != 1 {
+		t.Errorf("impl task fields not parsed: %+v", impl)
+	}
+	if def.Tasks[2].OnFailure != ContinueOnFailure {
+		t.Errorf("review on_failure = %q, want continue", def.Tasks[2].OnFailure)
+	}
+}
+
+func TestParseWorkflowDefinitionJSON(t *testing.T) {
+	data := `{"tasks": [{"id": "a", "program": "aider"}, {"id": "b", "depends_on": ["a"]}]}`
+	def, err := ParseWorkflowDefinition([]byte(data), true)
+	if err != nil {
+		t.Fatalf("ParseWorkflowDefinition: %v", err)
+	}
+	if def.Tasks[0].Program != "aider" || def.Tasks[1].DependsOn[0] != "a" {
+		t.Errorf("unexpected tasks: %+v, %+v", def.Tasks[0], def.Tasks[1])
+	}
+}
+
+func TestParseWorkflowDefinitionRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no tasks", "name: empty\n", "no tasks"},
+		{"unknown param", "tasks:\n  - id: a\n    prompt: '{{params.missing}}'\n", `unknown parameter "missing"`},
+		{"cycle", "tasks:\n  - id: a\n    depends_on: [b]\n  - id: b\n    depends_on: [a]\n", "dependency cycle"},
+		{"bad policy", "tasks:\n  - id: a\n    on_failure: explode\n", "invalid on_failure"},
+		{"duplicate param", "params:\n  - name: x\n  - name: x\ntasks:\n  - id: a\n", `duplicate parameter "x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWorkflowDefinition([]byte(tt.data), false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWorkflowDefinitionInstantiate(t *testing.T) {
+	def, err := ParseWorkflowDefinition([]byte(featureWorkflowYAML), false)
+	if err != nil {
+		t.Fatalf("ParseWorkflowDefinition: %v", err)
+	}
+
+	if _, err := def.Instantiate(nil); err == nil || !strings.Contains(err.Error(), "feature") {
+		t.Errorf("expected missing required parameter error, got %v", err)
+	}
+	if _, err := def.Instantiate(map[string]string{"feature": "x", "bogus": "y"}); err == nil {
+		t.Error("expected an error for an undeclared parameter")
+	}
+
+	tasks, err := def.Instantiate(map[string]string{"feature": "dark mode"})
+	if err != nil {
+		t.Fatalf("Instantiate: %v", err)
+	}
+	if tasks[0].Title != "Spec dark mode" || tasks[0].Prompt != "Write a spec for dark mode." {
+		t.Errorf("params not substituted: %+v", tasks[0])
+	}
+	if tasks[1].Prompt != "Implement the spec: {{tasks.spec.outputs.spec}}" {
+		t.Errorf("task references should be left for spawn time, got %q", tasks[1].Prompt)
+	}
+	if tasks[2].Program != "claude" {
+		t.Errorf("expected default reviewer program, got %q", tasks[2].Program)
+	}
+
+	// Instantiating must not modify the definition.
+	if def.Tasks[0].Title != "Spec {{params.feature}}" {
+		t.Errorf("definition was modified: %q", def.Tasks[0].Title)
+	}
+}
+
+func TestLoadWorkflowDefinitions(t *testing.T) {
+	repo := t.TempDir()
+	writeWorkflowFile(t, repo, "feature.yaml", featureWorkflowYAML)
+	writeWorkflowFile(t, repo, "hotfix.json", `{"description": "quick fix", "tasks": [{"id": "fix"}]}`)
+	writeWorkflowFile(t, repo, "broken.yml", "tasks: [")
+	writeWorkflowFile(t, repo, "README.md", "not a workflow")
+
+	defs, err := LoadWorkflowDefinitions(repo)
+	if err != nil {
+		t.Fatalf("LoadWorkflowDefinitions: %v", err)
+	}
+	if len(defs) != 2 || defs[0].Name != "feature" || defs[1].Name != "hotfix" {
+		t.Fatalf("expected feature and hotfix, got %+v", defs)
+	}
+
+	def, err := LoadWorkflowDefinition(repo, "hotfix")
+	if err != nil {
+		t.Fatalf("LoadWorkflowDefinition: %v", err)
+	}
+	if def.Description != "quick fix" {
+		t.Errorf("unexpected definition: %+v", def)
+	}
+	if _, err := LoadWorkflowDefinition(repo, "broken"); err == nil || !strings.Contains(err.Error(), "broken.yml") {
+		t.Errorf("expected parse error naming the file, got %v", err)
+	}
+	if _, err := LoadWorkflowDefinition(repo, "missing"); err == nil {
+		t.Error("expected an error for a missing workflow")
+	}
+}
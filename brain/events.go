@@ -15,15 +15,44 @@ var errSubscriberNotFound = errors.New("subscriber not found")
 type EventType string
 
 const (
-	EventStatusChanged         EventType = "status_changed"
-	EventMessageReceived       EventType = "message_received"
+	EventStatusChanged   EventType = "status_changed"
+	EventMessageReceived EventType = "message_received"
+	// EventMessageRead fires when an agent receives messages via get_brain or
+	// get_messages. Its source is the reader and its data holds the message ids.
+	EventMessageRead           EventType = "message_read"
 	EventAgentRemoved          EventType = "agent_removed"
 	EventWorkflowDefined       EventType = "workflow_defined"
 	EventTaskCompleted         EventType = "task_completed"
 	EventTaskTriggered         EventType = "task_triggered"
+	EventTaskRetried           EventType = "task_retried"
+	EventWorkflowCompleted     EventType = "workflow_completed"
 	EventInstanceStatusChanged EventType = "instance_status_changed"
 	EventInstanceCreated       EventType = "instance_created"
 	EventInstanceKilled        EventType = "instance_killed"
+	// EventFileClaimConflict fires when a claim_files request overlaps leases
+	// held by other agents. Its data holds patterns, holders, exclusive and
+	// granted (false if the claim was refused).
+	EventFileClaimConflict EventType = "file_claim_conflict"
+	// EventFileOverlap fires when two instances' worktree diffs start touching
+	// the same files. Its data holds instances, files and new_files.
+	EventFileOverlap EventType = "file_overlap"
+	// EventQuestionAsked fires when an agent asks the user something via
+	// ask_user. Its data holds id, question and choices.
+	EventQuestionAsked EventType = "question_asked"
+	// EventQuestionAnswered fires when the user answers a question. Its
+	// source is the agent that asked and its data holds id and answer.
+	EventQuestionAnswered EventType = "question_answered"
+	// EventPermissionRequested fires when an instance's program stops on a
+	// permission prompt that AutoYes will not answer. Its data holds prompt
+	// (the text from the pane), program and parent_title.
+	EventPermissionRequested EventType = "permission_requested"
+	// EventGap is a marker sent to stream subscribers in place of events they
+	// missed. Its data holds from_sequence, to_sequence and dropped (the number
+	// of matching events lost, when known). If the server restarted since the
+	// requested resume point, it holds reset=true instead of to_sequence and
+	// the replay restarts from the new server's first event. Gap markers have
+	// sequence 0 and the epoch of the server sending them.
+	EventGap EventType = "gap"
 )
 
 // Event is a single occurrence pushed to subscribers.
@@ -34,6 +63,29 @@ type Event struct {
 	Source    string         `json:"source"`
 	Data      map[string]any `json:"data,omitempty"`
 	Sequence  uint64         `json:"sequence"`
+	// Epoch identifies the server run that numbered the event. Sequences
+	// restart with every run.
+	Epoch string `json:"epoch,omitempty"`
+}
+
+// EventCursor is where a stream resumes: after Sequence among the events of
+// the server run identified by Epoch. A cursor without an epoch is taken to
+// refer to the running server.
+type EventCursor struct {
+	Epoch    string `json:"epoch,omitempty"`
+	Sequence uint64 `json:"sequence,omitempty"`
+}
+
+// After returns the cursor that resumes after e. A reset gap marker moves the
+// cursor to the start of the new server run.
+func (c EventCursor) After(e Event) EventCursor {
+	switch {
+	case e.Sequence > 0:
+		return EventCursor{Epoch: e.Epoch, Sequence: e.Sequence}
+	case e.Type == EventGap && e.Data["reset"] == true:
+		return EventCursor{Epoch: e.Epoch}
+	}
+	return c
 }
 
 // EventFilter controls which events a subscriber receives.
@@ -51,14 +103,45 @@ type Subscriber struct {
 	notify   chan struct{}
 	lastPoll time.Time
 	mu       sync.Mutex
+
+	// streaming subscribers hold a connection open and are never pruned.
+	streaming bool
+	// gap records events dropped from the buffer since the last drain.
+	gap *eventGap
+}
+
+// eventGap describes a run of sequence numbers a subscriber missed.
+type eventGap struct {
+	from, to uint64
+	dropped  int
+	reset    bool
 }
 
-// EventBus fans events out to matching subscribers with per-subscriber buffering.
+func (g *eventGap) event(epoch string) Event {
+	data := map[string]any{"from_sequence": g.from}
+	if g.reset {
+		data["reset"] = true
+	} else {
+		data["to_sequence"] = g.to
+	}
+	if g.dropped > 0 {
+		data["dropped"] = g.dropped
+	}
+	return Event{Type: EventGap, Timestamp: time.Now(), Data: data, Epoch: epoch}
+}
+
+// EventBus fans events out to matching subscribers with per-subscriber
+// buffering. It also keeps a history of recent events so stream subscribers
+// can resume after reconnecting.
 type EventBus struct {
 	mu          sync.RWMutex
 	subscribers map[string]*Subscriber
 	sequence    atomic.Uint64
 	maxBuffer   int
+	history     []Event
+	// epoch tells this bus's sequence numbers apart from those of earlier
+	// server runs.
+	epoch string
 }
 
 // NewEventBus creates an EventBus. maxBuffer caps each subscriber's queue.
@@ -69,6 +152,7 @@ func NewEventBus(maxBuffer int) *EventBus {
 	return &EventBus{
 		subscribers: make(map[string]*Subscriber),
 		maxBuffer:   maxBuffer,
+		epoch:       generateEpoch(),
 	}
 }
 
@@ -89,15 +173,66 @@ func (eb *EventBus) Subscribe(filter EventFilter) string {
 	return id
 }
 
+// SubscribeStream creates a streaming subscriber and returns its ID along with
+// the matching history events after from, so a reconnecting client can resume
+// where it left off. A zero cursor subscribes to new events only. If some
+// events after from are no longer in history, or from belongs to an earlier
+// server run, the replay starts with a gap marker.
+func (eb *EventBus) SubscribeStream(filter EventFilter, from EventCursor) (string, []Event) {
+	id := generateID()
+	sub := &Subscriber{
+		ID:        id,
+		Filter:    filter,
+		notify:    make(chan struct{}, 1),
+		lastPoll:  time.Now(),
+		streaming: true,
+	}
+
+	// Registering under the write lock orders the replay strictly before any
+	// event delivered to the buffer.
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.subscribers[id] = sub
+
+	if from == (EventCursor{}) {
+		return id, nil
+	}
+
+	var replay []Event
+	since := from.Sequence
+	switch {
+	case from.Epoch != "" && from.Epoch != eb.epoch, from.Epoch == "" && since > eb.sequence.Load():
+		// The cursor is from another server run, whose history is gone.
+		// Without an epoch, only a sequence that went backwards shows it.
+		replay = append(replay, (&eventGap{from: since + 1, reset: true}).event(eb.epoch))
+		since = 0
+	case len(eb.history) > 0 && eb.history[0].Sequence > since+1:
+		replay = append(replay, (&eventGap{from: since + 1, to: eb.history[0].Sequence - 1}).event(eb.epoch))
+	}
+	for _, e := range eb.history {
+		if e.Sequence > since && matchesFilter(e, filter) {
+			replay = append(replay, e)
+		}
+	}
+	return id, replay
+}
+
 // Emit publishes an event to all matching subscribers.
 func (eb *EventBus) Emit(event Event) {
-	event.Sequence = eb.sequence.Add(1)
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now()
 	}
 
-	eb.mu.RLock()
-	defer eb.mu.RUnlock()
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	// Sequence is assigned under the lock so history stays ordered.
+	event.Sequence = eb.sequence.Add(1)
+	event.Epoch = eb.epoch
+	eb.history = append(eb.history, event)
+	if len(eb.history) > eb.maxBuffer {
+		eb.history = eb.history[len(eb.history)-eb.maxBuffer:]
+	}
 
 	for _, sub := range eb.subscribers {
 		if !matchesFilter(event, sub.Filter) {
@@ -106,8 +241,9 @@ func (eb *EventBus) Emit(event Event) {
 
 		sub.mu.Lock()
 		sub.buffer = append(sub.buffer, event)
-		if len(sub.buffer) > eb.maxBuffer {
-			sub.buffer = sub.buffer[len(sub.buffer)-eb.maxBuffer:]
+		if over := len(sub.buffer) - eb.maxBuffer; over > 0 {
+			sub.recordGap(sub.buffer[:over])
+			sub.buffer = sub.buffer[over:]
 		}
 		sub.mu.Unlock()
 
@@ -119,7 +255,8 @@ func (eb *EventBus) Emit(event Event) {
 	}
 }
 
-// Poll drains the subscriber's buffer. If empty, blocks until events arrive or timeout.
+// Poll drains the subscriber's buffer, with a gap marker first if events were
+// dropped. If empty, blocks until events arrive or timeout.
 func (eb *EventBus) Poll(subscriberID string, timeout time.Duration) ([]Event, error) {
 	eb.mu.RLock()
 	sub, ok := eb.subscribers[subscriberID]
@@ -128,15 +265,9 @@ func (eb *EventBus) Poll(subscriberID string, timeout time.Duration) ([]Event, e
 		return nil, errSubscriberNotFound
 	}
 
-	sub.mu.Lock()
-	sub.lastPoll = time.Now()
-	if len(sub.buffer) > 0 {
-		events := sub.buffer
-		sub.buffer = nil
-		sub.mu.Unlock()
+	if events := eb.drain(sub); len(events) > 0 {
 		return events, nil
 	}
-	sub.mu.Unlock()
 
 	// Wait for events or timeout.
 	timer := time.NewTimer(timeout)
@@ -147,12 +278,55 @@ func (eb *EventBus) Poll(subscriberID string, timeout time.Duration) ([]Event, e
 	case <-timer.C:
 	}
 
+	return eb.drain(sub), nil
+}
+
+// Next blocks until a streaming subscriber has events and drains them, with a
+// gap marker first if any were dropped. It returns nil events when stop is
+// closed.
+func (eb *EventBus) Next(subscriberID string, stop <-chan struct{}) ([]Event, error) {
+	eb.mu.RLock()
+	sub, ok := eb.subscribers[subscriberID]
+	eb.mu.RUnlock()
+	if !ok {
+		return nil, errSubscriberNotFound
+	}
+
+	for {
+		if events := eb.drain(sub); len(events) > 0 {
+			return events, nil
+		}
+		select {
+		case <-sub.notify:
+		case <-stop:
+			return nil, nil
+		}
+	}
+}
+
+// drain empties the subscriber's buffer, reporting any events dropped from it
+// as a gap marker first, and clears the gap so it is reported once.
+func (eb *EventBus) drain(sub *Subscriber) []Event {
 	sub.mu.Lock()
-	events := sub.buffer
+	defer sub.mu.Unlock()
+	sub.lastPoll = time.Now()
+	var events []Event
+	if sub.gap != nil {
+		events = append(events, sub.gap.event(eb.epoch))
+		sub.gap = nil
+	}
+	events = append(events, sub.buffer...)
 	sub.buffer = nil
-	sub.mu.Unlock()
+	return events
+}
 
-	return events, nil
+// recordGap notes events dropped from the buffer. Caller holds sub.mu.
+func (sub *Subscriber) recordGap(dropped []Event) {
+	if sub.gap == nil {
+		sub.gap = &eventGap{from: dropped[0].Sequence}
+	}
+	sub.gap.to = dropped[len(dropped)-1].Sequence
+	sub.gap.dropped += len(dropped)
 }
 
 // Unsubscribe removes a subscriber.
@@ -170,7 +344,7 @@ func (eb *EventBus) PruneStale(maxAge time.Duration) int {
 	eb.mu.RLock()
 	for id, sub := range eb.subscribers {
 		sub.mu.Lock()
-		if sub.lastPoll.Before(cutoff) {
+		if !sub.streaming && sub.lastPoll.Before(cutoff) {
 			stale = append(stale, id)
 		}
 		sub.mu.Unlock()
@@ -217,6 +391,13 @@ func sliceContains[T comparable](haystack []T, needle T) bool {
 	return false
 }
 
+// generateEpoch returns a random ID for a server run.
+func generateEpoch() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 func generateID() string {
 	b := make([]byte, 8)
 	rand.Read(b)
@@ -144,12 +144,25 @@ func TestEventBusBufferCap(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(events) != 5 {
-		t.Fatalf("expected 5 events (capped), got %d", len(events))
+	if len(events) != 6 {
+		t.Fatalf("expected gap marker plus 5 events (capped), got %d", len(events))
 	}
 	// Oldest should have been dropped — sequences should be 6..10.
-	if events[0].Sequence != 6 {
-		t.Fatalf("expected first event sequence 6, got %d", events[0].Sequence)
+	if gap := events[0]; gap.Type != EventGap || gap.Data["from_sequence"] != uint64(1) || gap.Data["to_sequence"] != uint64(5) {
+		t.Fatalf("unexpected gap marker: %+v", gap)
+	}
+	if events[1].Sequence != 6 {
+		t.Fatalf("expected first event sequence 6, got %d", events[1].Sequence)
+	}
+
+	// The gap is reported once.
+	eb.Emit(Event{Type: EventStatusChanged, Source: "a"})
+	events, err = eb.Poll(subID, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Sequence != 11 {
+		t.Fatalf("expected only event 11, got %+v", events)
 	}
 }
 
@@ -238,3 +251,147 @@ func TestEventBusConcurrent(t *testing.T) {
 		}
 	}
 }
+
+func TestEventBusStreamResume(t *testing.T) {
+	eb := NewEventBus(100)
+	for i := 0; i < 5; i++ {
+		eb.Emit(Event{Type: EventStatusChanged, Source: "a"})
+	}
+
+	subID, replay := eb.SubscribeStream(EventFilter{}, EventCursor{Sequence: 3})
+	if len(replay) != 2 || replay[0].Sequence != 4 || replay[1].Sequence != 5 {
+		t.Fatalf("expected replay of sequences 4 and 5, got %+v", replay)
+	}
+
+	eb.Emit(Event{Type: EventStatusChanged, Source: "a"})
+	events, err := eb.Next(subID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Sequence != 6 {
+		t.Fatalf("expected live event 6, got %+v", events)
+	}
+
+	_, replay = eb.SubscribeStream(EventFilter{}, EventCursor{})
+	if len(replay) != 0 {
+		t.Fatalf("since 0 should not replay history, got %d events", len(replay))
+	}
+}
+
+func TestEventBusStreamResumeGap(t *testing.T) {
+	eb := NewEventBus(3)
+	for i := 0; i < 10; i++ {
+		eb.Emit(Event{Type: EventStatusChanged, Source: "a"})
+	}
+
+	// History only holds 8..10, so 3..7 are gone.
+	_, replay := eb.SubscribeStream(EventFilter{}, EventCursor{Sequence: 2})
+	if len(replay) != 4 {
+		t.Fatalf("expected gap marker plus 3 events, got %+v", replay)
+	}
+	gap := replay[0]
+	if gap.Type != EventGap || gap.Data["from_sequence"] != uint64(3) || gap.Data["to_sequence"] != uint64(7) {
+		t.Fatalf("unexpected gap marker: %+v", gap)
+	}
+	if replay[1].Sequence != 8 {
+		t.Errorf("expected replay to continue at 8, got %d", replay[1].Sequence)
+	}
+
+	// A resume point ahead of the bus means the server restarted.
+	_, replay = eb.SubscribeStream(EventFilter{}, EventCursor{Sequence: 50})
+	if len(replay) == 0 || replay[0].Type != EventGap || replay[0].Data["reset"] != true {
+		t.Fatalf("expected reset gap marker, got %+v", replay)
+	}
+}
+
+func TestEventBusStreamResumeOtherEpoch(t *testing.T) {
+	old := NewEventBus(100)
+	for i := 0; i < 5; i++ {
+		old.Emit(Event{Type: EventStatusChanged, Source: "a"})
+	}
+	eb := NewEventBus(100)
+	for i := 0; i < 8; i++ {
+		eb.Emit(Event{Type: EventStatusChanged, Source: "b"})
+	}
+
+	// The restarted bus has passed the old resume point, so only the epoch
+	// shows that the sequence numbers don't match up.
+	var from EventCursor
+	for _, e := range old.history {
+		from = from.After(e)
+	}
+	_, replay := eb.SubscribeStream(EventFilter{}, from)
+	if len(replay) != 9 {
+		t.Fatalf("expected reset gap marker plus 8 events, got %+v", replay)
+	}
+	gap := replay[0]
+	if gap.Type != EventGap || gap.Data["reset"] != true || gap.Epoch != eb.epoch {
+		t.Fatalf("expected reset gap marker from the new bus, got %+v", gap)
+	}
+	if replay[1].Sequence != 1 || replay[1].Source != "b" {
+		t.Errorf("expected replay to restart at 1, got %+v", replay[1])
+	}
+
+	// After the reset, the cursor resumes within the new bus's events.
+	from = from.After(gap)
+	if from != (EventCursor{Epoch: eb.epoch}) {
+		t.Fatalf("expected cursor at the start of the new epoch, got %+v", from)
+	}
+	from = from.After(replay[4])
+	_, replay = eb.SubscribeStream(EventFilter{}, from)
+	if len(replay) != 4 || replay[0].Sequence != 5 {
+		t.Fatalf("expected replay of 5..8, got %+v", replay)
+	}
+}
+
+func TestEventBusStreamGapOnOverflow(t *testing.T) {
+	eb := NewEventBus(5)
+	subID, _ := eb.SubscribeStream(EventFilter{Types: []EventType{EventStatusChanged}}, EventCursor{})
+
+	for i := 0; i < 8; i++ {
+		eb.Emit(Event{Type: EventStatusChanged, Source: "a"})
+	}
+
+	events, err := eb.Next(subID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 6 {
+		t.Fatalf("expected gap marker plus 5 events, got %d", len(events))
+	}
+	gap := events[0]
+	if gap.Type != EventGap || gap.Data["dropped"] != 3 || gap.Data["from_sequence"] != uint64(1) || gap.Data["to_sequence"] != uint64(3) {
+		t.Fatalf("unexpected gap marker: %+v", gap)
+	}
+	if events[1].Sequence != 4 {
+		t.Errorf("expected first delivered event 4, got %d", events[1].Sequence)
+	}
+}
+
+func TestEventBusStreamNextStops(t *testing.T) {
+	eb := NewEventBus(10)
+	subID, _ := eb.SubscribeStream(EventFilter{}, EventCursor{})
+
+	stop := make(chan struct{})
+	close(stop)
+	events, err := eb.Next(subID, stop)
+	if err != nil || events != nil {
+		t.Fatalf("expected nil events after stop, got %v, %v", events, err)
+	}
+}
+
+func TestEventBusPruneSkipsStreaming(t *testing.T) {
+	eb := NewEventBus(10)
+	subID, _ := eb.SubscribeStream(EventFilter{}, EventCursor{})
+
+	eb.mu.RLock()
+	sub := eb.subscribers[subID]
+	eb.mu.RUnlock()
+	sub.mu.Lock()
+	sub.lastPoll = time.Now().Add(-time.Hour)
+	sub.mu.Unlock()
+
+	if removed := eb.PruneStale(5 * time.Minute); removed != 0 {
+		t.Fatalf("streaming subscribers should not be pruned, removed %d", removed)
+	}
+}
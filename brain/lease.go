@@ -0,0 +1,319 @@
+package brain
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLeaseTTL is how long a file claim lasts unless renewed.
+	DefaultLeaseTTL = 30 * time.Minute
+	// maxLeaseTTL caps the lifetime an agent may request for a claim.
+	maxLeaseTTL = 24 * time.Hour
+)
+
+// FileLease is an advisory claim by an agent on the repo paths matching
+// Pattern. Patterns are repo-relative and may use path.Match wildcards plus
+// "**" to match any number of directories.
+type FileLease struct {
+	Pattern   string    `json:"pattern"`
+	Holder    string    `json:"holder"`
+	Exclusive bool      `json:"exclusive,omitempty"`
+	ClaimedAt time.Time `json:"claimed_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LeaseConflict describes an existing lease that overlaps a claimed pattern.
+type LeaseConflict struct {
+	Pattern     string `json:"pattern"`
+	Holder      string `json:"holder"`
+	HeldPattern string `json:"held_pattern"`
+	Exclusive   bool   `json:"exclusive,omitempty"`
+}
+
+// ClaimOptions controls how ClaimFiles treats overlapping leases.
+type ClaimOptions struct {
+	// Exclusive makes the claim fail if any pattern overlaps another agent's
+	// lease, and makes later overlapping claims by others fail.
+	Exclusive bool
+	// TTL is how long the lease lasts. Zero uses DefaultLeaseTTL.
+	TTL time.Duration
+}
+
+// ClaimFilesResult is returned by claim_files. When Granted is false no
+// lease was taken and Conflicts lists the leases that blocked the claim.
+type ClaimFilesResult struct {
+	Granted   bool            `json:"granted"`
+	Leases    []FileLease     `json:"leases,omitempty"`
+	Conflicts []LeaseConflict `json:"conflicts,omitempty"`
+}
+
+// ClaimFiles takes or renews leases on patterns for holder. Overlaps with
+// other agents' shared leases are reported but do not block the claim; an
+// overlap involving an exclusive lease, on either side, fails the whole claim.
+// Every overlap emits an EventFileClaimConflict.
+func (m *Manager) ClaimFiles(repoPath, holder string, patterns []string, opts ClaimOptions) (*ClaimFilesResult, error) {
+	patterns, err := normalizePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no files to claim")
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	if ttl > maxLeaseTTL {
+		ttl = maxLeaseTTL
+	}
+
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	now := time.Now().UTC()
+	pruneLeases(rs, now)
+
+	result := &ClaimFilesResult{Granted: true}
+	for _, p := range patterns {
+		for _, l := range rs.leases {
+			if l.Holder == holder || !patternsOverlap(p, l.Pattern) {
+				continue
+			}
+			result.Conflicts = append(result.Conflicts, LeaseConflict{
+				Pattern:     p,
+				Holder:      l.Holder,
+				HeldPattern: l.Pattern,
+				Exclusive:   l.Exclusive,
+			})
+			if opts.Exclusive || l.Exclusive {
+				result.Granted = false
+			}
+		}
+	}
+
+	if result.Granted {
+		for _, p := range patterns {
+			lease := rs.findLease(holder, p)
+			if lease == nil {
+				lease = &FileLease{Pattern: p, Holder: holder, ClaimedAt: now}
+				rs.leases = append(rs.leases, lease)
+			}
+			lease.Exclusive = opts.Exclusive
+			lease.ExpiresAt = now.Add(ttl)
+			result.Leases = append(result.Leases, *lease)
+		}
+		m.persist(repoPath, rs)
+	}
+	rs.mu.Unlock()
+
+	if len(result.Conflicts) > 0 {
+		holders := make([]string, 0, len(result.Conflicts))
+		for _, c := range result.Conflicts {
+			if !sliceContains(holders, c.Holder) {
+				holders = append(holders, c.Holder)
+			}
+		}
+		m.emitEvent(EventFileClaimConflict, repoPath, holder, map[string]any{
+			"patterns":  patterns,
+			"holders":   holders,
+			"exclusive": opts.Exclusive,
+			"granted":   result.Granted,
+		})
+	}
+	return result, nil
+}
+
+// ReleaseFiles drops holder's leases on the given patterns, or all of its
+// leases if none are given. It returns the number of leases released.
+func (m *Manager) ReleaseFiles(repoPath, holder string, patterns []string) (int, error) {
+	patterns, err := normalizePatterns(patterns)
+	if err != nil {
+		return 0, err
+	}
+
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	released := releaseLeases(rs, holder, patterns)
+	if released > 0 {
+		m.persist(repoPath, rs)
+	}
+	return released, nil
+}
+
+// ListLeases returns the repo's unexpired leases sorted by pattern.
+func (m *Manager) ListLeases(repoPath string) []FileLease {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	pruneLeases(rs, time.Now())
+	return copyLeases(rs)
+}
+
+// findLease returns holder's lease on pattern. Caller must hold rs.mu.
+func (rs *repoState) findLease(holder, pattern string) *FileLease {
+	for _, l := range rs.leases {
+		if l.Holder == holder && l.Pattern == pattern {
+			return l
+		}
+	}
+	return nil
+}
+
+// releaseLeases removes holder's leases on patterns, or all of them if
+// patterns is empty. Caller must hold rs.mu write lock.
+func releaseLeases(rs *repoState, holder string, patterns []string) int {
+	kept := rs.leases[:0]
+	released := 0
+	for _, l := range rs.leases {
+		if l.Holder == holder && (len(patterns) == 0 || sliceContains(patterns, l.Pattern)) {
+			released++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	rs.leases = kept
+	return released
+}
+
+// pruneLeases drops expired leases. Caller must hold rs.mu write lock.
+func pruneLeases(rs *repoState, now time.Time) {
+	kept := rs.leases[:0]
+	for _, l := range rs.leases {
+		if l.ExpiresAt.After(now) {
+			kept = append(kept, l)
+		}
+	}
+	rs.leases = kept
+}
+
+// copyLeases returns a sorted copy of the repo's leases. Caller must hold rs.mu.
+func copyLeases(rs *repoState) []FileLease {
+	leases := make([]FileLease, len(rs.leases))
+	for i, l := range rs.leases {
+		leases[i] = *l
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].Pattern != leases[j].Pattern {
+			return leases[i].Pattern < leases[j].Pattern
+		}
+		return leases[i].Holder < leases[j].Holder
+	})
+	return leases
+}
+
+// leaseConflicts returns warnings for files covered by other agents' leases.
+// Caller must hold rs.mu.
+func leaseConflicts(rs *repoState, holder string, files []string, now time.Time) []string {
+	var warnings []string
+	for _, f := range files {
+		f = cleanPattern(f)
+		for _, l := range rs.leases {
+			if l.Holder == holder || !l.ExpiresAt.After(now) || !patternsOverlap(f, l.Pattern) {
+				continue
+			}
+			kind := "claimed"
+			if l.Exclusive {
+				kind = "exclusively claimed"
+			}
+			warnings = append(warnings, fmt.Sprintf("%s is %s by %s (%s)", f, kind, l.Holder, l.Pattern))
+		}
+	}
+	return warnings
+}
+
+// normalizePatterns cleans and validates claim patterns, dropping duplicates.
+func normalizePatterns(patterns []string) ([]string, error) {
+	var out []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = cleanPattern(p)
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		if !sliceContains(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// cleanPattern makes a pattern comparable regardless of "./" prefixes,
+// duplicate separators and trailing slashes.
+func cleanPattern(p string) string {
+	return strings.TrimPrefix(path.Clean(strings.TrimPrefix(p, "./")), "/")
+}
+
+// hasGlobMeta reports whether p contains wildcard characters.
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
+
+// matchGlob reports whether name matches pattern, where a "**" segment
+// matches zero or more path segments and a directory pattern without
+// wildcards matches everything beneath it.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	// A fully matched literal prefix covers the files inside it, so that a
+	// claim on "internal/api" overlaps "internal/api/server.go".
+	return true
+}
+
+// patternsOverlap reports whether two patterns may match a common path.
+// When both contain wildcards it is conservative: they overlap unless their
+// literal leading directories diverge.
+func patternsOverlap(a, b string) bool {
+	switch {
+	case !hasGlobMeta(a):
+		return matchGlob(b, a) || matchGlob(a, b)
+	case !hasGlobMeta(b):
+		return matchGlob(a, b) || matchGlob(b, a)
+	}
+	pa, pb := literalPrefix(a), literalPrefix(b)
+	n := min(len(pa), len(pb))
+	for i := 0; i < n; i++ {
+		if pa[i] != pb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// literalPrefix returns the leading path segments of p without wildcards.
+func literalPrefix(p string) []string {
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		if hasGlobMeta(s) {
+			return segs[:i]
+		}
+	}
+	return segs
+}
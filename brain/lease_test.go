@@ -0,0 +1,142 @@
+package brain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"api/server.go", "api/server.go", true},
+		{"api/server.go", "api/client.go", false},
+		{"api", "api/server.go", true},
+		{"api/*.go", "api/server.go", true},
+		{"api/*.go", "api/v2/server.go", false},
+		{"api/**/*.go", "api/v2/server.go", true},
+		{"**", "web/index.html", true},
+		{"api/**", "web/*.ts", false},
+		{"api/**/*.go", "api/v2/*.go", true},
+		{"*.md", "docs/intro.md", false},
+	}
+	for _, tt := range tests {
+		if got := patternsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("patternsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := patternsOverlap(tt.b, tt.a); got != tt.want {
+			t.Errorf("patternsOverlap(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestManagerClaimFilesShared(t *testing.T) {
+	m := NewManager()
+	var events []Event
+	m.SetEventCallback(func(e Event) { events = append(events, e) })
+
+	res, err := m.ClaimFiles("/repo", "agent-1", []string{"./api/", "web/app.ts"}, ClaimOptions{})
+	if err != nil {
+		t.Fatalf("ClaimFiles: %v", err)
+	}
+	if !res.Granted || len(res.Leases) != 2 || res.Leases[0].Pattern != "api" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, err = m.ClaimFiles("/repo", "agent-2", []string{"api/**/*.go"}, ClaimOptions{})
+	if err != nil {
+		t.Fatalf("ClaimFiles: %v", err)
+	}
+	if !res.Granted || len(res.Conflicts) != 1 || res.Conflicts[0].Holder != "agent-1" {
+		t.Fatalf("shared claim should be granted with a conflict, got %+v", res)
+	}
+	if len(events) != 1 || events[0].Type != EventFileClaimConflict || events[0].Data["granted"] != true {
+		t.Errorf("expected one file_claim_conflict event, got %+v", events)
+	}
+
+	warnings := m.UpdateStatus("/repo", "agent-3", "docs", []string{"api/server.go"}).Conflicts
+	if len(warnings) != 2 {
+		t.Errorf("expected warnings for both leases, got %v", warnings)
+	}
+
+	if got := len(m.GetBrain("/repo", "agent-3").Leases); got != 3 {
+		t.Errorf("expected 3 leases in get_brain, got %d", got)
+	}
+}
+
+func TestManagerClaimFilesExclusive(t *testing.T) {
+	m := NewManager()
+
+	if _, err := m.ClaimFiles("/repo", "agent-1", []string{"api/server.go"}, ClaimOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := m.ClaimFiles("/repo", "agent-2", []string{"api/**", "web/**"}, ClaimOptions{Exclusive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Granted || len(res.Leases) != 0 {
+		t.Fatalf("exclusive claim over a held file should fail, got %+v", res)
+	}
+	if len(m.ListLeases("/repo")) != 1 {
+		t.Error("a refused claim must not take any lease")
+	}
+
+	// An exclusive lease also blocks later shared claims by others.
+	if res, _ := m.ClaimFiles("/repo", "agent-2", []string{"web/**"}, ClaimOptions{Exclusive: true}); !res.Granted {
+		t.Fatalf("expected exclusive claim on free paths, got %+v", res)
+	}
+	res, _ = m.ClaimFiles("/repo", "agent-3", []string{"web/index.html"}, ClaimOptions{})
+	if res.Granted || !res.Conflicts[0].Exclusive {
+		t.Errorf("shared claim over an exclusive lease should fail, got %+v", res)
+	}
+
+	// The holder can renew its own lease.
+	if res, _ := m.ClaimFiles("/repo", "agent-2", []string{"web/**"}, ClaimOptions{Exclusive: true}); !res.Granted {
+		t.Errorf("renewing an own lease should succeed, got %+v", res)
+	}
+}
+
+func TestManagerReleaseFiles(t *testing.T) {
+	m := NewManager()
+	m.ClaimFiles("/repo", "agent-1", []string{"a.go", "b.go", "c.go"}, ClaimOptions{})
+	m.ClaimFiles("/repo", "agent-2", []string{"d.go"}, ClaimOptions{})
+
+	if n, _ := m.ReleaseFiles("/repo", "agent-1", []string{"./a.go"}); n != 1 {
+		t.Errorf("released %d, want 1", n)
+	}
+	if n, _ := m.ReleaseFiles("/repo", "agent-1", nil); n != 2 {
+		t.Errorf("released %d, want 2", n)
+	}
+
+	m.RemoveAgent("/repo", "agent-2")
+	if leases := m.ListLeases("/repo"); len(leases) != 0 {
+		t.Errorf("expected no leases left, got %+v", leases)
+	}
+}
+
+func TestManagerLeaseExpiry(t *testing.T) {
+	m := NewManager()
+	if _, err := m.ClaimFiles("/repo", "agent-1", []string{"a.go"}, ClaimOptions{Exclusive: true, TTL: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if leases := m.ListLeases("/repo"); len(leases) != 0 {
+		t.Errorf("expected the lease to expire, got %+v", leases)
+	}
+	if res, _ := m.ClaimFiles("/repo", "agent-2", []string{"a.go"}, ClaimOptions{}); !res.Granted || len(res.Conflicts) != 0 {
+		t.Errorf("expired lease should not conflict, got %+v", res)
+	}
+}
+
+func TestManagerClaimFilesInvalid(t *testing.T) {
+	m := NewManager()
+	if _, err := m.ClaimFiles("/repo", "agent-1", []string{" "}, ClaimOptions{}); err == nil {
+		t.Error("expected an error for an empty claim")
+	}
+	if _, err := m.ClaimFiles("/repo", "agent-1", []string{"api/[.go"}, ClaimOptions{}); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("expected invalid pattern error, got %v", err)
+	}
+}
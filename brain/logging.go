@@ -0,0 +1,19 @@
+package brain
+
+import "github.com/ByteMirror/hivemind/log"
+
+// logInfo writes to the application info log when it has been initialized.
+// The brain package is also used from tests and the MCP binary, where the
+// application log is never set up.
+func logInfo(format string, args ...any) {
+	if log.InfoLog != nil {
+		log.InfoLog.Printf(format, args...)
+	}
+}
+
+// logWarn writes to the application warning log when it has been initialized.
+func logWarn(format string, args ...any) {
+	if log.WarningLog != nil {
+		log.WarningLog.Printf(format, args...)
+	}
+}
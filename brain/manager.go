@@ -14,10 +14,22 @@ const (
 
 // repoState holds brain state for a single repository.
 type repoState struct {
-	mu       sync.RWMutex
-	agents   map[string]*AgentStatus
-	messages []BrainMessage
-	workflow *Workflow
+	mu        sync.RWMutex
+	agents    map[string]*AgentStatus
+	messages  []BrainMessage
+	workflows map[string]*Workflow
+	leases    []*FileLease
+	// lastMessageID is the ID of the newest message ever posted, so IDs are
+	// never reused after older messages are trimmed.
+	lastMessageID int64
+	// questions holds ask_user questions, pending and recently answered.
+	questions []*Question
+	// questionWaiters is closed when the keyed question is answered or
+	// withdrawn.
+	questionWaiters map[string]chan struct{}
+	// overlaps is derived from worktree diffs on every refresh, so it is
+	// kept in memory only.
+	overlaps []FileOverlap
 }
 
 // Manager holds per-repo brain state in memory with mutex protection.
@@ -26,6 +38,8 @@ type Manager struct {
 	mu      sync.RWMutex
 	repos   map[string]*repoState
 	onEvent func(Event)
+	// store persists state after every mutation. Nil for in-memory only.
+	store *Store
 }
 
 // NewManager creates a new empty Manager.
@@ -35,6 +49,65 @@ func NewManager() *Manager {
 	}
 }
 
+// NewManagerWithStore creates a Manager backed by store. Previously saved state
+// is replayed so workflows resume with their task statuses intact.
+func NewManagerWithStore(store *Store) *Manager {
+	m := NewManager()
+	m.store = store
+
+	snaps, err := store.loadAll()
+	if err != nil {
+		logWarn("brain: failed to load state from %s: %v", store.Dir(), err)
+		return m
+	}
+	for _, snap := range snaps {
+		rs := &repoState{
+			agents:    snap.Agents,
+			messages:  snap.Messages,
+			workflows: make(map[string]*Workflow, len(snap.Workflows)),
+			leases:    snap.Leases,
+
+			lastMessageID: snap.LastMessageID,
+			questions:     snap.Questions,
+		}
+		assignMessageIDs(rs)
+		if rs.agents == nil {
+			rs.agents = make(map[string]*AgentStatus)
+		}
+		for _, wf := range snap.Workflows {
+			rs.workflows[wf.ID] = wf
+		}
+		m.repos[snap.RepoPath] = rs
+	}
+	if len(snaps) > 0 {
+		logInfo("brain: restored state for %d repo(s) from %s", len(snaps), store.Dir())
+	}
+	return m
+}
+
+// persist writes the repo's state to the store, if one is configured.
+// Caller must hold rs.mu (read or write) so writes for a repo are ordered.
+func (m *Manager) persist(repoPath string, rs *repoState) {
+	if m.store == nil {
+		return
+	}
+	snap := &repoSnapshot{
+		RepoPath: repoPath,
+		Agents:   rs.agents,
+		Messages: rs.messages,
+		Leases:   rs.leases,
+
+		LastMessageID: rs.lastMessageID,
+		Questions:     rs.questions,
+	}
+	for _, id := range sortedWorkflowIDs(rs) {
+		snap.Workflows = append(snap.Workflows, rs.workflows[id])
+	}
+	if err := m.store.save(snap); err != nil {
+		logWarn("brain: failed to persist state for %s: %v", repoPath, err)
+	}
+}
+
 // SetEventCallback sets the function called when the manager emits an event.
 func (m *Manager) SetEventCallback(fn func(Event)) {
 	m.onEvent = fn
@@ -69,7 +142,8 @@ func (m *Manager) getOrCreateRepo(repoPath string) *repoState {
 		return rs
 	}
 	rs = &repoState{
-		agents: make(map[string]*AgentStatus),
+		agents:    make(map[string]*AgentStatus),
+		workflows: make(map[string]*Workflow),
 	}
 	m.repos[repoPath] = rs
 	return rs
@@ -80,7 +154,6 @@ func (m *Manager) getOrCreateRepo(repoPath string) *repoState {
 func (m *Manager) GetBrain(repoPath, instanceID string) *BrainState {
 	rs := m.getOrCreateRepo(repoPath)
 	rs.mu.Lock()
-	defer rs.mu.Unlock()
 
 	pruneStale(rs)
 
@@ -92,18 +165,38 @@ func (m *Manager) GetBrain(repoPath, instanceID string) *BrainState {
 		agents[id] = &cp
 	}
 
-	// Filter messages: only those addressed to this agent or broadcast.
+	// Filter messages: only those addressed to this agent or broadcast. They
+	// count as read once delivered here.
 	var msgs []BrainMessage
-	for _, msg := range rs.messages {
-		if msg.To == instanceID || msg.To == "" {
-			msgs = append(msgs, msg)
+	var read []int64
+	for i := range rs.messages {
+		msg := &rs.messages[i]
+		if !msg.visibleTo(instanceID) {
+			continue
+		}
+		msgs = append(msgs, msg.copy())
+		if msg.From != instanceID && markRead(msg, instanceID) {
+			read = append(read, msg.ID)
 		}
 	}
+	if len(read) > 0 {
+		trimMessages(rs)
+		m.persist(repoPath, rs)
+	}
+
+	pruneLeases(rs, time.Now())
 
-	return &BrainState{
+	state := &BrainState{
 		Agents:   agents,
 		Messages: msgs,
+		Leases:   copyLeases(rs),
+		Overlaps: append([]FileOverlap(nil), rs.overlaps...),
 	}
+	rs.mu.Unlock()
+
+	// Emitted after unlocking so subscribers may call back into the manager.
+	m.emitMessagesRead(repoPath, instanceID, read)
+	return state
 }
 
 // UpdateStatus sets the agent's feature and files, returning conflict warnings.
@@ -111,35 +204,20 @@ func (m *Manager) UpdateStatus(repoPath, instanceID, feature string, files []str
 	return m.UpdateStatusWithRole(repoPath, instanceID, feature, files, "")
 }
 
-// SendMessage appends a message to the repo's message list, capping at maxMessages.
+// SendMessage appends a message to the repo's message list. See PostMessage.
 func (m *Manager) SendMessage(repoPath, from, to, content string) {
-	rs := m.getOrCreateRepo(repoPath)
-	rs.mu.Lock()
-
-	rs.messages = append(rs.messages, BrainMessage{
-		From:      from,
-		To:        to,
-		Content:   content,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-	})
-
-	if len(rs.messages) > maxMessages {
-		rs.messages = rs.messages[len(rs.messages)-maxMessages:]
-	}
-
-	rs.mu.Unlock()
-
-	m.emitEvent(EventMessageReceived, repoPath, from, map[string]any{
-		"to":      to,
-		"content": content,
-	})
+	m.PostMessage(repoPath, from, to, content, 0)
 }
 
-// RemoveAgent removes an agent from the repo's state.
+// RemoveAgent removes an agent from the repo's state, releases its file leases
+// and withdraws its unanswered questions.
 func (m *Manager) RemoveAgent(repoPath, instanceID string) {
 	rs := m.getOrCreateRepo(repoPath)
 	rs.mu.Lock()
 	delete(rs.agents, instanceID)
+	releaseLeases(rs, instanceID, nil)
+	withdrawQuestions(rs, instanceID)
+	m.persist(repoPath, rs)
 	rs.mu.Unlock()
 
 	m.emitEvent(EventAgentRemoved, repoPath, instanceID, nil)
@@ -191,7 +269,9 @@ func (m *Manager) UpdateStatusWithRole(repoPath, instanceID, feature string, fil
 			warnings = append(warnings, fmt.Sprintf("%s is also being worked on by: %s", f, strings.Join(agents, ", ")))
 		}
 	}
+	warnings = append(warnings, leaseConflicts(rs, instanceID, files, time.Now())...)
 
+	m.persist(repoPath, rs)
 	rs.mu.Unlock()
 
 	eventData := map[string]any{
@@ -205,137 +285,3 @@ func (m *Manager) UpdateStatusWithRole(repoPath, instanceID, feature string, fil
 
 	return &UpdateStatusResult{Conflicts: warnings}
 }
-
-// DefineWorkflow creates or replaces a workflow for a repo.
-func (m *Manager) DefineWorkflow(repoPath string, tasks []*WorkflowTask) *WorkflowResult {
-	rs := m.getOrCreateRepo(repoPath)
-	rs.mu.Lock()
-
-	wfID := fmt.Sprintf("wf-%d", time.Now().UnixMilli())
-	// Ensure all tasks start as pending.
-	for _, t := range tasks {
-		if t.Status == "" {
-			t.Status = TaskPending
-		}
-	}
-	rs.workflow = &Workflow{
-		ID:    wfID,
-		Tasks: tasks,
-	}
-
-	rs.mu.Unlock()
-
-	m.emitEvent(EventWorkflowDefined, repoPath, "", map[string]any{
-		"workflow_id": wfID,
-		"task_count":  len(tasks),
-	})
-
-	return &WorkflowResult{WorkflowID: wfID}
-}
-
-// GetWorkflow returns the current workflow for a repo, or nil if none exists.
-func (m *Manager) GetWorkflow(repoPath string) *Workflow {
-	rs := m.getOrCreateRepo(repoPath)
-	rs.mu.RLock()
-	defer rs.mu.RUnlock()
-	return rs.workflow
-}
-
-// GetWorkflowTask returns a single task from the workflow by ID.
-func (m *Manager) GetWorkflowTask(repoPath, taskID string) *WorkflowTask {
-	rs := m.getOrCreateRepo(repoPath)
-	rs.mu.RLock()
-	defer rs.mu.RUnlock()
-
-	if rs.workflow == nil {
-		return nil
-	}
-	for _, t := range rs.workflow.Tasks {
-		if t.ID == taskID {
-			return t
-		}
-	}
-	return nil
-}
-
-// CompleteTask marks a workflow task as done or failed.
-func (m *Manager) CompleteTask(repoPath, taskID string, status TaskStatus, errMsg string) error {
-	rs := m.getOrCreateRepo(repoPath)
-	rs.mu.Lock()
-
-	if rs.workflow == nil {
-		rs.mu.Unlock()
-		return fmt.Errorf("no workflow defined for repo")
-	}
-
-	found := false
-	for _, t := range rs.workflow.Tasks {
-		if t.ID == taskID {
-			t.Status = status
-			t.Error = errMsg
-			found = true
-			break
-		}
-	}
-
-	rs.mu.Unlock()
-
-	if !found {
-		return fmt.Errorf("task %q not found in workflow", taskID)
-	}
-
-	m.emitEvent(EventTaskCompleted, repoPath, taskID, map[string]any{
-		"task_id": taskID,
-		"status":  string(status),
-	})
-
-	return nil
-}
-
-// EvaluateWorkflow checks for pending tasks whose dependencies are all done,
-// marks them as running, and returns their IDs.
-func (m *Manager) EvaluateWorkflow(repoPath string) []string {
-	rs := m.getOrCreateRepo(repoPath)
-	rs.mu.Lock()
-
-	if rs.workflow == nil {
-		rs.mu.Unlock()
-		return nil
-	}
-
-	// Build status index.
-	statusMap := make(map[string]TaskStatus, len(rs.workflow.Tasks))
-	for _, t := range rs.workflow.Tasks {
-		statusMap[t.ID] = t.Status
-	}
-
-	var triggered []string
-	for _, t := range rs.workflow.Tasks {
-		if t.Status != TaskPending {
-			continue
-		}
-
-		// Check all dependencies are done.
-		allDone := true
-		for _, dep := range t.DependsOn {
-			if statusMap[dep] != TaskDone {
-				allDone = false
-				break
-			}
-		}
-		if allDone {
-			t.Status = TaskRunning
-			triggered = append(triggered, t.ID)
-		}
-	}
-
-	rs.mu.Unlock()
-
-	for _, taskID := range triggered {
-		m.emitEvent(EventTaskTriggered, repoPath, taskID, map[string]any{
-			"task_id": taskID,
-		})
-	}
-
-	return triggered
-}
@@ -0,0 +1,169 @@
+package brain
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxPendingMessages caps how many messages a repo keeps when the recipients
+// of directed messages never read them.
+const maxPendingMessages = 500
+
+// PostMessage appends a message from one agent to another, or to all agents if
+// to is empty, and returns it with its ID. A message with replyTo joins that
+// message's thread; when to is empty the reply goes to the original sender.
+func (m *Manager) PostMessage(repoPath, from, to, content string, replyTo int64) (*BrainMessage, error) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	if replyTo != 0 {
+		if replyTo < 0 || replyTo > rs.lastMessageID {
+			rs.mu.Unlock()
+			return nil, fmt.Errorf("message %d not found", replyTo)
+		}
+		if orig := rs.findMessage(replyTo); orig != nil && to == "" && orig.From != from {
+			to = orig.From
+		}
+	}
+
+	rs.lastMessageID++
+	msg := BrainMessage{
+		ID:        rs.lastMessageID,
+		From:      from,
+		To:        to,
+		Content:   content,
+		ReplyTo:   replyTo,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	rs.messages = append(rs.messages, msg)
+	trimMessages(rs)
+
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	data := map[string]any{
+		"id":      msg.ID,
+		"to":      to,
+		"content": content,
+	}
+	if replyTo != 0 {
+		data["reply_to"] = replyTo
+	}
+	m.emitEvent(EventMessageReceived, repoPath, from, data)
+	return &msg, nil
+}
+
+// GetMessages returns the messages from other agents addressed to instanceID
+// or broadcast, with an ID greater than since, and marks them read by
+// instanceID. With unreadOnly, messages instanceID has already read are left
+// out.
+func (m *Manager) GetMessages(repoPath, instanceID string, since int64, unreadOnly bool) []BrainMessage {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	var msgs []BrainMessage
+	var read []int64
+	for i := range rs.messages {
+		msg := &rs.messages[i]
+		if msg.ID <= since || msg.From == instanceID || !msg.visibleTo(instanceID) {
+			continue
+		}
+		if unreadOnly && sliceContains(msg.ReadBy, instanceID) {
+			continue
+		}
+		msgs = append(msgs, msg.copy())
+		if markRead(msg, instanceID) {
+			read = append(read, msg.ID)
+		}
+	}
+	if len(read) > 0 {
+		trimMessages(rs)
+		m.persist(repoPath, rs)
+	}
+	rs.mu.Unlock()
+
+	m.emitMessagesRead(repoPath, instanceID, read)
+	return msgs
+}
+
+// emitMessagesRead tells subscribers, typically the senders, that reader has
+// received the messages.
+func (m *Manager) emitMessagesRead(repoPath, reader string, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	m.emitEvent(EventMessageRead, repoPath, reader, map[string]any{"ids": ids})
+}
+
+// findMessage returns the message with the given ID, or nil if it has been
+// trimmed. Caller must hold rs.mu.
+func (rs *repoState) findMessage(id int64) *BrainMessage {
+	for i := range rs.messages {
+		if rs.messages[i].ID == id {
+			return &rs.messages[i]
+		}
+	}
+	return nil
+}
+
+// visibleTo reports whether the message is addressed to instanceID or broadcast.
+func (msg *BrainMessage) visibleTo(instanceID string) bool {
+	return msg.To == instanceID || msg.To == ""
+}
+
+// settled reports whether the message may be trimmed: broadcasts always may,
+// directed messages only once their recipient has read them.
+func (msg *BrainMessage) settled() bool {
+	return msg.To == "" || sliceContains(msg.ReadBy, msg.To)
+}
+
+func (msg *BrainMessage) copy() BrainMessage {
+	cp := *msg
+	cp.ReadBy = append([]string(nil), msg.ReadBy...)
+	return cp
+}
+
+// markRead records that reader has received msg, returning false if it
+// already had.
+func markRead(msg *BrainMessage, reader string) bool {
+	if sliceContains(msg.ReadBy, reader) {
+		return false
+	}
+	msg.ReadBy = append(msg.ReadBy, reader)
+	return true
+}
+
+// trimMessages drops the oldest settled messages beyond maxMessages, and the
+// oldest of any kind beyond maxPendingMessages. Caller must hold rs.mu write
+// lock.
+func trimMessages(rs *repoState) {
+	excess := len(rs.messages) - maxMessages
+	if excess > 0 {
+		kept := rs.messages[:0]
+		for _, msg := range rs.messages {
+			if excess > 0 && msg.settled() {
+				excess--
+				continue
+			}
+			kept = append(kept, msg)
+		}
+		rs.messages = kept
+	}
+	if len(rs.messages) > maxPendingMessages {
+		rs.messages = rs.messages[len(rs.messages)-maxPendingMessages:]
+	}
+}
+
+// assignMessageIDs numbers messages saved before IDs existed, continuing
+// from the highest ID seen.
+func assignMessageIDs(rs *repoState) {
+	for _, msg := range rs.messages {
+		rs.lastMessageID = max(rs.lastMessageID, msg.ID)
+	}
+	for i := range rs.messages {
+		if rs.messages[i].ID == 0 {
+			rs.lastMessageID++
+			rs.messages[i].ID = rs.lastMessageID
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package brain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestManagerPostMessageThreads(t *testing.T) {
+	m := NewManager()
+
+	q, err := m.PostMessage("/repo", "lead", "", "who owns auth.go?", 0)
+	if err != nil {
+		t.Fatalf("PostMessage: %v", err)
+	}
+	if q.ID != 1 {
+		t.Errorf("first message id = %d, want 1", q.ID)
+	}
+
+	reply, err := m.PostMessage("/repo", "coder", "", "I do", q.ID)
+	if err != nil {
+		t.Fatalf("PostMessage reply: %v", err)
+	}
+	if reply.ID != 2 || reply.ReplyTo != q.ID || reply.To != "lead" {
+		t.Errorf("reply = %+v, want id 2 addressed to lead in reply to 1", reply)
+	}
+
+	if _, err := m.PostMessage("/repo", "coder", "", "?", 99); err == nil {
+		t.Error("expected an error replying to an unknown message")
+	}
+}
+
+func TestManagerGetMessagesMarksRead(t *testing.T) {
+	m := NewManager()
+	var events []Event
+	m.SetEventCallback(func(e Event) { events = append(events, e) })
+
+	m.SendMessage("/repo", "agent-1", "agent-2", "first")
+	m.SendMessage("/repo", "agent-2", "", "my own broadcast")
+	m.SendMessage("/repo", "agent-1", "", "second")
+
+	msgs := m.GetMessages("/repo", "agent-2", 0, true)
+	if len(msgs) != 2 || msgs[0].Content != "first" || msgs[1].Content != "second" {
+		t.Fatalf("expected first and second, got %+v", msgs)
+	}
+	if last := events[len(events)-1]; last.Type != EventMessageRead || last.Source != "agent-2" {
+		t.Errorf("expected message_read event from agent-2, got %+v", last)
+	}
+
+	if msgs := m.GetMessages("/repo", "agent-2", 0, true); len(msgs) != 0 {
+		t.Errorf("expected no unread messages, got %+v", msgs)
+	}
+	if msgs := m.GetMessages("/repo", "agent-2", 1, false); len(msgs) != 1 || msgs[0].Content != "second" {
+		t.Errorf("expected only messages after id 1, got %+v", msgs)
+	}
+	// Other agents still see the broadcast as unread.
+	if msgs := m.GetMessages("/repo", "agent-3", 0, true); len(msgs) != 2 {
+		t.Errorf("expected 2 broadcasts for agent-3, got %+v", msgs)
+	}
+}
+
+func TestManagerGetBrainEmitsReadUnlocked(t *testing.T) {
+	m := NewManager()
+	var read []Event
+	m.SetEventCallback(func(e Event) {
+		if e.Type == EventMessageRead {
+			// Subscribers may call back into the manager.
+			m.GetBrain("/repo", "observer")
+			read = append(read, e)
+		}
+	})
+
+	m.SendMessage("/repo", "agent-1", "agent-2", "hello")
+	done := make(chan struct{})
+	go func() {
+		m.GetBrain("/repo", "agent-2")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetBrain deadlocked emitting message_read")
+	}
+	if len(read) != 1 || read[0].Source != "agent-2" {
+		t.Errorf("expected one message_read event from agent-2, got %+v", read)
+	}
+}
+
+func TestManagerKeepsUnreadDirectedMessages(t *testing.T) {
+	m := NewManager()
+
+	m.SendMessage("/repo", "lead", "slow-agent", "important")
+	for i := 0; i < maxMessages+10; i++ {
+		m.SendMessage("/repo", "agent-1", "", fmt.Sprintf("chatter-%d", i))
+	}
+
+	msgs := m.GetMessages("/repo", "slow-agent", 0, true)
+	if len(msgs) != maxMessages || msgs[0].Content != "important" {
+		t.Fatalf("expected the unread message to survive trimming, got %d messages starting %+v", len(msgs), msgs[0])
+	}
+
+	// Once read, it is trimmed like any other message.
+	m.SendMessage("/repo", "agent-1", "", "more")
+	for _, msg := range m.GetBrain("/repo", "slow-agent").Messages {
+		if msg.Content == "important" {
+			t.Error("read message should have been trimmed")
+		}
+	}
+}
+
+func TestManagerMessageIDsSurviveRestart(t *testing.T) {
+	store := NewStore(t.TempDir())
+	m := NewManagerWithStore(store)
+	for i := 0; i < maxMessages+5; i++ {
+		m.SendMessage("/repo", "agent-1", "", "hi")
+	}
+
+	restored := NewManagerWithStore(store)
+	msg, err := restored.PostMessage("/repo", "agent-1", "", "after restart", 0)
+	if err != nil {
+		t.Fatalf("PostMessage: %v", err)
+	}
+	if want := int64(maxMessages + 6); msg.ID != want {
+		t.Errorf("id after restart = %d, want %d", msg.ID, want)
+	}
+}
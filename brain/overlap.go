@@ -0,0 +1,101 @@
+package brain
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChangeSet is the set of files an instance has changed in its worktree.
+type ChangeSet struct {
+	Instance string
+	// Worktree is the directory the changes live in. Instances sharing a
+	// worktree see the same changes and never overlap with each other.
+	Worktree string
+	Files    []string
+}
+
+// FileOverlap is a set of files changed by two instances at once, which will
+// conflict when their branches are merged.
+type FileOverlap struct {
+	// Instances holds the two instance titles, sorted.
+	Instances []string `json:"instances"`
+	Files     []string `json:"files"`
+}
+
+// DetectFileOverlaps returns every pair of change sets in different worktrees
+// that touch a common file, ordered by instance names.
+func DetectFileOverlaps(changes []ChangeSet) []FileOverlap {
+	sorted := append([]ChangeSet(nil), changes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Instance < sorted[j].Instance })
+
+	var overlaps []FileOverlap
+	for i, a := range sorted {
+		if len(a.Files) == 0 {
+			continue
+		}
+		files := make(map[string]bool, len(a.Files))
+		for _, f := range a.Files {
+			files[f] = true
+		}
+		for _, b := range sorted[i+1:] {
+			if a.Worktree != "" && a.Worktree == b.Worktree {
+				continue
+			}
+			var common []string
+			for _, f := range b.Files {
+				if files[f] && !sliceContains(common, f) {
+					common = append(common, f)
+				}
+			}
+			if len(common) == 0 {
+				continue
+			}
+			sort.Strings(common)
+			overlaps = append(overlaps, FileOverlap{Instances: []string{a.Instance, b.Instance}, Files: common})
+		}
+	}
+	return overlaps
+}
+
+// SetFileOverlaps records the overlaps detected from the repo's worktree
+// diffs, replacing the previous set. An EventFileOverlap is emitted for each
+// pair that starts overlapping or overlaps on additional files.
+func (m *Manager) SetFileOverlaps(repoPath string, overlaps []FileOverlap) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	previous := make(map[string][]string, len(rs.overlaps))
+	for _, o := range rs.overlaps {
+		previous[overlapKey(o)] = o.Files
+	}
+	rs.overlaps = overlaps
+	rs.mu.Unlock()
+
+	for _, o := range overlaps {
+		var added []string
+		for _, f := range o.Files {
+			if !sliceContains(previous[overlapKey(o)], f) {
+				added = append(added, f)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+		m.emitEvent(EventFileOverlap, repoPath, o.Instances[0], map[string]any{
+			"instances": o.Instances,
+			"files":     o.Files,
+			"new_files": added,
+		})
+	}
+}
+
+// FileOverlaps returns the overlaps last recorded for the repo.
+func (m *Manager) FileOverlaps(repoPath string) []FileOverlap {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return append([]FileOverlap(nil), rs.overlaps...)
+}
+
+func overlapKey(o FileOverlap) string {
+	return strings.Join(o.Instances, "\x00")
+}
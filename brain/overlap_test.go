@@ -0,0 +1,57 @@
+package brain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectFileOverlaps(t *testing.T) {
+	overlaps := DetectFileOverlaps([]ChangeSet{
+		{Instance: "web", Worktree: "/wt/web", Files: []string{"api/server.go", "web/app.ts"}},
+		{Instance: "api", Worktree: "/wt/api", Files: []string{"api/server.go", "api/client.go"}},
+		{Instance: "docs", Worktree: "/wt/docs", Files: []string{"README.md"}},
+		// Shares a worktree with web, so its identical changes are not an overlap.
+		{Instance: "web-review", Worktree: "/wt/web", Files: []string{"api/server.go", "web/app.ts"}},
+	})
+
+	want := []FileOverlap{
+		{Instances: []string{"api", "web"}, Files: []string{"api/server.go"}},
+		{Instances: []string{"api", "web-review"}, Files: []string{"api/server.go"}},
+	}
+	if !reflect.DeepEqual(overlaps, want) {
+		t.Errorf("DetectFileOverlaps() = %+v, want %+v", overlaps, want)
+	}
+}
+
+func TestManagerSetFileOverlaps(t *testing.T) {
+	m := NewManager()
+	var events []Event
+	m.SetEventCallback(func(e Event) { events = append(events, e) })
+
+	first := []FileOverlap{{Instances: []string{"a", "b"}, Files: []string{"main.go"}}}
+	m.SetFileOverlaps("/repo", first)
+	m.SetFileOverlaps("/repo", first)
+	if len(events) != 1 || events[0].Type != EventFileOverlap || events[0].Source != "a" {
+		t.Fatalf("expected one file_overlap event, got %+v", events)
+	}
+
+	grown := []FileOverlap{{Instances: []string{"a", "b"}, Files: []string{"go.mod", "main.go"}}}
+	m.SetFileOverlaps("/repo", grown)
+	if len(events) != 2 || !reflect.DeepEqual(events[1].Data["new_files"], []string{"go.mod"}) {
+		t.Fatalf("expected event for the newly overlapping file, got %+v", events)
+	}
+
+	if got := m.GetBrain("/repo", "a").Overlaps; !reflect.DeepEqual(got, grown) {
+		t.Errorf("GetBrain overlaps = %+v, want %+v", got, grown)
+	}
+
+	// Clearing and re-detecting the same overlap reports it again.
+	m.SetFileOverlaps("/repo", nil)
+	if len(m.FileOverlaps("/repo")) != 0 {
+		t.Error("overlaps should be cleared")
+	}
+	m.SetFileOverlaps("/repo", first)
+	if len(events) != 3 {
+		t.Errorf("expected a new event after the overlap reappeared, got %d events", len(events))
+	}
+}
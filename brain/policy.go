@@ -0,0 +1,404 @@
+package brain
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFile is the name of the permission policy file, read from the config
+// directory and from a repo's .hivemind directory.
+const PolicyFile = "policy.yaml"
+
+// TargetScope limits which instances an agent may act on.
+type TargetScope string
+
+const (
+	// TargetsAny allows acting on every instance.
+	TargetsAny TargetScope = "any"
+	// TargetsChildren allows acting only on instances the agent spawned.
+	TargetsChildren TargetScope = "children"
+	// TargetsDescendants allows acting on the agent's children, their
+	// children, and so on.
+	TargetsDescendants TargetScope = "descendants"
+)
+
+// RolePolicy restricts what agents with a given role may do over the brain
+// socket. Zero values impose no restriction.
+type RolePolicy struct {
+	// Allow lists the brain methods the role may call. Empty allows all.
+	Allow []string `yaml:"allow"`
+	// Deny lists brain methods the role may not call, even if allowed above.
+	Deny []string `yaml:"deny"`
+	// MaxChildren caps how many live instances the agent may have spawned.
+	MaxChildren *int `yaml:"max_children"`
+	// Targets limits the instances inject_message, pause_instance,
+	// resume_instance and kill_instance may act on.
+	Targets TargetScope `yaml:"targets"`
+}
+
+// Policy maps agent roles to their permissions. Agents whose role has no
+// entry get Default; without a Default they are unrestricted.
+type Policy struct {
+	Default *RolePolicy            `yaml:"default"`
+	Roles   map[string]*RolePolicy `yaml:"roles"`
+}
+
+// LoadPolicy reads the global policy from configDir and adds the repo's
+// .hivemind/policy.yaml. Agents can write to the repo, so its file can only
+// tighten the global policy: each role gets both files' restrictions. It
+// returns nil if neither file exists.
+func LoadPolicy(configDir, repoPath string) (*Policy, error) {
+	paths := policyPaths(configDir, repoPath)
+	global, err := parsePolicyFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) < 2 {
+		return global, nil
+	}
+	repo, err := parsePolicyFile(paths[1])
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return global, nil
+	}
+	if global == nil {
+		return repo, nil
+	}
+
+	merged := &Policy{
+		Default: tighten(global.Default, repo.Default),
+		Roles:   make(map[string]*RolePolicy),
+	}
+	for _, p := range []*Policy{global, repo} {
+		for role := range p.Roles {
+			merged.Roles[role] = tighten(global.ForRole(role), repo.ForRole(role))
+		}
+	}
+	return merged, nil
+}
+
+// targetScopeRank orders target scopes from the widest to the narrowest.
+var targetScopeRank = map[TargetScope]int{"": 0, TargetsAny: 0, TargetsDescendants: 1, TargetsChildren: 2}
+
+// tighten returns a role policy with the restrictions of both a and b: only
+// methods both allow, every method either denies, the lower child limit and
+// the narrower targets. Either may be nil.
+func tighten(a, b *RolePolicy) *RolePolicy {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	rp := &RolePolicy{
+		Deny:        append(slices.Clone(a.Deny), b.Deny...),
+		MaxChildren: a.MaxChildren,
+		Targets:     a.Targets,
+	}
+	switch {
+	case len(a.Allow) == 0:
+		rp.Allow = b.Allow
+	case len(b.Allow) == 0:
+		rp.Allow = a.Allow
+	default:
+		for _, method := range a.Allow {
+			if sliceContains(b.Allow, method) {
+				rp.Allow = append(rp.Allow, method)
+			}
+		}
+		if len(rp.Allow) == 0 {
+			// An empty list would allow everything; deny the methods
+			// of one list instead.
+			rp.Allow = a.Allow
+			rp.Deny = append(rp.Deny, a.Allow...)
+		}
+	}
+	if b.MaxChildren != nil && (a.MaxChildren == nil || *b.MaxChildren < *a.MaxChildren) {
+		rp.MaxChildren = b.MaxChildren
+	}
+	if targetScopeRank[b.Targets] > targetScopeRank[a.Targets] {
+		rp.Targets = b.Targets
+	}
+	return rp
+}
+
+// policyPaths returns the policy files LoadPolicy reads, in overlay order.
+func policyPaths(configDir, repoPath string) []string {
+	paths := []string{filepath.Join(configDir, PolicyFile)}
+	if repoPath != "" {
+		paths = append(paths, filepath.Join(repoPath, ".hivemind", PolicyFile))
+	}
+	return paths
+}
+
+// cachedPolicy holds a repo's loaded policy, or why it failed to load, and
+// the modification times of the files it was loaded from.
+type cachedPolicy struct {
+	policy *Policy
+	err    error
+	mtimes []time.Time
+}
+
+// policyFor returns the policy for repoPath, reloading it only when one of
+// its files changed.
+func (s *Server) policyFor(repoPath string) (*Policy, error) {
+	configDir := filepath.Dir(s.socketPath)
+	paths := policyPaths(configDir, repoPath)
+	mtimes := make([]time.Time, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			mtimes[i] = info.ModTime()
+		}
+	}
+
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+	if c, ok := s.policies[repoPath]; ok && slices.EqualFunc(c.mtimes, mtimes, time.Time.Equal) {
+		return c.policy, c.err
+	}
+	policy, err := LoadPolicy(configDir, repoPath)
+	if s.policies == nil {
+		s.policies = make(map[string]*cachedPolicy)
+	}
+	s.policies[repoPath] = &cachedPolicy{policy: policy, err: err, mtimes: mtimes}
+	return policy, err
+}
+
+func parsePolicyFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for role, rp := range p.Roles {
+		if err := rp.validate(); err != nil {
+			return nil, fmt.Errorf("%s: role %q: %w", path, role, err)
+		}
+	}
+	if p.Default != nil {
+		if err := p.Default.validate(); err != nil {
+			return nil, fmt.Errorf("%s: default: %w", path, err)
+		}
+	}
+	return &p, nil
+}
+
+func (rp *RolePolicy) validate() error {
+	if rp == nil {
+		return fmt.Errorf("empty entry")
+	}
+	switch rp.Targets {
+	case "", TargetsAny, TargetsChildren, TargetsDescendants:
+	default:
+		return fmt.Errorf("invalid targets %q (must be any, children or descendants)", rp.Targets)
+	}
+	if rp.MaxChildren != nil && *rp.MaxChildren < 0 {
+		return fmt.Errorf("max_children must not be negative")
+	}
+	return nil
+}
+
+// ForRole returns the policy for a role, or nil if the role is unrestricted.
+func (p *Policy) ForRole(role string) *RolePolicy {
+	if p == nil {
+		return nil
+	}
+	if rp, ok := p.Roles[role]; ok && role != "" {
+		return rp
+	}
+	return p.Default
+}
+
+// allows reports whether the role may call method.
+func (rp *RolePolicy) allows(method string) bool {
+	if len(rp.Allow) > 0 && !sliceContains(rp.Allow, method) {
+		return false
+	}
+	return !sliceContains(rp.Deny, method)
+}
+
+// InstanceInfo describes a running instance for policy checks.
+type InstanceInfo struct {
+	Title       string
+	RepoPath    string
+	Role        string
+	ParentTitle string
+}
+
+// SetInstances replaces the server's view of the running instances. The TUI
+// calls it as instances come and go; requests from instances it does not
+// know about are denied.
+func (s *Server) SetInstances(instances []InstanceInfo) {
+	s.instMu.Lock()
+	defer s.instMu.Unlock()
+	s.instances = make(map[string]InstanceInfo, len(instances))
+	for _, info := range instances {
+		s.instances[info.Title] = info
+	}
+	// Instances still starting up have a token but aren't listed yet.
+	for title, info := range s.issued {
+		if _, ok := s.instances[title]; ok {
+			delete(s.issued, title)
+			continue
+		}
+		s.instances[title] = info
+	}
+}
+
+// trackInstanceEvent records instances created or killed between SetInstances
+// calls, so that spawn caps apply immediately.
+func (s *Server) trackInstanceEvent(event Event) {
+	s.instMu.Lock()
+	defer s.instMu.Unlock()
+	switch event.Type {
+	case EventInstanceCreated:
+		if s.instances == nil {
+			s.instances = make(map[string]InstanceInfo)
+		}
+		s.instances[event.Source] = InstanceInfo{
+			Title:       event.Source,
+			RepoPath:    event.RepoPath,
+			Role:        toString(event.Data["role"]),
+			ParentTitle: toString(event.Data["parent_title"]),
+		}
+	case EventInstanceKilled:
+		delete(s.instances, event.Source)
+	}
+}
+
+// enforcePolicy checks an authenticated request against the policy for the
+// role of the instance it comes from. Read-only requests without a token and
+// requests made with the operator key are not subject to the policy. For
+// create_instance it also reserves a child slot; the returned release func
+// must be called once the request has finished.
+func (s *Server) enforcePolicy(req Request) (release func(), err error) {
+	if req.Method == MethodPing || req.Token == "" || s.isOperator(req.Token) {
+		return nil, nil
+	}
+	return s.checkPolicy(req)
+}
+
+// authorizeSpawn applies sourceInstance's create_instance policy to an
+// instance a workflow spawns for it, as if it had asked for it directly.
+// Workflows started by the user, or whose creator is gone, are not
+// restricted. The returned release func must be called once the spawn has
+// finished.
+func (s *Server) authorizeSpawn(sourceInstance string) (release func(), err error) {
+	s.instMu.Lock()
+	_, ok := s.instances[sourceInstance]
+	s.instMu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return s.checkPolicy(Request{Method: MethodCreateInstance, InstanceID: sourceInstance})
+}
+
+// checkPolicy checks a request made as req.InstanceID against the policy for
+// its role, denying it if the instance is unknown.
+func (s *Server) checkPolicy(req Request) (release func(), err error) {
+	s.instMu.Lock()
+	caller, ok := s.instances[req.InstanceID]
+	s.instMu.Unlock()
+	if !ok {
+		return nil, s.deny(req, InstanceInfo{}, "unknown instance")
+	}
+	// The policy is looked up by the caller's own repo, not the one it
+	// claims in the request.
+	policy, err := s.policyFor(caller.RepoPath)
+	if err != nil {
+		return nil, s.deny(req, caller, err.Error())
+	}
+	rp := policy.ForRole(caller.Role)
+	if rp == nil {
+		return nil, nil
+	}
+
+	if !rp.allows(req.Method) {
+		return nil, s.deny(req, caller, req.Method+" is not allowed")
+	}
+
+	s.instMu.Lock()
+	defer s.instMu.Unlock()
+
+	if target := policyTarget(req); target != "" && !s.inScope(caller.Title, target, rp.Targets) {
+		return nil, s.deny(req, caller, fmt.Sprintf("%q is not one of its %s", target, rp.Targets))
+	}
+
+	if req.Method == MethodCreateInstance && rp.MaxChildren != nil {
+		children := s.pendingChildren[caller.Title]
+		for _, info := range s.instances {
+			if info.ParentTitle == caller.Title {
+				children++
+			}
+		}
+		if children >= *rp.MaxChildren {
+			return nil, s.deny(req, caller, fmt.Sprintf("child limit of %d reached", *rp.MaxChildren))
+		}
+		if s.pendingChildren == nil {
+			s.pendingChildren = make(map[string]int)
+		}
+		s.pendingChildren[caller.Title]++
+		return func() {
+			s.instMu.Lock()
+			defer s.instMu.Unlock()
+			if s.pendingChildren[caller.Title]--; s.pendingChildren[caller.Title] <= 0 {
+				delete(s.pendingChildren, caller.Title)
+			}
+		}, nil
+	}
+	return nil, nil
+}
+
+// deny logs a rejected request and returns the error sent to the caller.
+func (s *Server) deny(req Request, caller InstanceInfo, reason string) error {
+	role := caller.Role
+	if role == "" {
+		role = "default"
+	}
+	logWarn("brain: denied %s from %q (role %s): %s", req.Method, req.InstanceID, role, reason)
+	return fmt.Errorf("permission denied for role %s: %s", role, reason)
+}
+
+// policyTarget returns the instance a request acts on, if any.
+func policyTarget(req Request) string {
+	switch req.Method {
+	case MethodInjectMessage:
+		return toString(req.Params["to"])
+	case MethodPauseInstance, MethodResumeInstance, MethodKillInstance:
+		return toString(req.Params["target"])
+	}
+	return ""
+}
+
+// inScope reports whether target is within the caller's target scope. The
+// caller must hold instMu.
+func (s *Server) inScope(caller, target string, scope TargetScope) bool {
+	switch scope {
+	case TargetsChildren:
+		return s.instances[target].ParentTitle == caller
+	case TargetsDescendants:
+		// Walk up from the target; the step limit guards against cycles.
+		parent := s.instances[target].ParentTitle
+		for i := 0; parent != "" && i <= len(s.instances); i++ {
+			if parent == caller {
+				return true
+			}
+			parent = s.instances[parent].ParentTitle
+		}
+		return false
+	}
+	return true
+}
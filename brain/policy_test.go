@@ -0,0 +1,203 @@
+package brain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePolicy(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, PolicyFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPolicyMergesRepoOverGlobal(t *testing.T) {
+	configDir := t.TempDir()
+	repo := t.TempDir()
+
+	if p, err := LoadPolicy(configDir, repo); err != nil || p != nil {
+		t.Fatalf("expected no policy without files, got %+v, %v", p, err)
+	}
+
+	writePolicy(t, configDir, `
+default:
+  deny: [kill_instance]
+roles:
+  reviewer:
+    allow: [get_brain, send_message]
+  coder:
+    max_children: 2
+  worker:
+    max_children: 1
+    targets: children
+`)
+	writePolicy(t, filepath.Join(repo, ".hivemind"), `
+roles:
+  coder:
+    max_children: 0
+    targets: descendants
+  reviewer:
+    allow: [get_brain, kill_instance]
+  worker: {}
+  lead:
+    max_children: 5
+`)
+
+	p, err := LoadPolicy(configDir, repo)
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	if rp := p.ForRole("coder"); rp == nil || *rp.MaxChildren != 0 || rp.Targets != TargetsDescendants {
+		t.Errorf("the repo should tighten the coder entry, got %+v", rp)
+	}
+	if rp := p.ForRole("reviewer"); rp == nil || rp.allows("kill_instance") || rp.allows("send_message") || !rp.allows("get_brain") {
+		t.Errorf("the reviewer should only get methods both files allow, got %+v", rp)
+	}
+	if rp := p.ForRole("worker"); rp == nil || *rp.MaxChildren != 1 || rp.Targets != TargetsChildren {
+		t.Errorf("an empty repo entry should not lift the worker's limits, got %+v", rp)
+	}
+	if rp := p.ForRole("lead"); rp == nil || *rp.MaxChildren != 5 || rp.allows("kill_instance") {
+		t.Errorf("a repo-only role should keep the global default, got %+v", rp)
+	}
+	if rp := p.ForRole(""); rp == nil || rp.allows("kill_instance") || !rp.allows("create_instance") {
+		t.Errorf("roleless agents should get the default, got %+v", rp)
+	}
+}
+
+func TestTightenDisjointAllowLists(t *testing.T) {
+	rp := tighten(&RolePolicy{Allow: []string{MethodGetBrain}}, &RolePolicy{Allow: []string{MethodSendMessage}})
+	for _, method := range []string{MethodGetBrain, MethodSendMessage, MethodKillInstance} {
+		if rp.allows(method) {
+			t.Errorf("%s should not be allowed when the allow lists share nothing", method)
+		}
+	}
+}
+
+func TestLoadPolicyRejectsInvalid(t *testing.T) {
+	configDir := t.TempDir()
+	writePolicy(t, configDir, "roles:\n  coder:\n    targets: siblings\n")
+	if _, err := LoadPolicy(configDir, ""); err == nil || !strings.Contains(err.Error(), "invalid targets") {
+		t.Errorf("expected invalid targets error, got %v", err)
+	}
+}
+
+func TestServerEnforcesPolicy(t *testing.T) {
+	srv := startTestServer(t)
+	writePolicy(t, filepath.Dir(srv.SocketPath()), `
+roles:
+  lead:
+    max_children: 1
+    targets: descendants
+  reviewer:
+    allow: [get_brain, update_status, send_message]
+`)
+	srv.SetInstances([]InstanceInfo{
+		{Title: "lead", RepoPath: "/repo", Role: "lead"},
+		{Title: "other", RepoPath: "/repo"},
+		{Title: "reviewer", RepoPath: "/repo", Role: "reviewer"},
+	})
+
+	// Fake TUI: report created instances back the way the app does.
+	go func() {
+		for action := range srv.Actions() {
+			if action.Type == ActionCreateInstance {
+				srv.PushEvent(Event{
+					Type:     EventInstanceCreated,
+					Source:   toString(action.Params["title"]),
+					RepoPath: "/repo",
+					Data:     map[string]any{"parent_title": action.Params["source_instance"]},
+				})
+				action.ResponseCh <- ActionResponse{OK: true, Data: map[string]any{"title": action.Params["title"]}}
+				continue
+			}
+			action.ResponseCh <- ActionResponse{OK: true}
+		}
+	}()
+	client := newTestClient(t, srv)
+
+	if _, err := client.CreateInstance("/repo", "lead", CreateInstanceParams{Title: "child"}); err != nil {
+		t.Fatalf("first spawn: %v", err)
+	}
+	_, err := client.CreateInstance("/repo", "lead", CreateInstanceParams{Title: "child-2"})
+	if err == nil || !strings.Contains(err.Error(), "child limit of 1") {
+		t.Errorf("expected child limit error, got %v", err)
+	}
+
+	if err := client.KillInstance("/repo", "lead", "child"); err != nil {
+		t.Errorf("killing a descendant: %v", err)
+	}
+	if err := client.KillInstance("/repo", "lead", "other"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected kill of a non-descendant to be denied, got %v", err)
+	}
+
+	if _, err := client.GetBrain("/repo", "reviewer"); err != nil {
+		t.Errorf("reviewer get_brain: %v", err)
+	}
+	if err := client.KillInstance("/repo", "reviewer", "other"); err == nil || !strings.Contains(err.Error(), "kill_instance is not allowed") {
+		t.Errorf("expected reviewer kill to be denied, got %v", err)
+	}
+
+	// A workflow defined by the lead cannot spawn past its child limit.
+	result, err := client.DefineWorkflow("/repo", "lead", []*WorkflowTask{{ID: "t1", Title: "T1", Prompt: "do it"}})
+	if err != nil {
+		t.Fatalf("define_workflow: %v", err)
+	}
+	task := srv.Manager().GetWorkflowTask("/repo", result.WorkflowID, "t1")
+	if task == nil || task.Status != TaskFailed || !strings.Contains(task.Error, "child limit of 1") {
+		t.Errorf("expected the workflow's spawn to be denied, got %+v", task)
+	}
+
+	// The CLI sends the operator key and is not restricted.
+	key, err := LoadOperatorKey(filepath.Dir(srv.SocketPath()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient(srv.SocketPath())
+	cli.SetToken("", key)
+	if err := cli.KillInstance("/repo", "cli", "other"); err != nil {
+		t.Errorf("the CLI should not be restricted: %v", err)
+	}
+
+	// A token whose instance the server no longer knows is denied.
+	token, err := srv.tokens.issue("ghost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ghost := NewClient(srv.SocketPath())
+	ghost.SetToken("ghost", token)
+	if _, err := ghost.GetBrain("/repo", "ghost"); err == nil || !strings.Contains(err.Error(), "unknown instance") {
+		t.Errorf("expected unknown instance to be denied, got %v", err)
+	}
+}
+
+func TestServerReloadsChangedPolicy(t *testing.T) {
+	srv := startTestServer(t)
+	configDir := filepath.Dir(srv.SocketPath())
+	writePolicy(t, configDir, "roles:\n  reviewer:\n    deny: [kill_instance]\n")
+	srv.SetInstances([]InstanceInfo{{Title: "reviewer", RepoPath: "/repo", Role: "reviewer"}})
+	client := newTestClient(t, srv)
+
+	if _, err := client.GetBrain("/repo", "reviewer"); err != nil {
+		t.Fatalf("get_brain: %v", err)
+	}
+	policy, _ := srv.policyFor("/repo")
+	if again, _ := srv.policyFor("/repo"); again != policy {
+		t.Error("an unchanged policy should be served from the cache")
+	}
+
+	writePolicy(t, configDir, "roles:\n  reviewer:\n    deny: [get_brain]\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(configDir, PolicyFile), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetBrain("/repo", "reviewer"); err == nil || !strings.Contains(err.Error(), "get_brain is not allowed") {
+		t.Errorf("expected the changed policy to apply, got %v", err)
+	}
+}
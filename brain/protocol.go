@@ -1,14 +1,21 @@
 package brain
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // IPC method constants shared between client and server.
 const (
 	MethodGetBrain     = "get_brain"
 	MethodUpdateStatus = "update_status"
 	MethodSendMessage  = "send_message"
+	MethodGetMessages  = "get_messages"
 	MethodRemoveAgent  = "remove_agent"
 	MethodPing         = "ping"
+	// File leases.
+	MethodClaimFiles   = "claim_files"
+	MethodReleaseFiles = "release_files"
 
 	// Tier 3 methods — relayed to the TUI via action channel.
 	MethodCreateInstance = "create_instance"
@@ -16,26 +23,36 @@ const (
 	MethodPauseInstance  = "pause_instance"
 	MethodResumeInstance = "resume_instance"
 	MethodKillInstance   = "kill_instance"
+	// MethodAskUser blocks until the user answers or the wait elapses.
+	MethodAskUser        = "ask_user"
 	MethodDefineWorkflow = "define_workflow"
 	MethodCompleteTask   = "complete_task"
 	MethodGetWorkflow    = "get_workflow"
+	MethodListWorkflows  = "list_workflows"
 	// Event subscription methods.
 	MethodSubscribe   = "subscribe"
 	MethodPollEvents  = "poll_events"
 	MethodUnsubscribe = "unsubscribe"
+	// MethodStreamEvents keeps the connection open and writes one Event JSON
+	// object per line after the initial Response.
+	MethodStreamEvents = "stream_events"
 )
 
 // Request is the JSON envelope sent from client to server over the Unix socket.
 type Request struct {
-	Method     string         `json:"method"`
-	InstanceID string         `json:"instance_id"`
-	RepoPath   string         `json:"repo_path"`
-	Params     map[string]any `json:"params,omitempty"`
+	Method     string `json:"method"`
+	InstanceID string `json:"instance_id"`
+	// Token authenticates InstanceID; see Server.IssueToken.
+	Token    string         `json:"token,omitempty"`
+	RepoPath string         `json:"repo_path"`
+	Params   map[string]any `json:"params,omitempty"`
 }
 
 // Response is the JSON envelope sent from server to client.
 type Response struct {
-	OK    bool            `json:"ok"`
+	OK bool `json:"ok"`
+	// Data holds the result, or for some errors their details, e.g. a
+	// WorkflowValidationError.
 	Data  json.RawMessage `json:"data,omitempty"`
 	Error string          `json:"error,omitempty"`
 }
@@ -50,16 +67,24 @@ type AgentStatus struct {
 
 // BrainMessage is a directed message between agents.
 type BrainMessage struct {
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Content   string `json:"content"`
+	// ID increases with every message posted to the repo.
+	ID      int64  `json:"id,omitempty"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Content string `json:"content"`
+	// ReplyTo is the ID of the message this one answers.
+	ReplyTo   int64  `json:"reply_to,omitempty"`
 	Timestamp string `json:"timestamp"`
+	// ReadBy lists the agents the message has been delivered to.
+	ReadBy []string `json:"read_by,omitempty"`
 }
 
 // BrainState is the coordination state for a single repository.
 type BrainState struct {
 	Agents   map[string]*AgentStatus `json:"agents"`
 	Messages []BrainMessage          `json:"messages"`
+	Leases   []FileLease             `json:"leases,omitempty"`
+	Overlaps []FileOverlap           `json:"overlaps,omitempty"`
 }
 
 // UpdateStatusResult is returned by UpdateStatus with optional conflict warnings.
@@ -67,6 +92,16 @@ type UpdateStatusResult struct {
 	Conflicts []string `json:"conflicts,omitempty"`
 }
 
+// GetMessagesResult is returned by get_messages.
+type GetMessagesResult struct {
+	Messages []BrainMessage `json:"messages"`
+}
+
+// ReleaseFilesResult is returned by release_files.
+type ReleaseFilesResult struct {
+	Released int `json:"released"`
+}
+
 // --- Tier 3: Action channel types ---
 // These are used for requests that must be relayed from the brain server to the TUI.
 
@@ -79,6 +114,9 @@ const (
 	ActionPauseInstance  ActionType = "pause_instance"
 	ActionResumeInstance ActionType = "resume_instance"
 	ActionKillInstance   ActionType = "kill_instance"
+	// ActionAskUser notifies the TUI of a new question. Its params hold
+	// repo_path and id; the answer goes back through Manager.AnswerQuestion.
+	ActionAskUser ActionType = "ask_user"
 )
 
 // ActionRequest is sent from the brain server to the TUI via a channel.
@@ -123,16 +161,51 @@ type InjectMessageParams struct {
 	Format  string `json:"format,omitempty"` // "plain" or "hivemind" (default)
 }
 
+// AskUserParams holds parameters for asking the user a question.
+type AskUserParams struct {
+	Question string   `json:"question,omitempty"`
+	Choices  []string `json:"choices,omitempty"`
+	// QuestionID resumes waiting on a question asked earlier instead of
+	// asking a new one.
+	QuestionID string `json:"question_id,omitempty"`
+	// Wait is how long to wait for the answer. Zero uses DefaultAskWait.
+	Wait time.Duration `json:"-"`
+}
+
 // --- Tier 3: Workflow DAG types ---
 
 // TaskStatus tracks the state of a workflow task.
 type TaskStatus string
 
 const (
-	TaskPending TaskStatus = "pending"
-	TaskRunning TaskStatus = "running"
-	TaskDone    TaskStatus = "done"
-	TaskFailed  TaskStatus = "failed"
+	TaskPending   TaskStatus = "pending"
+	TaskRunning   TaskStatus = "running"
+	TaskDone      TaskStatus = "done"
+	TaskFailed    TaskStatus = "failed"
+	TaskSkipped   TaskStatus = "skipped"   // an upstream task failed with on_failure=skip_dependents
+	TaskCancelled TaskStatus = "cancelled" // the workflow failed before the task finished
+)
+
+// IsTerminal reports whether a task in this status will never run again.
+func (s TaskStatus) IsTerminal() bool {
+	switch s {
+	case TaskDone, TaskFailed, TaskSkipped, TaskCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// FailurePolicy controls what happens when a task fails after exhausting its retries.
+type FailurePolicy string
+
+const (
+	// FailWorkflow cancels every unfinished task and kills their instances. This is the default.
+	FailWorkflow FailurePolicy = "fail_workflow"
+	// SkipDependents marks every task downstream of the failed task as skipped.
+	SkipDependents FailurePolicy = "skip_dependents"
+	// ContinueOnFailure treats the failed task as satisfied for its dependents.
+	ContinueOnFailure FailurePolicy = "continue"
 )
 
 // WorkflowTask represents a single task in a workflow DAG.
@@ -145,19 +218,74 @@ type WorkflowTask struct {
 	Prompt     string     `json:"prompt,omitempty"`
 	Role       string     `json:"role,omitempty"`
 	Error      string     `json:"error,omitempty"`
+	// Program overrides the agent program for the task's instance (e.g. "aider").
+	Program string `json:"program,omitempty"`
+
+	// MaxRetries is how many times a failed task is re-spawned before its
+	// OnFailure policy applies.
+	MaxRetries int `json:"max_retries,omitempty"`
+	// Timeout is how long the task's instance may sit idle without completing
+	// the task before it is marked failed (Go duration, e.g. "15m").
+	Timeout string `json:"timeout,omitempty"`
+	// OnFailure selects the failure policy. Empty means fail_workflow.
+	OnFailure FailurePolicy `json:"on_failure,omitempty"`
+	// Attempts counts how many times the task has been started.
+	Attempts int `json:"attempts,omitempty"`
+	// IdleSince is set (RFC3339) while the task's instance is idle.
+	IdleSince string `json:"idle_since,omitempty"`
+
+	// Outputs holds structured results reported via complete_task. Downstream
+	// prompts can reference them as {{tasks.<id>.outputs.<key>}}.
+	Outputs map[string]any `json:"outputs,omitempty"`
+	// Branch is the git branch of the task's instance, available to downstream
+	// prompts as {{tasks.<id>.branch}}.
+	Branch string `json:"branch,omitempty"`
 }
 
+// WorkflowStatus tracks the overall state of a workflow.
+type WorkflowStatus string
+
+const (
+	WorkflowRunning   WorkflowStatus = "running"
+	WorkflowDone      WorkflowStatus = "done"
+	WorkflowFailed    WorkflowStatus = "failed"
+	WorkflowCancelled WorkflowStatus = "cancelled"
+)
+
 // Workflow is a DAG of tasks for a repository.
 type Workflow struct {
-	ID    string          `json:"id"`
-	Tasks []*WorkflowTask `json:"tasks"`
+	ID     string          `json:"id"`
+	Status WorkflowStatus  `json:"status,omitempty"`
+	Tasks  []*WorkflowTask `json:"tasks"`
+	// CreatedBy is the instance that defined the workflow. Tasks re-spawned by
+	// the server (retries) are created as its children.
+	CreatedBy string `json:"created_by,omitempty"`
 }
 
 // WorkflowResult is returned by workflow operations.
 type WorkflowResult struct {
-	WorkflowID string   `json:"workflow_id"`
-	Triggered  []string `json:"triggered,omitempty"`
-	Error      string   `json:"error,omitempty"`
+	WorkflowID string `json:"workflow_id"`
+	// Levels groups task IDs by topological depth; set by define_workflow.
+	Levels    [][]string `json:"levels,omitempty"`
+	Triggered []string   `json:"triggered,omitempty"`
+	Retrying  bool       `json:"retrying,omitempty"`
+	Skipped   []string   `json:"skipped,omitempty"`
+	Cancelled []string   `json:"cancelled,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// FailureOutcome describes what a task's failure policy did.
+type FailureOutcome struct {
+	// Instance is the instance that was running the failed task.
+	Instance string
+	// Retrying is true when the task was reset to pending for another attempt.
+	Retrying bool
+	// Skipped lists dependents marked skipped.
+	Skipped []string
+	// Cancelled lists unfinished tasks cancelled because the workflow failed.
+	Cancelled []string
+	// KillInstances lists instances of cancelled running tasks.
+	KillInstances []string
 }
 
 // --- Event subscription types ---
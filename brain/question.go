@@ -0,0 +1,239 @@
+package brain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAskWait is how long ask_user waits for an answer unless told otherwise.
+	DefaultAskWait = 5 * time.Minute
+	// maxAskWait caps a single ask_user wait; agents re-ask with the question
+	// ID to keep waiting.
+	maxAskWait = 30 * time.Minute
+	// maxAnsweredQuestions is how many answered questions a repo keeps so that
+	// agents reconnecting after a restart can still collect their answer.
+	maxAnsweredQuestions = 20
+)
+
+// ErrQuestionWithdrawn is returned while waiting on a question that was
+// removed before it was answered, e.g. because its agent was killed.
+var ErrQuestionWithdrawn = errors.New("question was withdrawn")
+
+// Question is something an agent asked the user through ask_user.
+type Question struct {
+	ID       string    `json:"id"`
+	RepoPath string    `json:"repo_path"`
+	From     string    `json:"from"`
+	Text     string    `json:"text"`
+	Choices  []string  `json:"choices,omitempty"`
+	AskedAt  time.Time `json:"asked_at"`
+	// Answer is empty until the user answers.
+	Answer     string     `json:"answer,omitempty"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+}
+
+// Answered reports whether the user has answered the question.
+func (q *Question) Answered() bool {
+	return q.AnsweredAt != nil
+}
+
+// AskUser records a question from an agent for the user and emits an
+// EventQuestionAsked. The question stays pending, across restarts when the
+// manager has a store, until AnswerQuestion is called.
+func (m *Manager) AskUser(repoPath, from, text string, choices []string) (*Question, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("question must not be empty")
+	}
+	var cleaned []string
+	for _, c := range choices {
+		if c = strings.TrimSpace(c); c != "" && !sliceContains(cleaned, c) {
+			cleaned = append(cleaned, c)
+		}
+	}
+
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	// IDs are q-<millis>, bumped on collision like workflow IDs.
+	ms := time.Now().UnixMilli()
+	for rs.findQuestion(fmt.Sprintf("q-%d", ms)) != nil {
+		ms++
+	}
+	q := &Question{
+		ID:       fmt.Sprintf("q-%d", ms),
+		RepoPath: repoPath,
+		From:     from,
+		Text:     text,
+		Choices:  cleaned,
+		AskedAt:  time.Now().UTC(),
+	}
+	rs.questions = append(rs.questions, q)
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	m.emitEvent(EventQuestionAsked, repoPath, from, map[string]any{
+		"id":       q.ID,
+		"question": q.Text,
+		"choices":  q.Choices,
+	})
+	cp := *q
+	return &cp, nil
+}
+
+// AnswerQuestion records the user's answer and wakes any agent waiting on it.
+func (m *Manager) AnswerQuestion(repoPath, id, answer string) error {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return fmt.Errorf("answer must not be empty")
+	}
+
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	q := rs.findQuestion(id)
+	if q == nil {
+		rs.mu.Unlock()
+		return fmt.Errorf("question %q not found", id)
+	}
+	if q.Answered() {
+		rs.mu.Unlock()
+		return fmt.Errorf("question %q was already answered", id)
+	}
+	now := time.Now().UTC()
+	q.Answer = answer
+	q.AnsweredAt = &now
+	pruneAnsweredQuestions(rs)
+	rs.wakeQuestion(id)
+	m.persist(repoPath, rs)
+	from := q.From
+	rs.mu.Unlock()
+
+	m.emitEvent(EventQuestionAnswered, repoPath, from, map[string]any{
+		"id":     id,
+		"answer": answer,
+	})
+	return nil
+}
+
+// GetQuestion returns a copy of the question, or nil if it does not exist.
+func (m *Manager) GetQuestion(repoPath, id string) *Question {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	q := rs.findQuestion(id)
+	if q == nil {
+		return nil
+	}
+	cp := *q
+	return &cp
+}
+
+// WaitForAnswer blocks until the question is answered or withdrawn, or ctx is
+// done, and returns its current state. A question still pending when ctx is
+// done is returned without error.
+func (m *Manager) WaitForAnswer(ctx context.Context, repoPath, id string) (*Question, error) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	q := rs.findQuestion(id)
+	if q == nil {
+		rs.mu.Unlock()
+		return nil, fmt.Errorf("question %q not found", id)
+	}
+	if q.Answered() {
+		cp := *q
+		rs.mu.Unlock()
+		return &cp, nil
+	}
+	if rs.questionWaiters == nil {
+		rs.questionWaiters = make(map[string]chan struct{})
+	}
+	ch, ok := rs.questionWaiters[id]
+	if !ok {
+		ch = make(chan struct{})
+		rs.questionWaiters[id] = ch
+	}
+	rs.mu.Unlock()
+
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+
+	if q := m.GetQuestion(repoPath, id); q != nil {
+		return q, nil
+	}
+	return nil, ErrQuestionWithdrawn
+}
+
+// PendingQuestions returns the repo's unanswered questions, oldest first.
+func (m *Manager) PendingQuestions(repoPath string) []Question {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	var pending []Question
+	for _, q := range rs.questions {
+		if !q.Answered() {
+			pending = append(pending, *q)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].AskedAt.Before(pending[j].AskedAt) })
+	return pending
+}
+
+// withdrawQuestions drops from's unanswered questions and wakes their
+// waiters. Caller must hold rs.mu write lock.
+func withdrawQuestions(rs *repoState, from string) {
+	kept := rs.questions[:0]
+	for _, q := range rs.questions {
+		if q.From == from && !q.Answered() {
+			rs.wakeQuestion(q.ID)
+			continue
+		}
+		kept = append(kept, q)
+	}
+	rs.questions = kept
+}
+
+// findQuestion returns the question with the given ID. Caller must hold rs.mu.
+func (rs *repoState) findQuestion(id string) *Question {
+	for _, q := range rs.questions {
+		if q.ID == id {
+			return q
+		}
+	}
+	return nil
+}
+
+// wakeQuestion releases everyone waiting on the question. Caller must hold
+// rs.mu write lock.
+func (rs *repoState) wakeQuestion(id string) {
+	if ch, ok := rs.questionWaiters[id]; ok {
+		close(ch)
+		delete(rs.questionWaiters, id)
+	}
+}
+
+// pruneAnsweredQuestions keeps only the newest maxAnsweredQuestions answered
+// questions. Caller must hold rs.mu write lock.
+func pruneAnsweredQuestions(rs *repoState) {
+	answered := 0
+	for _, q := range rs.questions {
+		if q.Answered() {
+			answered++
+		}
+	}
+	kept := rs.questions[:0]
+	for _, q := range rs.questions {
+		if q.Answered() && answered > maxAnsweredQuestions {
+			answered--
+			continue
+		}
+		kept = append(kept, q)
+	}
+	rs.questions = kept
+}
@@ -0,0 +1,156 @@
+package brain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerAskAndAnswer(t *testing.T) {
+	m := NewManager()
+	var events []Event
+	m.SetEventCallback(func(e Event) { events = append(events, e) })
+
+	q, err := m.AskUser("/repo", "coder", "  Which database?  ", []string{"postgres", "", "sqlite", "postgres"})
+	if err != nil {
+		t.Fatalf("AskUser: %v", err)
+	}
+	if q.Text != "Which database?" || len(q.Choices) != 2 {
+		t.Errorf("question = %+v, want trimmed text and 2 choices", q)
+	}
+	if pending := m.PendingQuestions("/repo"); len(pending) != 1 || pending[0].ID != q.ID {
+		t.Fatalf("expected one pending question, got %+v", pending)
+	}
+
+	done := make(chan *Question, 1)
+	go func() {
+		got, err := m.WaitForAnswer(context.Background(), "/repo", q.ID)
+		if err != nil {
+			t.Errorf("WaitForAnswer: %v", err)
+		}
+		done <- got
+	}()
+
+	if err := m.AnswerQuestion("/repo", q.ID, "sqlite"); err != nil {
+		t.Fatalf("AnswerQuestion: %v", err)
+	}
+	select {
+	case got := <-done:
+		if !got.Answered() || got.Answer != "sqlite" {
+			t.Errorf("waiter got %+v, want answer sqlite", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiter was not woken by the answer")
+	}
+
+	if err := m.AnswerQuestion("/repo", q.ID, "postgres"); err == nil {
+		t.Error("expected an error answering twice")
+	}
+	if pending := m.PendingQuestions("/repo"); len(pending) != 0 {
+		t.Errorf("expected no pending questions, got %+v", pending)
+	}
+	if len(events) != 2 || events[0].Type != EventQuestionAsked || events[1].Type != EventQuestionAnswered || events[1].Source != "coder" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+
+	if _, err := m.AskUser("/repo", "coder", " ", nil); err == nil {
+		t.Error("expected an error for an empty question")
+	}
+}
+
+func TestManagerWaitForAnswerTimesOut(t *testing.T) {
+	m := NewManager()
+	q, _ := m.AskUser("/repo", "coder", "Proceed?", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	got, err := m.WaitForAnswer(ctx, "/repo", q.ID)
+	if err != nil {
+		t.Fatalf("WaitForAnswer: %v", err)
+	}
+	if got.Answered() {
+		t.Errorf("expected the question to still be pending, got %+v", got)
+	}
+}
+
+func TestManagerRemoveAgentWithdrawsQuestions(t *testing.T) {
+	m := NewManager()
+	q, _ := m.AskUser("/repo", "coder", "Proceed?", nil)
+	other, _ := m.AskUser("/repo", "reviewer", "Merge?", nil)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := m.WaitForAnswer(context.Background(), "/repo", q.ID)
+		errCh <- err
+	}()
+	// Let the waiter register before the agent is removed.
+	time.Sleep(20 * time.Millisecond)
+
+	m.RemoveAgent("/repo", "coder")
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrQuestionWithdrawn) {
+			t.Errorf("expected ErrQuestionWithdrawn, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiter was not woken when the agent was removed")
+	}
+
+	if pending := m.PendingQuestions("/repo"); len(pending) != 1 || pending[0].ID != other.ID {
+		t.Errorf("expected only the reviewer's question, got %+v", pending)
+	}
+}
+
+func TestManagerQuestionsSurviveRestart(t *testing.T) {
+	store := NewStore(t.TempDir())
+	m := NewManagerWithStore(store)
+	q, _ := m.AskUser("/repo", "coder", "Which database?", []string{"postgres", "sqlite"})
+
+	restored := NewManagerWithStore(store)
+	pending := restored.PendingQuestions("/repo")
+	if len(pending) != 1 || pending[0].ID != q.ID || len(pending[0].Choices) != 2 {
+		t.Fatalf("expected the question to be restored, got %+v", pending)
+	}
+	if err := restored.AnswerQuestion("/repo", q.ID, "postgres"); err != nil {
+		t.Fatalf("AnswerQuestion: %v", err)
+	}
+
+	// The answer is kept so the agent can collect it after another restart.
+	again := NewManagerWithStore(store)
+	if got := again.GetQuestion("/repo", q.ID); got == nil || got.Answer != "postgres" {
+		t.Errorf("expected the answered question to be restored, got %+v", got)
+	}
+}
+
+func TestClientAskUser(t *testing.T) {
+	s := startTestServer(t)
+	c := newTestClient(t, s)
+
+	// The TUI is notified without being waited on; answer from there.
+	go func() {
+		action := <-s.Actions()
+		repo, _ := action.Params["repo_path"].(string)
+		id, _ := action.Params["id"].(string)
+		if err := s.Manager().AnswerQuestion(repo, id, "sqlite"); err != nil {
+			t.Errorf("AnswerQuestion: %v", err)
+		}
+	}()
+
+	q, err := c.AskUser("/repo", "coder", AskUserParams{Question: "Which database?", Choices: []string{"postgres", "sqlite"}})
+	if err != nil {
+		t.Fatalf("AskUser: %v", err)
+	}
+	if q.Answer != "sqlite" {
+		t.Errorf("answer = %q, want sqlite", q.Answer)
+	}
+
+	// Resuming works only for the agent that asked.
+	if _, err := c.AskUser("/repo", "reviewer", AskUserParams{QuestionID: q.ID, Wait: time.Second}); err == nil {
+		t.Error("expected an error resuming another agent's question")
+	}
+	got, err := c.AskUser("/repo", "coder", AskUserParams{QuestionID: q.ID, Wait: time.Second})
+	if err != nil || got.Answer != "sqlite" {
+		t.Errorf("resume = %+v, %v; want the stored answer", got, err)
+	}
+}
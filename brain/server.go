@@ -2,17 +2,23 @@ package brain
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
 
 const serverActionTimeout = 30 * time.Second
 
+// taskTimeoutCheckInterval is how often idle workflow tasks are checked against their timeout.
+const taskTimeoutCheckInterval = 15 * time.Second
+
 // connDeadline returns the connection deadline based on the request method.
 func connDeadline(method string) time.Duration {
 	switch method {
@@ -27,6 +33,8 @@ func connDeadline(method string) time.Duration {
 	case MethodPollEvents:
 		// Long-poll: up to 25s wait + overhead.
 		return 35 * time.Second
+	case MethodAskUser:
+		return maxAskWait + 10*time.Second
 	default:
 		// Read-only methods (ping, get_brain, etc).
 		return 5 * time.Second
@@ -45,18 +53,44 @@ type Server struct {
 	// accept loop if the TUI is briefly busy.
 	actionCh chan ActionRequest
 
+	// tokens holds the tokens issued to instances at spawn.
+	tokens *tokenTable
+
+	// operatorHash is the hash of the key the hivemind CLI authenticates
+	// with, saved at operatorPath when the server starts.
+	operatorPath string
+	operatorHash string
+
+	// instances is the TUI's view of running instances, used to enforce the
+	// permission policy. issued holds instances given a token that the TUI
+	// hasn't listed yet. pendingChildren counts create_instance requests
+	// still in flight per parent, so concurrent spawns respect max_children.
+	instMu          sync.Mutex
+	instances       map[string]InstanceInfo
+	issued          map[string]InstanceInfo
+	pendingChildren map[string]int
+
+	// policies caches the permission policy of each repo.
+	policyMu sync.Mutex
+	policies map[string]*cachedPolicy
+
 	wg     sync.WaitGroup
 	closed chan struct{}
 }
 
 // NewServer creates a new brain server. Call Start() to begin listening.
+// State is persisted to a "brain" directory next to the socket and replayed
+// here, so coordination survives TUI and daemon restarts.
 func NewServer(socketPath string) *Server {
+	stateDir := filepath.Join(filepath.Dir(socketPath), "brain")
 	s := &Server{
-		manager:    NewManager(),
-		eventBus:   NewEventBus(1000),
-		socketPath: socketPath,
-		actionCh:   make(chan ActionRequest, 16),
-		closed:     make(chan struct{}),
+		manager:      NewManagerWithStore(NewStore(stateDir)),
+		eventBus:     NewEventBus(1000),
+		socketPath:   socketPath,
+		actionCh:     make(chan ActionRequest, 16),
+		tokens:       newTokenTable(filepath.Join(stateDir, TokenFile)),
+		operatorPath: filepath.Join(stateDir, OperatorKeyFile),
+		closed:       make(chan struct{}),
 	}
 	s.manager.SetEventCallback(func(e Event) {
 		s.eventBus.Emit(e)
@@ -73,6 +107,12 @@ func (s *Server) Actions() <-chan ActionRequest {
 // Start begins listening on the Unix socket. It blocks in an accept loop
 // until Stop() is called.
 func (s *Server) Start() error {
+	key, err := ensureOperatorKey(s.operatorPath)
+	if err != nil {
+		return err
+	}
+	s.operatorHash = hashToken(key)
+
 	// Remove stale socket file from a previous run.
 	os.Remove(s.socketPath)
 
@@ -105,6 +145,20 @@ func (s *Server) Start() error {
 		}
 	}()
 
+	// Fail workflow tasks whose instances have sat idle past their timeout.
+	go func() {
+		ticker := time.NewTicker(taskTimeoutCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.checkTaskTimeouts()
+			case <-s.closed:
+				return
+			}
+		}
+	}()
+
 	return nil
 }
 
@@ -170,6 +224,11 @@ func (s *Server) handleConn(conn net.Conn) {
 		return
 	}
 
+	if req.Method == MethodStreamEvents {
+		s.streamEvents(conn, req)
+		return
+	}
+
 	// Extend deadline based on method type.
 	conn.SetDeadline(time.Now().Add(connDeadline(req.Method)))
 
@@ -178,6 +237,17 @@ func (s *Server) handleConn(conn net.Conn) {
 }
 
 func (s *Server) dispatch(req Request) Response {
+	if err := s.authenticate(req); err != nil {
+		return Response{Error: err.Error()}
+	}
+	release, err := s.enforcePolicy(req)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	if release != nil {
+		defer release()
+	}
+
 	switch req.Method {
 	case MethodPing:
 		return Response{OK: true}
@@ -209,13 +279,45 @@ func (s *Server) dispatch(req Request) Response {
 	case MethodSendMessage:
 		to, _ := req.Params["to"].(string)
 		content, _ := req.Params["content"].(string)
-		s.manager.SendMessage(req.RepoPath, req.InstanceID, to, content)
-		return Response{OK: true}
+		replyTo, _ := req.Params["reply_to"].(float64)
+		msg, err := s.manager.PostMessage(req.RepoPath, req.InstanceID, to, content, int64(replyTo))
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return Response{Error: "marshal error: " + err.Error()}
+		}
+		return Response{OK: true, Data: data}
+
+	case MethodGetMessages:
+		since, _ := req.Params["since"].(float64)
+		unreadOnly, _ := req.Params["unread_only"].(bool)
+		msgs := s.manager.GetMessages(req.RepoPath, req.InstanceID, int64(since), unreadOnly)
+		data, err := json.Marshal(GetMessagesResult{Messages: msgs})
+		if err != nil {
+			return Response{Error: "marshal error: " + err.Error()}
+		}
+		return Response{OK: true, Data: data}
 
 	case MethodRemoveAgent:
 		s.manager.RemoveAgent(req.RepoPath, req.InstanceID)
 		return Response{OK: true}
 
+	case MethodClaimFiles:
+		return s.dispatchClaimFiles(req)
+
+	case MethodReleaseFiles:
+		released, err := s.manager.ReleaseFiles(req.RepoPath, req.InstanceID, toStringSlice(req.Params["files"]))
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		data, err := json.Marshal(ReleaseFilesResult{Released: released})
+		if err != nil {
+			return Response{Error: "marshal error: " + err.Error()}
+		}
+		return Response{OK: true, Data: data}
+
 	// Tier 3: actions relayed to TUI via action channel.
 	case MethodCreateInstance:
 		// Forward the requesting agent's ID so the TUI can inherit topic.
@@ -249,6 +351,9 @@ func (s *Server) dispatch(req Request) Response {
 	case MethodKillInstance:
 		return s.sendAction(ActionKillInstance, req.Params)
 
+	case MethodAskUser:
+		return s.dispatchAskUser(req)
+
 	case MethodDefineWorkflow:
 		return s.dispatchDefineWorkflow(req)
 
@@ -258,6 +363,9 @@ func (s *Server) dispatch(req Request) Response {
 	case MethodGetWorkflow:
 		return s.dispatchGetWorkflow(req)
 
+	case MethodListWorkflows:
+		return s.dispatchListWorkflows(req)
+
 	case MethodSubscribe:
 		return s.handleSubscribe(req)
 
@@ -272,6 +380,43 @@ func (s *Server) dispatch(req Request) Response {
 	}
 }
 
+// readOnlyMethods may be called without a token by anyone that can reach the
+// socket.
+var readOnlyMethods = map[string]bool{
+	MethodPing:          true,
+	MethodGetWorkflow:   true,
+	MethodListWorkflows: true,
+	MethodSubscribe:     true,
+	MethodPollEvents:    true,
+	MethodUnsubscribe:   true,
+	MethodStreamEvents:  true,
+}
+
+// authenticate checks that the request carries the token issued to the
+// instance it claims to come from. The hivemind CLI acts for the user with
+// the operator key, under any name that isn't an instance's. Requests without
+// a token may only call the read-only methods.
+func (s *Server) authenticate(req Request) error {
+	if req.Method == MethodPing {
+		return nil
+	}
+	if req.Token == "" {
+		if !readOnlyMethods[req.Method] || (req.InstanceID != "" && s.isInstance(req.InstanceID)) {
+			logWarn("brain: rejected %s from %q: missing token", req.Method, req.InstanceID)
+			return errors.New("unauthorized: missing token")
+		}
+		return nil
+	}
+	if s.isOperator(req.Token) && !s.isInstance(req.InstanceID) {
+		return nil
+	}
+	if !s.tokens.valid(req.InstanceID, req.Token) {
+		logWarn("brain: rejected %s from %q: invalid token", req.Method, req.InstanceID)
+		return fmt.Errorf("unauthorized: token does not match instance %q", req.InstanceID)
+	}
+	return nil
+}
+
 // sendAction relays a request to the TUI via the action channel and blocks until
 // the TUI responds or the timeout expires.
 func (s *Server) sendAction(actionType ActionType, params map[string]any) Response {
@@ -310,6 +455,80 @@ func (s *Server) sendAction(actionType ActionType, params map[string]any) Respon
 	}
 }
 
+// notifyAction queues an action for the TUI without waiting for it to be
+// handled. It is dropped if no TUI is draining the channel.
+func (s *Server) notifyAction(actionType ActionType, params map[string]any) {
+	select {
+	case s.actionCh <- ActionRequest{Type: actionType, Params: params, ResponseCh: make(chan ActionResponse, 1)}:
+	default:
+		logWarn("brain: action channel full, dropped %s notification", actionType)
+	}
+}
+
+// dispatchAskUser handles the ask_user method. A request without
+// question_id asks a new question; with one, it resumes waiting on a question
+// the same agent asked earlier.
+func (s *Server) dispatchAskUser(req Request) Response {
+	wait := DefaultAskWait
+	if v, ok := req.Params["wait"].(float64); ok && v > 0 {
+		wait = min(time.Duration(v)*time.Second, maxAskWait)
+	}
+
+	id := toString(req.Params["question_id"])
+	if id == "" {
+		q, err := s.manager.AskUser(req.RepoPath, req.InstanceID, toString(req.Params["question"]), toStringSlice(req.Params["choices"]))
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		id = q.ID
+		s.notifyAction(ActionAskUser, map[string]any{"repo_path": req.RepoPath, "id": id})
+	} else if q := s.manager.GetQuestion(req.RepoPath, id); q == nil || q.From != req.InstanceID {
+		return Response{Error: fmt.Sprintf("question %q not found", id)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	q, err := s.manager.WaitForAnswer(ctx, req.RepoPath, id)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		return Response{Error: "marshal error: " + err.Error()}
+	}
+	return Response{OK: true, Data: data}
+}
+
+// dispatchClaimFiles handles the claim_files method.
+func (s *Server) dispatchClaimFiles(req Request) Response {
+	opts := ClaimOptions{}
+	opts.Exclusive, _ = req.Params["exclusive"].(bool)
+	if ttl := toString(req.Params["ttl"]); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return Response{Error: fmt.Sprintf("invalid ttl %q (use a duration like \"45m\")", ttl)}
+		}
+		opts.TTL = d
+	}
+
+	result, err := s.manager.ClaimFiles(req.RepoPath, req.InstanceID, toStringSlice(req.Params["files"]), opts)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return Response{Error: "marshal error: " + err.Error()}
+	}
+	return Response{OK: true, Data: data}
+}
+
 // dispatchDefineWorkflow handles the define_workflow method.
 func (s *Server) dispatchDefineWorkflow(req Request) Response {
 	tasksRaw, ok := req.Params["tasks"].([]any)
@@ -323,13 +542,9 @@ func (s *Server) dispatchDefineWorkflow(req Request) Response {
 		if !ok {
 			continue
 		}
-		task := &WorkflowTask{
-			ID:        toString(taskMap["id"]),
-			Title:     toString(taskMap["title"]),
-			Status:    TaskPending,
-			DependsOn: toStringSlice(taskMap["depends_on"]),
-			Prompt:    toString(taskMap["prompt"]),
-			Role:      toString(taskMap["role"]),
+		task, err := parseWorkflowTask(taskMap)
+		if err != nil {
+			return Response{Error: err.Error()}
 		}
 		tasks = append(tasks, task)
 	}
@@ -338,26 +553,15 @@ func (s *Server) dispatchDefineWorkflow(req Request) Response {
 		return Response{Error: "no valid tasks provided"}
 	}
 
-	result := s.manager.DefineWorkflow(req.RepoPath, tasks)
-
-	// Auto-trigger tasks with no dependencies.
-	triggered := s.manager.EvaluateWorkflow(req.RepoPath)
-	result.Triggered = triggered
-
-	// Spawn instances for triggered tasks.
-	// Include source_instance so children inherit the parent's topic and ParentTitle.
-	for _, taskID := range triggered {
-		task := s.manager.GetWorkflowTask(req.RepoPath, taskID)
-		if task == nil {
-			continue
+	result, err := s.StartWorkflow(req.RepoPath, req.InstanceID, tasks)
+	if err != nil {
+		// Name the offending tasks in a form the caller can act on.
+		var verr *WorkflowValidationError
+		if errors.As(err, &verr) {
+			data, _ := json.Marshal(verr)
+			return Response{Error: err.Error(), Data: data}
 		}
-		s.sendAction(ActionCreateInstance, map[string]any{
-			"title":           task.ID,
-			"prompt":          task.Prompt,
-			"role":            task.Role,
-			"source_instance": req.InstanceID,
-			"_from_workflow":  true,
-		})
+		return Response{Error: err.Error()}
 	}
 
 	data, err := json.Marshal(result)
@@ -367,61 +571,281 @@ func (s *Server) dispatchDefineWorkflow(req Request) Response {
 	return Response{OK: true, Data: data}
 }
 
+// parseWorkflowTask builds a WorkflowTask from a JSON-decoded task object,
+// validating the retry, timeout, and failure policy fields.
+func parseWorkflowTask(taskMap map[string]any) (*WorkflowTask, error) {
+	task := &WorkflowTask{
+		ID:        toString(taskMap["id"]),
+		Title:     toString(taskMap["title"]),
+		Status:    TaskPending,
+		DependsOn: toStringSlice(taskMap["depends_on"]),
+		Prompt:    toString(taskMap["prompt"]),
+		Role:      toString(taskMap["role"]),
+		Program:   toString(taskMap["program"]),
+		OnFailure: FailurePolicy(toString(taskMap["on_failure"])),
+	}
+
+	if v, ok := taskMap["max_retries"].(float64); ok {
+		if v < 0 {
+			return nil, fmt.Errorf("task %q: max_retries must be >= 0", task.ID)
+		}
+		task.MaxRetries = int(v)
+	}
+
+	switch v := taskMap["timeout"].(type) {
+	case string:
+		if v != "" {
+			if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+				return nil, fmt.Errorf("task %q: invalid timeout %q (use a duration like \"15m\")", task.ID, v)
+			}
+			task.Timeout = v
+		}
+	case float64:
+		// Bare numbers are seconds.
+		if v <= 0 {
+			return nil, fmt.Errorf("task %q: timeout must be positive", task.ID)
+		}
+		task.Timeout = (time.Duration(v) * time.Second).String()
+	}
+
+	switch task.OnFailure {
+	case "", FailWorkflow, SkipDependents, ContinueOnFailure:
+	default:
+		return nil, fmt.Errorf("task %q: invalid on_failure %q (must be 'fail_workflow', 'skip_dependents', or 'continue')", task.ID, task.OnFailure)
+	}
+
+	return task, nil
+}
+
 // dispatchCompleteTask handles the complete_task method.
 func (s *Server) dispatchCompleteTask(req Request) Response {
 	taskID, _ := req.Params["task_id"].(string)
 	status, _ := req.Params["status"].(string)
 	errMsg, _ := req.Params["error"].(string)
+	outputs, _ := req.Params["outputs"].(map[string]any)
 
 	if taskID == "" {
 		return Response{Error: "missing required parameter: task_id"}
 	}
 
-	var taskStatus TaskStatus
+	// Without an explicit workflow_id, the task ID must identify exactly one workflow.
+	wfID, err := s.manager.ResolveTaskWorkflow(req.RepoPath, toString(req.Params["workflow_id"]), taskID)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	// Only the instance running a task may report it finished.
+	if task := s.manager.GetWorkflowTask(req.RepoPath, wfID, taskID); task == nil || task.AssignedTo != req.InstanceID {
+		return Response{Error: fmt.Sprintf("task %q is not assigned to %q", taskID, req.InstanceID)}
+	}
+
+	var result *WorkflowResult
 	switch status {
 	case "done", "":
-		taskStatus = TaskDone
+		if err := s.manager.CompleteTask(req.RepoPath, wfID, taskID, TaskDone, errMsg, outputs); err != nil {
+			return Response{Error: err.Error()}
+		}
+
+		// Evaluate DAG for newly unblocked tasks.
+		triggered := s.manager.EvaluateWorkflow(req.RepoPath, wfID)
+		s.spawnTasks(req.RepoPath, wfID, req.InstanceID, triggered)
+		result = &WorkflowResult{Triggered: triggered}
 	case "failed":
-		taskStatus = TaskFailed
+		// Retries are re-spawned as children of the workflow's creator, not
+		// of the agent reporting the failure.
+		result, err = s.handleTaskFailure(req.RepoPath, wfID, s.workflowOwner(req.RepoPath, wfID), taskID, errMsg, false)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
 	default:
 		return Response{Error: "invalid status: " + status + " (must be 'done' or 'failed')"}
 	}
+	result.WorkflowID = wfID
 
-	if err := s.manager.CompleteTask(req.RepoPath, taskID, taskStatus, errMsg); err != nil {
-		return Response{Error: err.Error()}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return Response{Error: "marshal error: " + err.Error()}
+	}
+	return Response{OK: true, Data: data}
+}
+
+// handleTaskFailure fails a task and carries out its policy: instances of
+// cancelled tasks are killed, and retried or newly unblocked tasks are spawned.
+// When killAssigned is set, the failed task's own instance is killed as well
+// (used for timeouts, where the agent is stuck rather than finished).
+func (s *Server) handleTaskFailure(repoPath, workflowID, sourceInstance, taskID, errMsg string, killAssigned bool) (*WorkflowResult, error) {
+	outcome, err := s.manager.FailTask(repoPath, workflowID, taskID, errMsg)
+	if err != nil {
+		return nil, err
 	}
 
-	// Evaluate DAG for newly unblocked tasks.
-	triggered := s.manager.EvaluateWorkflow(req.RepoPath)
+	kill := outcome.KillInstances
+	if killAssigned && outcome.Instance != "" {
+		kill = append([]string{outcome.Instance}, kill...)
+	}
+	for _, target := range kill {
+		if resp := s.sendAction(ActionKillInstance, map[string]any{"target": target}); !resp.OK {
+			logWarn("brain: failed to kill instance %q after task %q failed: %s", target, taskID, resp.Error)
+		}
+	}
 
-	// Spawn instances for triggered tasks.
-	// Include source_instance so children inherit the parent's topic and ParentTitle.
-	for _, tid := range triggered {
-		task := s.manager.GetWorkflowTask(req.RepoPath, tid)
+	triggered := s.manager.EvaluateWorkflow(repoPath, workflowID)
+	s.spawnTasks(repoPath, workflowID, sourceInstance, triggered)
+
+	return &WorkflowResult{
+		WorkflowID: workflowID,
+		Triggered:  triggered,
+		Retrying:   outcome.Retrying,
+		Skipped:    outcome.Skipped,
+		Cancelled:  outcome.Cancelled,
+	}, nil
+}
+
+// spawnTasks asks the TUI to create an instance for each triggered task.
+// Prompts are rendered first so they include upstream task results.
+// Include source_instance so children inherit the parent's topic and ParentTitle.
+// Spawns are subject to the source instance's create_instance policy. A task
+// whose instance cannot be created is failed, which may retry it.
+func (s *Server) spawnTasks(repoPath, workflowID, sourceInstance string, taskIDs []string) {
+	for _, taskID := range taskIDs {
+		task := s.manager.GetWorkflowTask(repoPath, workflowID, taskID)
 		if task == nil {
 			continue
 		}
-		s.sendAction(ActionCreateInstance, map[string]any{
-			"title":           task.ID,
-			"prompt":          task.Prompt,
+		release, err := s.authorizeSpawn(sourceInstance)
+		if err != nil {
+			if _, ferr := s.handleTaskFailure(repoPath, workflowID, sourceInstance, taskID, err.Error(), false); ferr != nil {
+				logWarn("brain: failed to mark task %q failed: %v", taskID, ferr)
+			}
+			continue
+		}
+		prompt, err := s.manager.RenderTaskPrompt(repoPath, workflowID, taskID)
+		if err != nil {
+			logWarn("brain: failed to render prompt for task %q: %v", taskID, err)
+			prompt = task.Prompt
+		}
+		resp := s.sendAction(ActionCreateInstance, map[string]any{
+			"title":           task.AssignedTo,
+			"prompt":          prompt,
 			"role":            task.Role,
-			"source_instance": req.InstanceID,
+			"program":         task.Program,
+			"source_instance": sourceInstance,
 			"_from_workflow":  true,
 		})
+		if release != nil {
+			release()
+		}
+		if resp.OK {
+			var created struct {
+				Branch string `json:"branch"`
+			}
+			if len(resp.Data) > 0 && json.Unmarshal(resp.Data, &created) == nil && created.Branch != "" {
+				s.manager.SetTaskBranch(repoPath, workflowID, taskID, created.Branch)
+			}
+			continue
+		}
+		logWarn("brain: failed to spawn instance for task %q: %s", taskID, resp.Error)
+		if _, err := s.handleTaskFailure(repoPath, workflowID, sourceInstance, taskID, "failed to spawn instance: "+resp.Error, false); err != nil {
+			logWarn("brain: failed to mark task %q failed: %v", taskID, err)
+		}
 	}
+}
 
-	result := WorkflowResult{Triggered: triggered}
-	data, err := json.Marshal(result)
+// StartWorkflow defines a workflow and spawns its ready tasks. createdBy may be
+// empty for workflows started by the user rather than an agent. It relays
+// actions to the TUI, so the TUI must call it off its update loop.
+func (s *Server) StartWorkflow(repoPath, createdBy string, tasks []*WorkflowTask) (*WorkflowResult, error) {
+	result, err := s.manager.DefineWorkflow(repoPath, createdBy, tasks)
 	if err != nil {
-		return Response{Error: "marshal error: " + err.Error()}
+		return nil, err
+	}
+
+	// Auto-trigger tasks with no dependencies.
+	triggered := s.manager.EvaluateWorkflow(repoPath, result.WorkflowID)
+	result.Triggered = triggered
+	s.spawnTasks(repoPath, result.WorkflowID, createdBy, triggered)
+	return result, nil
+}
+
+// RetryWorkflowTask manually re-runs a failed, skipped, or cancelled task and
+// spawns whatever becomes ready. It relays actions to the TUI, so the TUI must
+// call it off its update loop.
+func (s *Server) RetryWorkflowTask(repoPath, workflowID, taskID string) (*WorkflowResult, error) {
+	if err := s.manager.RetryTask(repoPath, workflowID, taskID); err != nil {
+		return nil, err
+	}
+	triggered := s.manager.EvaluateWorkflow(repoPath, workflowID)
+	s.spawnTasks(repoPath, workflowID, s.workflowOwner(repoPath, workflowID), triggered)
+	return &WorkflowResult{WorkflowID: workflowID, Triggered: triggered}, nil
+}
+
+// CancelWorkflow cancels the unfinished tasks of a workflow and kills the
+// instances still running them. Like RetryWorkflowTask, it must be called off
+// the TUI's update loop.
+func (s *Server) CancelWorkflow(repoPath, workflowID string) error {
+	kill, err := s.manager.CancelWorkflow(repoPath, workflowID)
+	if err != nil {
+		return err
+	}
+	for _, target := range kill {
+		if resp := s.sendAction(ActionKillInstance, map[string]any{"target": target}); !resp.OK {
+			logWarn("brain: failed to kill instance %q after cancelling workflow %s: %s", target, workflowID, resp.Error)
+		}
+	}
+	return nil
+}
+
+// workflowOwner returns the instance that defined the given workflow.
+func (s *Server) workflowOwner(repoPath, workflowID string) string {
+	if wf := s.manager.GetWorkflow(repoPath, workflowID); wf != nil {
+		return wf.CreatedBy
+	}
+	return ""
+}
+
+// handleInstanceEvent keeps workflow tasks in step with their instances: idle
+// time is tracked for timeouts, and a running task whose instance is killed
+// is marked failed.
+func (s *Server) handleInstanceEvent(event Event) {
+	switch event.Type {
+	case EventInstanceStatusChanged:
+		status, _ := event.Data["status"].(string)
+		s.manager.SetTaskIdle(event.RepoPath, event.Source, status == "ready")
+	case EventInstanceKilled:
+		wfID, taskID := s.manager.RunningTaskForInstance(event.RepoPath, event.Source)
+		if taskID == "" {
+			return
+		}
+		// Failure handling may relay actions to the TUI, which is the caller
+		// of PushEvent, so it must not block.
+		go func() {
+			errMsg := fmt.Sprintf("instance %q was killed", event.Source)
+			if _, err := s.handleTaskFailure(event.RepoPath, wfID, s.workflowOwner(event.RepoPath, wfID), taskID, errMsg, false); err != nil {
+				logWarn("brain: failed to fail task %q: %v", taskID, err)
+			}
+		}()
+	}
+}
+
+// checkTaskTimeouts fails running tasks whose instances have been idle past
+// their timeout and kills the stuck instances.
+func (s *Server) checkTaskTimeouts() {
+	for _, to := range s.manager.TimedOutTasks(time.Now()) {
+		errMsg := fmt.Sprintf("timed out: instance %q idle for %s", to.Instance, to.Idle.Round(time.Second))
+		if _, err := s.handleTaskFailure(to.RepoPath, to.WorkflowID, s.workflowOwner(to.RepoPath, to.WorkflowID), to.TaskID, errMsg, true); err != nil {
+			logWarn("brain: failed to time out task %q: %v", to.TaskID, err)
+		}
 	}
-	return Response{OK: true, Data: data}
 }
 
-// dispatchGetWorkflow handles the get_workflow method.
+// dispatchGetWorkflow handles the get_workflow method. Without a workflow_id
+// it returns the most recently defined workflow.
 func (s *Server) dispatchGetWorkflow(req Request) Response {
-	workflow := s.manager.GetWorkflow(req.RepoPath)
+	wfID := toString(req.Params["workflow_id"])
+	workflow := s.manager.GetWorkflow(req.RepoPath, wfID)
 	if workflow == nil {
+		if wfID != "" {
+			return Response{Error: fmt.Sprintf("workflow %q not found", wfID)}
+		}
 		return Response{OK: true, Data: json.RawMessage(`{"tasks":[]}`)}
 	}
 	data, err := json.Marshal(workflow)
@@ -431,24 +855,41 @@ func (s *Server) dispatchGetWorkflow(req Request) Response {
 	return Response{OK: true, Data: data}
 }
 
+// dispatchListWorkflows handles the list_workflows method.
+func (s *Server) dispatchListWorkflows(req Request) Response {
+	data, err := json.Marshal(map[string]any{
+		"workflows": s.manager.ListWorkflows(req.RepoPath),
+	})
+	if err != nil {
+		return Response{Error: "marshal error: " + err.Error()}
+	}
+	return Response{OK: true, Data: data}
+}
+
 // PushEvent emits an event into the event bus (used by TUI for instance lifecycle events).
 func (s *Server) PushEvent(event Event) {
 	s.eventBus.Emit(event)
+	s.trackInstanceEvent(event)
+	s.handleInstanceEvent(event)
 }
 
-func (s *Server) handleSubscribe(req Request) Response {
+// parseEventFilter reads the subscription filter shared by subscribe and stream_events.
+func parseEventFilter(params map[string]any) EventFilter {
 	var filter EventFilter
-	if types := toStringSlice(req.Params["types"]); len(types) > 0 {
+	if types := toStringSlice(params["types"]); len(types) > 0 {
 		for _, t := range types {
 			filter.Types = append(filter.Types, EventType(t))
 		}
 	}
-	filter.Instances = toStringSlice(req.Params["instances"])
-	if pt, ok := req.Params["parent_title"].(string); ok {
+	filter.Instances = toStringSlice(params["instances"])
+	if pt, ok := params["parent_title"].(string); ok {
 		filter.ParentTitle = pt
 	}
+	return filter
+}
 
-	subID := s.eventBus.Subscribe(filter)
+func (s *Server) handleSubscribe(req Request) Response {
+	subID := s.eventBus.Subscribe(parseEventFilter(req.Params))
 
 	result := SubscribeResult{SubscriberID: subID}
 	data, err := json.Marshal(result)
@@ -485,6 +926,83 @@ func (s *Server) handlePollEvents(req Request) Response {
 	return Response{OK: true, Data: data}
 }
 
+// streamEvents serves a stream_events request: it replies with the subscriber
+// ID, replays history after the "since" sequence of the server run "epoch"
+// names, then writes each matching
+// event as a JSON line until the client disconnects or the server stops.
+func (s *Server) streamEvents(conn net.Conn, req Request) {
+	if err := s.authenticate(req); err != nil {
+		writeResponse(conn, Response{Error: err.Error()})
+		return
+	}
+	if _, err := s.enforcePolicy(req); err != nil {
+		writeResponse(conn, Response{Error: err.Error()})
+		return
+	}
+	from := EventCursor{Epoch: toString(req.Params["epoch"])}
+	if v, ok := req.Params["since"].(float64); ok && v > 0 {
+		from.Sequence = uint64(v)
+	}
+	subID, replay := s.eventBus.SubscribeStream(parseEventFilter(req.Params), from)
+	defer s.eventBus.Unsubscribe(subID)
+
+	conn.SetDeadline(time.Time{})
+
+	// The client sends nothing after the request, so a read returning means
+	// it hung up.
+	stop := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(stop)
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.closed:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	data, _ := json.Marshal(SubscribeResult{SubscriberID: subID})
+	if !writeStreamLine(conn, Response{OK: true, Data: data}) {
+		return
+	}
+	for _, e := range replay {
+		if !writeStreamLine(conn, e) {
+			return
+		}
+	}
+	for {
+		events, err := s.eventBus.Next(subID, stop)
+		if err != nil || events == nil {
+			return
+		}
+		for _, e := range events {
+			if !writeStreamLine(conn, e) {
+				return
+			}
+		}
+	}
+}
+
+// streamWriteTimeout bounds how long a stream waits on a slow reader before
+// dropping the connection.
+const streamWriteTimeout = 10 * time.Second
+
+// writeStreamLine writes v as a JSON line and reports whether it succeeded.
+func writeStreamLine(conn net.Conn, v any) bool {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logWarn("brain: failed to marshal stream line: %v", err)
+		return false
+	}
+	conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	_, err = conn.Write(append(data, '\n'))
+	return err == nil
+}
+
 func (s *Server) handleUnsubscribe(req Request) Response {
 	subID, _ := req.Params["subscriber_id"].(string)
 	if subID == "" {
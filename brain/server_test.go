@@ -2,11 +2,16 @@ package brain
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func startTestServer(t *testing.T) *Server {
@@ -29,9 +34,114 @@ func startTestServer(t *testing.T) *Server {
 	return s
 }
 
-func roundTrip(t *testing.T, socketPath string, req Request) Response {
+// testTokens caches the tokens issued by testToken, per server and instance.
+var testTokens sync.Map
+
+// testToken returns the token of instanceID, issuing one the first time.
+// Instances the server doesn't know yet are registered without a role or
+// parent, so tests can use any instance ID.
+func testToken(s *Server, instanceID string) (string, error) {
+	key := [2]any{s, instanceID}
+	if token, ok := testTokens.Load(key); ok {
+		return token.(string), nil
+	}
+	token, err := s.tokens.issue(instanceID)
+	if err != nil {
+		return "", err
+	}
+	s.instMu.Lock()
+	if _, ok := s.instances[instanceID]; !ok {
+		if s.instances == nil {
+			s.instances = make(map[string]InstanceInfo)
+		}
+		s.instances[instanceID] = InstanceInfo{Title: instanceID}
+	}
+	s.instMu.Unlock()
+	testTokens.Store(key, token)
+	return token, nil
+}
+
+// signTestRequest adds the token of the request's instance, or the operator
+// key if it names none.
+func signTestRequest(s *Server, req *Request) error {
+	if req.Token != "" {
+		return nil
+	}
+	var err error
+	if req.InstanceID == "" {
+		req.Token, err = readOperatorKey(s.operatorPath)
+	} else {
+		req.Token, err = testToken(s, req.InstanceID)
+	}
+	return err
+}
+
+// newTestClient returns a client that can act as any instance, or as the
+// operator. It talks to the server through a proxy that signs each request.
+func newTestClient(t *testing.T, s *Server) *Client {
 	t.Helper()
-	conn, err := net.Dial("unix", socketPath)
+	sock := s.SocketPath() + ".proxy"
+	if _, err := os.Stat(sock); err == nil {
+		return NewClient(sock)
+	}
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen proxy: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go proxyConn(t, s, conn)
+		}
+	}()
+	return NewClient(sock)
+}
+
+func proxyConn(t *testing.T, s *Server, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		t.Errorf("proxy: %v", err)
+		return
+	}
+	if err := signTestRequest(s, &req); err != nil {
+		t.Errorf("proxy: %v", err)
+		return
+	}
+	upstream, err := net.Dial("unix", s.SocketPath())
+	if err != nil {
+		t.Errorf("proxy: %v", err)
+		return
+	}
+	defer upstream.Close()
+	data, _ := json.Marshal(req)
+	if _, err := upstream.Write(append(data, '\n')); err != nil {
+		return
+	}
+	go func() {
+		io.Copy(upstream, reader)
+		upstream.(*net.UnixConn).CloseWrite()
+	}()
+	io.Copy(conn, upstream)
+}
+
+// roundTrip sends a single request, with the token of its instance unless it
+// already carries one.
+func roundTrip(t *testing.T, s *Server, req Request) Response {
+	t.Helper()
+	if err := signTestRequest(s, &req); err != nil {
+		t.Fatalf("sign request: %v", err)
+	}
+	conn, err := net.Dial("unix", s.SocketPath())
 	if err != nil {
 		t.Fatalf("dial failed: %v", err)
 	}
@@ -57,17 +167,135 @@ func roundTrip(t *testing.T, socketPath string, req Request) Response {
 
 func TestServerPing(t *testing.T) {
 	s := startTestServer(t)
-	resp := roundTrip(t, s.SocketPath(), Request{Method: MethodPing})
+	resp := roundTrip(t, s, Request{Method: MethodPing})
 	if !resp.OK {
 		t.Errorf("ping: OK = false, error = %q", resp.Error)
 	}
 }
 
+func TestServerRejectsImpersonation(t *testing.T) {
+	s := startTestServer(t)
+
+	// An agent holding its own token cannot act as another instance.
+	agent := NewClient(s.SocketPath())
+	token, err := s.IssueToken(InstanceInfo{Title: "child"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent.SetToken("child", token)
+	if err := agent.Ping(); err != nil {
+		t.Fatalf("ping should not need a valid token: %v", err)
+	}
+	if err := agent.SendMessage("/repo", "child", "parent", "hi"); err != nil {
+		t.Fatalf("request as own instance: %v", err)
+	}
+	err = agent.SendMessage("/repo", "parent", "child", "do as I say")
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("expected impersonation to be rejected, got %v", err)
+	}
+
+	anonymous := NewClient(s.SocketPath())
+	if _, err := anonymous.GetBrain("/repo", "child"); err == nil || !strings.Contains(err.Error(), "missing token") {
+		t.Fatalf("expected request as an instance without its token to be rejected, got %v", err)
+	}
+
+	state := s.Manager().GetBrain("/repo", "parent")
+	if len(state.Messages) != 1 || state.Messages[0].From != "child" {
+		t.Errorf("only the authenticated message should be stored, got %+v", state.Messages)
+	}
+}
+
+func TestServerRequiresTokenToAct(t *testing.T) {
+	s := startTestServer(t)
+	s.SetInstances([]InstanceInfo{{Title: "worker", RepoPath: "/repo"}})
+
+	// Without a token, nothing can be changed, whatever name is used.
+	anonymous := NewClient(s.SocketPath())
+	if err := anonymous.KillInstance("/repo", "", "worker"); err == nil || !strings.Contains(err.Error(), "missing token") {
+		t.Errorf("expected tokenless kill_instance to be rejected, got %v", err)
+	}
+	if err := anonymous.SendMessage("/repo", "someone", "worker", "hi"); err == nil || !strings.Contains(err.Error(), "missing token") {
+		t.Errorf("expected tokenless send_message to be rejected, got %v", err)
+	}
+	select {
+	case action := <-s.Actions():
+		t.Fatalf("a tokenless request reached the TUI: %+v", action)
+	default:
+	}
+	// It can still read workflows.
+	if _, err := anonymous.ListWorkflows("/repo", ""); err != nil {
+		t.Errorf("list_workflows without a token: %v", err)
+	}
+}
+
+func TestServerEventsWithToken(t *testing.T) {
+	s := startTestServer(t)
+	token, err := s.IssueToken(InstanceInfo{Title: "agent", RepoPath: "/repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewClient(s.SocketPath())
+	agent.SetToken("agent", token)
+
+	subID, err := agent.Subscribe("/repo", EventFilter{Types: []EventType{EventMessageReceived}})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := agent.SendMessage("/repo", "agent", "other", "hi"); err != nil {
+		t.Fatalf("send_message: %v", err)
+	}
+	events, err := agent.PollEvents(subID, 1)
+	if err != nil {
+		t.Fatalf("poll_events: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventMessageReceived {
+		t.Errorf("expected the message event, got %+v", events)
+	}
+	if err := agent.Unsubscribe(subID); err != nil {
+		t.Errorf("unsubscribe: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	streamed := make(chan Event, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- agent.StreamEvents(ctx, EventFilter{Types: []EventType{EventMessageReceived}}, EventCursor{}, func(ev Event) error {
+			select {
+			case streamed <- ev:
+			default:
+			}
+			return nil
+		})
+	}()
+	// The stream only sees events emitted once it is open, so keep sending.
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-streamed:
+			if ev.Type != EventMessageReceived {
+				t.Errorf("expected a message event, got %+v", ev)
+			}
+			return
+		case err := <-done:
+			t.Fatalf("stream_events: %v", err)
+		case <-ticker.C:
+			if err := agent.SendMessage("/repo", "agent", "other", "again"); err != nil {
+				t.Fatalf("send_message: %v", err)
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the streamed event")
+		}
+	}
+}
+
 func TestServerUpdateAndGetBrain(t *testing.T) {
 	s := startTestServer(t)
 
 	// Update status for agent-1
-	resp := roundTrip(t, s.SocketPath(), Request{
+	resp := roundTrip(t, s, Request{
 		Method:     MethodUpdateStatus,
 		InstanceID: "agent-1",
 		RepoPath:   "/repo",
@@ -81,7 +309,7 @@ func TestServerUpdateAndGetBrain(t *testing.T) {
 	}
 
 	// Get brain for agent-1
-	resp = roundTrip(t, s.SocketPath(), Request{
+	resp = roundTrip(t, s, Request{
 		Method:     MethodGetBrain,
 		InstanceID: "agent-1",
 		RepoPath:   "/repo",
@@ -105,7 +333,7 @@ func TestServerUpdateAndGetBrain(t *testing.T) {
 func TestServerSendMessage(t *testing.T) {
 	s := startTestServer(t)
 
-	resp := roundTrip(t, s.SocketPath(), Request{
+	resp := roundTrip(t, s, Request{
 		Method:     MethodSendMessage,
 		InstanceID: "agent-1",
 		RepoPath:   "/repo",
@@ -119,7 +347,7 @@ func TestServerSendMessage(t *testing.T) {
 	}
 
 	// Verify agent-2 can see the message
-	resp = roundTrip(t, s.SocketPath(), Request{
+	resp = roundTrip(t, s, Request{
 		Method:     MethodGetBrain,
 		InstanceID: "agent-2",
 		RepoPath:   "/repo",
@@ -142,17 +370,17 @@ func TestServerRemoveAgent(t *testing.T) {
 	s := startTestServer(t)
 
 	// Add two agents
-	roundTrip(t, s.SocketPath(), Request{
+	roundTrip(t, s, Request{
 		Method: MethodUpdateStatus, InstanceID: "agent-1", RepoPath: "/repo",
 		Params: map[string]any{"feature": "a"},
 	})
-	roundTrip(t, s.SocketPath(), Request{
+	roundTrip(t, s, Request{
 		Method: MethodUpdateStatus, InstanceID: "agent-2", RepoPath: "/repo",
 		Params: map[string]any{"feature": "b"},
 	})
 
 	// Remove agent-1
-	resp := roundTrip(t, s.SocketPath(), Request{
+	resp := roundTrip(t, s, Request{
 		Method: MethodRemoveAgent, InstanceID: "agent-1", RepoPath: "/repo",
 	})
 	if !resp.OK {
@@ -160,7 +388,7 @@ func TestServerRemoveAgent(t *testing.T) {
 	}
 
 	// Verify only agent-2 remains
-	resp = roundTrip(t, s.SocketPath(), Request{
+	resp = roundTrip(t, s, Request{
 		Method: MethodGetBrain, InstanceID: "agent-2", RepoPath: "/repo",
 	})
 	var state BrainState
@@ -175,7 +403,7 @@ func TestServerRemoveAgent(t *testing.T) {
 
 func TestServerUnknownMethod(t *testing.T) {
 	s := startTestServer(t)
-	resp := roundTrip(t, s.SocketPath(), Request{Method: "nonexistent"})
+	resp := roundTrip(t, s, Request{Method: "nonexistent"})
 	if resp.OK {
 		t.Error("expected OK=false for unknown method")
 	}
@@ -188,13 +416,13 @@ func TestServerConflictDetection(t *testing.T) {
 	s := startTestServer(t)
 
 	// agent-1 claims auth.go
-	roundTrip(t, s.SocketPath(), Request{
+	roundTrip(t, s, Request{
 		Method: MethodUpdateStatus, InstanceID: "agent-1", RepoPath: "/repo",
 		Params: map[string]any{"feature": "auth", "files": []any{"auth.go"}},
 	})
 
 	// agent-2 also claims auth.go
-	resp := roundTrip(t, s.SocketPath(), Request{
+	resp := roundTrip(t, s, Request{
 		Method: MethodUpdateStatus, InstanceID: "agent-2", RepoPath: "/repo",
 		Params: map[string]any{"feature": "auth fix", "files": []any{"auth.go"}},
 	})
@@ -0,0 +1,97 @@
+package brain
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// repoSnapshot is the on-disk format for a single repository's brain state.
+type repoSnapshot struct {
+	RepoPath  string                  `json:"repo_path"`
+	Agents    map[string]*AgentStatus `json:"agents"`
+	Messages  []BrainMessage          `json:"messages"`
+	Workflows []*Workflow             `json:"workflows,omitempty"`
+	Leases    []*FileLease            `json:"leases,omitempty"`
+	// LastMessageID keeps message IDs increasing across restarts.
+	LastMessageID int64       `json:"last_message_id,omitempty"`
+	Questions     []*Question `json:"questions,omitempty"`
+}
+
+// Store persists per-repo brain state to disk so agents, messages, and
+// workflows survive TUI and daemon restarts. Each repo is stored in its own
+// file: <dir>/<hash>.json.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a Store rooted at dir. The directory is created on first save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory the store writes to.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// path returns the snapshot file for a repo.
+func (s *Store) path(repoPath string) string {
+	h := sha256.Sum256([]byte(repoPath))
+	return filepath.Join(s.dir, fmt.Sprintf("%x.json", h[:8]))
+}
+
+// save atomically writes a repo snapshot.
+func (s *Store) save(snap *repoSnapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal brain snapshot: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("create brain dir: %w", err)
+	}
+
+	p := s.path(snap.RepoPath)
+	tmpPath := p + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p)
+}
+
+// loadAll reads every snapshot in the store. Unreadable or corrupt files are
+// skipped so a single bad file doesn't discard the rest of the state.
+func (s *Store) loadAll() ([]*repoSnapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snaps []*repoSnapshot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			logWarn("brain: failed to read snapshot %s: %v", e.Name(), err)
+			continue
+		}
+		var snap repoSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			logWarn("brain: failed to parse snapshot %s: %v", e.Name(), err)
+			continue
+		}
+		if snap.RepoPath == "" {
+			continue
+		}
+		snaps = append(snaps, &snap)
+	}
+	return snaps, nil
+}
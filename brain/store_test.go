@@ -0,0 +1,96 @@
+package brain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagerWithStoreRestoresState(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	m := NewManagerWithStore(store)
+	m.UpdateStatusWithRole("/repo", "agent-1", "auth", []string{"auth.go"}, "coder")
+	m.SendMessage("/repo", "agent-1", "agent-2", "hello")
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "task-1", Title: "implement"},
+		{ID: "task-2", Title: "test", DependsOn: []string{"task-1"}},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+	if err := m.CompleteTask("/repo", wfID, "task-1", TaskDone, "", nil); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+
+	// Simulate a restart: a fresh manager over the same store.
+	restored := NewManagerWithStore(store)
+
+	state := restored.GetBrain("/repo", "agent-2")
+	if state.Agents["agent-1"] == nil || state.Agents["agent-1"].Role != "coder" {
+		t.Errorf("expected agent-1 with role coder, got %+v", state.Agents["agent-1"])
+	}
+	if len(state.Messages) != 1 || state.Messages[0].Content != "hello" {
+		t.Errorf("expected restored message, got %+v", state.Messages)
+	}
+
+	wf := restored.GetWorkflow("/repo", wfID)
+	if wf == nil {
+		t.Fatal("expected restored workflow, got nil")
+	}
+	if got := restored.GetWorkflowTask("/repo", wfID, "task-1").Status; got != TaskDone {
+		t.Errorf("task-1: expected done, got %s", got)
+	}
+	if got := restored.GetWorkflowTask("/repo", wfID, "task-2").Status; got != TaskPending {
+		t.Errorf("task-2: expected pending, got %s", got)
+	}
+
+	// The restored workflow keeps driving the DAG.
+	triggered := restored.EvaluateWorkflow("/repo", wfID)
+	if len(triggered) != 1 || triggered[0] != "task-2" {
+		t.Errorf("expected task-2 to trigger after restore, got %v", triggered)
+	}
+}
+
+func TestManagerWithStoreRepoIsolation(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	m := NewManagerWithStore(store)
+	wfA := mustDefineWorkflow(t, m, "/repo-a", "", []*WorkflowTask{{ID: "task-a"}})
+	wfB := mustDefineWorkflow(t, m, "/repo-b", "", []*WorkflowTask{{ID: "task-b"}})
+
+	restored := NewManagerWithStore(store)
+	if restored.GetWorkflowTask("/repo-a", wfA, "task-a") == nil {
+		t.Error("expected task-a in repo-a")
+	}
+	if restored.GetWorkflowTask("/repo-b", wfB, "task-b") == nil {
+		t.Error("expected task-b in repo-b")
+	}
+	if len(restored.ListWorkflows("/repo-a")) != 1 || restored.GetWorkflowTask("/repo-a", wfA, "task-b") != nil {
+		t.Error("repo-a should not contain task-b")
+	}
+}
+
+func TestStoreSkipsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	m := NewManagerWithStore(store)
+	m.SendMessage("/repo", "agent-1", "", "still here")
+
+	if err := os.WriteFile(filepath.Join(dir, "garbage.json"), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewManagerWithStore(store)
+	state := restored.GetBrain("/repo", "agent-2")
+	if len(state.Messages) != 1 {
+		t.Fatalf("expected 1 restored message, got %d", len(state.Messages))
+	}
+}
+
+func TestStoreMissingDir(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "does-not-exist"))
+	m := NewManagerWithStore(store)
+	if wf := m.GetWorkflow("/repo", ""); wf != nil {
+		t.Errorf("expected nil workflow, got %v", wf)
+	}
+}
@@ -0,0 +1,107 @@
+package brain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// taskRefPattern matches prompt references to upstream task results, e.g.
+// {{tasks.design.outputs.summary}} or {{tasks.impl.branch}}.
+var taskRefPattern = regexp.MustCompile(`\{\{\s*tasks\.([A-Za-z0-9_-]+)\.([A-Za-z0-9_.-]+?)\s*\}\}`)
+
+// taskRef is a single {{tasks.<id>.<field>}} reference.
+type taskRef struct {
+	TaskID string
+	Field  string
+}
+
+func (r taskRef) String() string {
+	return fmt.Sprintf("tasks.%s.%s", r.TaskID, r.Field)
+}
+
+// parseTaskRefs returns every task reference in text, in order of appearance.
+func parseTaskRefs(text string) []taskRef {
+	var refs []taskRef
+	for _, m := range taskRefPattern.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, taskRef{TaskID: m[1], Field: m[2]})
+	}
+	return refs
+}
+
+// validTaskField reports whether field can be resolved on a task.
+func validTaskField(field string) bool {
+	switch field {
+	case "branch", "instance", "status", "title", "error", "outputs":
+		return true
+	}
+	return strings.HasPrefix(field, "outputs.") && len(field) > len("outputs.")
+}
+
+// renderTaskTemplate replaces task references in text with values from the
+// referenced tasks. Missing outputs render as empty strings; references to
+// unknown tasks are left untouched.
+func renderTaskTemplate(text string, byID map[string]*WorkflowTask) string {
+	return taskRefPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := taskRefPattern.FindStringSubmatch(match)
+		t := byID[m[1]]
+		if t == nil {
+			return match
+		}
+		return resolveTaskField(t, m[2])
+	})
+}
+
+// resolveTaskField returns the string value of a task field. Output keys may be
+// dotted to reach into nested objects.
+func resolveTaskField(t *WorkflowTask, field string) string {
+	switch field {
+	case "branch":
+		return t.Branch
+	case "instance":
+		return t.AssignedTo
+	case "status":
+		return string(t.Status)
+	case "title":
+		return t.Title
+	case "error":
+		return t.Error
+	case "outputs":
+		return formatOutputValue(t.Outputs)
+	}
+
+	key, ok := strings.CutPrefix(field, "outputs.")
+	if !ok {
+		return ""
+	}
+	var v any = t.Outputs
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		v = obj[part]
+	}
+	return formatOutputValue(v)
+}
+
+// formatOutputValue renders an output for inclusion in a prompt: strings as-is,
+// everything else as JSON.
+func formatOutputValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any:
+		if len(v) == 0 {
+			return ""
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,67 @@
+package brain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderTaskTemplate(t *testing.T) {
+	byID := map[string]*WorkflowTask{
+		"design": {
+			ID:     "design",
+			Status: TaskDone,
+			Outputs: map[string]any{
+				"summary": "use a token bucket",
+				"files":   []any{"limiter.go", "limiter_test.go"},
+				"meta":    map[string]any{"owner": "alice"},
+			},
+		},
+		"impl": {ID: "impl", Branch: "hivemind/impl", AssignedTo: "impl-retry1"},
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Plan: {{tasks.design.outputs.summary}}", "Plan: use a token bucket"},
+		{"Files: {{ tasks.design.outputs.files }}", `Files: ["limiter.go","limiter_test.go"]`},
+		{"Owner: {{tasks.design.outputs.meta.owner}}", "Owner: alice"},
+		{"Review {{tasks.impl.branch}} from {{tasks.impl.instance}}", "Review hivemind/impl from impl-retry1"},
+		{"Status {{tasks.design.status}}", "Status done"},
+		{"Missing [{{tasks.design.outputs.nope}}]", "Missing []"},
+		{"Unknown {{tasks.other.branch}}", "Unknown {{tasks.other.branch}}"},
+		{"No templates here", "No templates here"},
+	}
+	for _, tt := range tests {
+		if got := renderTaskTemplate(tt.in, byID); got != tt.want {
+			t.Errorf("renderTaskTemplate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWorkflowTemplateRefs(t *testing.T) {
+	_, err := ValidateWorkflow([]*WorkflowTask{
+		{ID: "design"},
+		{ID: "impl", DependsOn: []string{"design"}, Prompt: "Build {{tasks.design.outputs.summary}}"},
+		{ID: "review", DependsOn: []string{"impl"}, Prompt: "Check {{tasks.impl.branch}} against {{tasks.design.outputs.summary}}"},
+	})
+	if err != nil {
+		t.Fatalf("transitive references should be allowed: %v", err)
+	}
+
+	_, err = ValidateWorkflow([]*WorkflowTask{
+		{ID: "a", Prompt: "{{tasks.b.outputs.x}}"},
+		{ID: "b", DependsOn: []string{"a"}, Prompt: "{{tasks.a.nonsense}}"},
+	})
+	verr, ok := err.(*WorkflowValidationError)
+	if !ok {
+		t.Fatalf("expected *WorkflowValidationError, got %v", err)
+	}
+	want := map[string][]string{
+		"a": {"tasks.b.outputs.x"},
+		"b": {"tasks.a.nonsense"},
+	}
+	if !reflect.DeepEqual(verr.InvalidRefs, want) {
+		t.Errorf("InvalidRefs = %v, want %v", verr.InvalidRefs, want)
+	}
+}
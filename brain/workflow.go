@@ -0,0 +1,755 @@
+package brain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxWorkflows caps how many workflows a repo keeps. When exceeded, the oldest
+// finished workflows are dropped; running workflows are never pruned.
+const maxWorkflows = 20
+
+// DefineWorkflow adds a new workflow to a repo alongside any existing ones.
+// createdBy is the instance that defined it; server-initiated retries are
+// spawned as its children. Task lists that are not a valid DAG are rejected
+// with a *WorkflowValidationError; see ValidateWorkflow.
+func (m *Manager) DefineWorkflow(repoPath, createdBy string, tasks []*WorkflowTask) (*WorkflowResult, error) {
+	levels, err := ValidateWorkflow(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	// IDs are wf-<millis>; bump on collision so two workflows defined in the
+	// same millisecond don't replace each other.
+	ms := time.Now().UnixMilli()
+	wfID := fmt.Sprintf("wf-%d", ms)
+	for rs.workflows[wfID] != nil {
+		ms++
+		wfID = fmt.Sprintf("wf-%d", ms)
+	}
+
+	// Ensure all tasks start as pending.
+	for _, t := range tasks {
+		if t.Status == "" {
+			t.Status = TaskPending
+		}
+	}
+	rs.workflows[wfID] = &Workflow{
+		ID:        wfID,
+		Status:    WorkflowRunning,
+		Tasks:     tasks,
+		CreatedBy: createdBy,
+	}
+	pruneWorkflows(rs)
+
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	m.emitEvent(EventWorkflowDefined, repoPath, "", map[string]any{
+		"workflow_id": wfID,
+		"task_count":  len(tasks),
+	})
+
+	return &WorkflowResult{WorkflowID: wfID, Levels: levels}, nil
+}
+
+// GetWorkflow returns a snapshot of a workflow by ID. An empty workflowID
+// selects the most recently defined workflow. Returns nil if no matching
+// workflow exists.
+func (m *Manager) GetWorkflow(repoPath, workflowID string) *Workflow {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	if workflowID != "" {
+		return cloneWorkflow(rs.workflows[workflowID])
+	}
+	ids := sortedWorkflowIDs(rs)
+	if len(ids) == 0 {
+		return nil
+	}
+	return cloneWorkflow(rs.workflows[ids[len(ids)-1]])
+}
+
+// ListWorkflows returns snapshots of every workflow for a repo, oldest first.
+func (m *Manager) ListWorkflows(repoPath string) []*Workflow {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	ids := sortedWorkflowIDs(rs)
+	out := make([]*Workflow, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, cloneWorkflow(rs.workflows[id]))
+	}
+	return out
+}
+
+// GetWorkflowTask returns a snapshot of a single task from a workflow by ID.
+func (m *Manager) GetWorkflowTask(repoPath, workflowID, taskID string) *WorkflowTask {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	wf := rs.workflows[workflowID]
+	if wf == nil {
+		return nil
+	}
+	return cloneTask(findTask(wf, taskID))
+}
+
+// ResolveTaskWorkflow returns the ID of the workflow that owns taskID. When
+// workflowID is set it is validated and returned as-is. Otherwise the task is
+// looked up across the repo's workflows, preferring unfinished ones; it is an
+// error if the task ID is ambiguous.
+func (m *Manager) ResolveTaskWorkflow(repoPath, workflowID, taskID string) (string, error) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	if len(rs.workflows) == 0 {
+		return "", fmt.Errorf("no workflow defined for repo")
+	}
+	if workflowID != "" {
+		if rs.workflows[workflowID] == nil {
+			return "", fmt.Errorf("workflow %q not found", workflowID)
+		}
+		return workflowID, nil
+	}
+
+	var active, all []string
+	for _, id := range sortedWorkflowIDs(rs) {
+		wf := rs.workflows[id]
+		t := findTask(wf, taskID)
+		if t == nil {
+			continue
+		}
+		all = append(all, id)
+		if !t.Status.IsTerminal() {
+			active = append(active, id)
+		}
+	}
+
+	candidates := active
+	if len(candidates) == 0 {
+		candidates = all
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("task %q not found in any workflow", taskID)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("task %q exists in multiple workflows (%s); pass workflow_id", taskID, strings.Join(candidates, ", "))
+	}
+}
+
+// CompleteTask marks a workflow task as done or failed. Outputs of a done task
+// are stored for downstream prompt templates. Failures go through the task's
+// retry and failure policy; see FailTask.
+func (m *Manager) CompleteTask(repoPath, workflowID, taskID string, status TaskStatus, errMsg string, outputs map[string]any) error {
+	if status == TaskFailed {
+		_, err := m.FailTask(repoPath, workflowID, taskID, errMsg)
+		return err
+	}
+
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	wf, t, err := lookupTask(rs, workflowID, taskID)
+	if err == nil {
+		err = checkRunning(t)
+	}
+	if err != nil {
+		rs.mu.Unlock()
+		return err
+	}
+	prev := wf.Status
+	t.Status = status
+	t.Error = errMsg
+	t.IdleSince = ""
+	if outputs != nil {
+		t.Outputs = outputs
+	}
+	finished := updateWorkflowStatus(wf, prev)
+	wfStatus := wf.Status
+
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	m.emitEvent(EventTaskCompleted, repoPath, taskID, map[string]any{
+		"workflow_id": workflowID,
+		"task_id":     taskID,
+		"status":      string(status),
+	})
+	if finished {
+		m.emitWorkflowCompleted(repoPath, workflowID, wfStatus)
+	}
+
+	return nil
+}
+
+// FailTask marks a workflow task as failed and applies its policy. If the task
+// has retries left it is reset to pending so the next EvaluateWorkflow re-triggers
+// it. Otherwise its OnFailure policy decides what happens to the rest of the DAG.
+func (m *Manager) FailTask(repoPath, workflowID, taskID, errMsg string) (*FailureOutcome, error) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	wf, t, err := lookupTask(rs, workflowID, taskID)
+	if err == nil {
+		err = checkRunning(t)
+	}
+	if err != nil {
+		rs.mu.Unlock()
+		return nil, err
+	}
+	prev := wf.Status
+	t.Error = errMsg
+	t.IdleSince = ""
+
+	outcome := applyFailurePolicy(wf, t)
+	finished := updateWorkflowStatus(wf, prev)
+	wfStatus := wf.Status
+	attempts := t.Attempts
+
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	m.emitEvent(EventTaskCompleted, repoPath, taskID, map[string]any{
+		"workflow_id": workflowID,
+		"task_id":     taskID,
+		"status":      string(TaskFailed),
+		"error":       errMsg,
+		"retrying":    outcome.Retrying,
+	})
+	if outcome.Retrying {
+		m.emitEvent(EventTaskRetried, repoPath, taskID, map[string]any{
+			"workflow_id": workflowID,
+			"task_id":     taskID,
+			"attempts":    attempts,
+		})
+	}
+	if finished {
+		m.emitWorkflowCompleted(repoPath, workflowID, wfStatus)
+	}
+
+	return outcome, nil
+}
+
+// EvaluateWorkflow checks a workflow for pending tasks whose dependencies are
+// all satisfied, marks them as running, and returns their IDs. Each triggered
+// task is assigned the instance title it should be spawned under.
+func (m *Manager) EvaluateWorkflow(repoPath, workflowID string) []string {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	wf := rs.workflows[workflowID]
+	if wf == nil || wf.Status != WorkflowRunning {
+		rs.mu.Unlock()
+		return nil
+	}
+
+	// Build task index.
+	byID := make(map[string]*WorkflowTask, len(wf.Tasks))
+	for _, t := range wf.Tasks {
+		byID[t.ID] = t
+	}
+
+	var triggered []string
+	for _, t := range wf.Tasks {
+		if t.Status != TaskPending {
+			continue
+		}
+
+		// Check all dependencies are satisfied.
+		ready := true
+		for _, dep := range t.DependsOn {
+			if !dependencySatisfied(byID[dep]) {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			t.Status = TaskRunning
+			t.Attempts++
+			t.AssignedTo = taskInstanceTitle(rs, wf, t)
+			t.IdleSince = ""
+			t.Branch = ""
+			t.Outputs = nil
+			triggered = append(triggered, t.ID)
+		}
+	}
+
+	if len(triggered) > 0 {
+		m.persist(repoPath, rs)
+	}
+	rs.mu.Unlock()
+
+	for _, taskID := range triggered {
+		m.emitEvent(EventTaskTriggered, repoPath, taskID, map[string]any{
+			"workflow_id": workflowID,
+			"task_id":     taskID,
+		})
+	}
+
+	return triggered
+}
+
+// RetryTask manually re-runs a failed, skipped, or cancelled task. The task and
+// any skipped or cancelled tasks downstream of it are reset to pending, and a
+// failed workflow is resumed so the next EvaluateWorkflow re-triggers them.
+// The retry does not count against the task's MaxRetries.
+func (m *Manager) RetryTask(repoPath, workflowID, taskID string) error {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	wf, t, err := lookupTask(rs, workflowID, taskID)
+	if err != nil {
+		rs.mu.Unlock()
+		return err
+	}
+	switch t.Status {
+	case TaskFailed, TaskSkipped, TaskCancelled:
+	default:
+		rs.mu.Unlock()
+		return fmt.Errorf("task %q is %s; only failed, skipped, or cancelled tasks can be retried", taskID, t.Status)
+	}
+
+	resetTask(t)
+	for _, d := range downstreamTasks(wf, taskID) {
+		if d.Status == TaskSkipped || d.Status == TaskCancelled {
+			resetTask(d)
+		}
+	}
+	// A failed or cancelled workflow cancelled its unrelated tasks too; resume them.
+	if wf.Status == WorkflowFailed || wf.Status == WorkflowCancelled {
+		for _, other := range wf.Tasks {
+			if other.Status == TaskCancelled {
+				resetTask(other)
+			}
+		}
+	}
+	wf.Status = WorkflowRunning
+	attempts := t.Attempts
+
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	m.emitEvent(EventTaskRetried, repoPath, taskID, map[string]any{
+		"workflow_id": workflowID,
+		"task_id":     taskID,
+		"attempts":    attempts,
+		"manual":      true,
+	})
+	return nil
+}
+
+// CancelWorkflow cancels every pending and running task in a workflow and
+// returns the instances of the running tasks so the caller can kill them.
+func (m *Manager) CancelWorkflow(repoPath, workflowID string) ([]string, error) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+
+	wf := rs.workflows[workflowID]
+	if wf == nil {
+		rs.mu.Unlock()
+		return nil, fmt.Errorf("workflow %q not found", workflowID)
+	}
+	if wf.Status != WorkflowRunning {
+		rs.mu.Unlock()
+		return nil, fmt.Errorf("workflow %s is already %s", workflowID, wf.Status)
+	}
+
+	var kill []string
+	for _, t := range wf.Tasks {
+		switch t.Status {
+		case TaskRunning:
+			if t.AssignedTo != "" {
+				kill = append(kill, t.AssignedTo)
+			}
+			fallthrough
+		case TaskPending:
+			t.Status = TaskCancelled
+			t.IdleSince = ""
+		}
+	}
+	wf.Status = WorkflowCancelled
+
+	m.persist(repoPath, rs)
+	rs.mu.Unlock()
+
+	m.emitWorkflowCompleted(repoPath, workflowID, WorkflowCancelled)
+	return kill, nil
+}
+
+// RenderTaskPrompt returns a task's prompt with {{tasks.<id>.<field>}}
+// references filled in from the other tasks in its workflow.
+func (m *Manager) RenderTaskPrompt(repoPath, workflowID, taskID string) (string, error) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	wf, t, err := lookupTask(rs, workflowID, taskID)
+	if err != nil {
+		return "", err
+	}
+	byID := make(map[string]*WorkflowTask, len(wf.Tasks))
+	for _, other := range wf.Tasks {
+		byID[other.ID] = other
+	}
+	return renderTaskTemplate(t.Prompt, byID), nil
+}
+
+// SetTaskBranch records the git branch of the instance running a task.
+func (m *Manager) SetTaskBranch(repoPath, workflowID, taskID, branch string) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	_, t, err := lookupTask(rs, workflowID, taskID)
+	if err != nil || t.Branch == branch {
+		return
+	}
+	t.Branch = branch
+	m.persist(repoPath, rs)
+}
+
+// RunningTaskForInstance returns the workflow and task IDs of the running task
+// assigned to the given instance, or empty strings if there is none.
+func (m *Manager) RunningTaskForInstance(repoPath, instanceTitle string) (workflowID, taskID string) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	if wf, t := runningTaskFor(rs, instanceTitle); t != nil {
+		return wf.ID, t.ID
+	}
+	return "", ""
+}
+
+// SetTaskIdle records whether the instance running a workflow task is idle.
+// Idle time is measured against the task's Timeout.
+func (m *Manager) SetTaskIdle(repoPath, instanceTitle string, idle bool) {
+	rs := m.getOrCreateRepo(repoPath)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	_, t := runningTaskFor(rs, instanceTitle)
+	if t == nil {
+		return
+	}
+	switch {
+	case idle && t.IdleSince == "":
+		t.IdleSince = time.Now().UTC().Format(time.RFC3339)
+	case !idle && t.IdleSince != "":
+		t.IdleSince = ""
+	default:
+		return
+	}
+	m.persist(repoPath, rs)
+}
+
+// TimedOutTask identifies a running task whose instance has been idle past its timeout.
+type TimedOutTask struct {
+	RepoPath   string
+	WorkflowID string
+	TaskID     string
+	Instance   string
+	Idle       time.Duration
+}
+
+// TimedOutTasks returns every running task, across all repos and workflows,
+// whose instance has been idle for longer than the task's Timeout.
+func (m *Manager) TimedOutTasks(now time.Time) []TimedOutTask {
+	m.mu.RLock()
+	repos := make(map[string]*repoState, len(m.repos))
+	for path, rs := range m.repos {
+		repos[path] = rs
+	}
+	m.mu.RUnlock()
+
+	var out []TimedOutTask
+	for repoPath, rs := range repos {
+		rs.mu.RLock()
+		for _, wf := range rs.workflows {
+			for _, t := range wf.Tasks {
+				if t.Status != TaskRunning || t.IdleSince == "" {
+					continue
+				}
+				timeout, err := time.ParseDuration(t.Timeout)
+				if err != nil || timeout <= 0 {
+					continue
+				}
+				since, err := time.Parse(time.RFC3339, t.IdleSince)
+				if err != nil {
+					continue
+				}
+				if idle := now.Sub(since); idle > timeout {
+					out = append(out, TimedOutTask{
+						RepoPath:   repoPath,
+						WorkflowID: wf.ID,
+						TaskID:     t.ID,
+						Instance:   t.AssignedTo,
+						Idle:       idle,
+					})
+				}
+			}
+		}
+		rs.mu.RUnlock()
+	}
+	return out
+}
+
+func (m *Manager) emitWorkflowCompleted(repoPath, wfID string, status WorkflowStatus) {
+	m.emitEvent(EventWorkflowCompleted, repoPath, "", map[string]any{
+		"workflow_id": wfID,
+		"status":      string(status),
+	})
+}
+
+// findTask returns the task with the given ID, or nil.
+func findTask(wf *Workflow, taskID string) *WorkflowTask {
+	for _, t := range wf.Tasks {
+		if t.ID == taskID {
+			return t
+		}
+	}
+	return nil
+}
+
+// lookupTask returns the workflow and task for the given IDs.
+// Caller must hold rs.mu.
+func lookupTask(rs *repoState, workflowID, taskID string) (*Workflow, *WorkflowTask, error) {
+	wf := rs.workflows[workflowID]
+	if wf == nil {
+		if len(rs.workflows) == 0 {
+			return nil, nil, fmt.Errorf("no workflow defined for repo")
+		}
+		return nil, nil, fmt.Errorf("workflow %q not found", workflowID)
+	}
+	t := findTask(wf, taskID)
+	if t == nil {
+		return nil, nil, fmt.Errorf("task %q not found in workflow %s", taskID, workflowID)
+	}
+	return wf, t, nil
+}
+
+// checkRunning returns an error unless t is running, so a task is only
+// completed or failed once per attempt.
+func checkRunning(t *WorkflowTask) error {
+	if t.Status != TaskRunning {
+		return fmt.Errorf("task %q is %s, not running", t.ID, t.Status)
+	}
+	return nil
+}
+
+// runningTaskFor returns the running task assigned to instanceTitle across all
+// of the repo's workflows, or nil. Caller must hold rs.mu.
+func runningTaskFor(rs *repoState, instanceTitle string) (*Workflow, *WorkflowTask) {
+	if instanceTitle == "" {
+		return nil, nil
+	}
+	for _, wf := range rs.workflows {
+		for _, t := range wf.Tasks {
+			if t.Status == TaskRunning && t.AssignedTo == instanceTitle {
+				return wf, t
+			}
+		}
+	}
+	return nil, nil
+}
+
+// taskInstanceTitle returns the instance title for the task's current attempt.
+// The first attempt uses the task ID; retries get a numbered suffix so they
+// don't collide with the instance of the failed attempt. If another workflow
+// in the repo already used the title, the workflow's ID is appended.
+// Caller must hold rs.mu.
+func taskInstanceTitle(rs *repoState, wf *Workflow, t *WorkflowTask) string {
+	title := t.ID
+	if t.Attempts > 1 {
+		title = fmt.Sprintf("%s-retry%d", t.ID, t.Attempts-1)
+	}
+	for _, other := range rs.workflows {
+		if other == wf {
+			continue
+		}
+		for _, ot := range other.Tasks {
+			if ot.AssignedTo == title {
+				return title + "-" + strings.TrimPrefix(wf.ID, "wf-")
+			}
+		}
+	}
+	return title
+}
+
+// sortedWorkflowIDs returns the repo's workflow IDs oldest first.
+// Caller must hold rs.mu.
+func sortedWorkflowIDs(rs *repoState) []string {
+	ids := make([]string, 0, len(rs.workflows))
+	for id := range rs.workflows {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pruneWorkflows drops the oldest finished workflows once the repo holds more
+// than maxWorkflows. Caller must hold rs.mu write lock.
+func pruneWorkflows(rs *repoState) {
+	excess := len(rs.workflows) - maxWorkflows
+	if excess <= 0 {
+		return
+	}
+	for _, id := range sortedWorkflowIDs(rs) {
+		if excess == 0 {
+			return
+		}
+		if rs.workflows[id].Status != WorkflowRunning {
+			delete(rs.workflows, id)
+			excess--
+		}
+	}
+}
+
+// dependencySatisfied reports whether dep no longer blocks its dependents.
+func dependencySatisfied(dep *WorkflowTask) bool {
+	if dep == nil {
+		return false
+	}
+	return dep.Status == TaskDone || (dep.Status == TaskFailed && dep.OnFailure == ContinueOnFailure)
+}
+
+// applyFailurePolicy marks t failed (or pending for a retry) and applies its
+// OnFailure policy to the rest of the workflow. Caller must hold the repo lock.
+func applyFailurePolicy(wf *Workflow, t *WorkflowTask) *FailureOutcome {
+	outcome := &FailureOutcome{Instance: t.AssignedTo}
+
+	if t.MaxRetries > 0 && t.Attempts <= t.MaxRetries {
+		t.Status = TaskPending
+		outcome.Retrying = true
+		return outcome
+	}
+
+	t.Status = TaskFailed
+	switch t.OnFailure {
+	case ContinueOnFailure:
+		// Dependents treat the failure as satisfied; see dependencySatisfied.
+	case SkipDependents:
+		outcome.Skipped = skipDependents(wf, t.ID)
+	default:
+		wf.Status = WorkflowFailed
+		for _, other := range wf.Tasks {
+			switch other.Status {
+			case TaskRunning:
+				if other.AssignedTo != "" {
+					outcome.KillInstances = append(outcome.KillInstances, other.AssignedTo)
+				}
+				fallthrough
+			case TaskPending:
+				other.Status = TaskCancelled
+				other.IdleSince = ""
+				outcome.Cancelled = append(outcome.Cancelled, other.ID)
+			}
+		}
+	}
+	return outcome
+}
+
+// skipDependents marks every pending task downstream of taskID as skipped and
+// returns their IDs.
+func skipDependents(wf *Workflow, taskID string) []string {
+	var skipped []string
+	for _, d := range downstreamTasks(wf, taskID) {
+		if d.Status != TaskPending {
+			continue
+		}
+		d.Status = TaskSkipped
+		skipped = append(skipped, d.ID)
+	}
+	return skipped
+}
+
+// downstreamTasks returns every task that transitively depends on taskID, in
+// breadth-first order.
+func downstreamTasks(wf *Workflow, taskID string) []*WorkflowTask {
+	dependents := make(map[string][]*WorkflowTask)
+	for _, t := range wf.Tasks {
+		for _, dep := range t.DependsOn {
+			dependents[dep] = append(dependents[dep], t)
+		}
+	}
+
+	var out []*WorkflowTask
+	seen := map[string]bool{taskID: true}
+	queue := []string{taskID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[id] {
+			if seen[d.ID] {
+				continue
+			}
+			seen[d.ID] = true
+			out = append(out, d)
+			queue = append(queue, d.ID)
+		}
+	}
+	return out
+}
+
+// cloneWorkflow copies a workflow so callers can read it without holding the
+// repo lock.
+func cloneWorkflow(wf *Workflow) *Workflow {
+	if wf == nil {
+		return nil
+	}
+	c := *wf
+	c.Tasks = make([]*WorkflowTask, len(wf.Tasks))
+	for i, t := range wf.Tasks {
+		c.Tasks[i] = cloneTask(t)
+	}
+	return &c
+}
+
+// cloneTask copies a task. Outputs are replaced wholesale, never mutated, so
+// the map is shared.
+func cloneTask(t *WorkflowTask) *WorkflowTask {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.DependsOn = append([]string(nil), t.DependsOn...)
+	return &c
+}
+
+// resetTask returns a task to pending so it is triggered again.
+func resetTask(t *WorkflowTask) {
+	t.Status = TaskPending
+	t.Error = ""
+	t.IdleSince = ""
+}
+
+// updateWorkflowStatus marks the workflow done once every task is terminal.
+// prev is the status before the current mutation. Returns true if the workflow
+// just finished (done or failed).
+func updateWorkflowStatus(wf *Workflow, prev WorkflowStatus) bool {
+	if wf.Status == WorkflowRunning {
+		done := true
+		for _, t := range wf.Tasks {
+			if !t.Status.IsTerminal() {
+				done = false
+				break
+			}
+		}
+		if done {
+			wf.Status = WorkflowDone
+		}
+	}
+	return wf.Status != prev && wf.Status != WorkflowRunning
+}
@@ -1,9 +1,22 @@
 package brain
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
+// mustDefineWorkflow defines a workflow and returns its ID, failing the test on error.
+func mustDefineWorkflow(t *testing.T, m *Manager, repoPath, createdBy string, tasks []*WorkflowTask) string {
+	t.Helper()
+	result, err := m.DefineWorkflow(repoPath, createdBy, tasks)
+	if err != nil {
+		t.Fatalf("DefineWorkflow: %v", err)
+	}
+	return result.WorkflowID
+}
+
 func TestManagerDefineWorkflow(t *testing.T) {
 	m := NewManager()
 
@@ -13,12 +26,20 @@ func TestManagerDefineWorkflow(t *testing.T) {
 		{ID: "task-3", Title: "code review", DependsOn: []string{"task-1", "task-2"}},
 	}
 
-	result := m.DefineWorkflow("/repo", tasks)
+	result, err := m.DefineWorkflow("/repo", "", tasks)
+	if err != nil {
+		t.Fatalf("DefineWorkflow: %v", err)
+	}
+	wfID := result.WorkflowID
 	if result.WorkflowID == "" {
 		t.Error("expected non-empty workflow ID")
 	}
+	wantLevels := [][]string{{"task-1"}, {"task-2"}, {"task-3"}}
+	if !reflect.DeepEqual(result.Levels, wantLevels) {
+		t.Errorf("levels = %v, want %v", result.Levels, wantLevels)
+	}
 
-	wf := m.GetWorkflow("/repo")
+	wf := m.GetWorkflow("/repo", wfID)
 	if wf == nil {
 		t.Fatal("expected workflow, got nil")
 	}
@@ -41,34 +62,34 @@ func TestManagerEvaluateWorkflow(t *testing.T) {
 		{ID: "task-3", Title: "code review", DependsOn: []string{"task-1", "task-2"}},
 	}
 
-	m.DefineWorkflow("/repo", tasks)
+	wfID := mustDefineWorkflow(t, m, "/repo", "", tasks)
 
 	// First evaluation: task-1 and task-2 have no deps, should be triggered.
-	triggered := m.EvaluateWorkflow("/repo")
+	triggered := m.EvaluateWorkflow("/repo", wfID)
 	if len(triggered) != 2 {
 		t.Fatalf("expected 2 triggered tasks, got %d: %v", len(triggered), triggered)
 	}
 
 	// Verify they are now running.
 	for _, tid := range triggered {
-		task := m.GetWorkflowTask("/repo", tid)
+		task := m.GetWorkflowTask("/repo", wfID, tid)
 		if task.Status != TaskRunning {
 			t.Errorf("task %s: expected running, got %s", tid, task.Status)
 		}
 	}
 
 	// task-3 should still be pending (deps not done).
-	task3 := m.GetWorkflowTask("/repo", "task-3")
+	task3 := m.GetWorkflowTask("/repo", wfID, "task-3")
 	if task3.Status != TaskPending {
 		t.Errorf("task-3: expected pending, got %s", task3.Status)
 	}
 
 	// Complete task-1 and task-2.
-	m.CompleteTask("/repo", "task-1", TaskDone, "")
-	m.CompleteTask("/repo", "task-2", TaskDone, "")
+	m.CompleteTask("/repo", wfID, "task-1", TaskDone, "", nil)
+	m.CompleteTask("/repo", wfID, "task-2", TaskDone, "", nil)
 
 	// Second evaluation: task-3 should now be triggered.
-	triggered = m.EvaluateWorkflow("/repo")
+	triggered = m.EvaluateWorkflow("/repo", wfID)
 	if len(triggered) != 1 {
 		t.Fatalf("expected 1 triggered task, got %d: %v", len(triggered), triggered)
 	}
@@ -83,18 +104,29 @@ func TestManagerCompleteTask(t *testing.T) {
 	tasks := []*WorkflowTask{
 		{ID: "task-1", Title: "work"},
 	}
-	m.DefineWorkflow("/repo", tasks)
+	wfID := mustDefineWorkflow(t, m, "/repo", "", tasks)
+
+	// A task that hasn't started can't be completed.
+	if err := m.CompleteTask("/repo", wfID, "task-1", TaskDone, "", nil); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("expected completing a pending task to fail, got %v", err)
+	}
+	m.EvaluateWorkflow("/repo", wfID)
 
 	// Complete with success.
-	err := m.CompleteTask("/repo", "task-1", TaskDone, "")
+	err := m.CompleteTask("/repo", wfID, "task-1", TaskDone, "", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	task := m.GetWorkflowTask("/repo", "task-1")
+	task := m.GetWorkflowTask("/repo", wfID, "task-1")
 	if task.Status != TaskDone {
 		t.Errorf("expected done, got %s", task.Status)
 	}
+
+	// Nor can it be completed again.
+	if err := m.CompleteTask("/repo", wfID, "task-1", TaskFailed, "late", nil); err == nil {
+		t.Error("expected completing a finished task to fail")
+	}
 }
 
 func TestManagerCompleteTaskFailed(t *testing.T) {
@@ -104,15 +136,16 @@ func TestManagerCompleteTaskFailed(t *testing.T) {
 		{ID: "task-1", Title: "work"},
 		{ID: "task-2", Title: "depends on 1", DependsOn: []string{"task-1"}},
 	}
-	m.DefineWorkflow("/repo", tasks)
+	wfID := mustDefineWorkflow(t, m, "/repo", "", tasks)
+	m.EvaluateWorkflow("/repo", wfID)
 
 	// Fail task-1.
-	err := m.CompleteTask("/repo", "task-1", TaskFailed, "compilation error")
+	err := m.CompleteTask("/repo", wfID, "task-1", TaskFailed, "compilation error", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	task := m.GetWorkflowTask("/repo", "task-1")
+	task := m.GetWorkflowTask("/repo", wfID, "task-1")
 	if task.Status != TaskFailed {
 		t.Errorf("expected failed, got %s", task.Status)
 	}
@@ -121,7 +154,7 @@ func TestManagerCompleteTaskFailed(t *testing.T) {
 	}
 
 	// task-2 should NOT be triggered (dependency failed, not done).
-	triggered := m.EvaluateWorkflow("/repo")
+	triggered := m.EvaluateWorkflow("/repo", wfID)
 	if len(triggered) != 0 {
 		t.Errorf("expected 0 triggered tasks (dep failed), got %d: %v", len(triggered), triggered)
 	}
@@ -133,9 +166,9 @@ func TestManagerCompleteTaskNotFound(t *testing.T) {
 	tasks := []*WorkflowTask{
 		{ID: "task-1", Title: "work"},
 	}
-	m.DefineWorkflow("/repo", tasks)
+	wfID := mustDefineWorkflow(t, m, "/repo", "", tasks)
 
-	err := m.CompleteTask("/repo", "nonexistent", TaskDone, "")
+	err := m.CompleteTask("/repo", wfID, "nonexistent", TaskDone, "", nil)
 	if err == nil {
 		t.Error("expected error for nonexistent task")
 	}
@@ -144,7 +177,7 @@ func TestManagerCompleteTaskNotFound(t *testing.T) {
 func TestManagerCompleteTaskNoWorkflow(t *testing.T) {
 	m := NewManager()
 
-	err := m.CompleteTask("/repo", "task-1", TaskDone, "")
+	err := m.CompleteTask("/repo", "wf-1", "task-1", TaskDone, "", nil)
 	if err == nil {
 		t.Error("expected error when no workflow defined")
 	}
@@ -153,7 +186,7 @@ func TestManagerCompleteTaskNoWorkflow(t *testing.T) {
 func TestManagerGetWorkflowNil(t *testing.T) {
 	m := NewManager()
 
-	wf := m.GetWorkflow("/repo")
+	wf := m.GetWorkflow("/repo", "")
 	if wf != nil {
 		t.Errorf("expected nil workflow, got %v", wf)
 	}
@@ -165,9 +198,9 @@ func TestManagerGetWorkflowTask(t *testing.T) {
 	tasks := []*WorkflowTask{
 		{ID: "task-1", Title: "work", Prompt: "do the thing", Role: "coder"},
 	}
-	m.DefineWorkflow("/repo", tasks)
+	wfID := mustDefineWorkflow(t, m, "/repo", "", tasks)
 
-	task := m.GetWorkflowTask("/repo", "task-1")
+	task := m.GetWorkflowTask("/repo", wfID, "task-1")
 	if task == nil {
 		t.Fatal("expected task, got nil")
 	}
@@ -182,7 +215,7 @@ func TestManagerGetWorkflowTask(t *testing.T) {
 	}
 
 	// Nonexistent task.
-	if m.GetWorkflowTask("/repo", "nonexistent") != nil {
+	if m.GetWorkflowTask("/repo", wfID, "nonexistent") != nil {
 		t.Error("expected nil for nonexistent task")
 	}
 }
@@ -208,11 +241,11 @@ func TestManagerUpdateStatusWithRole(t *testing.T) {
 func TestManagerWorkflowRepoIsolation(t *testing.T) {
 	m := NewManager()
 
-	m.DefineWorkflow("/repo-a", []*WorkflowTask{{ID: "task-a", Title: "work a"}})
-	m.DefineWorkflow("/repo-b", []*WorkflowTask{{ID: "task-b", Title: "work b"}})
+	mustDefineWorkflow(t, m, "/repo-a", "", []*WorkflowTask{{ID: "task-a", Title: "work a"}})
+	mustDefineWorkflow(t, m, "/repo-b", "", []*WorkflowTask{{ID: "task-b", Title: "work b"}})
 
-	wfA := m.GetWorkflow("/repo-a")
-	wfB := m.GetWorkflow("/repo-b")
+	wfA := m.GetWorkflow("/repo-a", "")
+	wfB := m.GetWorkflow("/repo-b", "")
 
 	if wfA.Tasks[0].ID != "task-a" {
 		t.Errorf("repo-a should have task-a, got %s", wfA.Tasks[0].ID)
@@ -221,3 +254,350 @@ func TestManagerWorkflowRepoIsolation(t *testing.T) {
 		t.Errorf("repo-b should have task-b, got %s", wfB.Tasks[0].ID)
 	}
 }
+
+func TestManagerFailTaskRetries(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "architect", []*WorkflowTask{
+		{ID: "task-1", Title: "flaky", MaxRetries: 1},
+	})
+
+	triggered := m.EvaluateWorkflow("/repo", wfID)
+	if len(triggered) != 1 {
+		t.Fatalf("expected 1 triggered, got %v", triggered)
+	}
+	if got := m.GetWorkflowTask("/repo", wfID, "task-1").AssignedTo; got != "task-1" {
+		t.Errorf("first attempt AssignedTo = %q, want %q", got, "task-1")
+	}
+
+	outcome, err := m.FailTask("/repo", wfID, "task-1", "boom")
+	if err != nil {
+		t.Fatalf("FailTask: %v", err)
+	}
+	if !outcome.Retrying {
+		t.Fatal("expected task to be retried")
+	}
+	if outcome.Instance != "task-1" {
+		t.Errorf("outcome.Instance = %q, want %q", outcome.Instance, "task-1")
+	}
+
+	// The retry is re-triggered under a fresh instance title.
+	triggered = m.EvaluateWorkflow("/repo", wfID)
+	if len(triggered) != 1 {
+		t.Fatalf("expected retry to trigger, got %v", triggered)
+	}
+	task := m.GetWorkflowTask("/repo", wfID, "task-1")
+	if task.AssignedTo != "task-1-retry1" {
+		t.Errorf("retry AssignedTo = %q, want %q", task.AssignedTo, "task-1-retry1")
+	}
+	if task.Attempts != 2 {
+		t.Errorf("Attempts = %d, want 2", task.Attempts)
+	}
+
+	// Out of retries: the default policy fails the workflow.
+	outcome, err = m.FailTask("/repo", wfID, "task-1", "boom again")
+	if err != nil {
+		t.Fatalf("FailTask: %v", err)
+	}
+	if outcome.Retrying {
+		t.Error("expected no more retries")
+	}
+	if got := m.GetWorkflow("/repo", wfID).Status; got != WorkflowFailed {
+		t.Errorf("workflow status = %s, want failed", got)
+	}
+}
+
+func TestManagerFailTaskFailWorkflowCancels(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "task-1"},
+		{ID: "task-2"},
+		{ID: "task-3", DependsOn: []string{"task-1", "task-2"}},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+
+	outcome, err := m.FailTask("/repo", wfID, "task-1", "broken")
+	if err != nil {
+		t.Fatalf("FailTask: %v", err)
+	}
+	if len(outcome.Cancelled) != 2 {
+		t.Errorf("expected task-2 and task-3 cancelled, got %v", outcome.Cancelled)
+	}
+	if len(outcome.KillInstances) != 1 || outcome.KillInstances[0] != "task-2" {
+		t.Errorf("expected task-2's instance to be killed, got %v", outcome.KillInstances)
+	}
+	if got := m.GetWorkflowTask("/repo", wfID, "task-3").Status; got != TaskCancelled {
+		t.Errorf("task-3: expected cancelled, got %s", got)
+	}
+	if triggered := m.EvaluateWorkflow("/repo", wfID); len(triggered) != 0 {
+		t.Errorf("failed workflow should not trigger tasks, got %v", triggered)
+	}
+}
+
+func TestManagerFailTaskSkipDependents(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "lint", OnFailure: SkipDependents},
+		{ID: "fix-lint", DependsOn: []string{"lint"}},
+		{ID: "report", DependsOn: []string{"fix-lint"}},
+		{ID: "docs"},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+
+	outcome, err := m.FailTask("/repo", wfID, "lint", "lint crashed")
+	if err != nil {
+		t.Fatalf("FailTask: %v", err)
+	}
+	if len(outcome.Skipped) != 2 {
+		t.Errorf("expected fix-lint and report skipped, got %v", outcome.Skipped)
+	}
+	if got := m.GetWorkflowTask("/repo", wfID, "docs").Status; got != TaskRunning {
+		t.Errorf("docs: expected running, got %s", got)
+	}
+	if got := m.GetWorkflow("/repo", wfID).Status; got != WorkflowRunning {
+		t.Errorf("workflow status = %s, want running", got)
+	}
+
+	if err := m.CompleteTask("/repo", wfID, "docs", TaskDone, "", nil); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	if got := m.GetWorkflow("/repo", wfID).Status; got != WorkflowDone {
+		t.Errorf("workflow status = %s, want done once every task is terminal", got)
+	}
+}
+
+func TestManagerFailTaskContinue(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "optional", OnFailure: ContinueOnFailure},
+		{ID: "next", DependsOn: []string{"optional"}},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+
+	if _, err := m.FailTask("/repo", wfID, "optional", "meh"); err != nil {
+		t.Fatalf("FailTask: %v", err)
+	}
+	triggered := m.EvaluateWorkflow("/repo", wfID)
+	if len(triggered) != 1 || triggered[0] != "next" {
+		t.Errorf("expected next to trigger despite failed dependency, got %v", triggered)
+	}
+}
+
+func TestManagerTaskIdleTimeout(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "task-1", Timeout: "10m"},
+		{ID: "task-2"},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+
+	m.SetTaskIdle("/repo", "task-1", true)
+	m.SetTaskIdle("/repo", "task-2", true)
+
+	if got := m.TimedOutTasks(time.Now()); len(got) != 0 {
+		t.Fatalf("expected no timeouts yet, got %v", got)
+	}
+
+	got := m.TimedOutTasks(time.Now().Add(11 * time.Minute))
+	if len(got) != 1 {
+		t.Fatalf("expected 1 timed out task (task-2 has no timeout), got %v", got)
+	}
+	if got[0].TaskID != "task-1" || got[0].Instance != "task-1" || got[0].RepoPath != "/repo" {
+		t.Errorf("unexpected timed out task: %+v", got[0])
+	}
+
+	// Becoming busy again resets the idle clock.
+	m.SetTaskIdle("/repo", "task-1", false)
+	if got := m.TimedOutTasks(time.Now().Add(11 * time.Minute)); len(got) != 0 {
+		t.Errorf("expected no timeouts after instance resumed work, got %v", got)
+	}
+}
+
+func TestManagerRunningTaskForInstance(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{{ID: "task-1"}})
+
+	if _, got := m.RunningTaskForInstance("/repo", "task-1"); got != "" {
+		t.Errorf("pending task should not match, got %q", got)
+	}
+	m.EvaluateWorkflow("/repo", wfID)
+	if gotWF, got := m.RunningTaskForInstance("/repo", "task-1"); got != "task-1" || gotWF != wfID {
+		t.Errorf("RunningTaskForInstance = (%q, %q), want (%q, %q)", gotWF, got, wfID, "task-1")
+	}
+	if _, got := m.RunningTaskForInstance("/repo", "other"); got != "" {
+		t.Errorf("unexpected task for unrelated instance: %q", got)
+	}
+}
+
+func TestManagerConcurrentWorkflows(t *testing.T) {
+	m := NewManager()
+	wf1 := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{{ID: "build"}, {ID: "test", DependsOn: []string{"build"}}})
+	wf2 := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{{ID: "build"}})
+	if wf1 == wf2 {
+		t.Fatalf("expected distinct workflow IDs, both were %s", wf1)
+	}
+
+	workflows := m.ListWorkflows("/repo")
+	if len(workflows) != 2 || workflows[0].ID != wf1 || workflows[1].ID != wf2 {
+		t.Fatalf("ListWorkflows = %v, want [%s %s]", workflows, wf1, wf2)
+	}
+	if got := m.GetWorkflow("/repo", "").ID; got != wf2 {
+		t.Errorf("GetWorkflow without ID = %s, want latest %s", got, wf2)
+	}
+
+	m.EvaluateWorkflow("/repo", wf1)
+	m.EvaluateWorkflow("/repo", wf2)
+
+	// Same task ID in both workflows must not share an instance title.
+	title1 := m.GetWorkflowTask("/repo", wf1, "build").AssignedTo
+	title2 := m.GetWorkflowTask("/repo", wf2, "build").AssignedTo
+	if title1 == title2 {
+		t.Errorf("expected distinct instance titles, both were %q", title1)
+	}
+	if gotWF, _ := m.RunningTaskForInstance("/repo", title2); gotWF != wf2 {
+		t.Errorf("instance %q resolved to %s, want %s", title2, gotWF, wf2)
+	}
+
+	// The shared task ID is ambiguous without a workflow ID.
+	if _, err := m.ResolveTaskWorkflow("/repo", "", "build"); err == nil {
+		t.Error("expected ambiguity error for task in two workflows")
+	}
+	if got, err := m.ResolveTaskWorkflow("/repo", "", "test"); err != nil || got != wf1 {
+		t.Errorf("ResolveTaskWorkflow(test) = %s, %v; want %s", got, err, wf1)
+	}
+
+	// Completing in one workflow leaves the other untouched.
+	if err := m.CompleteTask("/repo", wf2, "build", TaskDone, "", nil); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	if got := m.GetWorkflow("/repo", wf2).Status; got != WorkflowDone {
+		t.Errorf("wf2: expected done, got %s", got)
+	}
+	if got := m.GetWorkflowTask("/repo", wf1, "build").Status; got != TaskRunning {
+		t.Errorf("wf1 build: expected running, got %s", got)
+	}
+
+	// Once wf2's build is finished, the active one in wf1 wins the lookup.
+	if got, err := m.ResolveTaskWorkflow("/repo", "", "build"); err != nil || got != wf1 {
+		t.Errorf("ResolveTaskWorkflow(build) = %s, %v; want %s", got, err, wf1)
+	}
+}
+
+func TestManagerPrunesFinishedWorkflows(t *testing.T) {
+	m := NewManager()
+	running := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{{ID: "keep"}})
+	for i := 0; i < maxWorkflows+5; i++ {
+		id := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{{ID: "t"}})
+		m.EvaluateWorkflow("/repo", id)
+		if err := m.CompleteTask("/repo", id, "t", TaskDone, "", nil); err != nil {
+			t.Fatalf("CompleteTask: %v", err)
+		}
+	}
+
+	workflows := m.ListWorkflows("/repo")
+	if len(workflows) > maxWorkflows+1 {
+		t.Errorf("expected at most %d workflows, got %d", maxWorkflows+1, len(workflows))
+	}
+	if m.GetWorkflow("/repo", running) == nil {
+		t.Error("running workflow should never be pruned")
+	}
+}
+
+func TestManagerDefineWorkflowRejectsInvalidDAG(t *testing.T) {
+	m := NewManager()
+	_, err := m.DefineWorkflow("/repo", "", []*WorkflowTask{
+		{ID: "a", DependsOn: []string{"b"}},
+		{ID: "b", DependsOn: []string{"a"}},
+	})
+	if err == nil {
+		t.Fatal("expected error for cyclic workflow")
+	}
+	if wf := m.GetWorkflow("/repo", ""); wf != nil {
+		t.Errorf("invalid workflow should not be stored, got %s", wf.ID)
+	}
+}
+
+func TestManagerRetryTaskResumesFailedWorkflow(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "task-1"},
+		{ID: "task-2"},
+		{ID: "task-3", DependsOn: []string{"task-1"}},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+	if _, err := m.FailTask("/repo", wfID, "task-1", "broken"); err != nil {
+		t.Fatalf("FailTask: %v", err)
+	}
+
+	if err := m.RetryTask("/repo", wfID, "missing"); err == nil {
+		t.Error("expected an error retrying an unknown task")
+	}
+	if err := m.RetryTask("/repo", wfID, "task-1"); err != nil {
+		t.Fatalf("RetryTask: %v", err)
+	}
+	if got := m.GetWorkflow("/repo", wfID).Status; got != WorkflowRunning {
+		t.Errorf("workflow status = %s, want running", got)
+	}
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		if got := m.GetWorkflowTask("/repo", wfID, id).Status; got != TaskPending {
+			t.Errorf("%s: expected pending after retry, got %s", id, got)
+		}
+	}
+
+	triggered := m.EvaluateWorkflow("/repo", wfID)
+	if len(triggered) != 2 {
+		t.Errorf("expected task-1 and task-2 triggered, got %v", triggered)
+	}
+	if err := m.RetryTask("/repo", wfID, "task-1"); err == nil {
+		t.Error("expected an error retrying a running task")
+	}
+}
+
+func TestManagerCancelWorkflow(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{
+		{ID: "task-1"},
+		{ID: "task-2", DependsOn: []string{"task-1"}},
+	})
+	m.EvaluateWorkflow("/repo", wfID)
+
+	kill, err := m.CancelWorkflow("/repo", wfID)
+	if err != nil {
+		t.Fatalf("CancelWorkflow: %v", err)
+	}
+	if len(kill) != 1 || kill[0] != "task-1" {
+		t.Errorf("expected task-1's instance to be killed, got %v", kill)
+	}
+	wf := m.GetWorkflow("/repo", wfID)
+	if wf.Status != WorkflowCancelled {
+		t.Errorf("workflow status = %s, want cancelled", wf.Status)
+	}
+	for _, task := range wf.Tasks {
+		if task.Status != TaskCancelled {
+			t.Errorf("%s: expected cancelled, got %s", task.ID, task.Status)
+		}
+	}
+
+	// Completing a cancelled task must not revive the workflow.
+	if err := m.CompleteTask("/repo", wfID, "task-1", TaskDone, "", nil); err == nil {
+		if got := m.GetWorkflow("/repo", wfID).Status; got != WorkflowCancelled {
+			t.Errorf("workflow status = %s after late completion, want cancelled", got)
+		}
+	}
+	if triggered := m.EvaluateWorkflow("/repo", wfID); len(triggered) != 0 {
+		t.Errorf("cancelled workflow should not trigger tasks, got %v", triggered)
+	}
+	if _, err := m.CancelWorkflow("/repo", wfID); err == nil {
+		t.Error("expected an error cancelling a finished workflow")
+	}
+}
+
+func TestManagerGetWorkflowReturnsCopy(t *testing.T) {
+	m := NewManager()
+	wfID := mustDefineWorkflow(t, m, "/repo", "", []*WorkflowTask{{ID: "task-1"}})
+
+	wf := m.GetWorkflow("/repo", wfID)
+	wf.Tasks[0].Status = TaskDone
+	if got := m.GetWorkflowTask("/repo", wfID, "task-1").Status; got != TaskPending {
+		t.Errorf("mutating a returned workflow changed manager state: %s", got)
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ByteMirror/hivemind/brain"
+
+	"github.com/spf13/cobra"
+)
+
+// cliInstanceID is the name the CLI uses when talking to agents, so replies
+// addressed to it show up in `hivemind brain status`.
+const cliInstanceID = "cli"
+
+var (
+	brainJSON     bool
+	brainRepoFlag string
+
+	brainSendTo      string
+	brainSendFrom    string
+	brainSendInject  bool
+	brainSendReplyTo int64
+
+	brainEventsFollow    bool
+	brainEventsTypes     []string
+	brainEventsInstances []string
+	brainEventsAllRepos  bool
+	brainEventsTimeout   int
+	brainEventsSince     uint64
+	brainEventsEpoch     string
+
+	brainSpawnTitle   string
+	brainSpawnPrompt  string
+	brainSpawnProgram string
+	brainSpawnRole    string
+	brainSpawnTopic   string
+	brainSpawnSkip    bool
+
+	brainCmd = &cobra.Command{
+		Use:   "brain",
+		Short: "Inspect and coordinate running agents through the brain socket",
+	}
+
+	brainStatusCmd = &cobra.Command{
+		Use:          "status",
+		Short:        "Show agents, recent messages and workflows for this repository",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, repoPath, err := brainTarget()
+			if err != nil {
+				return err
+			}
+			state, err := client.GetBrain(repoPath, cliInstanceID)
+			if err != nil {
+				return err
+			}
+			workflows, err := client.ListWorkflows(repoPath, "")
+			if err != nil {
+				return err
+			}
+
+			if brainJSON {
+				return printJSON(map[string]any{
+					"repo_path": repoPath,
+					"agents":    state.Agents,
+					"messages":  state.Messages,
+					"workflows": workflows,
+				})
+			}
+			printBrainStatus(state, workflows)
+			return nil
+		},
+	}
+
+	brainSendCmd = &cobra.Command{
+		Use:          "send --to <instance> <message>",
+		Short:        "Send a message to an agent (or all agents when --to is omitted)",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, repoPath, err := brainTarget()
+			if err != nil {
+				return err
+			}
+			content := strings.Join(args, " ")
+
+			to := brainSendTo
+			var id int64
+			if brainSendInject {
+				if brainSendTo == "" {
+					return fmt.Errorf("--inject requires --to")
+				}
+				err = client.InjectMessage(repoPath, brainSendFrom, brain.InjectMessageParams{To: brainSendTo, Content: content})
+			} else {
+				var msg *brain.BrainMessage
+				msg, err = client.PostMessage(repoPath, brainSendFrom, brainSendTo, content, brainSendReplyTo)
+				if msg != nil {
+					to, id = msg.To, msg.ID
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if brainJSON {
+				return printJSON(map[string]any{"ok": true, "id": id, "to": to, "injected": brainSendInject})
+			}
+			if to == "" {
+				fmt.Println("Message broadcast to all agents")
+			} else {
+				fmt.Printf("Message sent to %s\n", to)
+			}
+			return nil
+		},
+	}
+
+	brainEventsCmd = &cobra.Command{
+		Use:   "events",
+		Short: "Print brain events as they happen",
+		Long: "Print brain events as they happen. Without --follow, waits up to --timeout seconds " +
+			"for the first batch of matching events and exits. With --follow, holds a stream open until " +
+			"interrupted, reconnecting and resuming after the last printed sequence if hivemind restarts. " +
+			"Missed events are reported as a \"gap\" event.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, repoPath, err := brainTarget()
+			if err != nil {
+				return err
+			}
+
+			filter := brain.EventFilter{Instances: brainEventsInstances}
+			for _, t := range brainEventsTypes {
+				filter.Types = append(filter.Types, brain.EventType(t))
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if brainEventsFollow {
+				return followEvents(ctx, client, repoPath, filter)
+			}
+
+			subID, err := client.Subscribe(repoPath, filter)
+			if err != nil {
+				return err
+			}
+			defer client.Unsubscribe(subID)
+
+			deadline := time.Now().Add(time.Duration(brainEventsTimeout) * time.Second)
+			for ctx.Err() == nil {
+				remaining := int(time.Until(deadline).Seconds())
+				if remaining <= 0 {
+					return nil
+				}
+				wait := min(25, remaining)
+
+				events, err := pollEvents(ctx, client, subID, wait)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+
+				printed := 0
+				for _, ev := range events {
+					if !eventInRepo(ev, repoPath) {
+						continue
+					}
+					if err := printEvent(ev); err != nil {
+						return err
+					}
+					printed++
+				}
+				if printed > 0 {
+					return nil
+				}
+			}
+			return nil
+		},
+	}
+
+	brainSpawnCmd = &cobra.Command{
+		Use:          "spawn --title <title> --prompt <prompt>",
+		Short:        "Ask the running hivemind to start a new agent instance",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, repoPath, err := brainTarget()
+			if err != nil {
+				return err
+			}
+
+			params := brain.CreateInstanceParams{
+				Title:   brainSpawnTitle,
+				Prompt:  brainSpawnPrompt,
+				Program: brainSpawnProgram,
+				Role:    brainSpawnRole,
+				Topic:   brainSpawnTopic,
+			}
+			if cmd.Flags().Changed("skip-permissions") {
+				params.SkipPermissions = &brainSpawnSkip
+			}
+			result, err := client.CreateInstance(repoPath, "", params)
+			if err != nil {
+				return err
+			}
+
+			if brainJSON {
+				return printJSON(result)
+			}
+			fmt.Printf("Instance %s %s\n", result.Title, result.Status)
+			return nil
+		},
+	}
+)
+
+func init() {
+	brainCmd.PersistentFlags().BoolVar(&brainJSON, "json", false, "Print machine-readable JSON")
+	brainCmd.PersistentFlags().StringVar(&brainRepoFlag, "repo", "", "Repository path (defaults to the current directory)")
+
+	brainSendCmd.Flags().StringVar(&brainSendTo, "to", "", "Recipient instance title (empty broadcasts to all agents)")
+	brainSendCmd.Flags().StringVar(&brainSendFrom, "from", cliInstanceID, "Sender name shown to agents")
+	brainSendCmd.Flags().BoolVar(&brainSendInject, "inject", false, "Type the message into the recipient's terminal instead of its inbox")
+	brainSendCmd.Flags().Int64Var(&brainSendReplyTo, "reply-to", 0, "ID of the message being answered (without --to, replies to its sender)")
+
+	brainEventsCmd.Flags().BoolVarP(&brainEventsFollow, "follow", "f", false, "Keep streaming events until interrupted")
+	brainEventsCmd.Flags().StringSliceVar(&brainEventsTypes, "type", nil, "Only show events of these types (repeatable, e.g. task_completed)")
+	brainEventsCmd.Flags().StringSliceVar(&brainEventsInstances, "instance", nil, "Only show events from these instances (repeatable)")
+	brainEventsCmd.Flags().BoolVar(&brainEventsAllRepos, "all-repos", false, "Include events from other repositories")
+	brainEventsCmd.Flags().IntVar(&brainEventsTimeout, "timeout", 60, "Seconds to wait for events without --follow")
+	brainEventsCmd.Flags().Uint64Var(&brainEventsSince, "since", 0, "With --follow, first replay buffered events after this sequence number")
+	brainEventsCmd.Flags().StringVar(&brainEventsEpoch, "epoch", "", "With --since, the epoch of the event the sequence number comes from")
+
+	brainSpawnCmd.Flags().StringVar(&brainSpawnTitle, "title", "", "Instance title (required)")
+	brainSpawnCmd.Flags().StringVar(&brainSpawnPrompt, "prompt", "", "Initial prompt for the agent")
+	brainSpawnCmd.Flags().StringVar(&brainSpawnProgram, "program", "", "Agent program (defaults to the TUI's program)")
+	brainSpawnCmd.Flags().StringVar(&brainSpawnRole, "role", "", "Agent role (e.g. coder, reviewer)")
+	brainSpawnCmd.Flags().StringVar(&brainSpawnTopic, "topic", "", "Topic to place the instance in")
+	brainSpawnCmd.Flags().BoolVar(&brainSpawnSkip, "skip-permissions", true, "Start the agent with permission prompts skipped")
+	if err := brainSpawnCmd.MarkFlagRequired("title"); err != nil {
+		panic(err)
+	}
+
+	brainCmd.AddCommand(brainStatusCmd)
+	brainCmd.AddCommand(brainSendCmd)
+	brainCmd.AddCommand(brainEventsCmd)
+	brainCmd.AddCommand(brainSpawnCmd)
+}
+
+// brainTarget connects to the brain server and resolves the repository the
+// command applies to.
+func brainTarget() (*brain.Client, string, error) {
+	repoPath := brainRepoFlag
+	if repoPath == "" {
+		repoPath = "."
+	}
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+	client, err := brainClient()
+	if err != nil {
+		return nil, "", err
+	}
+	return client, repoPath, nil
+}
+
+// followEvents streams events until ctx is cancelled. When the connection
+// drops it reconnects, resuming after the last event seen so nothing is
+// printed twice. If the server restarted meanwhile, it starts over with the
+// new server's events.
+func followEvents(ctx context.Context, client *brain.Client, repoPath string, filter brain.EventFilter) error {
+	from := brain.EventCursor{Epoch: brainEventsEpoch, Sequence: brainEventsSince}
+	for {
+		err := client.StreamEvents(ctx, filter, from, func(ev brain.Event) error {
+			from = from.After(ev)
+			if ev.Type != brain.EventGap && !eventInRepo(ev, repoPath) {
+				return nil
+			}
+			return printEvent(ev)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "event stream interrupted (%v); reconnecting...\n", err)
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// eventInRepo reports whether an event should be shown for repoPath.
+func eventInRepo(ev brain.Event, repoPath string) bool {
+	return brainEventsAllRepos || ev.RepoPath == "" || ev.RepoPath == repoPath
+}
+
+// pollEvents long-polls in the background so an interrupt returns immediately.
+func pollEvents(ctx context.Context, client *brain.Client, subID string, timeoutSec int) ([]brain.Event, error) {
+	type result struct {
+		events []brain.Event
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		events, err := client.PollEvents(subID, timeoutSec)
+		ch <- result{events, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.events, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printEvent writes one event per line: NDJSON with --json, otherwise
+// "time type source key=value...".
+func printEvent(ev brain.Event) error {
+	if brainJSON {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	keys := make([]string, 0, len(ev.Data))
+	for k := range ev.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = fmt.Sprintf("%s=%v", k, ev.Data[k])
+	}
+	fmt.Printf("%s  %-24s %-20s %s\n", ev.Timestamp.Local().Format("15:04:05"), ev.Type, ev.Source, strings.Join(fields, " "))
+	return nil
+}
+
+func printBrainStatus(state *brain.BrainState, workflows []*brain.Workflow) {
+	titles := make([]string, 0, len(state.Agents))
+	for title := range state.Agents {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
+	fmt.Printf("Agents (%d)\n", len(titles))
+	for _, title := range titles {
+		a := state.Agents[title]
+		role := a.Role
+		if role == "" {
+			role = "-"
+		}
+		fmt.Printf("  %-20s %-10s %s (%d files)\n", title, role, a.Feature, len(a.Files))
+	}
+
+	if len(state.Leases) > 0 {
+		fmt.Printf("\nFile leases (%d)\n", len(state.Leases))
+		for _, l := range state.Leases {
+			mode := "shared"
+			if l.Exclusive {
+				mode = "exclusive"
+			}
+			fmt.Printf("  %-30s %-20s %-9s until %s\n", l.Pattern, l.Holder, mode, l.ExpiresAt.Local().Format(time.Kitchen))
+		}
+	}
+
+	if len(state.Overlaps) > 0 {
+		fmt.Printf("\nOverlapping changes (%d)\n", len(state.Overlaps))
+		for _, o := range state.Overlaps {
+			fmt.Printf("  %s ↔ %s: %s\n", o.Instances[0], o.Instances[1], strings.Join(o.Files, ", "))
+		}
+	}
+
+	fmt.Printf("\nMessages to %s or all agents (%d)\n", cliInstanceID, len(state.Messages))
+	for _, msg := range state.Messages {
+		to := msg.To
+		if to == "" {
+			to = "all"
+		}
+		thread := ""
+		if msg.ReplyTo != 0 {
+			thread = fmt.Sprintf(" (re #%d)", msg.ReplyTo)
+		}
+		fmt.Printf("  #%-4d %s → %s%s: %s\n", msg.ID, msg.From, to, thread, msg.Content)
+	}
+
+	fmt.Printf("\nWorkflows (%d)\n", len(workflows))
+	for _, wf := range workflows {
+		done := 0
+		for _, t := range wf.Tasks {
+			if t.Status == brain.TaskDone {
+				done++
+			}
+		}
+		fmt.Printf("  %-20s %-10s %d/%d tasks done\n", wf.ID, wf.Status, done, len(wf.Tasks))
+	}
+}
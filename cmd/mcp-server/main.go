@@ -46,6 +46,7 @@ func main() {
 	socketPath := filepath.Join(hivemindDir, "hivemind.sock")
 	var brainClient hivemindmcp.BrainClient
 	socketClient := brain.NewClient(socketPath)
+	socketClient.SetToken(instanceID, os.Getenv("HIVEMIND_INSTANCE_TOKEN"))
 	if err := socketClient.Ping(); err == nil {
 		hivemindmcp.Log("brain: using socket client (%s)", socketPath)
 		brainClient = socketClient
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/agent"
 )
 
 const (
@@ -31,7 +32,8 @@ func GetConfigDir() (string, error) {
 type MemoryConfig struct {
 	// Enabled turns the memory system on/off. Default false until configured.
 	Enabled bool `json:"enabled"`
-	// EmbeddingProvider selects the embedding backend: "openai", "ollama", or "none".
+	// EmbeddingProvider selects the embedding backend: "openai", "ollama",
+	// "local" (in-process, no network), or "none".
 	// When "none" or unset, memory search falls back to keyword-only (FTS).
 	EmbeddingProvider string `json:"embedding_provider,omitempty"`
 	// OpenAIAPIKey is the API key for OpenAI embeddings.
@@ -76,6 +78,21 @@ type Config struct {
 	SkipGitHooks *bool `json:"skip_git_hooks,omitempty"`
 	// Memory configures the IDE-wide memory system.
 	Memory *MemoryConfig `json:"memory,omitempty"`
+	// Agents declares agent programs beyond the built-in ones, or overrides
+	// a built-in agent of the same command.
+	Agents []agent.Spec `json:"agents,omitempty"`
+	// Budget caps the model usage of each instance. Instances over budget are
+	// paused.
+	Budget *BudgetConfig `json:"budget,omitempty"`
+}
+
+// BudgetConfig limits what a single instance may spend. Zero means no limit.
+type BudgetConfig struct {
+	// MaxCostUSD is the estimated cost in US dollars an instance may reach.
+	MaxCostUSD float64 `json:"max_cost_usd,omitempty"`
+	// MaxTokens is the number of tokens an instance may use, counting
+	// input, output and cached tokens.
+	MaxTokens int64 `json:"max_tokens,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
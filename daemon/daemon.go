@@ -14,9 +14,11 @@ import (
 	"github.com/ByteMirror/hivemind/config"
 	"github.com/ByteMirror/hivemind/log"
 	"github.com/ByteMirror/hivemind/session"
+	"github.com/ByteMirror/hivemind/session/approval"
 )
 
-// RunDaemon runs the daemon process which iterates over all sessions and runs AutoYes mode on them.
+// RunDaemon runs the daemon process which iterates over all sessions, pauses those over budget and
+// answers their permission prompts with the auto-approve rules, in AutoYes mode.
 // It's expected that the main process kills the daemon when the main process starts.
 func RunDaemon(cfg *config.Config) error {
 	log.InfoLog.Printf("starting daemon")
@@ -37,8 +39,10 @@ func RunDaemon(cfg *config.Config) error {
 
 	// Start brain IPC server for multi-agent coordination
 	var brainServer *brain.Server
+	var approvals *approval.Engine
 	configDir, err := config.GetConfigDir()
 	if err == nil {
+		approvals = approval.NewEngine(configDir)
 		socketPath := filepath.Join(configDir, "hivemind.sock")
 		brainServer = brain.NewServer(socketPath)
 		if err := brainServer.Start(); err != nil {
@@ -46,6 +50,17 @@ func RunDaemon(cfg *config.Config) error {
 			brainServer = nil
 		} else {
 			log.InfoLog.Printf("brain server started on %s", socketPath)
+			session.SetTokenIssuer(brainServer)
+			infos := make([]brain.InstanceInfo, 0, len(instances))
+			for _, instance := range instances {
+				infos = append(infos, brain.InstanceInfo{
+					Title:       instance.Title,
+					RepoPath:    instance.GetRepoPath(),
+					Role:        instance.Role,
+					ParentTitle: instance.ParentTitle,
+				})
+			}
+			brainServer.SetInstances(infos)
 		}
 	}
 
@@ -64,8 +79,22 @@ func RunDaemon(cfg *config.Config) error {
 			for _, instance := range instances {
 				// We only store started instances, but check anyway.
 				if instance.Started() && !instance.Paused() {
+					instance.UpdateUsage()
+					if paused, err := instance.EnforceBudget(cfg.Budget); err != nil {
+						log.ErrorLog.Printf("failed to pause %s over budget: %v", instance.Title, err)
+					} else if paused {
+						if brainServer != nil && instance.GetRepoPath() != "" {
+							brainServer.Manager().RemoveAgent(instance.GetRepoPath(), instance.Title)
+						}
+						// Save now: the daemon may be killed before it exits cleanly.
+						if err := storage.SaveInstances(instances); err != nil {
+							log.ErrorLog.Printf("failed to save instances: %v", err)
+						}
+						continue
+					}
 					if _, hasPrompt := instance.HasUpdated(); hasPrompt {
-						instance.TapEnter()
+						// Prompts the rules leave to the user wait for the TUI.
+						instance.ApplyApprovalRules(approvals)
 						if err := instance.UpdateDiffStats(); err != nil {
 							if everyN.ShouldLog() {
 								log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
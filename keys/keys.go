@@ -61,6 +61,7 @@ const (
 
 	KeyCommandPalette // Key for opening command palette
 	KeyMemoryBrowser  // Key for opening the memory file browser
+	KeyInbox          // Key for opening the needs-attention queue
 
 	KeyAutomations    // Key for opening the automations manager
 
@@ -113,6 +114,7 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"y":           KeyAutoYes,
 	"ctrl+p":      KeyCommandPalette,
 	"M":           KeyMemoryBrowser,
+	"I":           KeyInbox,
 	"A":           KeyAutomations,
 }
 
@@ -266,6 +268,10 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("M"),
 		key.WithHelp("M", "memory"),
 	),
+	KeyInbox: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "attention"),
+	),
 	KeyAutomations: key.NewBinding(
 		key.WithKeys("A"),
 		key.WithHelp("A", "automations"),
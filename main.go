@@ -12,6 +12,7 @@ import (
 	"github.com/ByteMirror/hivemind/daemon"
 	"github.com/ByteMirror/hivemind/log"
 	"github.com/ByteMirror/hivemind/session"
+	"github.com/ByteMirror/hivemind/session/agent"
 	"github.com/ByteMirror/hivemind/session/git"
 	"github.com/ByteMirror/hivemind/session/tmux"
 
@@ -34,6 +35,7 @@ var (
 			if daemonFlag {
 				cfg := config.LoadConfig()
 				session.NotificationsEnabled = cfg.AreNotificationsEnabled()
+				configureAgents(cfg)
 				if err := daemon.RunDaemon(cfg); err != nil {
 					log.ErrorLog.Printf("failed to start daemon: %v", err)
 					return err
@@ -53,6 +55,7 @@ var (
 
 			cfg := config.LoadConfig()
 			session.NotificationsEnabled = cfg.AreNotificationsEnabled()
+			configureAgents(cfg)
 
 			// Program flag overrides config
 			program := cfg.DefaultProgram
@@ -148,6 +151,13 @@ var (
 	}
 )
 
+// configureAgents installs the agents declared in the config file.
+func configureAgents(cfg *config.Config) {
+	if err := agent.Configure(cfg.Agents); err != nil {
+		log.WarningLog.Printf("invalid agent config: %v", err)
+	}
+}
+
 func init() {
 	rootCmd.Flags().StringVarP(&programFlag, "program", "p", "",
 		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
@@ -165,6 +175,10 @@ func init() {
 	rootCmd.AddCommand(debugCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(workflowCmd)
+	rootCmd.AddCommand(brainCmd)
+	rootCmd.AddCommand(usageCmd)
+	rootCmd.AddCommand(memoryCmd)
 }
 
 func main() {
@@ -13,8 +13,11 @@ import (
 type BrainClient interface {
 	GetBrain(repoPath, instanceID string) (*brain.BrainState, error)
 	UpdateStatus(repoPath, instanceID, feature string, files []string) (*brain.UpdateStatusResult, error)
-	SendMessage(repoPath, from, to, content string) error
+	PostMessage(repoPath, from, to, content string, replyTo int64) (*brain.BrainMessage, error)
+	GetMessages(repoPath, instanceID string, since int64, unreadOnly bool) ([]brain.BrainMessage, error)
 	RemoveAgent(repoPath, instanceID string) error
+	ClaimFiles(repoPath, instanceID string, files []string, opts brain.ClaimOptions) (*brain.ClaimFilesResult, error)
+	ReleaseFiles(repoPath, instanceID string, files []string) (int, error)
 
 	// Tier 3: actions relayed to TUI.
 	CreateInstance(repoPath, instanceID string, params brain.CreateInstanceParams) (*brain.CreateInstanceResult, error)
@@ -22,11 +25,13 @@ type BrainClient interface {
 	PauseInstance(repoPath, instanceID, target string) error
 	ResumeInstance(repoPath, instanceID, target string) error
 	KillInstance(repoPath, instanceID, target string) error
+	AskUser(repoPath, instanceID string, params brain.AskUserParams) (*brain.Question, error)
 
 	// Tier 3: workflow DAG.
 	DefineWorkflow(repoPath, instanceID string, tasks []*brain.WorkflowTask) (*brain.WorkflowResult, error)
-	CompleteTask(repoPath, instanceID, taskID, status, errMsg string) (*brain.WorkflowResult, error)
-	GetWorkflow(repoPath, instanceID string) (*brain.Workflow, error)
+	CompleteTask(repoPath, instanceID, workflowID, taskID, status, errMsg string, outputs map[string]any) (*brain.WorkflowResult, error)
+	GetWorkflow(repoPath, instanceID, workflowID string) (*brain.Workflow, error)
+	ListWorkflows(repoPath, instanceID string) ([]*brain.Workflow, error)
 
 	// Event subscription.
 	Subscribe(repoPath string, filter brain.EventFilter) (string, error)
@@ -107,24 +112,33 @@ func (c *fileBrainClient) UpdateStatus(repoPath, instanceID, feature string, fil
 	return &brain.UpdateStatusResult{Conflicts: warnings}, nil
 }
 
-func (c *fileBrainClient) SendMessage(repoPath, from, to, content string) error {
+// PostMessage appends a message to the brain file. The file format has no
+// message IDs, so replies require the socket.
+func (c *fileBrainClient) PostMessage(repoPath, from, to, content string, replyTo int64) (*brain.BrainMessage, error) {
+	if replyTo != 0 {
+		return nil, errRequiresSocket
+	}
 	bf, err := readBrain(c.hivemindDir, repoPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	bf.Messages = append(bf.Messages, brainMessage{
+	msg := brainMessage{
 		From:      from,
 		To:        to,
 		Content:   content,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
-	})
+	}
+	bf.Messages = append(bf.Messages, msg)
 
 	if len(bf.Messages) > maxMessages {
 		bf.Messages = bf.Messages[len(bf.Messages)-maxMessages:]
 	}
 
-	return writeBrain(c.hivemindDir, repoPath, bf)
+	if err := writeBrain(c.hivemindDir, repoPath, bf); err != nil {
+		return nil, err
+	}
+	return &brain.BrainMessage{From: msg.From, To: msg.To, Content: msg.Content, Timestamp: msg.Timestamp}, nil
 }
 
 func (c *fileBrainClient) RemoveAgent(repoPath, instanceID string) error {
@@ -139,6 +153,18 @@ func (c *fileBrainClient) RemoveAgent(repoPath, instanceID string) error {
 
 var errRequiresSocket = fmt.Errorf("this operation requires the Hivemind TUI to be running (socket connection)")
 
+func (c *fileBrainClient) GetMessages(repoPath, instanceID string, since int64, unreadOnly bool) ([]brain.BrainMessage, error) {
+	return nil, errRequiresSocket
+}
+
+func (c *fileBrainClient) ClaimFiles(repoPath, instanceID string, files []string, opts brain.ClaimOptions) (*brain.ClaimFilesResult, error) {
+	return nil, errRequiresSocket
+}
+
+func (c *fileBrainClient) ReleaseFiles(repoPath, instanceID string, files []string) (int, error) {
+	return 0, errRequiresSocket
+}
+
 func (c *fileBrainClient) CreateInstance(repoPath, instanceID string, params brain.CreateInstanceParams) (*brain.CreateInstanceResult, error) {
 	return nil, errRequiresSocket
 }
@@ -159,15 +185,23 @@ func (c *fileBrainClient) KillInstance(repoPath, instanceID, target string) erro
 	return errRequiresSocket
 }
 
+func (c *fileBrainClient) AskUser(repoPath, instanceID string, params brain.AskUserParams) (*brain.Question, error) {
+	return nil, errRequiresSocket
+}
+
 func (c *fileBrainClient) DefineWorkflow(repoPath, instanceID string, tasks []*brain.WorkflowTask) (*brain.WorkflowResult, error) {
 	return nil, errRequiresSocket
 }
 
-func (c *fileBrainClient) CompleteTask(repoPath, instanceID, taskID, status, errMsg string) (*brain.WorkflowResult, error) {
+func (c *fileBrainClient) CompleteTask(repoPath, instanceID, workflowID, taskID, status, errMsg string, outputs map[string]any) (*brain.WorkflowResult, error) {
+	return nil, errRequiresSocket
+}
+
+func (c *fileBrainClient) GetWorkflow(repoPath, instanceID, workflowID string) (*brain.Workflow, error) {
 	return nil, errRequiresSocket
 }
 
-func (c *fileBrainClient) GetWorkflow(repoPath, instanceID string) (*brain.Workflow, error) {
+func (c *fileBrainClient) ListWorkflows(repoPath, instanceID string) ([]*brain.Workflow, error) {
 	return nil, errRequiresSocket
 }
 
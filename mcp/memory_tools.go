@@ -163,6 +163,15 @@ func parseOptionalIntArg(req gomcp.CallToolRequest, key string, fallback int) in
 	return fallback
 }
 
+func parseOptionalFloatArg(req gomcp.CallToolRequest, key string, fallback float64) float64 {
+	if args := req.GetArguments(); args != nil {
+		if v, ok := args[key].(float64); ok {
+			return v
+		}
+	}
+	return fallback
+}
+
 func parseOptionalBoolArg(req gomcp.CallToolRequest, key string, fallback bool) bool {
 	if args := req.GetArguments(); args != nil {
 		if v, ok := args[key].(bool); ok {
@@ -283,8 +292,9 @@ func handleMemorySearch(globalMgr *memory.Manager, repoMgr *memory.Manager, lega
 		}
 
 		maxResults := parseOptionalIntArg(req, "max_results", 10)
+		opts := searchOptsFromRequest(req, maxResults)
 
-		globalResults, err := globalMgr.Search(query, memory.SearchOpts{MaxResults: maxResults})
+		globalResults, err := globalMgr.Search(query, opts)
 		if err != nil {
 			Log("memory_search global error: %v", err)
 			return toolErrWithHint("search failed", err, `Retry with a shorter keyword query, e.g. memory_search(query="conventions").`), nil
@@ -307,7 +317,7 @@ func handleMemorySearch(globalMgr *memory.Manager, repoMgr *memory.Manager, lega
 		addResults("global", globalResults)
 
 		if repoMgr != nil {
-			repoResults, repoErr := repoMgr.Search(query, memory.SearchOpts{MaxResults: maxResults})
+			repoResults, repoErr := repoMgr.Search(query, opts)
 			if repoErr != nil {
 				Log("memory_search repo error: %v", repoErr)
 			} else {
@@ -316,7 +326,7 @@ func handleMemorySearch(globalMgr *memory.Manager, repoMgr *memory.Manager, lega
 		}
 
 		if legacyRepoMgr != nil {
-			legacyResults, legacyErr := legacyRepoMgr.Search(query, memory.SearchOpts{MaxResults: maxResults})
+			legacyResults, legacyErr := legacyRepoMgr.Search(query, opts)
 			if legacyErr != nil {
 				Log("memory_search legacy repo error: %v", legacyErr)
 			} else {
@@ -337,6 +347,30 @@ func handleMemorySearch(globalMgr *memory.Manager, repoMgr *memory.Manager, lega
 	}
 }
 
+// searchOptsFromRequest reads the scoring, query mode and filter arguments
+// of memory_search.
+func searchOptsFromRequest(req gomcp.CallToolRequest, maxResults int) memory.SearchOpts {
+	opts := memory.SearchOpts{
+		MaxResults:        maxResults,
+		Mode:              memory.QueryMode(req.GetString("mode", "")),
+		KeywordWeight:     float32(parseOptionalFloatArg(req, "keyword_weight", 0)),
+		VectorWeight:      float32(parseOptionalFloatArg(req, "vector_weight", 0)),
+		DecayHalfLifeDays: parseOptionalFloatArg(req, "decay_half_life_days", 0),
+		DecayAllFiles:     parseOptionalBoolArg(req, "decay_all_files", false),
+		PathPrefix:        req.GetString("path_prefix", ""),
+		Tags:              req.GetStringSlice("tags", nil),
+	}
+	if args := req.GetArguments(); args != nil {
+		if fields, ok := args["fields"].(map[string]any); ok {
+			opts.Fields = make(map[string]string, len(fields))
+			for k, v := range fields {
+				opts.Fields[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return opts
+}
+
 // handleMemoryGet reads specific lines from a memory file.
 func handleMemoryGet(globalMgr *memory.Manager, repoMgr *memory.Manager, legacyRepoMgr *memory.Manager) mcpserver.ToolHandlerFunc {
 	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
@@ -318,3 +318,30 @@ func TestHandleMemoryDiff_RespectsScopeForBarePath(t *testing.T) {
 	assert.Contains(t, resultText(t, result), "diff --git")
 	assert.Contains(t, resultText(t, result), "+two")
 }
+
+func TestHandleMemorySearch_AppliesOptions(t *testing.T) {
+	mgr, err := memory.NewManagerWithOptions(t.TempDir(), nil, memory.ManagerOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { mgr.Close() })
+	require.NoError(t, mgr.WriteFile("infra.md", "---\ntags: [infra]\n---\nThe deploy pipeline runs nightly.", ""))
+	require.NoError(t, mgr.WriteFile("web.md", "The web deploy pipeline runs on merge.", ""))
+
+	handler := handleMemorySearch(mgr, nil, nil)
+	req := gomcp.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{
+		"query":          "deploy pipeline",
+		"mode":           "phrase",
+		"tags":           []interface{}{"infra"},
+		"keyword_weight": 1.0,
+	}
+	result, err := handler(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+
+	var results []memory.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(resultText(t, result)), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, "infra.md", results[0].Path)
+	assert.Equal(t, float32(1), results[0].Breakdown.KeywordWeight)
+	assert.Equal(t, results[0].Breakdown.Keyword, results[0].Score)
+}
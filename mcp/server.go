@@ -37,6 +37,8 @@ You are part of a team. Other agents may be running concurrently in the same rep
 - send_message(to, message): Send a targeted message to a specific agent by title, or broadcast
   to all agents by leaving "to" empty. Messages appear in the recipient's next get_brain call.
   Keep messages concise and actionable: what changed, what they should know.
+- get_messages(): Read only the messages you have not seen yet. Directed messages are kept until
+  you read them. Answer one with send_message(reply_to=<id>) to keep the thread together.
 - inject_message(to, message): For urgent coordination only. This types directly into another
   agent's terminal input, bypassing the polling-based message system. Use sparingly — the target
   agent may be mid-thought.
@@ -57,18 +59,41 @@ Common patterns:
 - kill_instance(target): Terminate an agent permanently. The tmux session is destroyed and
   the worktree is cleaned up.
 
+### Asking the User
+When you need a decision only the user can make (product choices, credentials, destructive
+operations), call ask_user(question, choices) instead of guessing. It waits for the answer.
+If it returns without one, call it again with the question_id to keep waiting.
+
 ## Workflow Orchestration
 
 For complex multi-step tasks, define a workflow DAG instead of spawning agents manually.
 
 1. Call define_workflow with a JSON array of tasks. Each task has an id, title, prompt, role,
    and a depends_on list of task IDs. Tasks whose dependencies are already satisfied will be
-   triggered immediately (each spawning a new agent instance).
-2. When a sub-agent finishes its work, it calls complete_task(task_id, status). If status is
-   "done", any tasks that depended on it (and whose other dependencies are also complete)
-   will be triggered automatically.
+   triggered immediately (each spawning a new agent instance). Duplicate IDs, unknown
+   dependencies, and cycles are rejected with an error naming the offending tasks. The result
+   includes "levels": task IDs grouped by dependency depth. Check they match your plan.
+2. When a sub-agent finishes its work, it calls complete_task(task_id, status, outputs). If
+   status is "done", any tasks that depended on it (and whose other dependencies are also
+   complete) will be triggered automatically.
 3. Use get_workflow to inspect the current DAG state: which tasks are pending, running, or done.
 
+Tasks pass results downstream through prompt templates. A prompt can reference any upstream
+task (a direct or transitive dependency) with {{tasks.<id>.outputs.<key>}} for values reported
+in complete_task outputs, or {{tasks.<id>.branch}}, {{tasks.<id>.instance}}, {{tasks.<id>.status}}
+for details of the task's agent. Templates are filled in when the task is spawned. For example,
+a review task can use "Review branch {{tasks.impl.branch}}: {{tasks.impl.outputs.summary}}".
+
+A repo can run several workflows at once. define_workflow returns a workflow_id; pass it to
+get_workflow and complete_task to address that workflow (complete_task can omit it when the task
+ID is unique across active workflows). list_workflows shows every workflow in the repo.
+
+Tasks can declare how failures are handled. max_retries re-spawns a failed task (under a new
+instance title such as "task-1-retry1"). timeout fails a task whose agent sits idle that long
+without calling complete_task. A task whose agent is killed is also marked failed. Once retries
+are exhausted, on_failure decides the rest: "fail_workflow" (default) cancels every unfinished
+task, "skip_dependents" skips only the downstream tasks, and "continue" lets dependents run.
+
 ### Waiting for Sub-Agents
 Use wait_for_events instead of polling get_brain in a loop. It long-polls for real-time events
 with server-side buffering so no events are missed between polls.
@@ -141,7 +166,10 @@ user's environment, preferences, and project decisions.
 | get_brain | Read shared state: agent statuses, file ownership, messages for you |
 | list_instances | See all agents, their status, branch, and activity |
 | update_status | Declare your feature, files, and role; detect conflicts |
-| send_message | Message another agent or broadcast to all |
+| claim_files | Lease files or globs before editing; exclusive claims fail on overlap |
+| release_files | Release your file leases when done |
+| send_message | Message another agent or broadcast to all; reply_to threads a reply |
+| get_messages | Read your unread messages and mark them read |
 | get_my_session_summary | Your session: changed files, commits, diff stats |
 | get_my_diff | Full git diff of your changes since base commit |
 
@@ -153,13 +181,15 @@ user's environment, preferences, and project decisions.
 | pause_instance | Suspend an agent, preserving its tmux session |
 | resume_instance | Resume a paused agent |
 | kill_instance | Terminate an agent and clean up its worktree |
+| ask_user | Ask the user a question and wait for the answer |
 
 ### Workflows (Tier 3)
 | Tool | Purpose |
 |------|---------|
 | define_workflow | Create a task DAG with dependencies |
 | complete_task | Mark a task done/failed; triggers dependents |
-| get_workflow | Inspect the current DAG state |
+| get_workflow | Inspect a workflow's DAG state |
+| list_workflows | List every workflow in the repo |
 | wait_for_events | Long-poll for real-time events (replaces polling) |
 | unsubscribe_events | Remove an event subscription |
 
@@ -274,6 +304,32 @@ func (h *HivemindMCPServer) registerMemoryTools() {
 		gomcp.WithNumber("max_results",
 			gomcp.Description("Maximum results to return (default 10). Example: max_results=5."),
 		),
+		gomcp.WithString("mode",
+			gomcp.Description("How keywords match: \"any\" word (default), \"all\" words, the exact \"phrase\", or words starting with a query word (\"prefix\")."),
+			gomcp.Enum("any", "all", "phrase", "prefix"),
+		),
+		gomcp.WithNumber("keyword_weight",
+			gomcp.Description("Weight of the keyword (BM25) score in the combined score (default 0.4)."),
+		),
+		gomcp.WithNumber("vector_weight",
+			gomcp.Description("Weight of the semantic (vector) score in the combined score (default 0.6)."),
+		),
+		gomcp.WithNumber("decay_half_life_days",
+			gomcp.Description("Days after which the score of an older note halves (default about 69). Negative disables decay."),
+		),
+		gomcp.WithBoolean("decay_all_files",
+			gomcp.Description("Decay every file by age, not only dated journal files (YYYY-MM-DD.md)."),
+		),
+		gomcp.WithString("path_prefix",
+			gomcp.Description("Only search files whose path starts with this. Example: path_prefix=\"repos/\"."),
+		),
+		gomcp.WithArray("tags",
+			gomcp.Description("Only search files tagged with all of these frontmatter tags."),
+			gomcp.WithStringItems(),
+		),
+		gomcp.WithObject("fields",
+			gomcp.Description("Only search files whose frontmatter fields have these values, e.g. {\"source\": \"standup\", \"metadata.team\": \"infra\"}."),
+		),
 	)
 	h.server.AddTool(memSearch, handleMemorySearch(mgr, repoMgr, legacyRepoMgr))
 
@@ -625,6 +681,33 @@ func (h *HivemindMCPServer) registerTier2Tools() {
 	)
 	h.server.AddTool(updateStatus, handleUpdateStatus(h.brainClient, h.repoPath, h.instanceID))
 
+	claimFiles := gomcp.NewTool("claim_files",
+		gomcp.WithDescription(
+			"Claim files before editing them so other agents know you hold them. "+
+				"Claims expire unless renewed by claiming again. Reports overlaps with other agents' claims; "+
+				"an exclusive claim fails instead if anyone else holds an overlapping path.",
+		),
+		gomcp.WithString("files",
+			gomcp.Required(),
+			gomcp.Description("Comma-separated repo-relative paths, directories or globs (e.g. 'api/server.go, web/**/*.ts')."),
+		),
+		gomcp.WithBoolean("exclusive",
+			gomcp.Description("Fail if another agent holds an overlapping path, and block others from claiming it. Defaults to false."),
+		),
+		gomcp.WithNumber("ttl_minutes",
+			gomcp.Description("How long the claim lasts. Defaults to 30 minutes."),
+		),
+	)
+	h.server.AddTool(claimFiles, handleClaimFiles(h.brainClient, h.repoPath, h.instanceID))
+
+	releaseFiles := gomcp.NewTool("release_files",
+		gomcp.WithDescription("Release file claims when you are done editing. Releases all of your claims if no files are given."),
+		gomcp.WithString("files",
+			gomcp.Description("Comma-separated patterns to release, exactly as claimed."),
+		),
+	)
+	h.server.AddTool(releaseFiles, handleReleaseFiles(h.brainClient, h.repoPath, h.instanceID))
+
 	sendMessage := gomcp.NewTool("send_message",
 		gomcp.WithDescription(
 			"Send a message to another agent or broadcast to all. "+
@@ -638,8 +721,26 @@ func (h *HivemindMCPServer) registerTier2Tools() {
 			gomcp.Required(),
 			gomcp.Description("The message content. Be concise and actionable."),
 		),
+		gomcp.WithNumber("reply_to",
+			gomcp.Description("ID of the message you are answering. Without 'to', the reply goes to its sender."),
+		),
 	)
 	h.server.AddTool(sendMessage, handleSendMessage(h.brainClient, h.repoPath, h.instanceID))
+
+	getMessages := gomcp.NewTool("get_messages",
+		gomcp.WithDescription(
+			"Read messages sent to you or broadcast, oldest first. Returned messages are marked read, "+
+				"so by default you only see what is new. Each message has an id you can pass to "+
+				"send_message's reply_to or use as 'since' next time.",
+		),
+		gomcp.WithNumber("since",
+			gomcp.Description("Only return messages with an id greater than this."),
+		),
+		gomcp.WithBoolean("unread_only",
+			gomcp.Description("Skip messages you have already read (default true)."),
+		),
+	)
+	h.server.AddTool(getMessages, handleGetMessages(h.brainClient, h.repoPath, h.instanceID))
 }
 
 // registerTier3Tools registers write/action tools for agent lifecycle, coordination, and workflows.
@@ -717,16 +818,44 @@ func (h *HivemindMCPServer) registerTier3Tools() {
 	)
 	h.server.AddTool(killInstance, handleKillInstance(h.brainClient, h.repoPath, h.instanceID))
 
+	askUser := gomcp.NewTool("ask_user",
+		gomcp.WithDescription(
+			"Ask the user a question and wait for their answer. The question appears in the Hivemind "+
+				"inbox. Use this for decisions you should not make alone instead of guessing. "+
+				"If no answer arrives in time, call again with question_id to keep waiting.",
+		),
+		gomcp.WithString("question",
+			gomcp.Description("The question, with enough context to answer it without looking at your session."),
+		),
+		gomcp.WithArray("choices",
+			gomcp.Description("Optional answers to choose from. The user may still answer freely."),
+			gomcp.WithStringItems(),
+		),
+		gomcp.WithString("question_id",
+			gomcp.Description("ID of a question you asked earlier, to keep waiting for its answer instead of asking again."),
+		),
+		gomcp.WithNumber("wait_minutes",
+			gomcp.Description("How long to wait for the answer (default 5, max 30)."),
+		),
+	)
+	h.server.AddTool(askUser, handleAskUser(h.brainClient, h.repoPath, h.instanceID))
+
 	defineWorkflow := gomcp.NewTool("define_workflow",
 		gomcp.WithDescription(
 			"Define a workflow as a directed acyclic graph (DAG) of tasks with dependencies. "+
 				"Tasks whose dependencies are already satisfied are triggered immediately, each spawning "+
 				"a new agent instance. When a task completes via complete_task, downstream dependents "+
-				"are automatically triggered. Use this for multi-step tasks that have a clear dependency structure.",
+				"are automatically triggered. Use this for multi-step tasks that have a clear dependency structure. "+
+				"Task lists with duplicate IDs, unknown dependencies, or cycles are rejected. The result lists "+
+				"the topological levels of the DAG so you can confirm the execution order.",
 		),
 		gomcp.WithString("tasks_json",
 			gomcp.Required(),
-			gomcp.Description("JSON array of task objects: [{\"id\": \"task-1\", \"title\": \"Implement feature\", \"depends_on\": [], \"prompt\": \"...\", \"role\": \"coder\"}, ...]"),
+			gomcp.Description("JSON array of task objects: [{\"id\": \"task-1\", \"title\": \"Implement feature\", \"depends_on\": [], \"prompt\": \"...\", \"role\": \"coder\"}, ...]. "+
+				"Optional per-task fields: \"program\" (agent program for the task, e.g. \"aider\"; defaults to the TUI's program), \"max_retries\" (re-spawn a failed task up to N times), "+
+				"\"timeout\" (e.g. \"15m\": fail the task if its agent sits idle this long without completing it), "+
+				"\"on_failure\" (\"fail_workflow\" (default) cancels the rest of the workflow, \"skip_dependents\" skips downstream tasks, "+
+				"\"continue\" lets dependents run anyway)."),
 		),
 	)
 	h.server.AddTool(defineWorkflow, handleDefineWorkflow(h.brainClient, h.repoPath, h.instanceID))
@@ -740,21 +869,37 @@ func (h *HivemindMCPServer) registerTier3Tools() {
 			gomcp.Required(),
 			gomcp.Description("The ID of the task to complete."),
 		),
+		gomcp.WithString("workflow_id",
+			gomcp.Description("Workflow the task belongs to. Optional when the task ID is unique across the repo's active workflows."),
+		),
 		gomcp.WithString("status",
 			gomcp.Description("Task status: 'done' (default) or 'failed'."),
 		),
 		gomcp.WithString("error",
 			gomcp.Description("Error message if the task failed."),
 		),
+		gomcp.WithObject("outputs",
+			gomcp.Description("Structured results for downstream tasks, e.g. {\"summary\": \"...\", \"files\": [\"api.go\"]}. "+
+				"Dependent tasks can reference them in their prompts as {{tasks.<task_id>.outputs.<key>}}."),
+		),
 	)
 	h.server.AddTool(completeTask, handleCompleteTask(h.brainClient, h.repoPath, h.instanceID))
 
 	getWorkflow := gomcp.NewTool("get_workflow",
-		gomcp.WithDescription("Get the current workflow DAG: all tasks, their statuses, and dependencies."),
+		gomcp.WithDescription("Get a workflow DAG: all tasks, their statuses, and dependencies."),
+		gomcp.WithString("workflow_id",
+			gomcp.Description("Workflow to inspect. Defaults to the most recently defined workflow."),
+		),
 		gomcp.WithReadOnlyHintAnnotation(true),
 	)
 	h.server.AddTool(getWorkflow, handleGetWorkflow(h.brainClient, h.repoPath, h.instanceID))
 
+	listWorkflows := gomcp.NewTool("list_workflows",
+		gomcp.WithDescription("List every workflow DAG in this repo with its status and tasks, oldest first."),
+		gomcp.WithReadOnlyHintAnnotation(true),
+	)
+	h.server.AddTool(listWorkflows, handleListWorkflows(h.brainClient, h.repoPath, h.instanceID))
+
 	waitForEvents := gomcp.NewTool("wait_for_events",
 		gomcp.WithDescription(
 			"Long-poll for real-time events (status changes, instance lifecycle, messages, workflow triggers). "+
@@ -768,7 +913,9 @@ func (h *HivemindMCPServer) registerTier3Tools() {
 		),
 		gomcp.WithString("types",
 			gomcp.Description("Comma-separated event types to filter: status_changed, message_received, agent_removed, "+
-				"workflow_defined, task_completed, task_triggered, instance_status_changed, instance_created, instance_killed. "+
+				"workflow_defined, task_completed, task_triggered, task_retried, workflow_completed, "+
+				"message_read, instance_status_changed, instance_created, instance_killed, file_claim_conflict, file_overlap, "+
+				"question_asked, question_answered, permission_requested. "+
 				"Leave empty for all types."),
 		),
 		gomcp.WithString("instances",
@@ -3,9 +3,11 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/ByteMirror/hivemind/brain"
 
@@ -93,14 +95,7 @@ func handleUpdateStatus(client BrainClient, repoPath, instanceID string) mcpserv
 			return gomcp.NewToolResultError("missing required parameter: feature"), nil
 		}
 
-		var files []string
-		if filesArg != "" {
-			for _, f := range strings.Split(filesArg, ",") {
-				if trimmed := strings.TrimSpace(f); trimmed != "" {
-					files = append(files, trimmed)
-				}
-			}
-		}
+		files := splitCommaList(filesArg)
 
 		// Use role-aware update if a role is provided.
 		result, err := client.UpdateStatus(repoPath, instanceID, feature, files)
@@ -153,20 +148,113 @@ func handleSendMessage(client BrainClient, repoPath, instanceID string) mcpserve
 		if message == "" {
 			return gomcp.NewToolResultError("missing required parameter: message"), nil
 		}
+		replyTo := int64(req.GetFloat("reply_to", 0))
 
-		if err := client.SendMessage(repoPath, instanceID, to, message); err != nil {
+		msg, err := client.PostMessage(repoPath, instanceID, to, message, replyTo)
+		if err != nil {
 			return gomcp.NewToolResultError("failed to send message: " + err.Error()), nil
 		}
 
-		target := to
+		target := msg.To
 		if target == "" {
 			target = "all agents"
 		}
 		Log("send_message: %s → %s", instanceID, target)
-		return gomcp.NewToolResultText(fmt.Sprintf("Message sent to %s.", target)), nil
+		if msg.ID == 0 {
+			return gomcp.NewToolResultText(fmt.Sprintf("Message sent to %s.", target)), nil
+		}
+		return gomcp.NewToolResultText(fmt.Sprintf("Message %d sent to %s.", msg.ID, target)), nil
+	}
+}
+
+// handleGetMessages returns messages for the calling agent and marks them read.
+func handleGetMessages(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
+		Log("tool call: get_messages (instanceID=%s)", instanceID)
+		since := int64(req.GetFloat("since", 0))
+		unreadOnly := req.GetBool("unread_only", true)
+
+		msgs, err := client.GetMessages(repoPath, instanceID, since, unreadOnly)
+		if err != nil {
+			return gomcp.NewToolResultError("failed to get messages: " + err.Error()), nil
+		}
+		if len(msgs) == 0 {
+			return gomcp.NewToolResultText("No new messages."), nil
+		}
+
+		data, err := json.MarshalIndent(msgs, "", "  ")
+		if err != nil {
+			return gomcp.NewToolResultError("failed to marshal messages: " + err.Error()), nil
+		}
+		Log("get_messages: returning %d messages", len(msgs))
+		return gomcp.NewToolResultText(string(data)), nil
 	}
 }
 
+// handleClaimFiles takes advisory leases on files so parallel agents know who is editing what.
+func handleClaimFiles(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
+		Log("tool call: claim_files (instanceID=%s)", instanceID)
+		files := splitCommaList(req.GetString("files", ""))
+		if len(files) == 0 {
+			return gomcp.NewToolResultError("missing required parameter: files"), nil
+		}
+		opts := brain.ClaimOptions{Exclusive: req.GetBool("exclusive", false)}
+		if minutes := req.GetFloat("ttl_minutes", 0); minutes > 0 {
+			opts.TTL = time.Duration(minutes * float64(time.Minute))
+		}
+
+		result, err := client.ClaimFiles(repoPath, instanceID, files, opts)
+		if err != nil {
+			return gomcp.NewToolResultError("failed to claim files: " + err.Error()), nil
+		}
+
+		var conflicts []string
+		for _, c := range result.Conflicts {
+			kind := "claimed"
+			if c.Exclusive {
+				kind = "exclusively claimed"
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s overlaps %s, %s by %s", c.Pattern, c.HeldPattern, kind, c.Holder))
+		}
+		if !result.Granted {
+			Log("claim_files: %s refused: %v", instanceID, conflicts)
+			return gomcp.NewToolResultError("Claim refused, no files were claimed:\n" + strings.Join(conflicts, "\n") +
+				"\nCoordinate with the holders (send_message) or wait for them to release the files."), nil
+		}
+
+		text := fmt.Sprintf("Claimed %d pattern(s) until %s.", len(result.Leases), result.Leases[0].ExpiresAt.Local().Format(time.Kitchen))
+		if len(conflicts) > 0 {
+			text += " Shared with other agents:\n" + strings.Join(conflicts, "\n")
+		}
+		Log("claim_files: %s claimed %v", instanceID, files)
+		return gomcp.NewToolResultText(text), nil
+	}
+}
+
+// handleReleaseFiles drops the calling agent's file leases.
+func handleReleaseFiles(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
+		Log("tool call: release_files (instanceID=%s)", instanceID)
+		released, err := client.ReleaseFiles(repoPath, instanceID, splitCommaList(req.GetString("files", "")))
+		if err != nil {
+			return gomcp.NewToolResultError("failed to release files: " + err.Error()), nil
+		}
+		return gomcp.NewToolResultText(fmt.Sprintf("Released %d lease(s).", released)), nil
+	}
+}
+
+// splitCommaList splits a comma-separated tool argument, dropping empty entries.
+func splitCommaList(arg string) []string {
+	var items []string
+	for _, f := range strings.Split(arg, ",") {
+		if trimmed := strings.TrimSpace(f); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
 // findMyInstance looks up the calling agent's instance in state.json by instanceID.
 func findMyInstance(reader *StateReader, instanceID string) (*InstanceInfo, error) {
 	if instanceID == "" {
@@ -397,6 +485,37 @@ func handleKillInstance(client BrainClient, repoPath, instanceID string) mcpserv
 	}
 }
 
+// handleAskUser asks the human a question and waits for the answer.
+func handleAskUser(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
+		Log("tool call: ask_user (instanceID=%s)", instanceID)
+		params := brain.AskUserParams{
+			Question:   req.GetString("question", ""),
+			Choices:    req.GetStringSlice("choices", nil),
+			QuestionID: req.GetString("question_id", ""),
+		}
+		if params.Question == "" && params.QuestionID == "" {
+			return gomcp.NewToolResultError("missing required parameter: question"), nil
+		}
+		if minutes := req.GetFloat("wait_minutes", 0); minutes > 0 {
+			params.Wait = time.Duration(minutes * float64(time.Minute))
+		}
+
+		q, err := client.AskUser(repoPath, instanceID, params)
+		if err != nil {
+			return gomcp.NewToolResultError("failed to ask user: " + err.Error()), nil
+		}
+		if !q.Answered() {
+			Log("ask_user: %s still waiting on %s", instanceID, q.ID)
+			return gomcp.NewToolResultText(fmt.Sprintf(
+				"No answer yet (question_id %q). Do not guess: call ask_user again with question_id=%q to keep waiting.",
+				q.ID, q.ID)), nil
+		}
+		Log("ask_user: %s got an answer to %s", instanceID, q.ID)
+		return gomcp.NewToolResultText("The user answered: " + q.Answer), nil
+	}
+}
+
 // handleDefineWorkflow creates a workflow DAG with task dependencies.
 func handleDefineWorkflow(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
 	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
@@ -417,6 +536,12 @@ func handleDefineWorkflow(client BrainClient, repoPath, instanceID string) mcpse
 
 		result, err := client.DefineWorkflow(repoPath, instanceID, tasks)
 		if err != nil {
+			// Spell out which tasks to fix.
+			var verr *brain.WorkflowValidationError
+			if errors.As(err, &verr) {
+				details, _ := json.MarshalIndent(verr, "", "  ")
+				return gomcp.NewToolResultError("invalid workflow: " + verr.Error() + "\n" + string(details)), nil
+			}
 			return gomcp.NewToolResultError("failed to define workflow: " + err.Error()), nil
 		}
 
@@ -430,6 +555,7 @@ func handleDefineWorkflow(client BrainClient, repoPath, instanceID string) mcpse
 func handleCompleteTask(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
 	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
 		Log("tool call: complete_task (instanceID=%s)", instanceID)
+		workflowID := req.GetString("workflow_id", "")
 		taskID := req.GetString("task_id", "")
 		status := req.GetString("status", "done")
 		errMsg := req.GetString("error", "")
@@ -438,7 +564,22 @@ func handleCompleteTask(client BrainClient, repoPath, instanceID string) mcpserv
 			return gomcp.NewToolResultError("missing required parameter: task_id"), nil
 		}
 
-		result, err := client.CompleteTask(repoPath, instanceID, taskID, status, errMsg)
+		// outputs may arrive as an object or as a JSON-encoded string.
+		var outputs map[string]any
+		if args := req.GetArguments(); args != nil {
+			switch v := args["outputs"].(type) {
+			case map[string]any:
+				outputs = v
+			case string:
+				if v != "" {
+					if err := json.Unmarshal([]byte(v), &outputs); err != nil {
+						return gomcp.NewToolResultError("invalid outputs: must be a JSON object: " + err.Error()), nil
+					}
+				}
+			}
+		}
+
+		result, err := client.CompleteTask(repoPath, instanceID, workflowID, taskID, status, errMsg, outputs)
 		if err != nil {
 			return gomcp.NewToolResultError("failed to complete task: " + err.Error()), nil
 		}
@@ -449,12 +590,13 @@ func handleCompleteTask(client BrainClient, repoPath, instanceID string) mcpserv
 	}
 }
 
-// handleGetWorkflow returns the current workflow DAG.
+// handleGetWorkflow returns a workflow DAG by ID, or the most recent one.
 func handleGetWorkflow(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
 	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
 		Log("tool call: get_workflow (instanceID=%s)", instanceID)
+		workflowID := req.GetString("workflow_id", "")
 
-		workflow, err := client.GetWorkflow(repoPath, instanceID)
+		workflow, err := client.GetWorkflow(repoPath, instanceID, workflowID)
 		if err != nil {
 			return gomcp.NewToolResultError("failed to get workflow: " + err.Error()), nil
 		}
@@ -465,6 +607,25 @@ func handleGetWorkflow(client BrainClient, repoPath, instanceID string) mcpserve
 	}
 }
 
+// handleListWorkflows returns every workflow DAG in the repo.
+func handleListWorkflows(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
+		Log("tool call: list_workflows (instanceID=%s)", instanceID)
+
+		workflows, err := client.ListWorkflows(repoPath, instanceID)
+		if err != nil {
+			return gomcp.NewToolResultError("failed to list workflows: " + err.Error()), nil
+		}
+		if workflows == nil {
+			workflows = []*brain.Workflow{}
+		}
+
+		data, _ := json.MarshalIndent(map[string]any{"workflows": workflows}, "", "  ")
+		Log("list_workflows: returning %d workflows", len(workflows))
+		return gomcp.NewToolResultText(string(data)), nil
+	}
+}
+
 // handleWaitForEvents long-polls for events, optionally creating a subscription first.
 func handleWaitForEvents(client BrainClient, repoPath, instanceID string) mcpserver.ToolHandlerFunc {
 	return func(ctx context.Context, req gomcp.CallToolRequest) (*gomcp.CallToolResult, error) {
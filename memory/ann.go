@@ -0,0 +1,576 @@
+package memory
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HNSW parameters. annM is the number of neighbours a node keeps per layer
+// (twice that on layer 0); annEfConstruction is the candidate list size
+// while inserting.
+const (
+	annM              = 16
+	annEfConstruction = 200
+	// annFormatVersion is bumped when the on-disk layout changes; older
+	// files are discarded and rebuilt.
+	annFormatVersion = 1
+)
+
+// annIndex is an HNSW (hierarchical navigable small world) graph over the
+// chunk embeddings, for approximate nearest-neighbour search by cosine
+// similarity. Vectors are stored normalized, so similarity is a dot product.
+// Deleted chunks are tombstoned and dropped when the graph is compacted.
+// Not safe for concurrent use.
+type annIndex struct {
+	// provider names the embedding provider of the vectors.
+	provider string
+	dims     int
+	nodes    []annNode
+	byID     map[int64]int32
+	entry    int32 // -1 when empty
+	maxLevel int
+	live     int
+	// idSum is the sum of the live chunk IDs. Together with live it tells
+	// whether the index matches chunks_vec.
+	idSum int64
+	dirty bool
+	rng   *rand.Rand
+}
+
+type annNode struct {
+	ID      int64
+	Vec     []float32
+	Links   [][]int32 // neighbour node indices per layer
+	Deleted bool
+}
+
+// annHit is a search result: a chunk and its cosine similarity to the query.
+type annHit struct {
+	id    int64
+	score float32
+}
+
+func newANNIndex(dims int) *annIndex {
+	return &annIndex{
+		dims:  dims,
+		byID:  make(map[int64]int32),
+		entry: -1,
+		rng:   rand.New(rand.NewSource(1)),
+	}
+}
+
+// Len returns the number of live vectors.
+func (x *annIndex) Len() int { return x.live }
+
+// Has reports whether the chunk is indexed.
+func (x *annIndex) Has(id int64) bool {
+	_, ok := x.byID[id]
+	return ok
+}
+
+// Add inserts the vector of a chunk, replacing any earlier one.
+func (x *annIndex) Add(id int64, vec []float32) error {
+	if len(vec) != x.dims {
+		return fmt.Errorf("vector has %d dimensions, index has %d", len(vec), x.dims)
+	}
+	x.Remove(id)
+
+	level := x.randomLevel()
+	n := int32(len(x.nodes))
+	x.nodes = append(x.nodes, annNode{ID: id, Vec: normalize(vec), Links: make([][]int32, level+1)})
+	x.byID[id] = n
+	x.live++
+	x.idSum += id
+	x.dirty = true
+
+	if x.entry < 0 {
+		x.entry, x.maxLevel = n, level
+		return nil
+	}
+
+	q := x.nodes[n].Vec
+	ep := x.entry
+	for l := x.maxLevel; l > level; l-- {
+		ep = x.greedy(q, ep, l)
+	}
+	for l := min(level, x.maxLevel); l >= 0; l-- {
+		candidates := x.searchLayer(q, []int32{ep}, annEfConstruction, l)
+		neighbours := x.selectNeighbours(candidates, annM)
+		x.nodes[n].Links[l] = neighbours
+		for _, nb := range neighbours {
+			x.link(nb, n, l)
+		}
+		ep = candidates[0].node
+	}
+	if level > x.maxLevel {
+		x.entry, x.maxLevel = n, level
+	}
+	return nil
+}
+
+// Remove tombstones the vector of a chunk. The graph is rebuilt once most of
+// it is tombstones.
+func (x *annIndex) Remove(id int64) {
+	n, ok := x.byID[id]
+	if !ok {
+		return
+	}
+	delete(x.byID, id)
+	x.nodes[n].Deleted = true
+	x.live--
+	x.idSum -= id
+	x.dirty = true
+	if len(x.nodes) > 64 && x.live < len(x.nodes)/2 {
+		x.compact()
+	}
+}
+
+// Search returns up to k chunks most similar to q, best first. ef is the
+// candidate list size; larger is slower and more accurate.
+func (x *annIndex) Search(q []float32, k, ef int) []annHit {
+	if x.entry < 0 || x.live == 0 || len(q) != x.dims {
+		return nil
+	}
+	q = normalize(q)
+	if ef < k {
+		ef = k
+	}
+	ep := x.entry
+	for l := x.maxLevel; l > 0; l-- {
+		ep = x.greedy(q, ep, l)
+	}
+	var hits []annHit
+	for _, c := range x.searchLayer(q, []int32{ep}, ef, 0) {
+		if node := x.nodes[c.node]; !node.Deleted {
+			hits = append(hits, annHit{id: node.ID, score: c.sim})
+			if len(hits) == k {
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// compact rebuilds the graph from the live vectors.
+func (x *annIndex) compact() {
+	nodes, provider := x.nodes, x.provider
+	*x = *newANNIndex(x.dims)
+	x.provider = provider
+	for _, node := range nodes {
+		if !node.Deleted {
+			_ = x.Add(node.ID, node.Vec)
+		}
+	}
+}
+
+func (x *annIndex) randomLevel() int {
+	return int(-math.Log(1-x.rng.Float64()) / math.Log(annM))
+}
+
+func (x *annIndex) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * annM
+	}
+	return annM
+}
+
+// link adds to as a neighbour of from, pruning from's neighbours if it has
+// too many.
+func (x *annIndex) link(from, to int32, level int) {
+	links := append(x.nodes[from].Links[level], to)
+	if len(links) > x.maxLinks(level) {
+		v := x.nodes[from].Vec
+		candidates := make([]annCandidate, len(links))
+		for i, nb := range links {
+			candidates[i] = annCandidate{node: nb, sim: dot(v, x.nodes[nb].Vec)}
+		}
+		sort.Slice(candidates, func(a, b int) bool { return candidates[a].sim > candidates[b].sim })
+		links = x.selectNeighbours(candidates, x.maxLinks(level))
+	}
+	x.nodes[from].Links[level] = links
+}
+
+// selectNeighbours picks up to m of the candidates, sorted by similarity to
+// a node, best first. It prefers candidates more similar to the node than to
+// an already picked neighbour, so the graph stays connected across clusters.
+func (x *annIndex) selectNeighbours(candidates []annCandidate, m int) []int32 {
+	picked := make([]int32, 0, m)
+	var skipped []int32
+	for _, c := range candidates {
+		if len(picked) == m {
+			break
+		}
+		diverse := true
+		for _, p := range picked {
+			if dot(x.nodes[c.node].Vec, x.nodes[p].Vec) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			picked = append(picked, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+	for _, s := range skipped {
+		if len(picked) == m {
+			break
+		}
+		picked = append(picked, s)
+	}
+	return picked
+}
+
+// greedy walks from ep to the node on a layer most similar to q.
+func (x *annIndex) greedy(q []float32, ep int32, level int) int32 {
+	best := dot(q, x.nodes[ep].Vec)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range x.nodes[ep].Links[level] {
+			if sim := dot(q, x.nodes[nb].Vec); sim > best {
+				ep, best, changed = nb, sim, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns the ef nodes on a layer most similar to q, best first.
+func (x *annIndex) searchLayer(q []float32, eps []int32, ef, level int) []annCandidate {
+	visited := make(map[int32]bool, ef*4)
+	var frontier annMaxHeap // best candidate first
+	var results annMinHeap  // worst result first
+	for _, ep := range eps {
+		c := annCandidate{node: ep, sim: dot(q, x.nodes[ep].Vec)}
+		visited[ep] = true
+		heap.Push(&frontier, c)
+		heap.Push(&results, c)
+	}
+	for frontier.Len() > 0 {
+		c := heap.Pop(&frontier).(annCandidate)
+		if results.Len() >= ef && c.sim < results[0].sim {
+			break
+		}
+		for _, nb := range x.nodes[c.node].Links[level] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			sim := dot(q, x.nodes[nb].Vec)
+			if results.Len() < ef || sim > results[0].sim {
+				heap.Push(&frontier, annCandidate{node: nb, sim: sim})
+				heap.Push(&results, annCandidate{node: nb, sim: sim})
+				if results.Len() > ef {
+					heap.Pop(&results)
+				}
+			}
+		}
+	}
+	out := make([]annCandidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(&results).(annCandidate)
+	}
+	return out
+}
+
+type annCandidate struct {
+	node int32
+	sim  float32
+}
+
+type annMaxHeap []annCandidate
+
+func (h annMaxHeap) Len() int           { return len(h) }
+func (h annMaxHeap) Less(i, j int) bool { return h[i].sim > h[j].sim }
+func (h annMaxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *annMaxHeap) Push(v any)        { *h = append(*h, v.(annCandidate)) }
+func (h *annMaxHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+type annMinHeap []annCandidate
+
+func (h annMinHeap) Len() int           { return len(h) }
+func (h annMinHeap) Less(i, j int) bool { return h[i].sim < h[j].sim }
+func (h annMinHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *annMinHeap) Push(v any)        { *h = append(*h, v.(annCandidate)) }
+func (h *annMinHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+// normalize returns v scaled to unit length.
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, f := range v {
+		norm += float64(f) * float64(f)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	scale := 1 / math.Sqrt(norm)
+	for i, f := range v {
+		out[i] = float32(float64(f) * scale)
+	}
+	return out
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// annFile is the on-disk form of an annIndex.
+type annFile struct {
+	Version  int
+	Provider string
+	Dims     int
+	Entry    int32
+	MaxLevel int
+	Nodes    []annNode
+}
+
+// save writes the index to path, replacing it atomically.
+func (x *annIndex) save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".vectors-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = gob.NewEncoder(tmp).Encode(annFile{
+		Version:  annFormatVersion,
+		Provider: x.provider,
+		Dims:     x.dims,
+		Entry:    x.entry,
+		MaxLevel: x.maxLevel,
+		Nodes:    x.nodes,
+	})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	x.dirty = false
+	return nil
+}
+
+// loadANNIndex reads an index saved by save.
+func loadANNIndex(path string) (*annIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var file annFile
+	if err := gob.NewDecoder(f).Decode(&file); err != nil {
+		return nil, err
+	}
+	if file.Version != annFormatVersion {
+		return nil, fmt.Errorf("vector index format %d, want %d", file.Version, annFormatVersion)
+	}
+	x := newANNIndex(file.Dims)
+	x.provider = file.Provider
+	x.nodes, x.entry, x.maxLevel = file.Nodes, file.Entry, file.MaxLevel
+	for i, node := range x.nodes {
+		if !node.Deleted {
+			x.byID[node.ID] = int32(i)
+			x.live++
+			x.idSum += node.ID
+		}
+	}
+	return x, nil
+}
+
+// annMinVectors is the store size from which vector search uses the index.
+// Smaller stores are scanned, which is about as fast and exact.
+var annMinVectors int64 = 2000
+
+// annPath returns where the index is saved.
+func (m *Manager) annPath() string {
+	return filepath.Join(m.dir, ".index", "vectors.hnsw")
+}
+
+// annSearch returns the chunks nearest to q from the index. ok is false if
+// the store is small enough to scan, or the index is not in step with the
+// database. In that case it is brought in step in the background, and the
+// caller scans until it is ready.
+func (m *Manager) annSearch(q []float32, limit int) (hits []annHit, ok bool) {
+	var count, sum int64
+	err := m.db.QueryRow(
+		"SELECT COUNT(DISTINCT chunk_id), COALESCE(SUM(DISTINCT chunk_id), 0) FROM chunks_vec WHERE provider=? AND dims=?",
+		m.provider.Name(), m.provider.Dims(),
+	).Scan(&count, &sum)
+	if err != nil || count < annMinVectors {
+		return nil, false
+	}
+
+	m.annMu.Lock()
+	defer m.annMu.Unlock()
+	if x := m.ann; x != nil && x.provider == m.provider.Name() && x.dims == m.provider.Dims() &&
+		int64(x.live) == count && x.idSum == sum {
+		return x.Search(q, limit, max(4*limit, 64)), true
+	}
+	if !m.annBuilding && !m.closed.Load() {
+		// The builder owns the index until it is done; vectors added or
+		// removed meanwhile are picked up by the next sync.
+		m.annBuilding = true
+		x := m.ann
+		m.ann = nil
+		m.annWG.Add(1)
+		go m.buildANN(x)
+	}
+	return nil, false
+}
+
+// buildANN brings x, or the saved index if x is nil, in step with the
+// database and installs it.
+func (m *Manager) buildANN(x *annIndex) {
+	defer m.annWG.Done()
+	x, err := m.syncANN(x)
+	m.annMu.Lock()
+	defer m.annMu.Unlock()
+	m.annBuilding = false
+	if err == nil {
+		m.ann = x
+	}
+}
+
+// errANNStopped is returned by syncANN when the manager closes.
+var errANNStopped = errors.New("index build stopped")
+
+// syncANN loads the index unless x is given, and brings it in step with the
+// current provider's vectors in chunks_vec. Other processes sharing the
+// store add and remove vectors the index hasn't seen. The caller must own x.
+func (m *Manager) syncANN(x *annIndex) (*annIndex, error) {
+	provider, dims := m.provider.Name(), m.provider.Dims()
+	if x == nil {
+		if loaded, err := loadANNIndex(m.annPath()); err == nil {
+			x = loaded
+		}
+	}
+	if x == nil || x.provider != provider || x.dims != dims {
+		x = newANNIndex(dims)
+		x.provider = provider
+	}
+
+	rows, err := m.db.Query("SELECT DISTINCT chunk_id FROM chunks_vec WHERE provider=? AND dims=?", provider, dims)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[int64]bool, x.live)
+	var missing []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stored[id] = true
+		if !x.Has(id) {
+			missing = append(missing, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var stale []int64
+	for id := range x.byID {
+		if !stored[id] {
+			stale = append(stale, id)
+		}
+	}
+	for _, id := range stale {
+		x.Remove(id)
+	}
+
+	const batch = 500
+	for start := 0; start < len(missing); start += batch {
+		if m.closed.Load() {
+			return nil, errANNStopped
+		}
+		ids := missing[start:min(start+batch, len(missing))]
+		placeholders, args := inPlaceholders(ids)
+		args = append(args, provider, dims)
+		rows, err := m.db.Query("SELECT chunk_id, embedding FROM chunks_vec WHERE chunk_id IN ("+placeholders+") AND provider=? AND dims=?", args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int64
+			var blob []byte
+			if err := rows.Scan(&id, &blob); err != nil {
+				continue
+			}
+			_ = x.Add(id, deserializeVec(blob))
+		}
+		rows.Close()
+	}
+	// Saving is best effort: a missing or stale file is rebuilt from the
+	// database.
+	if x.dirty {
+		_ = x.save(m.annPath())
+	}
+	return x, nil
+}
+
+// annAdd adds a stored vector to the index, if it is loaded.
+func (m *Manager) annAdd(chunkID int64, blob []byte) {
+	m.annMu.Lock()
+	defer m.annMu.Unlock()
+	if m.ann != nil {
+		_ = m.ann.Add(chunkID, deserializeVec(blob))
+	}
+}
+
+// annRemove removes deleted chunks from the index, if it is loaded.
+func (m *Manager) annRemove(chunkIDs []int64) {
+	m.annMu.Lock()
+	defer m.annMu.Unlock()
+	if m.ann != nil {
+		for _, id := range chunkIDs {
+			m.ann.Remove(id)
+		}
+	}
+}
+
+// saveANN writes the index to disk if it changed.
+func (m *Manager) saveANN() {
+	m.annMu.Lock()
+	defer m.annMu.Unlock()
+	if m.ann != nil && m.ann.dirty {
+		_ = m.ann.save(m.annPath())
+	}
+}
+
+// inPlaceholders returns "?, ?, ..." and the arguments for an IN clause.
+func inPlaceholders(ids []int64) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "), args
+}
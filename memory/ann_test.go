@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomVecs(n, dims int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	vecs := make([][]float32, n)
+	for i := range vecs {
+		vecs[i] = make([]float32, dims)
+		for j := range vecs[i] {
+			vecs[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	return vecs
+}
+
+// bruteForce returns the IDs (index+1) of the k vectors nearest to q.
+func bruteForce(vecs [][]float32, q []float32, k int, skip map[int64]bool) []int64 {
+	type scored struct {
+		id    int64
+		score float32
+	}
+	var all []scored
+	for i, v := range vecs {
+		if id := int64(i + 1); !skip[id] {
+			all = append(all, scored{id, cosine(q, v)})
+		}
+	}
+	sort.Slice(all, func(a, b int) bool { return all[a].score > all[b].score })
+	ids := make([]int64, k)
+	for i := range ids {
+		ids[i] = all[i].id
+	}
+	return ids
+}
+
+func recall(x *annIndex, vecs, queries [][]float32, k int, skip map[int64]bool) float64 {
+	found := 0
+	for _, q := range queries {
+		want := make(map[int64]bool, k)
+		for _, id := range bruteForce(vecs, q, k, skip) {
+			want[id] = true
+		}
+		for _, h := range x.Search(q, k, 64) {
+			if want[h.id] {
+				found++
+			}
+		}
+	}
+	return float64(found) / float64(len(queries)*k)
+}
+
+func TestANNIndex_Recall(t *testing.T) {
+	vecs := randomVecs(3000, 32, 1)
+	x := newANNIndex(32)
+	for i, v := range vecs {
+		require.NoError(t, x.Add(int64(i+1), v))
+	}
+	assert.Equal(t, 3000, x.Len())
+
+	queries := randomVecs(50, 32, 2)
+	assert.GreaterOrEqual(t, recall(x, vecs, queries, 10, nil), 0.9)
+
+	hits := x.Search(vecs[41], 1, 64)
+	require.Len(t, hits, 1)
+	assert.Equal(t, int64(42), hits[0].id)
+	assert.InDelta(t, 1.0, hits[0].score, 0.001)
+
+	assert.Error(t, x.Add(1, []float32{1, 2}), "dimension mismatch")
+}
+
+func TestANNIndex_RemoveAndCompact(t *testing.T) {
+	vecs := randomVecs(500, 16, 3)
+	x := newANNIndex(16)
+	for i, v := range vecs {
+		require.NoError(t, x.Add(int64(i+1), v))
+	}
+
+	// Removing most vectors compacts the graph; search skips removed ones.
+	removed := make(map[int64]bool)
+	for id := int64(1); id <= 400; id++ {
+		x.Remove(id)
+		removed[id] = true
+	}
+	assert.Equal(t, 100, x.Len())
+	assert.Less(t, len(x.nodes), 500, "tombstones are compacted away")
+	for _, h := range x.Search(vecs[0], 10, 64) {
+		assert.False(t, removed[h.id])
+	}
+	assert.GreaterOrEqual(t, recall(x, vecs, randomVecs(20, 16, 4), 5, removed), 0.9)
+
+	// Re-adding an ID replaces its vector.
+	require.NoError(t, x.Add(450, vecs[0]))
+	assert.Equal(t, 100, x.Len())
+	assert.Equal(t, int64(450), x.Search(vecs[0], 1, 64)[0].id)
+}
+
+func TestANNIndex_SaveLoad(t *testing.T) {
+	vecs := randomVecs(200, 8, 5)
+	x := newANNIndex(8)
+	for i, v := range vecs {
+		require.NoError(t, x.Add(int64(i+1), v))
+	}
+	x.Remove(7)
+
+	path := filepath.Join(t.TempDir(), "vectors.hnsw")
+	require.NoError(t, x.save(path))
+	assert.False(t, x.dirty)
+
+	loaded, err := loadANNIndex(path)
+	require.NoError(t, err)
+	assert.Equal(t, x.Len(), loaded.Len())
+	assert.Equal(t, x.idSum, loaded.idSum)
+	assert.False(t, loaded.Has(7))
+	assert.Equal(t, x.Search(vecs[3], 5, 64), loaded.Search(vecs[3], 5, 64))
+
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0600))
+	_, err = loadANNIndex(path)
+	assert.Error(t, err)
+}
+
+// wordProvider embeds text as a bag of hashed words.
+type wordProvider struct{}
+
+func (wordProvider) Dims() int    { return 64 }
+func (wordProvider) Name() string { return "test/words" }
+func (wordProvider) Embed(texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, 64)
+		for _, w := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(strings.Trim(w, ".,#")))
+			v[h.Sum32()%64]++
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestManager_VectorSearchUsesIndex(t *testing.T) {
+	old := annMinVectors
+	annMinVectors = 1
+	t.Cleanup(func() { annMinVectors = old })
+
+	dir := t.TempDir()
+	mgr, err := NewManagerWithOptions(dir, wordProvider{}, ManagerOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.WriteFile("tools.md", "# Tools\nThe deploy pipeline uses terraform and helm charts.", ""))
+	require.NoError(t, mgr.WriteFile("people.md", "# People\nAlice reviews frontend changes on fridays.", ""))
+
+	// The first search scans while the index is built in the background.
+	results, err := mgr.Search("terraform helm deploy", SearchOpts{MaxResults: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "tools.md", results[0].Path)
+	require.Eventually(t, func() bool {
+		mgr.annMu.Lock()
+		defer mgr.annMu.Unlock()
+		return mgr.ann != nil && !mgr.annBuilding
+	}, 5*time.Second, 10*time.Millisecond, "search loads the index")
+
+	hits, ok := mgr.annSearch(mustEmbed(t, "terraform helm deploy"), 1)
+	require.True(t, ok, "later searches use the index")
+	require.NotEmpty(t, hits)
+
+	// Deleted files leave the index along with the database.
+	require.NoError(t, mgr.Delete("tools.md"))
+	results, err = mgr.Search("terraform helm deploy", SearchOpts{MaxResults: 5})
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.NotEqual(t, "tools.md", r.Path)
+	}
+	_, ok = mgr.annSearch(mustEmbed(t, "terraform"), 1)
+	assert.True(t, ok, "deletes keep the index in step")
+	live := mgr.ann.Len()
+	mgr.Close()
+
+	// A reopened store loads the saved index instead of rebuilding it.
+	loaded, err := loadANNIndex(filepath.Join(dir, ".index", "vectors.hnsw"))
+	require.NoError(t, err)
+	assert.Equal(t, live, loaded.Len())
+}
+
+func mustEmbed(t *testing.T, text string) []float32 {
+	t.Helper()
+	vecs, err := wordProvider{}.Embed([]string{text})
+	require.NoError(t, err)
+	return vecs[0]
+}
@@ -39,6 +39,8 @@ func NewManagerFromConfig(cfg *config.Config) (*Manager, error) {
 		provider = NewOpenAIProvider(cfg.Memory.OpenAIAPIKey, cfg.Memory.OpenAIModel)
 	case "ollama":
 		provider = NewOllamaProvider(cfg.Memory.OllamaURL, cfg.Memory.OllamaModel)
+	case "local":
+		provider = NewLocalProvider()
 	default:
 		provider = &noopProvider{} // FTS-only
 	}
@@ -39,3 +39,17 @@ func TestNewManagerFromConfig_RespectsGitEnabledFalse(t *testing.T) {
 	_, statErr := os.Stat(filepath.Join(home, ".hivemind", "memory", ".git"))
 	assert.True(t, os.IsNotExist(statErr))
 }
+
+func TestNewManagerFromConfig_LocalProvider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	mgr, err := NewManagerFromConfig(&config.Config{
+		Memory: &config.MemoryConfig{Enabled: true, EmbeddingProvider: "local"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, mgr)
+	t.Cleanup(func() { mgr.Close() })
+
+	assert.Equal(t, "local/hash-ngram-v1", mgr.provider.Name())
+	assert.Equal(t, localDims, mgr.provider.Dims())
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// localDims is the size of the vectors LocalProvider produces.
+const localDims = 512
+
+// Feature weights of LocalProvider. Whole words count most; bigrams add
+// phrase matches and character trigrams match word forms ("deploy",
+// "deployment").
+const (
+	localWordWeight    = 1.0
+	localBigramWeight  = 0.5
+	localTrigramWeight = 0.25
+)
+
+// localStopwords are common English words left out of LocalProvider vectors.
+var localStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "i": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true,
+	"to": true, "was": true, "we": true, "were": true, "what": true, "when": true,
+	"which": true, "will": true, "with": true, "you": true,
+}
+
+// LocalProvider embeds text in-process, without a model or network access,
+// by hashing words, word bigrams and character trigrams into a fixed-size
+// vector. It finds text sharing words and word forms, not text that only
+// means the same thing.
+type LocalProvider struct{}
+
+func NewLocalProvider() *LocalProvider { return &LocalProvider{} }
+
+func (p *LocalProvider) Dims() int    { return localDims }
+func (p *LocalProvider) Name() string { return "local/hash-ngram-v1" }
+
+func (p *LocalProvider) Embed(texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = localEmbed(text)
+	}
+	return out, nil
+}
+
+// localEmbed returns the L2-normalized feature-hashed vector of text. Term
+// counts are dampened logarithmically so repeated words don't dominate.
+func localEmbed(text string) []float32 {
+	// counts holds how often each feature occurs, weights its weight.
+	counts := make(map[string]int)
+	weights := make(map[string]float64)
+	add := func(feature string, weight float64) {
+		counts[feature]++
+		weights[feature] = weight
+	}
+	words := localTokens(text)
+	for i, w := range words {
+		add("w:"+w, localWordWeight)
+		if i > 0 {
+			add("b:"+words[i-1]+" "+w, localBigramWeight)
+		}
+		padded := []rune("#" + w + "#")
+		for j := 0; j+3 <= len(padded); j++ {
+			add("t:"+string(padded[j:j+3]), localTrigramWeight)
+		}
+	}
+
+	vec := make([]float64, localDims)
+	for feature, count := range counts {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		// The top bit signs the feature so collisions cancel out on average.
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vec[sum%localDims] += sign * weights[feature] * (1 + math.Log(float64(count)))
+	}
+
+	var norm float64
+	for _, f := range vec {
+		norm += f * f
+	}
+	out := make([]float32, localDims)
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, f := range vec {
+		out[i] = float32(f / norm)
+	}
+	return out
+}
+
+// localTokens splits text into lowercase words, dropping stopwords.
+func localTokens(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if !localStopwords[f] {
+			words = append(words, f)
+		}
+	}
+	return words
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalProvider_Embed(t *testing.T) {
+	p := NewLocalProvider()
+	vecs, err := p.Embed([]string{
+		"The deployment pipeline runs terraform",
+		"deploying with terraform pipelines",
+		"Alice prefers tabs over spaces",
+		"",
+	})
+	require.NoError(t, err)
+	require.Len(t, vecs, 4)
+	for _, v := range vecs {
+		assert.Len(t, v, p.Dims())
+	}
+
+	assert.InDelta(t, 1.0, cosine(vecs[0], vecs[0]), 0.001)
+	assert.Greater(t, cosine(vecs[0], vecs[1]), cosine(vecs[0], vecs[2])+0.2,
+		"shared words and word forms are closer than unrelated text")
+	assert.Equal(t, float32(0), cosine(vecs[0], vecs[3]), "empty text has a zero vector")
+
+	again, err := p.Embed([]string{"The deployment pipeline runs terraform"})
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, vecs[0], again[0], 1e-6)
+}
+
+func TestLocalTokens_DropsStopwords(t *testing.T) {
+	assert.Equal(t, []string{"deploy", "api", "v2", "héllo"}, localTokens("Deploy the API (v2) to héllo!"))
+}
+
+func TestManager_SearchWithLocalProvider(t *testing.T) {
+	mgr, err := NewManagerWithOptions(t.TempDir(), NewLocalProvider(), ManagerOptions{})
+	require.NoError(t, err)
+	t.Cleanup(mgr.Close)
+
+	require.NoError(t, mgr.WriteFile("infra.md", "# Infra\nDeployments go through the terraform pipeline.", ""))
+	require.NoError(t, mgr.WriteFile("team.md", "# Team\nAlice prefers tabs over spaces.", ""))
+
+	// No keyword matches "deploying"; the trigrams of "deployments" do.
+	results, err := mgr.Search("deploying", SearchOpts{MaxResults: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "infra.md", results[0].Path)
+}
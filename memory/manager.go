@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +25,23 @@ type Manager struct {
 	reranker Reranker          // optional; nil == no reranking
 	gitRepo  *GitRepo          // nil if git init failed (non-fatal)
 	mu       sync.RWMutex
+
+	// ann is the nearest-neighbour index of chunks_vec, loaded in the
+	// background by the first search of a store large enough to need it.
+	// annBuilding is set while a build runs, which annWG waits for.
+	ann         *annIndex
+	annMu       sync.Mutex
+	annBuilding bool
+	annWG       sync.WaitGroup
+	// closed stops background work when the manager closes.
+	closed atomic.Bool
+
+	// reindexMu serializes Reindex; progress reports the job started by
+	// StartReindex.
+	reindexMu      sync.Mutex
+	progressMu     sync.Mutex
+	progress       ReindexProgress
+	reindexStopped atomic.Bool
 }
 
 // ManagerOptions configures optional manager behaviors.
@@ -56,6 +74,20 @@ func NewManagerWithOptions(dir string, provider EmbeddingProvider, opts ManagerO
 
 	mgr := &Manager{dir: dir, db: db, provider: provider}
 
+	// Stores indexed before frontmatter was recorded need it for filters.
+	if err := mgr.indexFields(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("index frontmatter: %w", err)
+	}
+
+	// Vectors from a previous provider can't be compared with the current
+	// one's; note how many chunks need re-embedding.
+	if provider.Dims() > 0 {
+		if status, err := mgr.indexStatus(); err == nil {
+			mgr.progress.Total = status.Stale()
+		}
+	}
+
 	// Initialize git repo (non-fatal) when enabled.
 	if opts.GitEnabled {
 		if repo, gitErr := InitGitRepo(dir); gitErr == nil {
@@ -85,6 +117,10 @@ func NewManagerWithOptions(dir string, provider EmbeddingProvider, opts ManagerO
 
 // Close releases resources held by the Manager.
 func (m *Manager) Close() {
+	m.StopReindex()
+	m.closed.Store(true)
+	m.annWG.Wait()
+	m.saveANN()
 	m.db.Close()
 }
 
@@ -272,6 +308,7 @@ func (m *Manager) syncFile(relPath string) error {
 		"SELECT c.id, c.text FROM chunks c JOIN files f ON c.file_id=f.id WHERE f.path=?",
 		relPath,
 	)
+	var oldChunks []int64
 	if queryErr == nil {
 		for cleanupRows.Next() {
 			var id int64
@@ -281,13 +318,17 @@ func (m *Manager) syncFile(relPath string) error {
 					"INSERT INTO chunks_fts(chunks_fts, rowid, text) VALUES('delete', ?, ?)",
 					id, text,
 				)
+				oldChunks = append(oldChunks, id)
 			}
 		}
 		cleanupRows.Close()
 	}
 
 	// Delete old file record (cascade deletes chunks and chunks_vec rows).
+	// Fields are deleted explicitly so a reused file ID never inherits them.
+	_, _ = m.db.Exec("DELETE FROM file_fields WHERE file_id IN (SELECT id FROM files WHERE path=?)", relPath)
 	_, _ = m.db.Exec("DELETE FROM files WHERE path=?", relPath)
+	m.annRemove(oldChunks)
 
 	// Insert file record.
 	res, err := m.db.Exec(
@@ -299,6 +340,11 @@ func (m *Manager) syncFile(relPath string) error {
 	}
 	fileID, _ := res.LastInsertId()
 
+	fm, _ := ParseFrontmatter(content)
+	if err := storeFields(m.db, fileID, fm); err != nil {
+		return err
+	}
+
 	chunks := chunkMarkdown(content)
 	for _, chunk := range chunks {
 		res, err := m.db.Exec(
@@ -331,11 +377,8 @@ func (m *Manager) syncFile(relPath string) error {
 
 func (m *Manager) embedAndStore(chunkID int64, text, textHash string) error {
 	// Check embedding cache first.
-	var cachedBlob []byte
-	row := m.db.QueryRow("SELECT embedding FROM embedding_cache WHERE text_hash=?", textHash)
-	if err := row.Scan(&cachedBlob); err == nil {
-		_, err = m.db.Exec("INSERT OR REPLACE INTO chunks_vec (chunk_id, embedding) VALUES (?, ?)", chunkID, cachedBlob)
-		return err
+	if blob := m.cachedEmbedding(textHash); blob != nil {
+		return m.storeVec(chunkID, blob)
 	}
 
 	vecs, err := m.provider.Embed([]string{text})
@@ -343,20 +386,73 @@ func (m *Manager) embedAndStore(chunkID int64, text, textHash string) error {
 		return err
 	}
 	blob := serializeVec(vecs[0])
+	m.cacheEmbedding(textHash, blob)
+	return m.storeVec(chunkID, blob)
+}
 
-	// Cache the embedding.
+// cachedEmbedding returns the current provider's cached vector of the text
+// with textHash, or nil.
+func (m *Manager) cachedEmbedding(textHash string) []byte {
+	var blob []byte
+	row := m.db.QueryRow("SELECT embedding FROM embedding_cache WHERE text_hash=? AND provider=?", textHash, m.provider.Name())
+	if err := row.Scan(&blob); err != nil || len(blob) != 4*m.provider.Dims() {
+		return nil
+	}
+	return blob
+}
+
+// cacheEmbedding caches the current provider's vector of the text with
+// textHash.
+func (m *Manager) cacheEmbedding(textHash string, blob []byte) {
 	_, _ = m.db.Exec(
 		"INSERT OR REPLACE INTO embedding_cache (text_hash, embedding, provider, model, created) VALUES (?, ?, ?, ?, ?)",
 		textHash, blob, m.provider.Name(), "", time.Now().UnixMilli(),
 	)
+}
 
-	_, err = m.db.Exec("INSERT OR REPLACE INTO chunks_vec (chunk_id, embedding) VALUES (?, ?)", chunkID, blob)
-	return err
+// storeVec replaces the vector of a chunk with one from the current provider.
+func (m *Manager) storeVec(chunkID int64, blob []byte) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM chunks_vec WHERE chunk_id=?", chunkID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO chunks_vec (chunk_id, embedding, provider, dims) VALUES (?, ?, ?, ?)",
+		chunkID, blob, m.provider.Name(), m.provider.Dims(),
+	); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	m.annAdd(chunkID, blob)
+	return nil
 }
 
 func (m *Manager) deleteFileRecord(relPath string) error {
-	_, err := m.db.Exec("DELETE FROM files WHERE path=?", relPath)
-	return err
+	var chunkIDs []int64
+	rows, err := m.db.Query("SELECT c.id FROM chunks c JOIN files f ON c.file_id=f.id WHERE f.path=?", relPath)
+	if err == nil {
+		for rows.Next() {
+			var id int64
+			if rows.Scan(&id) == nil {
+				chunkIDs = append(chunkIDs, id)
+			}
+		}
+		rows.Close()
+	}
+	if _, err := m.db.Exec("DELETE FROM file_fields WHERE file_id IN (SELECT id FROM files WHERE path=?)", relPath); err != nil {
+		return err
+	}
+	if _, err := m.db.Exec("DELETE FROM files WHERE path=?", relPath); err != nil {
+		return err
+	}
+	m.annRemove(chunkIDs)
+	return nil
 }
 
 // Read returns the body of a memory file with frontmatter stripped.
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errReindexStopped = errors.New("re-index stopped")
+
+// reindexBatch is how many chunks Reindex embeds per call to the provider
+// and stores per hold of the write lock.
+const reindexBatch = 32
+
+// IndexStatus describes how much of the store the current embedding
+// provider has embedded.
+type IndexStatus struct {
+	// Provider and Dims identify the current embedding provider.
+	Provider string
+	Dims     int
+	// Chunks is the number of indexed chunks; Current how many of them have
+	// a vector from the current provider.
+	Chunks  int
+	Current int
+}
+
+// Stale returns how many chunks lack a vector from the current provider.
+func (s IndexStatus) Stale() int {
+	if s.Dims == 0 {
+		return 0
+	}
+	return s.Chunks - s.Current
+}
+
+// ReindexProgress reports the re-index job started by StartReindex.
+type ReindexProgress struct {
+	Running bool
+	Done    int
+	Total   int
+	// Err is why the last job stopped early, if it did.
+	Err error
+}
+
+// IndexStatus reports whether the vectors in the store match the current
+// embedding provider. Vectors from another provider, or of another size,
+// are left out of vector search until Reindex replaces them.
+func (m *Manager) IndexStatus() (IndexStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.indexStatus()
+}
+
+func (m *Manager) indexStatus() (IndexStatus, error) {
+	s := IndexStatus{Provider: m.provider.Name(), Dims: m.provider.Dims()}
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM chunks").Scan(&s.Chunks); err != nil {
+		return s, err
+	}
+	err := m.db.QueryRow(
+		`SELECT COUNT(DISTINCT cv.chunk_id) FROM chunks_vec cv
+		 JOIN chunks c ON cv.chunk_id = c.id
+		 WHERE cv.provider = ? AND cv.dims = ?`,
+		s.Provider, s.Dims,
+	).Scan(&s.Current)
+	return s, err
+}
+
+// staleChunks returns the IDs of the chunks lacking a vector from the
+// current provider.
+func (m *Manager) staleChunks() ([]int64, error) {
+	rows, err := m.db.Query(
+		`SELECT c.id FROM chunks c
+		 WHERE NOT EXISTS (
+		     SELECT 1 FROM chunks_vec cv
+		     WHERE cv.chunk_id = c.id AND cv.provider = ? AND cv.dims = ?
+		 )
+		 ORDER BY c.id`,
+		m.provider.Name(), m.provider.Dims(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Reindex embeds every chunk lacking a vector from the current provider,
+// replacing vectors left by a previous one. Embeddings cached for the
+// provider are reused. progress, if not nil, is called after each batch.
+// Only one Reindex runs at a time; a second call waits for the first.
+func (m *Manager) Reindex(progress func(done, total int)) error {
+	if m.provider.Dims() == 0 || m.reindexStopped.Load() {
+		return nil
+	}
+	m.reindexMu.Lock()
+	defer m.reindexMu.Unlock()
+
+	m.mu.RLock()
+	ids, err := m.staleChunks()
+	m.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("find stale chunks: %w", err)
+	}
+	total := len(ids)
+	if progress != nil {
+		progress(0, total)
+	}
+	for start := 0; start < total; start += reindexBatch {
+		if m.reindexStopped.Load() {
+			return errReindexStopped
+		}
+		batch := ids[start:min(start+reindexBatch, total)]
+		if err := m.reindexChunks(batch); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(start+len(batch), total)
+		}
+	}
+	return nil
+}
+
+// reindexChunks embeds a batch of chunks and stores their vectors. The
+// provider is called without holding m.mu, so searches and writes go on
+// while it works; the write lock is only taken to store the results.
+func (m *Manager) reindexChunks(ids []int64) error {
+	type chunk struct {
+		id         int64
+		text, hash string
+		blob       []byte
+		fresh      bool
+	}
+	var chunks []chunk
+	var texts []string
+	m.mu.RLock()
+	for _, id := range ids {
+		var text string
+		if err := m.db.QueryRow("SELECT text FROM chunks WHERE id=?", id).Scan(&text); err != nil {
+			// Deleted since the job started.
+			continue
+		}
+		c := chunk{id: id, text: text, hash: hashText(text)}
+		if c.blob = m.cachedEmbedding(c.hash); c.blob == nil {
+			texts = append(texts, text)
+		}
+		chunks = append(chunks, c)
+	}
+	m.mu.RUnlock()
+
+	if len(texts) > 0 {
+		vecs, err := m.provider.Embed(texts)
+		if err != nil {
+			return fmt.Errorf("embed chunks: %w", err)
+		}
+		if len(vecs) != len(texts) {
+			return fmt.Errorf("embed chunks: got %d vectors for %d chunks", len(vecs), len(texts))
+		}
+		for i := range chunks {
+			if chunks[i].blob == nil {
+				chunks[i].blob, chunks[i].fresh = serializeVec(vecs[0]), true
+				vecs = vecs[1:]
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range chunks {
+		if c.fresh {
+			m.cacheEmbedding(c.hash, c.blob)
+		}
+		// Skip chunks deleted or replaced while the provider was working.
+		var text string
+		if err := m.db.QueryRow("SELECT text FROM chunks WHERE id=?", c.id).Scan(&text); err != nil || text != c.text {
+			continue
+		}
+		if err := m.storeVec(c.id, c.blob); err != nil {
+			return fmt.Errorf("store vector of chunk %d: %w", c.id, err)
+		}
+	}
+	return nil
+}
+
+// StartReindex runs Reindex in the background, unless it is already running.
+// Its progress is reported by ReindexProgress.
+func (m *Manager) StartReindex() {
+	m.progressMu.Lock()
+	if m.progress.Running {
+		m.progressMu.Unlock()
+		return
+	}
+	m.progress = ReindexProgress{Running: true}
+	m.progressMu.Unlock()
+
+	go func() {
+		err := m.Reindex(func(done, total int) {
+			m.progressMu.Lock()
+			m.progress.Done, m.progress.Total = done, total
+			m.progressMu.Unlock()
+		})
+		m.progressMu.Lock()
+		m.progress.Running = false
+		m.progress.Err = err
+		m.progressMu.Unlock()
+	}()
+}
+
+// StopReindex stops a running re-index job after its current batch and keeps
+// later ones from starting. Used when the manager is being replaced.
+func (m *Manager) StopReindex() {
+	m.reindexStopped.Store(true)
+}
+
+// ReindexProgress reports the background re-index job. Before a job has
+// run, Total is the number of stale chunks found when the manager opened.
+func (m *Manager) ReindexProgress() ReindexProgress {
+	m.progressMu.Lock()
+	defer m.progressMu.Unlock()
+	return m.progress
+}
+
+// NeedsReindex reports whether chunks lacked a vector from the current
+// provider when the manager opened, and no re-index job has run since.
+func (m *Manager) NeedsReindex() bool {
+	p := m.ReindexProgress()
+	return !p.Running && p.Err == nil && p.Done < p.Total
+}
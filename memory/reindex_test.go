@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ReindexAfterProviderChange(t *testing.T) {
+	dir := t.TempDir()
+	mgr, err := NewManagerWithOptions(dir, NewLocalProvider(), ManagerOptions{})
+	require.NoError(t, err)
+	require.NoError(t, mgr.WriteFile("tools.md", "# Tools\nThe deploy pipeline uses terraform and helm charts.", ""))
+	require.NoError(t, mgr.WriteFile("people.md", "# People\nAlice reviews frontend changes on fridays.", ""))
+	assert.False(t, mgr.NeedsReindex())
+	mgr.Close()
+
+	// Switching providers leaves every vector stale.
+	mgr, err = NewManagerWithOptions(dir, wordProvider{}, ManagerOptions{})
+	require.NoError(t, err)
+	status, err := mgr.IndexStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "test/words", status.Provider)
+	assert.Equal(t, 0, status.Current)
+	assert.Equal(t, status.Chunks, status.Stale())
+	assert.True(t, mgr.NeedsReindex())
+	assert.Equal(t, status.Stale(), mgr.ReindexProgress().Total)
+
+	var done, total int
+	require.NoError(t, mgr.Reindex(func(d, tot int) { done, total = d, tot }))
+	assert.Equal(t, status.Chunks, total)
+	assert.Equal(t, total, done)
+
+	status, err = mgr.IndexStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Stale())
+	var rows int
+	require.NoError(t, mgr.db.QueryRow("SELECT COUNT(*) FROM chunks_vec").Scan(&rows))
+	assert.Equal(t, status.Chunks, rows, "old vectors are replaced")
+
+	results, err := mgr.Search("terraform helm", SearchOpts{MaxResults: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "tools.md", results[0].Path)
+	mgr.Close()
+
+	mgr, err = NewManagerWithOptions(dir, wordProvider{}, ManagerOptions{})
+	require.NoError(t, err)
+	defer mgr.Close()
+	assert.False(t, mgr.NeedsReindex())
+}
+
+func TestManager_StartReindex(t *testing.T) {
+	dir := t.TempDir()
+	mgr, err := NewManagerWithOptions(dir, nil, ManagerOptions{})
+	require.NoError(t, err)
+	require.NoError(t, mgr.WriteFile("notes.md", "# Notes\nThe staging database runs postgres.", ""))
+	assert.False(t, mgr.NeedsReindex(), "keyword-only stores have nothing to embed")
+	mgr.Close()
+
+	mgr, err = NewManagerWithOptions(dir, wordProvider{}, ManagerOptions{})
+	require.NoError(t, err)
+	defer mgr.Close()
+	require.True(t, mgr.NeedsReindex())
+
+	mgr.StartReindex()
+	require.Eventually(t, func() bool { return !mgr.ReindexProgress().Running }, 5*time.Second, 10*time.Millisecond)
+	p := mgr.ReindexProgress()
+	require.NoError(t, p.Err)
+	assert.Equal(t, p.Total, p.Done)
+	assert.Positive(t, p.Total)
+	assert.False(t, mgr.NeedsReindex())
+}
+
+// gatedProvider is a wordProvider whose Embed waits for release.
+type gatedProvider struct {
+	wordProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p gatedProvider) Embed(texts []string) ([][]float32, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return p.wordProvider.Embed(texts)
+}
+
+func TestManager_ReindexEmbedsWithoutLock(t *testing.T) {
+	dir := t.TempDir()
+	mgr, err := NewManagerWithOptions(dir, nil, ManagerOptions{})
+	require.NoError(t, err)
+	require.NoError(t, mgr.WriteFile("notes.md", "# Notes\nThe staging database runs postgres.", ""))
+	mgr.Close()
+
+	p := gatedProvider{started: make(chan struct{}), release: make(chan struct{})}
+	mgr, err = NewManagerWithOptions(dir, p, ManagerOptions{})
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- mgr.Reindex(nil) }()
+	<-p.started
+
+	// The store stays writable while the provider works.
+	locked := make(chan struct{})
+	go func() {
+		mgr.mu.Lock()
+		mgr.mu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write lock held while embedding")
+	}
+
+	close(p.release)
+	require.NoError(t, <-errCh)
+	status, err := mgr.IndexStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Stale())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
@@ -20,6 +21,17 @@ CREATE TABLE IF NOT EXISTS files (
     hash  TEXT    NOT NULL
 );
 
+-- file_fields holds each file's frontmatter for search filters: one row per
+-- value, with list fields such as tags spread over several rows. Values are
+-- lowercased so filters match case-insensitively.
+CREATE TABLE IF NOT EXISTS file_fields (
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    key     TEXT    NOT NULL,
+    value   TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS file_fields_key_value ON file_fields(key, value);
+CREATE INDEX IF NOT EXISTS file_fields_file ON file_fields(file_id);
+
 CREATE TABLE IF NOT EXISTS chunks (
     id         INTEGER PRIMARY KEY,
     file_id    INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
@@ -36,7 +48,9 @@ CREATE VIRTUAL TABLE IF NOT EXISTS chunks_fts USING fts5(
 
 CREATE TABLE IF NOT EXISTS chunks_vec (
     chunk_id  INTEGER NOT NULL REFERENCES chunks(id) ON DELETE CASCADE,
-    embedding BLOB    NOT NULL
+    embedding BLOB    NOT NULL,
+    provider  TEXT    NOT NULL DEFAULT '',
+    dims      INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS embedding_cache (
@@ -60,5 +74,64 @@ func openDB(dbPath string) (*sql.DB, error) {
 		db.Close()
 		return nil, fmt.Errorf("apply schema: %w", err)
 	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
 	return db, nil
 }
+
+// migrate brings databases created by older versions up to the schema.
+// Vectors stored before chunks_vec recorded their provider keep an empty
+// provider, so they count as stale and are re-embedded by Reindex.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(chunks_vec)")
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name, typ string
+			notNull   int
+			dflt      sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !columns["provider"] {
+		if err := addColumn(db, "chunks_vec", "provider TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+	if !columns["dims"] {
+		if err := addColumn(db, "chunks_vec", "dims INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		// Vectors are stored as little-endian float32s.
+		if _, err := db.Exec("UPDATE chunks_vec SET dims = length(embedding) / 4 WHERE dims = 0"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to table. Another process opening the store at
+// the same time may have added it first, which is not an error.
+func addColumn(db *sql.DB, table, column string) error {
+	_, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column)
+	if err != nil && strings.Contains(err.Error(), "duplicate column") {
+		return nil
+	}
+	return err
+}
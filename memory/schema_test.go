@@ -1,8 +1,10 @@
 package memory
 
 import (
+	"database/sql"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,7 +14,7 @@ func TestOpenDB_CreatesAllTables(t *testing.T) {
 	defer db.Close()
 
 	// Verify all tables exist
-	tables := []string{"files", "chunks", "chunks_fts", "chunks_vec", "embedding_cache"}
+	tables := []string{"files", "file_fields", "chunks", "chunks_fts", "chunks_vec", "embedding_cache"}
 	for _, table := range tables {
 		var name string
 		row := db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", table)
@@ -20,3 +22,28 @@ func TestOpenDB_CreatesAllTables(t *testing.T) {
 		require.NoError(t, err, "table %q should exist", table)
 	}
 }
+
+func TestOpenDB_MigratesVectorColumns(t *testing.T) {
+	path := t.TempDir() + "/old.db"
+	old, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = old.Exec(`
+		CREATE TABLE chunks_vec (chunk_id INTEGER NOT NULL, embedding BLOB NOT NULL);
+		INSERT INTO chunks_vec (chunk_id, embedding) VALUES (1, ?);
+	`, serializeVec([]float32{1, 2, 3}))
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	db, err := openDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var provider string
+	var dims int
+	require.NoError(t, db.QueryRow("SELECT provider, dims FROM chunks_vec WHERE chunk_id=1").Scan(&provider, &dims))
+	assert.Equal(t, "", provider, "vectors of unknown origin are stale")
+	assert.Equal(t, 3, dims)
+
+	// Opening a migrated store again is a no-op.
+	require.NoError(t, migrate(db))
+}
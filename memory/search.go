@@ -21,11 +21,44 @@ type scoredResult struct {
 // global.md, MEMORY.md, hivemind-project.md are exempt.
 var datedFileRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\.md$`)
 
+// Default search scoring: the share of the keyword and vector scores in the
+// combined score, and the half-life of temporal decay (a 1% loss per day).
+const (
+	defaultKeywordWeight     = 0.4
+	defaultVectorWeight      = 0.6
+	defaultDecayHalfLifeDays = math.Ln2 / 0.01
+)
+
+// normalizeOpts fills in the defaults of opts and rejects invalid values.
+func normalizeOpts(opts SearchOpts) (SearchOpts, error) {
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = 10
+	}
+	if opts.KeywordWeight < 0 || opts.VectorWeight < 0 {
+		return opts, fmt.Errorf("search weights must not be negative")
+	}
+	if opts.KeywordWeight == 0 && opts.VectorWeight == 0 {
+		opts.KeywordWeight, opts.VectorWeight = defaultKeywordWeight, defaultVectorWeight
+	}
+	if opts.DecayHalfLifeDays == 0 {
+		opts.DecayHalfLifeDays = defaultDecayHalfLifeDays
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = QueryAny
+	case QueryAny, QueryAll, QueryPhrase, QueryPrefix:
+	default:
+		return opts, fmt.Errorf("unknown query mode %q (want any, all, phrase or prefix)", opts.Mode)
+	}
+	return opts, nil
+}
+
 // Search performs hybrid search: FTS5 BM25 + optional cosine similarity,
 // followed by optional Claude-based re-ranking.
 func (m *Manager) Search(query string, opts SearchOpts) ([]SearchResult, error) {
-	if opts.MaxResults <= 0 {
-		opts.MaxResults = 10
+	opts, err := normalizeOpts(opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch extra candidates when a reranker is configured so it has
@@ -35,19 +68,26 @@ func (m *Manager) Search(query string, opts SearchOpts) ([]SearchResult, error)
 		fetchLimit = 20
 	}
 
+	filter := newSearchFilter(opts)
+
 	m.mu.RLock()
 
-	// 1. BM25 keyword search via FTS5
-	bm25Results, err := m.bm25Search(query, fetchLimit)
-	if err != nil {
-		m.mu.RUnlock()
-		return nil, err
+	hasVectors := m.provider.Dims() > 0 && opts.VectorWeight > 0
+
+	// 1. BM25 keyword search via FTS5, unless weighted out of a vector search.
+	var bm25Results []scoredResult
+	if opts.KeywordWeight > 0 || !hasVectors {
+		bm25Results, err = m.bm25Search(query, opts.Mode, fetchLimit, filter)
+		if err != nil {
+			m.mu.RUnlock()
+			return nil, err
+		}
 	}
 
 	// 2. Vector search (if embeddings are configured)
 	var vecResults []scoredResult
-	if m.provider.Dims() > 0 {
-		vecResults, err = m.vectorSearch(query, fetchLimit)
+	if hasVectors {
+		vecResults, err = m.vectorSearch(query, fetchLimit, filter)
 		if err != nil {
 			// Non-fatal: fall back to keyword-only
 			vecResults = nil
@@ -59,15 +99,15 @@ func (m *Manager) Search(query string, opts SearchOpts) ([]SearchResult, error)
 	// content that keyword search missed (e.g. "projects I work on" vs
 	// "repositories" — zero keyword overlap but high semantic relevance).
 	if len(bm25Results) == 0 && len(vecResults) == 0 && m.reranker != nil {
-		bm25Results, err = m.allChunks(fetchLimit)
+		bm25Results, err = m.allChunks(fetchLimit, filter)
 		if err != nil {
 			bm25Results = nil
 		}
 	}
 
 	// 4. Merge and apply temporal decay under the read lock.
-	merged := mergeResults(bm25Results, vecResults, fetchLimit)
-	merged = applyTemporalDecay(merged, m)
+	merged := mergeResults(bm25Results, vecResults, fetchLimit, opts.KeywordWeight, opts.VectorWeight)
+	merged = applyTemporalDecay(merged, m, opts)
 
 	m.mu.RUnlock() // release before any external call
 
@@ -95,7 +135,10 @@ func (m *Manager) Search(query string, opts SearchOpts) ([]SearchResult, error)
 	return out, nil
 }
 
-func (m *Manager) bm25Search(query string, limit int) ([]scoredResult, error) {
+func (m *Manager) bm25Search(query string, mode QueryMode, limit int, filter searchFilter) ([]scoredResult, error) {
+	filterSQL, filterArgs := filter.sql("c.file_id")
+	args := append([]any{ftsQuery(query, mode)}, filterArgs...)
+	args = append(args, limit)
 	// FTS5 bm25() returns negative values (lower = better); negate for consistency.
 	rows, err := m.db.Query(`
 		SELECT c.id, f.path, c.start_line, c.end_line, c.text,
@@ -103,10 +146,10 @@ func (m *Manager) bm25Search(query string, limit int) ([]scoredResult, error) {
 		FROM chunks_fts
 		JOIN chunks c ON chunks_fts.rowid = c.id
 		JOIN files f ON c.file_id = f.id
-		WHERE chunks_fts MATCH ?
+		WHERE chunks_fts MATCH ?`+filterSQL+`
 		ORDER BY score DESC
 		LIMIT ?
-	`, ftsQuery(query), limit)
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -128,13 +171,15 @@ func (m *Manager) bm25Search(query string, limit int) ([]scoredResult, error) {
 // allChunks returns every indexed chunk with a uniform score of 0.
 // Used as fallback candidates when FTS5 produces no hits but a reranker
 // is available to do semantic selection.
-func (m *Manager) allChunks(limit int) ([]scoredResult, error) {
+func (m *Manager) allChunks(limit int, filter searchFilter) ([]scoredResult, error) {
+	filterSQL, args := filter.sql("c.file_id")
 	rows, err := m.db.Query(`
 		SELECT f.path, c.start_line, c.end_line, c.text
 		FROM chunks c
 		JOIN files f ON c.file_id = f.id
+		WHERE 1=1`+filterSQL+`
 		LIMIT ?
-	`, limit)
+	`, append(args, limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -152,20 +197,31 @@ func (m *Manager) allChunks(limit int) ([]scoredResult, error) {
 	return results, rows.Err()
 }
 
-func (m *Manager) vectorSearch(query string, limit int) ([]scoredResult, error) {
+func (m *Manager) vectorSearch(query string, limit int, filter searchFilter) ([]scoredResult, error) {
 	vecs, err := m.provider.Embed([]string{query})
 	if err != nil || len(vecs) == 0 {
 		return nil, err
 	}
 	queryVec := vecs[0]
 
-	// Load all chunk vectors into memory.
+	// Large stores are searched through the nearest-neighbour index. Its
+	// nearest chunks may all be filtered out, so filtered searches scan.
+	if !filter.active {
+		if hits, ok := m.annSearch(queryVec, limit); ok {
+			return m.annResults(hits)
+		}
+	}
+
+	// Load all chunk vectors of the current provider into memory. Vectors
+	// of another provider can't be compared and wait for Reindex.
+	filterSQL, filterArgs := filter.sql("c.file_id")
 	rows, err := m.db.Query(`
 		SELECT cv.chunk_id, cv.embedding, c.start_line, c.end_line, c.text, f.path
 		FROM chunks_vec cv
 		JOIN chunks c ON cv.chunk_id = c.id
 		JOIN files f ON c.file_id = f.id
-	`)
+		WHERE cv.provider = ? AND cv.dims = ?`+filterSQL+`
+	`, append([]any{m.provider.Name(), m.provider.Dims()}, filterArgs...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +254,54 @@ func (m *Manager) vectorSearch(query string, limit int) ([]scoredResult, error)
 	return results, nil
 }
 
-func mergeResults(bm25, vec []scoredResult, limit int) []scoredResult {
+// annResults loads the chunks of index hits, keeping their order.
+func (m *Manager) annResults(hits []annHit) ([]scoredResult, error) {
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.id
+	}
+	placeholders, args := inPlaceholders(ids)
+	rows, err := m.db.Query(`
+		SELECT c.id, c.start_line, c.end_line, c.text, f.path
+		FROM chunks c
+		JOIN files f ON c.file_id = f.id
+		WHERE c.id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]scoredResult, len(hits))
+	for rows.Next() {
+		var chunkID int64
+		var r scoredResult
+		if err := rows.Scan(&chunkID, &r.StartLine, &r.EndLine, &r.Snippet, &r.Path); err != nil {
+			continue
+		}
+		r.Snippet = truncate(r.Snippet, snippetMaxChars)
+		byID[chunkID] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]scoredResult, 0, len(hits))
+	for _, h := range hits {
+		if r, ok := byID[h.id]; ok {
+			r.vectorScore = h.score
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// mergeResults combines keyword and vector results into one list, scoring
+// each by the weighted sum of its normalized scores.
+func mergeResults(bm25, vec []scoredResult, limit int, keywordWeight, vectorWeight float32) []scoredResult {
 	seen := map[string]int{} // key -> index in merged
 	var merged []scoredResult
 
@@ -206,18 +309,24 @@ func mergeResults(bm25, vec []scoredResult, limit int) []scoredResult {
 	bm25Norm := normalizeScores(bm25, true)
 	vecNorm := normalizeScores(vec, false)
 
+	breakdown := ScoreBreakdown{KeywordWeight: keywordWeight, VectorWeight: vectorWeight, Decay: 1}
 	for _, r := range bm25Norm {
 		key := fmt.Sprintf("%s:%d", r.Path, r.StartLine)
-		r.Score = 0.4 * r.bm25Score
+		r.Breakdown = breakdown
+		r.Breakdown.Keyword = r.bm25Score
+		r.Score = keywordWeight * r.bm25Score
 		seen[key] = len(merged)
 		merged = append(merged, r)
 	}
 	for _, r := range vecNorm {
 		key := fmt.Sprintf("%s:%d", r.Path, r.StartLine)
 		if idx, ok := seen[key]; ok {
-			merged[idx].Score += 0.6 * r.vectorScore
+			merged[idx].Breakdown.Vector = r.vectorScore
+			merged[idx].Score += vectorWeight * r.vectorScore
 		} else {
-			r.Score = 0.6 * r.vectorScore
+			r.Breakdown = breakdown
+			r.Breakdown.Vector = r.vectorScore
+			r.Score = vectorWeight * r.vectorScore
 			merged = append(merged, r)
 		}
 	}
@@ -261,13 +370,22 @@ func normalizeScores(results []scoredResult, isBM25 bool) []scoredResult {
 	return out
 }
 
-func applyTemporalDecay(results []scoredResult, m *Manager) []scoredResult {
+// applyTemporalDecay lowers the scores of older files, halving them every
+// opts.DecayHalfLifeDays.
+func applyTemporalDecay(results []scoredResult, m *Manager, opts SearchOpts) []scoredResult {
+	halfLife := opts.DecayHalfLifeDays
+	if halfLife == 0 {
+		halfLife = defaultDecayHalfLifeDays
+	}
+	if halfLife < 0 {
+		return results
+	}
 	now := float64(time.Now().UnixMilli())
 	for i := range results {
-		// Only apply temporal decay to dated files (YYYY-MM-DD.md).
+		// By default only apply temporal decay to dated files (YYYY-MM-DD.md).
 		// Evergreen files like global.md, MEMORY.md, hivemind-project.md
 		// contain stable facts that remain relevant forever and must not decay.
-		if !datedFileRe.MatchString(filepath.Base(results[i].Path)) {
+		if !opts.DecayAllFiles && !datedFileRe.MatchString(filepath.Base(results[i].Path)) {
 			continue
 		}
 		var mtime int64
@@ -275,9 +393,10 @@ func applyTemporalDecay(results []scoredResult, m *Manager) []scoredResult {
 		if err := row.Scan(&mtime); err != nil {
 			continue
 		}
-		ageDays := (now - float64(mtime)) / 86_400_000
-		decay := math.Exp(-0.01 * ageDays)
-		results[i].Score *= float32(decay)
+		ageDays := max((now-float64(mtime))/86_400_000, 0)
+		decay := float32(math.Exp(-math.Ln2 * ageDays / halfLife))
+		results[i].Score *= decay
+		results[i].Breakdown.Decay = decay
 	}
 	// Re-sort after decay.
 	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
@@ -299,16 +418,20 @@ var ftsStopWords = map[string]bool{
 	"up": true, "out": true, "about": true, "work": true, "use": true, "get": true,
 }
 
-// ftsQuery sanitizes a query string for FTS5 MATCH syntax.
+// ftsQuery sanitizes a query string for FTS5 MATCH syntax, matching the
+// words as mode says.
 // Stop words are stripped so natural-language queries like "projects I work on"
 // reduce to meaningful tokens ("projects") rather than flooding FTS5 with
-// high-frequency words that score poorly.
+// high-frequency words that score poorly. Phrases keep them.
 // If stripping removes all tokens, the original query is used as-is.
-func ftsQuery(q string) string {
+func ftsQuery(q string, mode QueryMode) string {
 	tokens := strings.Fields(q)
 	if len(tokens) == 0 {
 		return q
 	}
+	if mode == QueryPhrase {
+		return `"` + strings.ReplaceAll(strings.Join(tokens, " "), `"`, "") + `"`
+	}
 	var meaningful []string
 	for _, t := range tokens {
 		if !ftsStopWords[strings.ToLower(t)] {
@@ -321,6 +444,12 @@ func ftsQuery(q string) string {
 	quoted := make([]string, len(meaningful))
 	for i, t := range meaningful {
 		quoted[i] = `"` + strings.ReplaceAll(t, `"`, "") + `"`
+		if mode == QueryPrefix {
+			quoted[i] += "*"
+		}
+	}
+	if mode == QueryAll {
+		return strings.Join(quoted, " AND ")
 	}
 	return strings.Join(quoted, " OR ")
 }
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// searchFilter restricts a search to the indexed files matching a condition
+// on the files table.
+type searchFilter struct {
+	active bool
+	cond   string
+	args   []any
+}
+
+// sql returns the condition restricting column to the filter's files, to
+// append to a WHERE clause, and its arguments.
+func (f searchFilter) sql(column string) (string, []any) {
+	if !f.active {
+		return "", nil
+	}
+	return " AND " + column + " IN (SELECT id FROM files WHERE " + f.cond + ")", f.args
+}
+
+// newSearchFilter builds the filter for the path, tag and field filters of
+// opts. Tags and field values are looked up in file_fields, which syncFile
+// fills from each file's frontmatter.
+func newSearchFilter(opts SearchOpts) searchFilter {
+	var conds []string
+	var args []any
+	if opts.PathPrefix != "" {
+		prefix := strings.TrimPrefix(filepath.ToSlash(opts.PathPrefix), "./")
+		conds = append(conds, `path LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	hasField := func(key, value string) {
+		conds = append(conds, "EXISTS (SELECT 1 FROM file_fields ff WHERE ff.file_id = files.id AND ff.key = ? AND ff.value = ?)")
+		args = append(args, key, strings.ToLower(value))
+	}
+	for _, tag := range opts.Tags {
+		hasField("tags", tag)
+	}
+	// Sorted so the same filters build the same query.
+	keys := make([]string, 0, len(opts.Fields))
+	for key := range opts.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := key
+		if name == "read_only" {
+			name = "read-only"
+		}
+		hasField(name, opts.Fields[key])
+	}
+	if len(conds) == 0 {
+		return searchFilter{}
+	}
+	return searchFilter{active: true, cond: strings.Join(conds, " AND "), args: args}
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// frontmatterFields returns the values of fm's fields by their YAML name,
+// lowercased. Keys of the metadata map are named "metadata.<key>", and each
+// item of a list is a value of its own. read-only is always present, so every
+// indexed file has at least one row in file_fields.
+func frontmatterFields(fm Frontmatter) map[string][]string {
+	fields := map[string][]string{
+		"read-only": {fmt.Sprint(fm.ReadOnly)},
+	}
+	add := func(key string, v any) {
+		fields[key] = append(fields[key], fieldValues(v)...)
+	}
+	if fm.Description != "" {
+		add("description", fm.Description)
+	}
+	if fm.Source != "" {
+		add("source", fm.Source)
+	}
+	if fm.Limit != 0 {
+		add("limit", fm.Limit)
+	}
+	if len(fm.Tags) > 0 {
+		add("tags", fm.Tags)
+	}
+	for key, v := range fm.Metadata {
+		add("metadata."+key, v)
+	}
+	for key, v := range fm.Extra {
+		add(key, v)
+	}
+	return fields
+}
+
+// fieldValues flattens v, or for lists its items, into lowercased strings.
+func fieldValues(v any) []string {
+	switch t := v.(type) {
+	case []string:
+		out := make([]string, len(t))
+		for i, item := range t {
+			out[i] = strings.ToLower(item)
+		}
+		return out
+	case []any:
+		var out []string
+		for _, item := range t {
+			out = append(out, fieldValues(item)...)
+		}
+		return out
+	}
+	return []string{strings.ToLower(fmt.Sprint(v))}
+}
+
+// storeFields records the frontmatter of a file for search filters.
+func storeFields(db *sql.DB, fileID int64, fm Frontmatter) error {
+	for key, values := range frontmatterFields(fm) {
+		for _, v := range values {
+			if _, err := db.Exec("INSERT INTO file_fields (file_id, key, value) VALUES (?, ?, ?)", fileID, key, v); err != nil {
+				return fmt.Errorf("insert field: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// indexFields fills file_fields for files indexed before it existed. Must be
+// called before the Manager is shared.
+func (m *Manager) indexFields() error {
+	rows, err := m.db.Query("SELECT id, path FROM files WHERE id NOT IN (SELECT file_id FROM file_fields)")
+	if err != nil {
+		return err
+	}
+	type file struct {
+		id   int64
+		path string
+	}
+	var files []file
+	for rows.Next() {
+		var f file
+		if err := rows.Scan(&f.id, &f.path); err != nil {
+			rows.Close()
+			return err
+		}
+		files = append(files, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(m.dir, f.path))
+		if err != nil {
+			continue
+		}
+		fm, _ := ParseFrontmatter(string(data))
+		if err := storeFields(m.db, f.id, fm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -36,7 +36,7 @@ func TestApplyTemporalDecay_DatedFileDecays(t *testing.T) {
 		{SearchResult: SearchResult{Path: "2020-01-01.md", Score: original}},
 	}
 
-	decayed := applyTemporalDecay(results, mgr)
+	decayed := applyTemporalDecay(results, mgr, SearchOpts{})
 	require.Len(t, decayed, 1)
 	assert.Less(t, decayed[0].Score, original,
 		"dated file score should be reduced by temporal decay")
@@ -58,7 +58,7 @@ func TestApplyTemporalDecay_DatedFileWithDirPrefix(t *testing.T) {
 		{SearchResult: SearchResult{Path: relPath, Score: original}},
 	}
 
-	decayed := applyTemporalDecay(results, mgr)
+	decayed := applyTemporalDecay(results, mgr, SearchOpts{})
 	require.Len(t, decayed, 1)
 	assert.Less(t, decayed[0].Score, original,
 		"dated file under a directory prefix should be reduced by temporal decay")
@@ -81,7 +81,7 @@ func TestApplyTemporalDecay_EvergreenFileExempt(t *testing.T) {
 		{SearchResult: SearchResult{Path: "global.md", Score: original}},
 	}
 
-	after := applyTemporalDecay(results, mgr)
+	after := applyTemporalDecay(results, mgr, SearchOpts{})
 	require.Len(t, after, 1)
 	assert.Equal(t, original, after[0].Score,
 		"evergreen file score must not be changed by temporal decay")
@@ -114,7 +114,7 @@ func TestApplyTemporalDecay_EvergreenFilesExempt_Multiple(t *testing.T) {
 		})
 	}
 
-	after := applyTemporalDecay(results, mgr)
+	after := applyTemporalDecay(results, mgr, SearchOpts{})
 	require.Len(t, after, len(evergreenFiles))
 	for _, r := range after {
 		assert.Equal(t, original, r.Score,
@@ -149,3 +149,154 @@ func TestDatedFileRe(t *testing.T) {
 		assert.False(t, datedFileRe.MatchString(name), "expected %q NOT to match datedFileRe", name)
 	}
 }
+
+func TestApplyTemporalDecay_HalfLife(t *testing.T) {
+	mgr := newTestManager(t)
+	require.NoError(t, mgr.Write("Journal entry.", "2026-01-01.md"))
+	require.NoError(t, mgr.Write("Stable fact.", "global.md"))
+	tenDaysAgo := time.Now().Add(-10 * 24 * time.Hour)
+	setFileMtime(t, mgr, "2026-01-01.md", tenDaysAgo)
+	setFileMtime(t, mgr, "global.md", tenDaysAgo)
+
+	results := func() []scoredResult {
+		return []scoredResult{
+			{SearchResult: SearchResult{Path: "2026-01-01.md", Score: 1, Breakdown: ScoreBreakdown{Decay: 1}}},
+			{SearchResult: SearchResult{Path: "global.md", Score: 1, Breakdown: ScoreBreakdown{Decay: 1}}},
+		}
+	}
+	byPath := func(rs []scoredResult) map[string]SearchResult {
+		out := make(map[string]SearchResult)
+		for _, r := range rs {
+			out[r.Path] = r.SearchResult
+		}
+		return out
+	}
+
+	got := byPath(applyTemporalDecay(results(), mgr, SearchOpts{DecayHalfLifeDays: 10}))
+	assert.InDelta(t, 0.5, got["2026-01-01.md"].Score, 0.01, "score halves after one half-life")
+	assert.InDelta(t, 0.5, got["2026-01-01.md"].Breakdown.Decay, 0.01)
+	assert.Equal(t, float32(1), got["global.md"].Score)
+
+	got = byPath(applyTemporalDecay(results(), mgr, SearchOpts{DecayHalfLifeDays: 10, DecayAllFiles: true}))
+	assert.InDelta(t, 0.5, got["global.md"].Score, 0.01, "DecayAllFiles decays evergreen files too")
+
+	got = byPath(applyTemporalDecay(results(), mgr, SearchOpts{DecayHalfLifeDays: -1}))
+	assert.Equal(t, float32(1), got["2026-01-01.md"].Score, "a negative half-life disables decay")
+}
+
+func TestFtsQuery_Modes(t *testing.T) {
+	tests := []struct {
+		mode QueryMode
+		want string
+	}{
+		{QueryAny, `"deploy" OR "pipeline"`},
+		{QueryAll, `"deploy" AND "pipeline"`},
+		{QueryPrefix, `"deploy"* OR "pipeline"*`},
+		{QueryPhrase, `"the deploy pipeline"`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ftsQuery(`the deploy "pipeline"`, tt.mode), tt.mode)
+	}
+}
+
+func TestSearch_QueryModes(t *testing.T) {
+	mgr := newTestManager(t)
+	require.NoError(t, mgr.WriteFile("a.md", "The deploy pipeline runs nightly.", ""))
+	require.NoError(t, mgr.WriteFile("b.md", "Pipeline docs and a deploy checklist.", ""))
+	require.NoError(t, mgr.WriteFile("c.md", "Deployment notes.", ""))
+
+	paths := func(opts SearchOpts) []string {
+		results, err := mgr.Search("deploy pipeline", opts)
+		require.NoError(t, err)
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Path)
+		}
+		return out
+	}
+	assert.ElementsMatch(t, []string{"a.md", "b.md"}, paths(SearchOpts{Mode: QueryAll}))
+	assert.Equal(t, []string{"a.md"}, paths(SearchOpts{Mode: QueryPhrase}))
+	assert.Contains(t, paths(SearchOpts{Mode: QueryPrefix}), "c.md")
+
+	_, err := mgr.Search("deploy", SearchOpts{Mode: "fuzzy"})
+	assert.Error(t, err)
+}
+
+func TestSearch_Filters(t *testing.T) {
+	mgr := newTestManager(t)
+	require.NoError(t, mgr.WriteFile("repos/api/notes.md",
+		"---\ntags: [infra, release]\nsource: standup\nmetadata:\n  team: platform\n---\nRelease steps for the api.", ""))
+	require.NoError(t, mgr.WriteFile("repos/web/notes.md",
+		"---\ntags: [frontend]\n---\nRelease steps for the web app.", ""))
+	require.NoError(t, mgr.WriteFile("global.md", "Release day is Thursday.", ""))
+
+	paths := func(opts SearchOpts) []string {
+		results, err := mgr.Search("release", opts)
+		require.NoError(t, err)
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Path)
+		}
+		return out
+	}
+	assert.Len(t, paths(SearchOpts{}), 3)
+	assert.ElementsMatch(t, []string{"repos/api/notes.md", "repos/web/notes.md"}, paths(SearchOpts{PathPrefix: "repos/"}))
+	assert.Equal(t, []string{"repos/api/notes.md"}, paths(SearchOpts{Tags: []string{"Infra", "release"}}))
+	assert.Equal(t, []string{"repos/api/notes.md"}, paths(SearchOpts{Fields: map[string]string{"source": "standup"}}))
+	assert.Equal(t, []string{"repos/api/notes.md"}, paths(SearchOpts{Fields: map[string]string{"metadata.team": "platform"}}))
+	assert.Empty(t, paths(SearchOpts{PathPrefix: "repos/", Tags: []string{"missing"}}))
+	assert.Empty(t, paths(SearchOpts{PathPrefix: "repos_"}), "LIKE wildcards in the prefix are literal")
+	assert.Len(t, paths(SearchOpts{Fields: map[string]string{"read_only": "false"}}), 3)
+
+	// Rewriting a file replaces its fields.
+	require.NoError(t, mgr.WriteFile("repos/web/notes.md",
+		"---\ntags: [infra]\n---\nRelease steps for the web app.", ""))
+	assert.ElementsMatch(t, []string{"repos/api/notes.md", "repos/web/notes.md"}, paths(SearchOpts{Tags: []string{"infra"}}))
+	assert.Empty(t, paths(SearchOpts{Tags: []string{"frontend"}}))
+}
+
+func TestSearch_FiltersOnStoreIndexedWithoutFields(t *testing.T) {
+	dir := t.TempDir()
+	mgr, err := NewManagerWithOptions(dir, nil, ManagerOptions{})
+	require.NoError(t, err)
+	require.NoError(t, mgr.WriteFile("notes.md", "---\ntags: [infra]\n---\nRelease steps.", ""))
+	_, err = mgr.db.Exec("DELETE FROM file_fields")
+	require.NoError(t, err)
+	mgr.Close()
+
+	mgr, err = NewManagerWithOptions(dir, nil, ManagerOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { mgr.Close() })
+	results, err := mgr.Search("release", SearchOpts{Tags: []string{"infra"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "notes.md", results[0].Path)
+}
+
+func TestSearch_WeightsAndBreakdown(t *testing.T) {
+	mgr, err := NewManagerWithOptions(t.TempDir(), wordProvider{}, ManagerOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { mgr.Close() })
+	require.NoError(t, mgr.WriteFile("tools.md", "# Tools\nThe deploy pipeline uses terraform and helm charts.", ""))
+	require.NoError(t, mgr.WriteFile("people.md", "# People\nAlice reviews frontend changes on fridays.", ""))
+
+	results, err := mgr.Search("terraform", SearchOpts{MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	b := results[0].Breakdown
+	assert.Equal(t, float32(0.4), b.KeywordWeight)
+	assert.Equal(t, float32(0.6), b.VectorWeight)
+	assert.Equal(t, float32(1), b.Decay)
+	assert.InDelta(t, b.KeywordWeight*b.Keyword+b.VectorWeight*b.Vector, results[0].Score, 1e-6)
+
+	// Weighting out the vector score leaves only keyword matches.
+	results, err = mgr.Search("terraform", SearchOpts{KeywordWeight: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "tools.md", results[0].Path)
+	assert.Zero(t, results[0].Breakdown.Vector)
+	assert.Equal(t, results[0].Breakdown.Keyword, results[0].Score)
+
+	_, err = mgr.Search("terraform", SearchOpts{VectorWeight: -1})
+	assert.Error(t, err)
+}
@@ -50,6 +50,17 @@ type SearchResult struct {
 	EndLine   int
 	Score     float32 // 0.0–1.0 combined score
 	Snippet   string  // up to 700 chars of matched text
+	Breakdown ScoreBreakdown
+}
+
+// ScoreBreakdown explains a search result's Score: the weighted sum of its
+// keyword and vector scores, multiplied by its temporal decay.
+type ScoreBreakdown struct {
+	Keyword       float32 // normalized BM25 score, 0.0–1.0
+	Vector        float32 // normalized cosine similarity, 0.0–1.0
+	KeywordWeight float32
+	VectorWeight  float32
+	Decay         float32 // 1.0 when the file doesn't decay
 }
 
 // FileInfo is metadata about one memory file, returned by List.
@@ -64,8 +75,41 @@ type FileInfo struct {
 type SearchOpts struct {
 	MaxResults int     // default 10
 	MinScore   float32 // default 0.0 (no filter)
+
+	// KeywordWeight and VectorWeight weigh the normalized BM25 and vector
+	// scores in the combined score. Both zero means the defaults, 0.4 and 0.6.
+	KeywordWeight float32
+	VectorWeight  float32
+
+	// DecayHalfLifeDays is the age at which temporal decay halves a score.
+	// Zero means the default of about 69 days; negative disables decay.
+	DecayHalfLifeDays float64
+	// DecayAllFiles decays every file by its modification time instead of
+	// only dated journal files (YYYY-MM-DD.md).
+	DecayAllFiles bool
+
+	// Mode selects how keyword search matches the query words; default QueryAny.
+	Mode QueryMode
+
+	// PathPrefix, Tags and Fields restrict the search to files under the path
+	// prefix, tagged with every tag, and whose frontmatter fields have the
+	// given values. Fields keys name top-level fields or "metadata.<key>".
+	// Tags and values are compared case-insensitively.
+	PathPrefix string
+	Tags       []string
+	Fields     map[string]string
 }
 
+// QueryMode selects how keyword search matches the words of a query.
+type QueryMode string
+
+const (
+	QueryAny    QueryMode = "any"    // any of the words (default)
+	QueryAll    QueryMode = "all"    // all of the words
+	QueryPhrase QueryMode = "phrase" // the words in order
+	QueryPrefix QueryMode = "prefix" // words starting with any of the words
+)
+
 // EmbeddingProvider abstracts an embedding API.
 type EmbeddingProvider interface {
 	Embed(texts []string) ([][]float32, error)
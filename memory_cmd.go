@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ByteMirror/hivemind/config"
+	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/memory"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	memoryCmd = &cobra.Command{
+		Use:   "memory",
+		Short: "Maintain the IDE-wide memory store",
+	}
+
+	memoryReindexCmd = &cobra.Command{
+		Use:   "reindex",
+		Short: "Re-embed memory chunks after the embedding provider changed",
+		Long: `Re-embed every memory chunk whose vector is missing or comes from another
+embedding provider than the configured one. Vectors of another provider are
+left out of semantic search until they are replaced. The hivemind TUI also
+does this in the background when it starts and when the memory settings
+change.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			mgr, err := memory.NewManagerFromConfig(config.LoadConfig())
+			if err != nil {
+				return err
+			}
+			if mgr == nil {
+				return fmt.Errorf("memory is disabled; enable it in the settings")
+			}
+			defer mgr.Close()
+
+			status, err := mgr.IndexStatus()
+			if err != nil {
+				return err
+			}
+			if status.Dims == 0 {
+				fmt.Println("No embedding provider is configured; keyword search needs no re-index.")
+				return nil
+			}
+			if status.Stale() == 0 {
+				fmt.Printf("All %d chunks are embedded with %s.\n", status.Chunks, status.Provider)
+				return nil
+			}
+
+			// Stop after the current batch on interrupt; finished batches
+			// are kept, and the next run picks up the rest.
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sigs)
+			go func() {
+				if _, ok := <-sigs; ok {
+					mgr.StopReindex()
+				}
+			}()
+
+			fmt.Printf("Re-embedding %d of %d chunks with %s\n", status.Stale(), status.Chunks, status.Provider)
+			err = mgr.Reindex(func(done, total int) {
+				fmt.Printf("\r%d/%d", done, total)
+			})
+			fmt.Println()
+			if err != nil {
+				return err
+			}
+			if status, err = mgr.IndexStatus(); err == nil && status.Stale() > 0 {
+				return fmt.Errorf("%d chunks are still not embedded with %s", status.Stale(), status.Provider)
+			}
+			fmt.Println("Done.")
+			return nil
+		},
+	}
+)
+
+func init() {
+	memoryCmd.AddCommand(memoryReindexCmd)
+}
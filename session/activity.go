@@ -5,6 +5,10 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/agent"
+	"github.com/ByteMirror/hivemind/session/transcript"
 )
 
 // Activity represents what an agent is currently doing.
@@ -17,24 +21,100 @@ type Activity struct {
 	Timestamp time.Time
 }
 
+// UpdateActivity refreshes LastActivity from the agent's transcript, falling
+// back to scraping the pane for agents without one or before it is found.
+func (i *Instance) UpdateActivity() {
+	if r := i.pollTranscript(); r != nil {
+		i.LastActivity = transcriptActivity(r.State())
+		return
+	}
+	if content, err := i.GetPaneContent(); err == nil && content != "" {
+		i.LastActivity = ParseActivity(content, i.Program)
+	}
+}
+
+// TranscriptState returns what the agent's transcript says so far. ok is
+// false if the agent writes no transcript or it wasn't found yet.
+func (i *Instance) TranscriptState() (state transcript.State, ok bool) {
+	i.transcriptMu.Lock()
+	r := i.transcript
+	i.transcriptMu.Unlock()
+	if r == nil || r.Path() == "" {
+		return transcript.State{}, false
+	}
+	return r.State(), true
+}
+
+// transcriptReader returns the reader following the agent's transcript,
+// creating it on first use, or nil if the agent writes none.
+func (i *Instance) transcriptReader() *transcript.Reader {
+	i.transcriptMu.Lock()
+	defer i.transcriptMu.Unlock()
+	if i.transcript == nil {
+		format := agent.For(i.Program).Transcript()
+		if format == nil {
+			return nil
+		}
+		i.transcript = transcript.NewReader(format, i.GetWorkingPath(), i.CreatedAt)
+		i.transcript.CountUsageSince(i.usageBase.UpdatedAt)
+	}
+	return i.transcript
+}
+
+// pollTranscript reads what the agent appended to its transcript. It
+// returns nil if the agent writes none or it wasn't found yet.
+func (i *Instance) pollTranscript() *transcript.Reader {
+	r := i.transcriptReader()
+	if r == nil {
+		return nil
+	}
+	if _, err := r.Poll(); err != nil {
+		log.WarningLog.Printf("transcript[%s]: %v", i.Title, err)
+	}
+	if r.Path() == "" {
+		return nil
+	}
+	return r
+}
+
+// closeTranscript stops following the agent's transcript.
+func (i *Instance) closeTranscript() {
+	i.transcriptMu.Lock()
+	defer i.transcriptMu.Unlock()
+	if i.transcript != nil {
+		i.transcript.Close()
+		i.transcript = nil
+	}
+}
+
+// transcriptActivity derives the current activity from a transcript: the
+// pending tool call, or "thinking" while a turn is in progress.
+func transcriptActivity(s transcript.State) *Activity {
+	if tc := s.LastToolCall(); tc != nil && !tc.Done {
+		detail := strings.TrimSpace(tc.Detail)
+		if tc.File {
+			detail = cleanFilename(detail)
+		}
+		return &Activity{Action: tc.Action, Detail: truncateDetail(detail, 40), Timestamp: time.Now()}
+	}
+	if s.InTurn {
+		return &Activity{Action: "thinking", Timestamp: time.Now()}
+	}
+	return nil
+}
+
 // ansiRegex strips ANSI escape codes from terminal output.
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 
-// claudeSpinnerAction matches Claude's spinner lines like "⠙ Editing src/auth.go"
-// The spinner character varies, so we match any single character before the action word.
-var claudeEditingRegex = regexp.MustCompile(`(?:Editing|Writing)\s+(.+)`)
-var claudeReadingRegex = regexp.MustCompile(`Reading\s+(.+)`)
-var claudeRunningRegex = regexp.MustCompile(`Running\s+(.+)`)
-var claudeSearchingRegex = regexp.MustCompile(`Searching`)
-var claudeShellCmdRegex = regexp.MustCompile(`\$\s+(.+)`)
-
-// aiderEditingRegex matches Aider's editing pattern.
-var aiderEditingRegex = regexp.MustCompile(`Editing\s+(.+)`)
+// shellCmdRegex matches shell commands echoed as "$ cmd", which most agents
+// print when they run one.
+var shellCmdRegex = regexp.MustCompile(`\$\s+(.+)`)
 
 // ParseActivity parses the pane content to extract the current activity.
-// It scans the last ~30 lines for known patterns. program is the agent name
-// (e.g. "claude", "aider"). Returns nil if no activity is detected.
+// It scans the last ~30 lines for the patterns of program's agent adapter and
+// for generic patterns. Returns nil if no activity is detected.
 func ParseActivity(content string, program string) *Activity {
+	adapter := agent.For(program)
 	clean := ansiRegex.ReplaceAllString(content, "")
 
 	lines := strings.Split(clean, "\n")
@@ -53,13 +133,15 @@ func ParseActivity(content string, program string) *Activity {
 			continue
 		}
 
-		if strings.Contains(strings.ToLower(program), "claude") {
-			if a := parseClaudeLine(line); a != nil {
-				return a
+		if a, ok := adapter.ParseActivity(line); ok {
+			detail := strings.TrimSpace(a.Detail)
+			if a.File {
+				detail = cleanFilename(detail)
 			}
-		} else if strings.Contains(strings.ToLower(program), "aider") {
-			if a := parseAiderLine(line); a != nil {
-				return a
+			return &Activity{
+				Action:    a.Action,
+				Detail:    truncateDetail(detail, 40),
+				Timestamp: time.Now(),
 			}
 		}
 
@@ -72,59 +154,9 @@ func ParseActivity(content string, program string) *Activity {
 	return nil
 }
 
-func parseClaudeLine(line string) *Activity {
-	if m := claudeEditingRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "editing",
-			Detail:    truncateDetail(cleanFilename(m[1]), 40),
-			Timestamp: time.Now(),
-		}
-	}
-	if m := claudeReadingRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "reading",
-			Detail:    truncateDetail(cleanFilename(m[1]), 40),
-			Timestamp: time.Now(),
-		}
-	}
-	if m := claudeRunningRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "running",
-			Detail:    truncateDetail(strings.TrimSpace(m[1]), 40),
-			Timestamp: time.Now(),
-		}
-	}
-	if claudeSearchingRegex.MatchString(line) {
-		return &Activity{
-			Action:    "searching",
-			Detail:    "",
-			Timestamp: time.Now(),
-		}
-	}
-	if m := claudeShellCmdRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "running",
-			Detail:    truncateDetail(strings.TrimSpace(m[1]), 40),
-			Timestamp: time.Now(),
-		}
-	}
-	return nil
-}
-
-func parseAiderLine(line string) *Activity {
-	if m := aiderEditingRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "editing",
-			Detail:    truncateDetail(cleanFilename(m[1]), 40),
-			Timestamp: time.Now(),
-		}
-	}
-	return nil
-}
-
 func parseGenericLine(line string) *Activity {
 	// Try to detect shell commands from common prompt patterns.
-	if m := claudeShellCmdRegex.FindStringSubmatch(line); m != nil {
+	if m := shellCmdRegex.FindStringSubmatch(line); m != nil {
 		return &Activity{
 			Action:    "running",
 			Detail:    truncateDetail(strings.TrimSpace(m[1]), 40),
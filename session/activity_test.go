@@ -3,6 +3,8 @@ package session
 import (
 	"strings"
 	"testing"
+
+	"github.com/ByteMirror/hivemind/session/transcript"
 )
 
 func TestParseActivity_ClaudeEditing(t *testing.T) {
@@ -228,3 +230,32 @@ func TestCleanFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestTranscriptActivity(t *testing.T) {
+	s := transcript.State{
+		InTurn: true,
+		ToolCalls: []transcript.ToolCall{
+			{ID: "1", Action: transcript.ActionRunning, Detail: "go test ./...", Done: true},
+			{ID: "2", Action: transcript.ActionEditing, Detail: "/repo/src/auth.go", File: true},
+		},
+	}
+
+	a := transcriptActivity(s)
+	if a == nil {
+		t.Fatal("expected activity, got nil")
+	}
+	if a.Action != "editing" || a.Detail != "auth.go" {
+		t.Errorf("expected 'editing auth.go', got %q %q", a.Action, a.Detail)
+	}
+
+	s.ToolCalls[1].Done = true
+	a = transcriptActivity(s)
+	if a == nil || a.Action != "thinking" {
+		t.Errorf("expected 'thinking' between tool calls, got %+v", a)
+	}
+
+	s.InTurn = false
+	if a := transcriptActivity(s); a != nil {
+		t.Errorf("expected no activity after the turn, got %+v", a)
+	}
+}
@@ -0,0 +1,167 @@
+// Package agent describes how hivemind drives each CLI agent program: how it
+// is launched, how the initial prompt reaches it, what its startup and
+// permission screens look like, how its output maps to activity and how the
+// hivemind MCP server is registered with it.
+//
+// Claude, Codex, Gemini, Aider and Amp are built in. Other agents are declared
+// in the config file as Specs and installed with Configure.
+package agent
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ByteMirror/hivemind/session/transcript"
+)
+
+// ErrMCPUnsupported is returned by RegisterMCP for agents hivemind cannot
+// register MCP servers with.
+var ErrMCPUnsupported = errors.New("agent does not support MCP registration")
+
+// AgentAdapter is the program-specific behavior of an agent.
+type AgentAdapter interface {
+	// Name is the agent's name, e.g. "claude".
+	Name() string
+	// Matches reports whether the program command line runs this agent.
+	Matches(program string) bool
+	// LaunchArgs returns the arguments appended to the program's command line.
+	// skipPermissions asks the agent to run without permission prompts.
+	LaunchArgs(skipPermissions bool) []string
+	// InitialPromptArgs returns the arguments that pass prompt on the command
+	// line, or nil if the prompt must be typed into the pane once the agent
+	// has started.
+	InitialPromptArgs(prompt string) []string
+	// StartupScreen returns the screen to wait for and dismiss after launch,
+	// or nil if the agent needs no startup handling.
+	StartupScreen(skipPermissions bool) *StartupScreen
+	// PermissionMarker returns text shown only while the agent waits for a
+	// permission decision, or "" if prompts are not detected.
+	PermissionMarker() string
+	// PermissionKeys returns the keystrokes that approve or deny a permission
+	// prompt.
+	PermissionKeys(approve bool) []byte
+	// ParseActivity parses one line of pane output.
+	ParseActivity(line string) (Activity, bool)
+	// RegisterMCP registers server with the agent for the project in dir. It
+	// returns ErrMCPUnsupported if the agent has no way to register one.
+	RegisterMCP(dir string, server MCPServer) error
+	// UnregisterMCP removes the server registered as name for dir.
+	UnregisterMCP(dir, name string) error
+	// MCPConfigFile returns the project config file, relative to dir, that
+	// RegisterMCP writes, or "" if the agent registers servers another way.
+	// The agent reads the file when it starts.
+	MCPConfigFile() string
+	// Transcript returns the format of the session transcripts the agent
+	// writes, or nil if it writes none hivemind can read.
+	Transcript() transcript.Format
+}
+
+// StartupScreen is a screen an agent may show right after launch, such as a
+// folder trust dialog.
+type StartupScreen struct {
+	// Text identifies the screen. When empty, the session only waits for the
+	// agent to produce output.
+	Text string
+	// Keys dismiss the screen.
+	Keys []byte
+	// MaxWait bounds how long to wait for the agent to start.
+	MaxWait time.Duration
+}
+
+// Activity is what an agent is doing according to a line of its output.
+type Activity struct {
+	Action string
+	Detail string
+	// File is set when Detail is a file path.
+	File bool
+}
+
+// MCPServer is an MCP server to register with an agent.
+type MCPServer struct {
+	// Name is unique per instance so agents sharing a worktree don't
+	// overwrite each other's registration.
+	Name    string
+	Command string
+	Env     map[string]string
+}
+
+var (
+	mu sync.RWMutex
+	// configured holds the adapters declared in the config file. They are
+	// matched before the built-in ones so users can override those.
+	configured []AgentAdapter
+)
+
+// builtins are the adapters hivemind ships with.
+var builtins = []AgentAdapter{
+	newClaude(),
+	newSpecAdapter(codexSpec),
+	newSpecAdapter(aiderSpec),
+	newSpecAdapter(geminiSpec),
+	newSpecAdapter(ampSpec),
+}
+
+// For returns the adapter for program. Programs no adapter matches get a
+// generic adapter with no program-specific behavior.
+func For(program string) AgentAdapter {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, a := range configured {
+		if a.Matches(program) {
+			return a
+		}
+	}
+	for _, a := range builtins {
+		if a.Matches(program) {
+			return a
+		}
+	}
+	return newSpecAdapter(Spec{Name: programName(program)})
+}
+
+// Configure installs the agents declared in the config file, replacing any
+// installed before. Invalid specs are skipped and reported in the error.
+func Configure(specs []Spec) error {
+	adapters := make([]AgentAdapter, 0, len(specs))
+	var errs []error
+	for _, s := range specs {
+		if err := s.validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		adapters = append(adapters, newSpecAdapter(s))
+	}
+
+	mu.Lock()
+	configured = adapters
+	mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Names returns the names of the built-in and configured agents.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	seen := make(map[string]bool)
+	var names []string
+	for _, a := range append(append([]AgentAdapter{}, builtins...), configured...) {
+		if !seen[a.Name()] {
+			seen[a.Name()] = true
+			names = append(names, a.Name())
+		}
+	}
+	return names
+}
+
+// programName returns the executable name of a program command line, e.g.
+// "claude" for "/usr/local/bin/claude --model sonnet".
+func programName(program string) string {
+	parts := strings.Fields(program)
+	if len(parts) == 0 {
+		return ""
+	}
+	return filepath.Base(parts[0])
+}
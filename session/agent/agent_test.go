@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForMatchesProgram(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		want    string
+	}{
+		{name: "bare command", program: "claude", want: "claude"},
+		{name: "path command", program: "/usr/local/bin/claude", want: "claude"},
+		{name: "command with args", program: "claude --model sonnet", want: "claude"},
+		{name: "path with args", program: "/opt/homebrew/bin/claude --model sonnet", want: "claude"},
+		{name: "codex", program: "codex --model gpt-5", want: "codex"},
+		{name: "aider", program: "aider --model ollama_chat/gemma3:1b", want: "aider"},
+		{name: "unknown", program: "goose session", want: "goose"},
+		{name: "empty", program: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, For(tt.program).Name())
+		})
+	}
+}
+
+func TestBuiltinAdapters(t *testing.T) {
+	claude := For("claude")
+	assert.Equal(t, []string{"--dangerously-skip-permissions"}, claude.LaunchArgs(true))
+	assert.Empty(t, claude.LaunchArgs(false))
+	assert.Equal(t, []string{"-p", "fix the tests"}, claude.InitialPromptArgs("fix the tests"))
+	assert.Equal(t, "Do you trust the files in this folder?", claude.StartupScreen(false).Text)
+	assert.Empty(t, claude.StartupScreen(true).Text, "no trust dialog when skipping permissions")
+	assert.Equal(t, []byte{0x1B}, claude.PermissionKeys(false))
+
+	act, ok := claude.ParseActivity("⠙ Editing src/auth.go")
+	require.True(t, ok)
+	assert.Equal(t, Activity{Action: "editing", Detail: "src/auth.go", File: true}, act)
+
+	aider := For("aider")
+	assert.Empty(t, aider.LaunchArgs(true), "aider has no skip-permissions flag")
+	assert.Nil(t, aider.InitialPromptArgs("fix the tests"), "aider's prompt is typed")
+	assert.Equal(t, []byte("n\r"), aider.PermissionKeys(false))
+	assert.Equal(t, []byte{0x0D}, aider.PermissionKeys(true))
+
+	generic := For("goose")
+	assert.Nil(t, generic.StartupScreen(false))
+	assert.Empty(t, generic.PermissionMarker())
+	assert.ErrorIs(t, generic.RegisterMCP(t.TempDir(), MCPServer{}), ErrMCPUnsupported)
+
+	assert.Empty(t, claude.MCPConfigFile(), "claude registers through its CLI")
+	assert.Equal(t, ".codex/config.toml", For("codex").MCPConfigFile())
+	assert.Equal(t, ".gemini/settings.json", For("gemini").MCPConfigFile())
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(nil) })
+
+	err := Configure([]Spec{
+		{
+			Name:              "goose",
+			InitialPromptArgs: []string{"--text", "{prompt}"},
+			PermissionMarker:  "Allow this tool call?",
+			Activity:          []ActivityPattern{{Action: "running", Pattern: `^> (.+)`}},
+		},
+		{Name: "codex", Commands: []string{"codex", "codex-dev"}, SkipPermissionsArgs: []string{"--full-auto"}},
+		{Name: "broken", Activity: []ActivityPattern{{Action: "editing", Pattern: `(`}}},
+	})
+	assert.Error(t, err, "invalid specs are reported")
+
+	goose := For("goose session")
+	assert.Equal(t, []string{"--text", "hi"}, goose.InitialPromptArgs("hi"))
+	assert.Equal(t, "Allow this tool call?", goose.PermissionMarker())
+	act, ok := goose.ParseActivity("> cargo build")
+	require.True(t, ok)
+	assert.Equal(t, Activity{Action: "running", Detail: "cargo build"}, act)
+
+	assert.Equal(t, []string{"--full-auto"}, For("codex-dev").LaunchArgs(true), "configured agents override built-ins")
+	assert.Equal(t, "broken", For("broken").Name())
+	assert.Nil(t, For("broken").StartupScreen(false), "invalid specs are not installed")
+	assert.Contains(t, Names(), "goose")
+	assert.Contains(t, Names(), "claude")
+}
+
+func TestSpecValidate(t *testing.T) {
+	assert.Error(t, Spec{}.validate())
+	assert.Error(t, Spec{Name: "x", MCPAddCommand: []string{"x", "mcp", "add", "{name}"}}.validate())
+	assert.Error(t, Spec{Name: "x", MCPAddCommand: []string{"x", "{env}", "{command}"}}.validate())
+	assert.NoError(t, claudeSpec.validate())
+}
+
+func TestMCPAddArgs(t *testing.T) {
+	server := MCPServer{
+		Name:    "hivemind-api",
+		Command: "/bin/hivemind-mcp",
+		Env:     map[string]string{"HIVEMIND_TIER": "3", "HIVEMIND_INSTANCE_ID": "api"},
+	}
+	assert.Equal(t,
+		[]string{"claude", "mcp", "add", "hivemind-api", "-e", "HIVEMIND_INSTANCE_ID=api", "-e", "HIVEMIND_TIER=3", "--", "/bin/hivemind-mcp"},
+		newSpecAdapter(claudeSpec).mcpAddArgs(server))
+}
+
+func TestMCPConfigJSON(t *testing.T) {
+	dir := t.TempDir()
+	settings := filepath.Join(dir, ".gemini", "settings.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(settings), 0755))
+	require.NoError(t, os.WriteFile(settings, []byte(`{"theme": "dark"}`), 0644))
+
+	gemini := For("gemini")
+	server := MCPServer{Name: "hivemind-api", Command: "/bin/hivemind-mcp", Env: map[string]string{"HIVEMIND_TIER": "3"}}
+	require.NoError(t, gemini.RegisterMCP(dir, server))
+
+	var cfg struct {
+		Theme      string `json:"theme"`
+		MCPServers map[string]struct {
+			Command string            `json:"command"`
+			Env     map[string]string `json:"env"`
+		} `json:"mcpServers"`
+	}
+	data, err := os.ReadFile(settings)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &cfg))
+	assert.Equal(t, "dark", cfg.Theme, "existing settings are kept")
+	assert.Equal(t, "/bin/hivemind-mcp", cfg.MCPServers["hivemind-api"].Command)
+	assert.Equal(t, "3", cfg.MCPServers["hivemind-api"].Env["HIVEMIND_TIER"])
+
+	require.NoError(t, gemini.UnregisterMCP(dir, "hivemind-api"))
+	data, err = os.ReadFile(settings)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"theme": "dark"}`, string(data))
+
+	// A file hivemind created is removed along with its directory.
+	other := t.TempDir()
+	require.NoError(t, gemini.RegisterMCP(other, server))
+	require.NoError(t, gemini.UnregisterMCP(other, "hivemind-api"))
+	assert.NoDirExists(t, filepath.Join(other, ".gemini"))
+}
+
+func TestMCPConfigTOML(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, ".codex", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(config), 0755))
+	require.NoError(t, os.WriteFile(config, []byte("model = \"o3\"\n"), 0644))
+
+	codex := For("codex")
+	api := MCPServer{Name: "hivemind-api", Command: "/bin/hivemind-mcp", Env: map[string]string{"HIVEMIND_INSTANCE_ID": "api", "HIVEMIND_TIER": "3"}}
+	web := MCPServer{Name: "hivemind-web", Command: "/bin/hivemind-mcp", Env: map[string]string{"HIVEMIND_INSTANCE_ID": "web \"2\""}}
+	require.NoError(t, codex.RegisterMCP(dir, api))
+	require.NoError(t, codex.RegisterMCP(dir, web))
+	require.NoError(t, codex.RegisterMCP(dir, api), "registering again replaces the table")
+
+	data, err := os.ReadFile(config)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "model = \"o3\"")
+	assert.Equal(t, 1, strings.Count(content, "[mcp_servers.hivemind-api]"))
+	assert.Contains(t, content, `env = { HIVEMIND_INSTANCE_ID = "api", HIVEMIND_TIER = "3" }`)
+	assert.Contains(t, content, `HIVEMIND_INSTANCE_ID = "web \"2\""`)
+
+	require.NoError(t, codex.UnregisterMCP(dir, "hivemind-api"))
+	require.NoError(t, codex.UnregisterMCP(dir, "hivemind-web"))
+	data, err = os.ReadFile(config)
+	require.NoError(t, err)
+	assert.Equal(t, "model = \"o3\"\n", string(data))
+}
+
+func TestMCPConfigExcludedFromGit(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "-q", repo).Run())
+	dir := filepath.Join(repo, "sub")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	codex := For("codex")
+	server := MCPServer{Name: "hivemind-api", Command: "/bin/hivemind-mcp", Env: map[string]string{"HIVEMIND_INSTANCE_TOKEN": "secret"}}
+	require.NoError(t, codex.RegisterMCP(dir, server))
+	require.NoError(t, codex.RegisterMCP(dir, server))
+
+	data, err := os.ReadFile(filepath.Join(repo, ".git", "info", "exclude"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "/sub/.codex/config.toml\n"), "the config file is excluded once")
+
+	out, err := exec.Command("git", "-C", repo, "status", "--porcelain", "--untracked-files=all").Output()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "config.toml")
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/ByteMirror/hivemind/log"
+)
+
+// trustPromptWaitWithSkip bounds Claude's startup wait when permissions are
+// skipped and no trust dialog is shown.
+const trustPromptWaitWithSkip = 10 * time.Second
+
+// claudeSpec describes Claude Code.
+var claudeSpec = Spec{
+	Name:                "claude",
+	SkipPermissionsArgs: []string{"--dangerously-skip-permissions"},
+	InitialPromptArgs:   []string{"-p", "{prompt}"},
+	StartupText:         "Do you trust the files in this folder?",
+	StartupKeys:         "\r",
+	StartupWaitSeconds:  15,
+	PermissionMarker:    "No, and tell Claude what to do differently",
+	// Claude's spinner lines look like "⠙ Editing src/auth.go".
+	Activity: []ActivityPattern{
+		{Action: "editing", Pattern: `(?:Editing|Writing)\s+(.+)`, File: true},
+		{Action: "reading", Pattern: `Reading\s+(.+)`, File: true},
+		{Action: "running", Pattern: `Running\s+(.+)`},
+		{Action: "searching", Pattern: `Searching`},
+	},
+	// Local scope is stored in ~/.claude.json and, unlike a project-scoped
+	// .mcp.json, doesn't ask the user to approve the server. The -e flags
+	// must come after the server name, before the -- separator.
+	MCPAddCommand:    []string{"claude", "mcp", "add", "{name}", "{env}", "--", "{command}"},
+	MCPEnvFlag:       "-e",
+	MCPRemoveCommand: []string{"claude", "mcp", "remove", "{name}"},
+	TranscriptFormat: "claude",
+}
+
+var codexSpec = Spec{
+	Name:                "codex",
+	SkipPermissionsArgs: []string{"--dangerously-bypass-approvals-and-sandbox"},
+	InitialPromptArgs:   []string{"{prompt}"},
+	// Codex reads .codex/config.toml in trusted projects.
+	MCPConfigFile:    ".codex/config.toml",
+	MCPConfigKey:     "mcp_servers",
+	TranscriptFormat: "codex",
+}
+
+// aiderStartup is the documentation prompt Aider and Gemini may show on first
+// launch; "D" declines it.
+const aiderStartup = "Open documentation url for more info"
+
+var geminiSpec = Spec{
+	Name:                "gemini",
+	SkipPermissionsArgs: []string{"--yolo"},
+	InitialPromptArgs:   []string{"--prompt-interactive", "{prompt}"},
+	StartupText:         aiderStartup,
+	StartupKeys:         "D\r",
+	StartupWaitSeconds:  45,
+	PermissionMarker:    "Yes, allow once",
+	MCPConfigFile:       ".gemini/settings.json",
+}
+
+// aiderSpec has no skip-permissions flag: --yes-always would also answer
+// Aider's questions that are not about permissions.
+var aiderSpec = Spec{
+	Name:               "aider",
+	StartupText:        aiderStartup,
+	StartupKeys:        "D\r",
+	StartupWaitSeconds: 45,
+	PermissionMarker:   "(Y)es/(N)o/(D)on't ask again",
+	DenyKeys:           "n\r",
+	Activity: []ActivityPattern{
+		{Action: "editing", Pattern: `Editing\s+(.+)`, File: true},
+	},
+}
+
+var ampSpec = Spec{
+	Name:                "amp",
+	SkipPermissionsArgs: []string{"--dangerously-allow-all"},
+}
+
+// claude adds the trust dialog handling and the .mcp.json fallback to
+// claudeSpec.
+type claude struct{ *specAdapter }
+
+func newClaude() claude { return claude{newSpecAdapter(claudeSpec)} }
+
+// StartupScreen skips the trust dialog when permissions are skipped, since
+// Claude doesn't show it then; the session only waits for output.
+func (c claude) StartupScreen(skipPermissions bool) *StartupScreen {
+	screen := c.specAdapter.StartupScreen(skipPermissions)
+	if skipPermissions {
+		screen.Text = ""
+		screen.MaxWait = trustPromptWaitWithSkip
+	}
+	return screen
+}
+
+// claudeMCPFile is the project-scoped config written when `claude mcp add`
+// fails.
+const claudeMCPFile = ".mcp.json"
+
+// RegisterMCP uses `claude mcp add` and falls back to writing .mcp.json into
+// dir when the CLI fails.
+func (c claude) RegisterMCP(dir string, server MCPServer) error {
+	if err := c.specAdapter.RegisterMCP(dir, server); err != nil {
+		log.WarningLog.Printf("MCP config: claude mcp add failed, writing %s instead: %v", claudeMCPFile, err)
+		return addMCPConfig(dir, claudeMCPFile, "mcpServers", server)
+	}
+	return nil
+}
+
+// UnregisterMCP removes the server from both places RegisterMCP may have put
+// it.
+func (c claude) UnregisterMCP(dir, name string) error {
+	cliErr := c.specAdapter.UnregisterMCP(dir, name)
+	fileErr := removeMCPConfig(dir, claudeMCPFile, "mcpServers", name)
+	if fileErr != nil {
+		return fileErr
+	}
+	if cliErr != nil {
+		log.InfoLog.Printf("MCP config: claude mcp remove failed: %v", cliErr)
+	}
+	return nil
+}
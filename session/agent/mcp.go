@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runCommand runs args in dir, including the command's output in the error.
+func runCommand(dir string, args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w (output: %s)", strings.Join(args[:min(len(args), 3)], " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// addMCPConfig declares server in the config file at path relative to dir,
+// keeping the rest of the file. TOML files get a [key.<name>] table, other
+// files are JSON with the server under key. The file holds the instance's
+// brain token, so it is kept out of git.
+func addMCPConfig(dir, file, key string, server MCPServer) error {
+	path := filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := excludeFromGit(dir, file); err != nil {
+		return fmt.Errorf("exclude %s from git: %w", file, err)
+	}
+	if filepath.Ext(path) == ".toml" {
+		return addMCPTOML(path, key, server)
+	}
+	return updateMCPJSON(dir, path, key, func(servers map[string]any) {
+		servers[server.Name] = map[string]any{"command": server.Command, "env": server.Env}
+	})
+}
+
+// excludeFromGit adds file, relative to dir, to the info/exclude file of the
+// git repository dir is in, unless it is already listed. Outside a git
+// repository it does nothing.
+func excludeFromGit(dir, file string) error {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "info/exclude", "--show-prefix")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil // not a git repository
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	excludePath := lines[0]
+	if !filepath.IsAbs(excludePath) {
+		excludePath = filepath.Join(dir, excludePath)
+	}
+	var prefix string
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+	pattern := "/" + prefix + filepath.ToSlash(filepath.Clean(file))
+
+	data, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	data = append(data, pattern+"\n"...)
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(excludePath, data, 0644)
+}
+
+// removeMCPConfig removes server name from the config file at path relative
+// to dir, deleting the file if nothing else is left in it.
+func removeMCPConfig(dir, file, key, name string) error {
+	path := filepath.Join(dir, file)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if filepath.Ext(path) == ".toml" {
+		return removeMCPTOML(dir, path, name)
+	}
+	return updateMCPJSON(dir, path, key, func(servers map[string]any) {
+		delete(servers, name)
+	})
+}
+
+// updateMCPJSON applies update to the server map under key in the JSON file
+// at path.
+func updateMCPJSON(dir, path, key string, update func(servers map[string]any)) error {
+	doc := make(map[string]any)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	servers, _ := doc[key].(map[string]any)
+	if servers == nil {
+		servers = make(map[string]any)
+	}
+	update(servers)
+	if len(servers) > 0 {
+		doc[key] = servers
+	} else {
+		delete(doc, key)
+	}
+
+	if len(doc) == 0 {
+		return removeFile(dir, path)
+	}
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// tomlMarkers return the comment lines around the table hivemind writes for
+// server name, which let removeMCPTOML find it without parsing TOML.
+func tomlMarkers(name string) (begin, end string) {
+	return "# hivemind-mcp begin " + name, "# hivemind-mcp end " + name
+}
+
+func addMCPTOML(path, key string, server MCPServer) error {
+	if err := removeMCPTOML("", path, server.Name); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	keys := make([]string, 0, len(server.Env))
+	for k := range server.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := make([]string, len(keys))
+	for i, k := range keys {
+		env[i] = fmt.Sprintf("%s = %s", k, tomlString(server.Env[k]))
+	}
+
+	begin, end := tomlMarkers(server.Name)
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(string(data), "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "%s\n[%s.%s]\ncommand = %s\nenv = { %s }\n%s\n",
+		begin, key, server.Name, tomlString(server.Command), strings.Join(env, ", "), end)
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+func removeMCPTOML(dir, path, name string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	begin, end := tomlMarkers(name)
+	var kept []string
+	inside := false
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case line == begin:
+			inside = true
+		case line == end:
+			inside = false
+		case !inside:
+			kept = append(kept, line)
+		}
+	}
+	rest := strings.TrimSpace(strings.Join(kept, "\n"))
+	if rest == "" {
+		return removeFile(dir, path)
+	}
+	return os.WriteFile(path, []byte(rest+"\n"), 0600)
+}
+
+// tomlString quotes s as a TOML basic string. JSON string escapes are a
+// subset of TOML's.
+func tomlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// removeFile removes path and, if it is below dir and left empty, its
+// directory.
+func removeFile(dir, path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if parent := filepath.Dir(path); dir != "" && parent != filepath.Clean(dir) {
+		_ = os.Remove(parent) // fails unless empty
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ByteMirror/hivemind/session/transcript"
+)
+
+// defaultStartupWait bounds the startup wait of specs that set a startup
+// screen but no wait.
+const defaultStartupWait = 15 * time.Second
+
+// Spec declares an agent in the config file.
+type Spec struct {
+	// Name identifies the agent.
+	Name string `json:"name"`
+	// Commands are the executable names that run the agent. Defaults to Name.
+	Commands []string `json:"commands,omitempty"`
+	// SkipPermissionsArgs are appended to the command line of instances that
+	// skip permission prompts.
+	SkipPermissionsArgs []string `json:"skip_permissions_args,omitempty"`
+	// InitialPromptArgs pass the initial prompt on the command line, with
+	// "{prompt}" replaced by the prompt. When empty the prompt is typed into
+	// the pane after startup.
+	InitialPromptArgs []string `json:"initial_prompt_args,omitempty"`
+	// StartupText identifies a screen shown after launch, which StartupKeys
+	// dismiss.
+	StartupText string `json:"startup_text,omitempty"`
+	StartupKeys string `json:"startup_keys,omitempty"`
+	// StartupWaitSeconds bounds how long to wait for the agent to start.
+	StartupWaitSeconds int `json:"startup_wait_seconds,omitempty"`
+	// PermissionMarker is text shown only while the agent waits for a
+	// permission decision.
+	PermissionMarker string `json:"permission_marker,omitempty"`
+	// ApproveKeys and DenyKeys answer a permission prompt. They default to
+	// Enter and Escape.
+	ApproveKeys string `json:"approve_keys,omitempty"`
+	DenyKeys    string `json:"deny_keys,omitempty"`
+	// Activity maps output lines to activities, first match wins.
+	Activity []ActivityPattern `json:"activity,omitempty"`
+	// MCPAddCommand registers an MCP server, run in the project directory.
+	// "{name}" and "{command}" are replaced by the server name and binary,
+	// and "{env}" expands to MCPEnvFlag KEY=VALUE for each variable.
+	MCPAddCommand []string `json:"mcp_add_command,omitempty"`
+	MCPEnvFlag    string   `json:"mcp_env_flag,omitempty"`
+	// MCPRemoveCommand unregisters the server added by MCPAddCommand, with
+	// "{name}" replaced by the server name.
+	MCPRemoveCommand []string `json:"mcp_remove_command,omitempty"`
+	// MCPConfigFile is the agent's project config file, relative to the
+	// project directory, to declare the server in when there is no
+	// MCPAddCommand. A .toml file gets a [<MCPConfigKey>.<name>] table; other
+	// files are JSON with the server under MCPConfigKey, which defaults to
+	// "mcpServers".
+	MCPConfigFile string `json:"mcp_config_file,omitempty"`
+	MCPConfigKey  string `json:"mcp_config_key,omitempty"`
+	// TranscriptFormat names the transcript format the agent writes, "claude"
+	// or "codex". Activity is taken from the transcript when one is found.
+	TranscriptFormat string `json:"transcript_format,omitempty"`
+}
+
+// ActivityPattern maps output lines matching Pattern to Action. The first
+// capture group, if any, is the detail.
+type ActivityPattern struct {
+	Action  string `json:"action"`
+	Pattern string `json:"pattern"`
+	// File is set when the detail is a file path.
+	File bool `json:"file,omitempty"`
+}
+
+func (s Spec) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("agent without a name")
+	}
+	for _, p := range s.Activity {
+		if p.Action == "" {
+			return fmt.Errorf("agent %q: activity pattern %q without an action", s.Name, p.Pattern)
+		}
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("agent %q: activity pattern: %w", s.Name, err)
+		}
+	}
+	if len(s.MCPAddCommand) > 0 && !containsArg(s.MCPAddCommand, "{command}") {
+		return fmt.Errorf("agent %q: mcp_add_command without {command}", s.Name)
+	}
+	if containsArg(s.MCPAddCommand, "{env}") && s.MCPEnvFlag == "" {
+		return fmt.Errorf("agent %q: mcp_add_command uses {env} without mcp_env_flag", s.Name)
+	}
+	if s.TranscriptFormat != "" {
+		if _, err := transcript.FormatNamed(s.TranscriptFormat); err != nil {
+			return fmt.Errorf("agent %q: %w", s.Name, err)
+		}
+	}
+	if filepath.IsAbs(s.MCPConfigFile) || strings.HasPrefix(filepath.Clean(s.MCPConfigFile), "..") {
+		return fmt.Errorf("agent %q: mcp_config_file must be inside the project", s.Name)
+	}
+	return nil
+}
+
+// specAdapter is an AgentAdapter driven by a Spec.
+type specAdapter struct {
+	spec     Spec
+	activity []compiledPattern
+}
+
+type compiledPattern struct {
+	ActivityPattern
+	re *regexp.Regexp
+}
+
+// newSpecAdapter returns the adapter for s, which must be valid.
+func newSpecAdapter(s Spec) *specAdapter {
+	a := &specAdapter{spec: s}
+	for _, p := range s.Activity {
+		a.activity = append(a.activity, compiledPattern{ActivityPattern: p, re: regexp.MustCompile(p.Pattern)})
+	}
+	return a
+}
+
+func (a *specAdapter) Name() string { return a.spec.Name }
+
+func (a *specAdapter) Matches(program string) bool {
+	name := programName(program)
+	if name == "" {
+		return false
+	}
+	if len(a.spec.Commands) == 0 {
+		return name == a.spec.Name
+	}
+	return containsArg(a.spec.Commands, name)
+}
+
+func (a *specAdapter) LaunchArgs(skipPermissions bool) []string {
+	if !skipPermissions {
+		return nil
+	}
+	return a.spec.SkipPermissionsArgs
+}
+
+func (a *specAdapter) InitialPromptArgs(prompt string) []string {
+	if len(a.spec.InitialPromptArgs) == 0 {
+		return nil
+	}
+	args := make([]string, len(a.spec.InitialPromptArgs))
+	for i, arg := range a.spec.InitialPromptArgs {
+		args[i] = strings.ReplaceAll(arg, "{prompt}", prompt)
+	}
+	return args
+}
+
+func (a *specAdapter) StartupScreen(bool) *StartupScreen {
+	if a.spec.StartupText == "" && a.spec.StartupWaitSeconds == 0 {
+		return nil
+	}
+	wait := time.Duration(a.spec.StartupWaitSeconds) * time.Second
+	if wait == 0 {
+		wait = defaultStartupWait
+	}
+	return &StartupScreen{Text: a.spec.StartupText, Keys: []byte(a.spec.StartupKeys), MaxWait: wait}
+}
+
+func (a *specAdapter) PermissionMarker() string { return a.spec.PermissionMarker }
+
+func (a *specAdapter) PermissionKeys(approve bool) []byte {
+	if approve {
+		if a.spec.ApproveKeys != "" {
+			return []byte(a.spec.ApproveKeys)
+		}
+		return []byte{0x0D}
+	}
+	if a.spec.DenyKeys != "" {
+		return []byte(a.spec.DenyKeys)
+	}
+	return []byte{0x1B}
+}
+
+func (a *specAdapter) ParseActivity(line string) (Activity, bool) {
+	for _, p := range a.activity {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		act := Activity{Action: p.Action, File: p.File}
+		if len(m) > 1 {
+			act.Detail = m[1]
+		}
+		return act, true
+	}
+	return Activity{}, false
+}
+
+func (a *specAdapter) RegisterMCP(dir string, server MCPServer) error {
+	switch {
+	case len(a.spec.MCPAddCommand) > 0:
+		return runCommand(dir, a.mcpAddArgs(server))
+	case a.spec.MCPConfigFile != "":
+		return addMCPConfig(dir, a.spec.MCPConfigFile, a.mcpConfigKey(), server)
+	}
+	return ErrMCPUnsupported
+}
+
+func (a *specAdapter) UnregisterMCP(dir, name string) error {
+	switch {
+	case len(a.spec.MCPRemoveCommand) > 0:
+		args := make([]string, len(a.spec.MCPRemoveCommand))
+		for i, arg := range a.spec.MCPRemoveCommand {
+			args[i] = strings.ReplaceAll(arg, "{name}", name)
+		}
+		return runCommand(dir, args)
+	case a.spec.MCPConfigFile != "":
+		return removeMCPConfig(dir, a.spec.MCPConfigFile, a.mcpConfigKey(), name)
+	}
+	return nil
+}
+
+func (a *specAdapter) MCPConfigFile() string {
+	if len(a.spec.MCPAddCommand) > 0 {
+		return ""
+	}
+	return a.spec.MCPConfigFile
+}
+
+func (a *specAdapter) Transcript() transcript.Format {
+	if a.spec.TranscriptFormat == "" {
+		return nil
+	}
+	f, _ := transcript.FormatNamed(a.spec.TranscriptFormat)
+	return f
+}
+
+func (a *specAdapter) mcpConfigKey() string {
+	if a.spec.MCPConfigKey != "" {
+		return a.spec.MCPConfigKey
+	}
+	return "mcpServers"
+}
+
+// mcpAddArgs expands MCPAddCommand for server.
+func (a *specAdapter) mcpAddArgs(server MCPServer) []string {
+	keys := make([]string, 0, len(server.Env))
+	for k := range server.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, arg := range a.spec.MCPAddCommand {
+		switch arg {
+		case "{env}":
+			for _, k := range keys {
+				args = append(args, a.spec.MCPEnvFlag, k+"="+server.Env[k])
+			}
+		default:
+			arg = strings.ReplaceAll(arg, "{name}", server.Name)
+			args = append(args, strings.ReplaceAll(arg, "{command}", server.Command))
+		}
+	}
+	return args
+}
+
+func containsArg(args []string, s string) bool {
+	for _, arg := range args {
+		if arg == s {
+			return true
+		}
+	}
+	return false
+}
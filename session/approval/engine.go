@@ -0,0 +1,136 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ByteMirror/hivemind/log"
+)
+
+// AuditFile is the name of the audit log of automatic decisions, kept in the
+// config directory. Each line is a JSON AuditEntry.
+const AuditFile = "approvals.log"
+
+// AuditEntry records one automatic decision.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Instance string    `json:"instance"`
+	Topic    string    `json:"topic,omitempty"`
+	RepoPath string    `json:"repo_path,omitempty"`
+	Program  string    `json:"program,omitempty"`
+	Decision Decision  `json:"decision"`
+	// Scope and Rule identify the rule that decided; both are empty when
+	// AutoYes approved a prompt no rule matched.
+	Scope  string `json:"scope,omitempty"`
+	Rule   string `json:"rule,omitempty"`
+	Prompt Prompt `json:"prompt"`
+}
+
+// Engine decides permission prompts with the rules of each repo, reloading
+// them when the rule files change. It is shared by the TUI and the daemon so
+// both apply the same rules. Safe for concurrent use.
+type Engine struct {
+	configDir string
+
+	mu    sync.Mutex
+	repos map[string]*cachedRules
+}
+
+// cachedRules holds a repo's loaded rules and the modification times of the
+// files they were loaded from.
+type cachedRules struct {
+	rules  *Rules
+	mtimes []time.Time
+}
+
+// NewEngine creates an Engine reading rules from configDir and repo
+// .hivemind directories and auditing to configDir.
+func NewEngine(configDir string) *Engine {
+	return &Engine{configDir: configDir, repos: make(map[string]*cachedRules)}
+}
+
+// Decide parses prompt text and decides it for s. e may be nil, in which case
+// only s.AutoYes applies.
+func (e *Engine) Decide(s Subject, text string) Result {
+	p := ParsePrompt(text)
+	if e == nil {
+		return (*Rules)(nil).Decide(s, p)
+	}
+	return e.rules(s.RepoPath).Decide(s, p)
+}
+
+// rules returns the repo's rules, reloading them if a rule file changed. A
+// file that fails to load is logged and ignored until it changes again.
+func (e *Engine) rules(repoPath string) *Rules {
+	paths := rulePaths(e.configDir, repoPath)
+	mtimes := make([]time.Time, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			mtimes[i] = info.ModTime()
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if c, ok := e.repos[repoPath]; ok && sameTimes(c.mtimes, mtimes) {
+		return c.rules
+	}
+	rules, err := Load(e.configDir, repoPath)
+	if err != nil {
+		log.WarningLog.Printf("approval rules ignored: %v", err)
+	}
+	e.repos[repoPath] = &cachedRules{rules: rules, mtimes: mtimes}
+	return rules
+}
+
+// Record appends an automatic decision to the audit log.
+func (e *Engine) Record(s Subject, res Result) error {
+	if e == nil {
+		return nil
+	}
+	entry := AuditEntry{
+		Time:     time.Now().UTC(),
+		Instance: s.Instance,
+		Topic:    s.Topic,
+		RepoPath: s.RepoPath,
+		Program:  s.Program,
+		Decision: res.Decision,
+		Scope:    res.Scope,
+		Prompt:   res.Prompt,
+	}
+	if res.Rule != nil {
+		entry.Rule = res.Rule.String()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	f, err := os.OpenFile(filepath.Join(e.configDir, AuditFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open approval audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write approval audit log: %w", err)
+	}
+	return nil
+}
+
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
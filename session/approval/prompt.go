@@ -0,0 +1,214 @@
+package approval
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Tool names for the prompts agent programs show most often. Other tools keep
+// the name shown in the prompt, lowercased.
+const (
+	ToolShell = "shell"
+	ToolEdit  = "edit"
+	ToolRead  = "read"
+	ToolFetch = "fetch"
+)
+
+// Prompt is a permission prompt parsed from pane text.
+type Prompt struct {
+	Tool    string `json:"tool,omitempty"`
+	Command string `json:"command,omitempty"`
+	Path    string `json:"path,omitempty"`
+	// Text is the prompt as taken from the pane.
+	Text string `json:"text"`
+	// Unclear is set when the command could not be told apart from the rest
+	// of the prompt, in which case Command holds every line it may span. Only
+	// deny rules decide unclear prompts.
+	Unclear bool `json:"unclear,omitempty"`
+}
+
+var (
+	// pathQuestionRe finds the file in questions like "Do you want to make
+	// this edit to main.go?" or aider's "Create new file foo.py?".
+	pathQuestionRe = regexp.MustCompile(`(?i)(?:make this edit to|edits to(?: file)?|(?:create )?new file|create|overwrite|write to)\s+(\S+?)\??(?:\s|$)`)
+	// toolCallRe finds an MCP or tool call like "server - tool_name(args)".
+	toolCallRe = regexp.MustCompile(`^(?:[\w.-]+\s+-\s+)?([\w.:-]+)\s*\(`)
+	// heredocRe finds the start of a heredoc like "<<EOF" or "<<-'EOF'".
+	heredocRe = regexp.MustCompile(`(?:^|[^<])<<-?\s*['"]?(\w+)['"]?`)
+)
+
+// ParsePrompt extracts the tool, command and file path from the text of a
+// Claude, Aider or Gemini permission prompt. Fields it cannot find are left
+// empty.
+func ParsePrompt(text string) Prompt {
+	p := Prompt{Text: text}
+	lines := nonEmptyLines(text)
+	if len(lines) == 0 {
+		return p
+	}
+	title := lines[0]
+	lower := strings.ToLower(title)
+	last := lines[len(lines)-1]
+
+	switch {
+	// Claude: "Bash command", the command, its description, then "Do you
+	// want to proceed?".
+	case strings.HasPrefix(lower, "bash"):
+		p.Tool = ToolShell
+		p.Command, p.Unclear = claudeCommand(lines[1:])
+	// Gemini: "Shell <command>".
+	case strings.HasPrefix(lower, "shell "):
+		p.Tool = ToolShell
+		p.Command = strings.TrimSpace(title[len("shell "):])
+	// Aider: the command lines, then "Run shell command? (Y)es/...".
+	case strings.Contains(strings.ToLower(last), "run shell command"):
+		p.Tool = ToolShell
+		if len(lines) > 1 {
+			p.Command = strings.Join(lines[:len(lines)-1], " && ")
+		}
+	case strings.Contains(lower, "edit") || strings.Contains(lower, "create") || strings.Contains(lower, "write") ||
+		strings.Contains(strings.ToLower(last), "new file"):
+		p.Tool = ToolEdit
+	case strings.HasPrefix(lower, "read"):
+		p.Tool = ToolRead
+	case strings.HasPrefix(lower, "fetch") || strings.HasPrefix(lower, "web"):
+		p.Tool = ToolFetch
+	// Claude: "Tool use" followed by "server - tool(args)".
+	case lower == "tool use" && len(lines) > 1:
+		if m := toolCallRe.FindStringSubmatch(lines[1]); m != nil {
+			p.Tool = strings.ToLower(m[1])
+		}
+	default:
+		if m := toolCallRe.FindStringSubmatch(title); m != nil {
+			p.Tool = strings.ToLower(m[1])
+		}
+	}
+
+	if p.Tool != ToolShell {
+		p.Path = promptPath(title, lines)
+	}
+	return p
+}
+
+// claudeCommand takes the command from the lines after the title of a Claude
+// Bash prompt. The command may span several lines and is followed by at most
+// one line of description before the question. When the lines can't be split
+// that way, it returns all of them and unclear.
+func claudeCommand(lines []string) (command string, unclear bool) {
+	question := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(line), "do you want to proceed") {
+			question = i
+			break
+		}
+	}
+	if question < 0 {
+		return strings.Join(lines, "\n"), true
+	}
+	body := lines[:question]
+	if len(body) == 0 {
+		return "", true
+	}
+	n, ok := commandLines(body)
+	if !ok || len(body)-n > 1 {
+		return strings.Join(body, "\n"), true
+	}
+	return strings.Join(body[:n], "\n"), false
+}
+
+// commandLines returns how many of lines the shell command starting on the
+// first one spans: a line ending in a backslash or an operator, an open quote
+// or a heredoc continues on the next line. ok is false if the command doesn't
+// end within lines.
+func commandLines(lines []string) (n int, ok bool) {
+	var quote rune
+	heredoc := ""
+	for i, line := range lines {
+		if heredoc != "" {
+			if line == heredoc {
+				return i + 1, true
+			}
+			continue
+		}
+		quote = scanQuotes(line, quote)
+		if quote != 0 {
+			continue
+		}
+		if m := heredocRe.FindStringSubmatch(line); m != nil {
+			heredoc = m[1]
+			continue
+		}
+		if !continuesLine(line) {
+			return i + 1, true
+		}
+	}
+	return len(lines), false
+}
+
+// scanQuotes returns the quote still open at the end of line, given the one
+// open at its start, or 0.
+func scanQuotes(line string, quote rune) rune {
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case r == quote:
+			quote = 0
+		}
+	}
+	return quote
+}
+
+// continuesLine reports whether a shell line ends in a way that continues the
+// command on the next line.
+func continuesLine(line string) bool {
+	for _, suffix := range []string{"\\", "&&", "||", "|", "(", "{"} {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// promptPath finds the file a prompt is about.
+func promptPath(title string, lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if m := pathQuestionRe.FindStringSubmatch(lines[i]); m != nil && looksLikePath(m[1]) {
+			return m[1]
+		}
+	}
+	// Gemini: "Edit main.go: ..." or "WriteFile main.go".
+	if fields := strings.Fields(title); len(fields) > 1 {
+		if f := strings.TrimSuffix(fields[1], ":"); looksLikePath(f) {
+			return f
+		}
+	}
+	// Claude and aider: the path on the line after the title or alone
+	// before the question.
+	for _, line := range lines[1:] {
+		if !strings.ContainsAny(line, " \t") && looksLikePath(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+// looksLikePath reports whether s could be a file path.
+func looksLikePath(s string) bool {
+	return s != "" && strings.ContainsAny(s, "/.") && !strings.HasSuffix(s, ".") && !strings.Contains(s, "://")
+}
+
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,328 @@
+// Package approval decides how permission prompts from agent programs are
+// answered. Rules match the prompt's tool, command and file path and are
+// scoped globally, per topic or per instance.
+package approval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RulesFile is the name of the auto-approve rules file, read from the config
+// directory and from a repo's .hivemind directory.
+const RulesFile = "approvals.yaml"
+
+// Decision is what to do with a permission prompt.
+type Decision string
+
+const (
+	// Allow approves the prompt.
+	Allow Decision = "allow"
+	// Deny declines the prompt.
+	Deny Decision = "deny"
+	// Ask leaves the prompt for the user.
+	Ask Decision = "ask"
+)
+
+// Rule matches a permission prompt. Every non-empty field must match. Fields
+// are glob patterns where * matches any text, including spaces and slashes.
+type Rule struct {
+	// Tool matches the tool the prompt is for, e.g. shell, edit or an MCP
+	// tool name. Case-insensitive.
+	Tool string `yaml:"tool,omitempty" json:"tool,omitempty"`
+	// Command matches the whole shell command.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+	// Path matches the file path the prompt touches. Relative patterns also
+	// match below any directory, and a trailing slash matches everything
+	// inside the directory.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Prompt matches the full prompt text.
+	Prompt string `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+}
+
+// String describes the rule for the audit log.
+func (r Rule) String() string {
+	var parts []string
+	for _, f := range []struct{ name, value string }{
+		{"tool", r.Tool}, {"command", r.Command}, {"path", r.Path}, {"prompt", r.Prompt},
+	} {
+		if f.value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", f.name, f.value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r Rule) empty() bool {
+	return r.Tool == "" && r.Command == "" && r.Path == "" && r.Prompt == ""
+}
+
+// matches reports whether the rule matches p.
+func (r Rule) matches(p Prompt) bool {
+	if r.Tool != "" && !matchGlob(strings.ToLower(r.Tool), strings.ToLower(p.Tool)) {
+		return false
+	}
+	if r.Command != "" && (p.Command == "" || !matchGlob(r.Command, p.Command)) {
+		return false
+	}
+	if r.Path != "" && (p.Path == "" || !matchPath(r.Path, p.Path)) {
+		return false
+	}
+	if r.Prompt != "" && !matchGlob(r.Prompt, p.Text) {
+		return false
+	}
+	return true
+}
+
+// RuleSet is a list of rules per decision. When rules of several decisions
+// match, deny wins over ask and ask over allow.
+type RuleSet struct {
+	Allow []Rule `yaml:"allow"`
+	Deny  []Rule `yaml:"deny"`
+	Ask   []Rule `yaml:"ask"`
+}
+
+// match returns the decision and rule of the first matching rule, checking
+// deny, then ask, then allow rules.
+func (rs *RuleSet) match(p Prompt) (Decision, *Rule) {
+	if rs == nil {
+		return "", nil
+	}
+	for _, group := range []struct {
+		decision Decision
+		rules    []Rule
+	}{{Deny, rs.Deny}, {Ask, rs.Ask}, {Allow, rs.Allow}} {
+		for i := range group.rules {
+			if group.rules[i].matches(p) {
+				return group.decision, &group.rules[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+func (rs *RuleSet) validate() error {
+	if rs == nil {
+		return fmt.Errorf("empty entry")
+	}
+	for _, rules := range [][]Rule{rs.Allow, rs.Deny, rs.Ask} {
+		for _, r := range rules {
+			if r.empty() {
+				return fmt.Errorf("rule without tool, command, path or prompt")
+			}
+		}
+	}
+	return nil
+}
+
+// Rules holds the auto-approve rules that apply to a repo. Instance rules take
+// precedence over topic rules, and topic rules over global ones: the most
+// specific scope with a matching rule decides.
+type Rules struct {
+	Global    *RuleSet            `yaml:"global"`
+	Topics    map[string]*RuleSet `yaml:"topics"`
+	Instances map[string]*RuleSet `yaml:"instances"`
+
+	// repo holds the deny and ask rules of the repo's approvals.yaml, which
+	// are decided separately and only apply when stricter.
+	repo *Rules
+}
+
+// Subject describes the instance whose prompt is being decided.
+type Subject struct {
+	Instance string
+	Topic    string
+	RepoPath string
+	Program  string
+	// AutoYes is the decision for prompts no rule matches: allow if set, ask
+	// otherwise.
+	AutoYes bool
+}
+
+// Result is the outcome of deciding a prompt.
+type Result struct {
+	Decision Decision
+	// Scope is "instance", "topic" or "global" for the scope of the matching
+	// rule, prefixed with "repo " for a rule from the repo's file, or "" when
+	// no rule matched.
+	Scope string
+	// Rule is the matching rule, or nil.
+	Rule   *Rule
+	Prompt Prompt
+}
+
+// Decide returns the decision for prompt p of subject s. r may be nil. The
+// repo's rules override the user's only with a stricter decision. An unclear
+// prompt is denied if a deny rule matches and asked otherwise.
+func (r *Rules) Decide(s Subject, p Prompt) Result {
+	res, ok := r.match(s, p)
+	if r != nil {
+		if repoRes, repoOK := r.repo.match(s, p); repoOK && (!ok || strictness[repoRes.Decision] > strictness[res.Decision]) {
+			repoRes.Scope = "repo " + repoRes.Scope
+			res, ok = repoRes, true
+		}
+	}
+	if ok {
+		return res
+	}
+	if s.AutoYes && !p.Unclear {
+		return Result{Decision: Allow, Prompt: p}
+	}
+	return Result{Decision: Ask, Prompt: p}
+}
+
+// strictness orders decisions from the most to the least permissive.
+var strictness = map[Decision]int{Allow: 1, Ask: 2, Deny: 3}
+
+// match returns the decision of the most specific scope with a rule matching
+// p, and whether there is one. r may be nil.
+func (r *Rules) match(s Subject, p Prompt) (Result, bool) {
+	if r == nil {
+		return Result{}, false
+	}
+	scopes := []struct {
+		name string
+		set  *RuleSet
+	}{
+		{"instance", r.Instances[s.Instance]},
+		{"topic", r.Topics[s.Topic]},
+		{"global", r.Global},
+	}
+	for _, scope := range scopes {
+		if scope.name == "topic" && s.Topic == "" {
+			continue
+		}
+		if d, rule := scope.set.match(p); rule != nil && (d == Deny || !p.Unclear) {
+			return Result{Decision: d, Scope: scope.name, Rule: rule, Prompt: p}, true
+		}
+	}
+	return Result{}, false
+}
+
+// Load reads the user's rules from configDir and adds the repo's
+// .hivemind/approvals.yaml. Agents can write to the repo, so its file can only
+// tighten the user's rules: its allow rules are ignored, and its deny and ask
+// rules apply when stricter than the user's decision. It returns nil if
+// neither file exists.
+func Load(configDir, repoPath string) (*Rules, error) {
+	paths := rulePaths(configDir, repoPath)
+	rules, err := parseRulesFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) < 2 {
+		return rules, nil
+	}
+	repo, err := parseRulesFile(paths[1])
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return rules, nil
+	}
+	for _, rs := range repo.sets() {
+		rs.Allow = nil
+	}
+	if rules == nil {
+		rules = &Rules{}
+	}
+	rules.repo = repo
+	return rules, nil
+}
+
+// sets returns every rule set of r.
+func (r *Rules) sets() []*RuleSet {
+	var sets []*RuleSet
+	if r.Global != nil {
+		sets = append(sets, r.Global)
+	}
+	for _, rs := range r.Topics {
+		sets = append(sets, rs)
+	}
+	for _, rs := range r.Instances {
+		sets = append(sets, rs)
+	}
+	return sets
+}
+
+// rulePaths returns the rule files that apply to repoPath, global first.
+func rulePaths(configDir, repoPath string) []string {
+	paths := []string{filepath.Join(configDir, RulesFile)}
+	if repoPath != "" {
+		paths = append(paths, filepath.Join(repoPath, ".hivemind", RulesFile))
+	}
+	return paths
+}
+
+func parseRulesFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read approval rules: %w", err)
+	}
+	var r Rules
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if r.Global != nil {
+		if err := r.Global.validate(); err != nil {
+			return nil, fmt.Errorf("%s: global: %w", path, err)
+		}
+	}
+	for name, rs := range r.Topics {
+		if err := rs.validate(); err != nil {
+			return nil, fmt.Errorf("%s: topic %q: %w", path, name, err)
+		}
+	}
+	for name, rs := range r.Instances {
+		if err := rs.validate(); err != nil {
+			return nil, fmt.Errorf("%s: instance %q: %w", path, name, err)
+		}
+	}
+	return &r, nil
+}
+
+// matchGlob reports whether s matches pattern, where * matches any run of
+// characters and ? matches one character.
+func matchGlob(pattern, s string) bool {
+	p, t := []rune(pattern), []rune(s)
+	// Iterative wildcard matching with backtracking to the last star.
+	pi, ti, star, mark := 0, 0, -1, 0
+	for ti < len(t) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == t[ti]):
+			pi++
+			ti++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, ti
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			ti = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// matchPath matches a file path pattern. See Rule.Path.
+func matchPath(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "*"
+	}
+	if matchGlob(pattern, path) {
+		return true
+	}
+	return !strings.HasPrefix(pattern, "/") && matchGlob("*/"+pattern, path)
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +13,8 @@ type DiffStats struct {
 	Added int
 	// Removed is the number of removed lines
 	Removed int
+	// Files lists the repo-relative paths touched by the diff
+	Files []string
 	// Error holds any error that occurred during diff computation
 	// This allows propagating setup errors (like missing base commit) without breaking the flow
 	Error error
@@ -46,6 +49,65 @@ func (g *GitWorktree) Diff() *DiffStats {
 		}
 	}
 	stats.Content = content
+	stats.Files = diffFiles(content)
 
 	return stats
 }
+
+// diffFiles returns the paths touched by a unified diff, using the new path
+// for renames and the old path for deletions.
+func diffFiles(content string) []string {
+	var files []string
+	var oldPath, pending string
+	// Only file headers are parsed: a removed line starting with "-- " would
+	// otherwise look like a "---" header.
+	inHeader := false
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			// Binary and mode-only changes have no ---/+++ lines, so fall back
+			// to the path on the diff line.
+			if pending != "" {
+				files = append(files, pending)
+			}
+			pending = ""
+			if i := strings.LastIndex(line, " b/"); i >= 0 {
+				pending = line[i+3:]
+			}
+			inHeader = true
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+		case !inHeader:
+		case strings.HasPrefix(line, "--- "):
+			oldPath = diffPath(line[4:], "a/")
+		case strings.HasPrefix(line, "+++ "):
+			p := diffPath(line[4:], "b/")
+			if p == "" {
+				p = oldPath
+			}
+			if p != "" {
+				files = append(files, p)
+			}
+			oldPath, pending = "", ""
+		}
+	}
+	if pending != "" {
+		files = append(files, pending)
+	}
+	return files
+}
+
+// diffPath decodes a path from a ---/+++ header line, which git quotes when
+// it contains special characters. It returns "" for /dev/null.
+func diffPath(s, prefix string) string {
+	s = strings.TrimSuffix(s, "\t")
+	if strings.HasPrefix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			s = unquoted
+		}
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffFiles(t *testing.T) {
+	content := `diff --git a/api/server.go b/api/server.go
+index 1111111..2222222 100644
+--- a/api/server.go
++++ b/api/server.go
+@@ -1,3 +1,3 @@
+--- not a header
++++ not a header either
+ context
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/docs/a b.md b/docs/a b.md
+new file mode 100644
+--- /dev/null
++++ "b/docs/a b.md"
+@@ -0,0 +1 @@
++hello
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	want := []string{"api/server.go", "old.txt", "docs/a b.md", "logo.png"}
+	if got := diffFiles(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffFiles() = %q, want %q", got, want)
+	}
+}
@@ -3,11 +3,13 @@ package session
 import (
 	"fmt"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ByteMirror/hivemind/session/git"
 	"github.com/ByteMirror/hivemind/session/tmux"
+	"github.com/ByteMirror/hivemind/session/transcript"
 )
 
 type Status int
@@ -97,11 +99,27 @@ type Instance struct {
 	// Reset to false when the instance resumes running. Used by the sidebar to
 	// persistently show a running indicator without flickering.
 	PromptDetected bool
+	// PermissionPrompt is the text of the permission prompt the program is
+	// blocked on, taken from the pane. Empty when none is waiting or the
+	// approval rules answered it.
+	PermissionPrompt string
+	// PermissionPromptAt is when PermissionPrompt was first seen.
+	PermissionPromptAt time.Time
+	// answeredPrompt is the prompt last answered, by the user or the approval
+	// rules, so it is not reported again while the pane still shows it.
+	answeredPrompt string
 
 	// CPUPercent is the current CPU usage of the instance's process tree.
 	CPUPercent float64
 	// MemMB is the current memory usage in megabytes (aggregated across process tree).
 	MemMB float64
+	// Usage is the model usage of the instance, including earlier runs.
+	Usage TokenUsage
+	// usageBase is the usage counted before the transcript reader started.
+	usageBase TokenUsage
+	// BudgetPaused is set once the instance was paused for exceeding the
+	// budget, so an instance the user resumes anyway is not paused again.
+	BudgetPaused bool
 
 	// SubAgentCount is the number of detected sub-agent processes (e.g. spawned Claude Code tasks).
 	SubAgentCount int
@@ -110,6 +128,13 @@ type Instance struct {
 
 	// LastActivity is the most recently detected agent activity (ephemeral, not persisted).
 	LastActivity *Activity
+	// transcript follows the agent's session transcript, if it writes one.
+	transcript   *transcript.Reader
+	transcriptMu sync.Mutex
+
+	// OverlapsWith lists the instances whose uncommitted changes touch the same
+	// files as this one's (ephemeral, not persisted).
+	OverlapsWith []string
 
 	// DiffStats stores the current git diff statistics
 	diffStats *git.DiffStats
@@ -142,6 +167,8 @@ func (i *Instance) ToInstanceData() InstanceData {
 		Role:            i.Role,
 		ParentTitle:     i.ParentTitle,
 		AutomationID:    i.AutomationID,
+		Usage:           i.Usage,
+		BudgetPaused:    i.BudgetPaused,
 	}
 
 	// Only include worktree data if gitWorktree is initialized
@@ -185,6 +212,9 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		Role:            data.Role,
 		ParentTitle:     data.ParentTitle,
 		AutomationID:    data.AutomationID,
+		Usage:           data.Usage,
+		usageBase:       data.Usage,
+		BudgetPaused:    data.BudgetPaused,
 		gitWorktree: git.NewGitWorktreeFromStorage(
 			data.Worktree.RepoPath,
 			data.Worktree.WorktreePath,
@@ -294,6 +324,8 @@ func (i *Instance) SetStatus(status Status) {
 	if status == Running || status == Loading {
 		i.LastActiveAt = time.Now()
 		i.PromptDetected = false
+		i.PermissionPrompt = ""
+		i.answeredPrompt = ""
 		i.Notified = false
 	}
 	i.Status = status
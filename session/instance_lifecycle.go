@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/agent"
 	"github.com/ByteMirror/hivemind/session/git"
 	"github.com/ByteMirror/hivemind/session/tmux"
 
@@ -85,16 +86,7 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 			return setupErr
 		}
 
-		if isClaudeProgram(i.Program) {
-			worktreePath := i.gitWorktree.GetWorktreePath()
-			repoPath := i.Path
-			title := i.Title
-			go func() {
-				if err := registerMCPServer(worktreePath, repoPath, title); err != nil {
-					log.WarningLog.Printf("failed to write MCP config: %v", err)
-				}
-			}()
-		}
+		i.registerMCP(i.gitWorktree.GetWorktreePath())
 
 		if memMgr := getMemoryManager(); memMgr != nil {
 			wtPath := i.gitWorktree.GetWorktreePath()
@@ -138,14 +130,19 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 	return nil
 }
 
+// configureInitialPromptArg passes the initial prompt on the command line
+// for agents that take it there.
 func (i *Instance) configureInitialPromptArg(tmuxSession *tmux.TmuxSession) {
-	if i.InitialPrompt != "" && isClaudeProgram(i.Program) {
-		tmuxSession.AppendArgs = append(tmuxSession.AppendArgs, "-p", i.InitialPrompt)
+	if i.InitialPrompt == "" {
+		return
 	}
+	tmuxSession.AppendArgs = append(tmuxSession.AppendArgs, agent.For(i.Program).InitialPromptArgs(i.InitialPrompt)...)
 }
 
+// sendInitialPromptViaTmux types the initial prompt into the pane for agents
+// that don't take it on the command line.
 func (i *Instance) sendInitialPromptViaTmux() {
-	if i.InitialPrompt == "" || isClaudeProgram(i.Program) || i.tmuxSession == nil {
+	if i.InitialPrompt == "" || i.tmuxSession == nil || agent.For(i.Program).InitialPromptArgs(i.InitialPrompt) != nil {
 		return
 	}
 	if err := i.tmuxSession.SendTextViaTmux(i.InitialPrompt); err != nil {
@@ -190,16 +187,7 @@ func (i *Instance) StartInSharedWorktree(worktree *git.GitWorktree, branch strin
 
 	i.setLoadingProgress(3, "Starting tmux session...")
 
-	if isClaudeProgram(i.Program) {
-		wtPath := worktree.GetWorktreePath()
-		repoPath := i.Path
-		title := i.Title
-		go func() {
-			if err := registerMCPServer(wtPath, repoPath, title); err != nil {
-				log.WarningLog.Printf("failed to write MCP config: %v", err)
-			}
-		}()
-	}
+	i.registerMCP(worktree.GetWorktreePath())
 
 	if memMgr := getMemoryManager(); memMgr != nil {
 		sharedWtPath := worktree.GetWorktreePath()
@@ -247,15 +235,7 @@ func (i *Instance) StartInMainRepo() error {
 	i.configureInitialPromptArg(tmuxSession)
 	i.tmuxSession = tmuxSession
 
-	if isClaudeProgram(i.Program) {
-		repoPath := i.Path
-		title := i.Title
-		go func() {
-			if err := registerMCPServer(repoPath, repoPath, title); err != nil {
-				log.WarningLog.Printf("failed to write MCP config: %v", err)
-			}
-		}()
-	}
+	i.registerMCP(i.Path)
 
 	var setupErr error
 	defer func() {
@@ -318,6 +298,14 @@ func (i *Instance) Kill() error {
 		}
 	}
 
+	i.closeTranscript()
+
+	// Remove the MCP registration before the worktree goes, so shared
+	// worktrees and the main repo don't keep a dead instance's server.
+	if err := unregisterMCPServer(i.Program, i.GetWorkingPath(), i.Title); err != nil {
+		log.WarningLog.Printf("failed to remove MCP config: %v", err)
+	}
+
 	// Then clean up git worktree (skip if shared — topic owns the worktree)
 	if i.gitWorktree != nil && !i.sharedWorktree {
 		if err := i.gitWorktree.Cleanup(); err != nil {
@@ -442,16 +430,7 @@ func (i *Instance) Resume() error {
 		return fmt.Errorf("failed to setup git worktree: %w", err)
 	}
 
-	if isClaudeProgram(i.Program) {
-		worktreePath := i.gitWorktree.GetWorktreePath()
-		repoPath := i.Path
-		title := i.Title
-		go func() {
-			if err := registerMCPServer(worktreePath, repoPath, title); err != nil {
-				log.WarningLog.Printf("failed to write MCP config: %v", err)
-			}
-		}()
-	}
+	i.registerMCP(i.gitWorktree.GetWorktreePath())
 
 	if memMgr := getMemoryManager(); memMgr != nil {
 		wtPath := i.gitWorktree.GetWorktreePath()
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/approval"
 	"github.com/ByteMirror/hivemind/session/git"
 	"github.com/ByteMirror/hivemind/session/tmux"
 )
@@ -158,14 +159,66 @@ func (i *Instance) NewEmbeddedTerminalForInstance(cols, rows int) (*EmbeddedTerm
 	return NewEmbeddedTerminal(sessionName, cols, rows)
 }
 
-// TapEnter sends an enter key press to the tmux session if AutoYes is enabled.
-func (i *Instance) TapEnter() {
-	if !i.started.Load() || !i.AutoYes {
-		return
+// ApplyApprovalRules handles the permission prompt found by the last
+// HasUpdated call. Prompts the rules (or AutoYes) allow or deny are answered
+// and audited; prompts left to the user are recorded in PermissionPrompt.
+// isNew is false when the same prompt was already handled, so callers only
+// announce a prompt once. engine may be nil.
+func (i *Instance) ApplyApprovalRules(engine *approval.Engine) (decision approval.Decision, isNew bool) {
+	if !i.started.Load() {
+		return approval.Ask, false
+	}
+	text := i.tmuxSession.PermissionPrompt()
+	subject := approval.Subject{
+		Instance: i.Title,
+		Topic:    i.TopicName,
+		RepoPath: i.GetRepoPath(),
+		Program:  i.Program,
+		AutoYes:  i.AutoYes,
 	}
-	if err := i.tmuxSession.TapEnter(); err != nil {
-		log.ErrorLog.Printf("error tapping enter: %v", err)
+	res := engine.Decide(subject, text)
+
+	if res.Decision == approval.Ask {
+		if text == "" || text == i.PermissionPrompt || text == i.answeredPrompt {
+			return approval.Ask, false
+		}
+		i.PermissionPrompt = text
+		i.PermissionPromptAt = time.Now()
+		return approval.Ask, true
+	}
+
+	// Keep answering while the prompt is on screen, in case a keystroke was
+	// lost, but audit it only once.
+	isNew = text != i.answeredPrompt
+	if err := i.tmuxSession.AnswerPermission(res.Decision == approval.Allow); err != nil {
+		log.ErrorLog.Printf("error answering permission prompt for %s: %v", i.Title, err)
+		return res.Decision, isNew
+	}
+	i.answeredPrompt = text
+	i.PermissionPrompt = ""
+	if isNew {
+		if err := engine.Record(subject, res); err != nil {
+			log.WarningLog.Printf("could not audit approval for %s: %v", i.Title, err)
+		}
 	}
+	return res.Decision, isNew
+}
+
+// AnswerPermission approves or denies the pending permission prompt without
+// attaching to the session.
+func (i *Instance) AnswerPermission(approve bool) error {
+	if !i.started.Load() {
+		return ErrInstanceNotStarted
+	}
+	if i.PermissionPrompt == "" {
+		return fmt.Errorf("instance %q is not waiting for permission", i.Title)
+	}
+	if err := i.tmuxSession.AnswerPermission(approve); err != nil {
+		return err
+	}
+	i.answeredPrompt = i.PermissionPrompt
+	i.PermissionPrompt = ""
+	return nil
 }
 
 func (i *Instance) Attach() (chan struct{}, error) {
@@ -1,14 +1,16 @@
 package session
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
-	"strings"
+	"sync"
 
+	"github.com/ByteMirror/hivemind/brain"
 	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/agent"
 )
 
 // mcpServerNameRe strips characters that aren't valid in MCP server names.
@@ -22,18 +24,45 @@ func mcpServerName(instanceTitle string) string {
 	return "hivemind-" + sanitized
 }
 
-// registerMCPServer registers the Hivemind MCP server with Claude Code for
-// the given worktree directory. It uses `claude mcp add` with local scope,
-// which stores the config in ~/.claude.json per-project and does NOT require
-// the approval prompt that project-scoped .mcp.json files trigger.
+// TokenIssuer issues the tokens instances authenticate to the brain server
+// with. It is implemented by *brain.Server.
+type TokenIssuer interface {
+	IssueToken(info brain.InstanceInfo) (string, error)
+	RevokeToken(instanceID string)
+}
+
+var (
+	tokenIssuer   TokenIssuer
+	tokenIssuerMu sync.RWMutex
+)
+
+// SetTokenIssuer sets where instances get their brain token from. Called by
+// the TUI and the daemon once their brain server has started.
+func SetTokenIssuer(issuer TokenIssuer) {
+	tokenIssuerMu.Lock()
+	defer tokenIssuerMu.Unlock()
+	tokenIssuer = issuer
+}
+
+func getTokenIssuer() TokenIssuer {
+	tokenIssuerMu.RLock()
+	defer tokenIssuerMu.RUnlock()
+	return tokenIssuer
+}
+
+// registerMCPServer registers the Hivemind MCP server with the instance's
+// agent for the project in dir, for agents that support it.
 //
 // Each instance gets a unique server name (hivemind-<title>) so that multiple
-// agents in a shared worktree each get their own HIVEMIND_INSTANCE_ID.
+// agents in a shared worktree each get their own HIVEMIND_INSTANCE_ID, along
+// with the HIVEMIND_INSTANCE_TOKEN the brain server checks that ID against.
 //
-// If the hivemind-mcp binary or claude CLI is not found, this silently
-// returns nil — MCP is a progressive enhancement.
-func registerMCPServer(worktreePath, repoPath, instanceTitle string) error {
-	log.InfoLog.Printf("MCP config: registering for instance=%q worktree=%s repo=%s", instanceTitle, worktreePath, repoPath)
+// If the hivemind-mcp binary is not found or the agent can't register MCP
+// servers, this silently returns nil — MCP is a progressive enhancement.
+func registerMCPServer(program, dir string, info brain.InstanceInfo) error {
+	adapter := agent.For(program)
+	instanceTitle, repoPath := info.Title, info.RepoPath
+	log.InfoLog.Printf("MCP config: registering for instance=%q agent=%s dir=%s repo=%s", instanceTitle, adapter.Name(), dir, repoPath)
 
 	mcpBinary, err := findMCPBinary()
 	if err != nil {
@@ -44,60 +73,75 @@ func registerMCPServer(worktreePath, repoPath, instanceTitle string) error {
 	serverName := mcpServerName(instanceTitle)
 	log.InfoLog.Printf("MCP config: using binary %s (server name: %s)", mcpBinary, serverName)
 
-	// Use `claude mcp add` to register the MCP server with local scope (default).
-	// Local scope is stored in ~/.claude.json and doesn't require user approval,
-	// unlike project-scoped .mcp.json which prompts for confirmation.
-	// Note: -e flag must come after the server name, before -- separator.
-	cmd := exec.Command("claude", "mcp", "add",
-		serverName,
-		"-e", fmt.Sprintf("HIVEMIND_INSTANCE_ID=%s", instanceTitle),
-		"-e", fmt.Sprintf("HIVEMIND_REPO_PATH=%s", repoPath),
-		"-e", "HIVEMIND_TIER=3",
-		"--",
-		mcpBinary,
-	)
-	cmd.Dir = worktreePath
-
-	output, err := cmd.CombinedOutput()
+	token, err := instanceToken(info)
 	if err != nil {
-		log.WarningLog.Printf("MCP config: claude mcp add failed: %v (output: %s)", err, strings.TrimSpace(string(output)))
-		// Fall back to writing .mcp.json directly
-		return writeMCPConfigFile(worktreePath, repoPath, instanceTitle, mcpBinary)
+		// Without a token the agent's brain requests are rejected, but the
+		// rest of the MCP tools still work.
+		log.WarningLog.Printf("MCP config: no instance token: %v", err)
 	}
 
-	log.InfoLog.Printf("MCP config: registered via claude mcp add (local scope)")
+	err = adapter.RegisterMCP(dir, agent.MCPServer{
+		Name:    serverName,
+		Command: mcpBinary,
+		Env:     mcpServerEnv(instanceTitle, token, repoPath),
+	})
+	if errors.Is(err, agent.ErrMCPUnsupported) {
+		log.InfoLog.Printf("MCP config: skipped (%s does not support MCP registration)", adapter.Name())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.InfoLog.Printf("MCP config: registered with %s", adapter.Name())
 	return nil
 }
 
-// writeMCPConfigFile writes a .mcp.json file into the worktree directory as a
-// fallback when `claude mcp add` is unavailable. Uses a unique server name
-// per instance so multiple agents in a shared worktree don't collide.
-func writeMCPConfigFile(worktreePath, repoPath, instanceTitle, mcpBinary string) error {
-	log.InfoLog.Printf("MCP config: falling back to .mcp.json for instance=%q", instanceTitle)
+// registerMCP registers the instance's MCP server for the project in dir.
+// Agents that read it from a config file only do so when they start, so the
+// file is written before the agent is launched; other agents are registered
+// in the background.
+func (i *Instance) registerMCP(dir string) {
+	info := i.brainInstanceInfo()
+	program := i.Program
+	register := func() {
+		if err := registerMCPServer(program, dir, info); err != nil {
+			log.WarningLog.Printf("failed to write MCP config: %v", err)
+		}
+	}
+	if agent.For(program).MCPConfigFile() != "" {
+		register()
+		return
+	}
+	go register()
+}
 
-	serverName := mcpServerName(instanceTitle)
+// unregisterMCPServer removes the instance's MCP server registration from
+// the project in dir and revokes its brain token.
+func unregisterMCPServer(program, dir, instanceTitle string) error {
+	if issuer := getTokenIssuer(); issuer != nil {
+		issuer.RevokeToken(instanceTitle)
+	}
+	return agent.For(program).UnregisterMCP(dir, mcpServerName(instanceTitle))
+}
 
-	// Write the JSON manually to avoid importing encoding/json for a simple template
-	content := fmt.Sprintf(`{
-  "mcpServers": {
-    %q: {
-      "command": %q,
-      "env": {
-        "HIVEMIND_INSTANCE_ID": %q,
-        "HIVEMIND_REPO_PATH": %q,
-        "HIVEMIND_TIER": "3"
-      }
-    }
-  }
+// mcpServerEnv returns the environment the MCP server identifies the
+// instance by.
+func mcpServerEnv(instanceTitle, token, repoPath string) map[string]string {
+	return map[string]string{
+		"HIVEMIND_INSTANCE_ID":    instanceTitle,
+		"HIVEMIND_INSTANCE_TOKEN": token,
+		"HIVEMIND_REPO_PATH":      repoPath,
+		"HIVEMIND_TIER":           "3",
+	}
 }
-`, serverName, mcpBinary, instanceTitle, repoPath)
 
-	mcpPath := filepath.Join(worktreePath, ".mcp.json")
-	if err := os.WriteFile(mcpPath, []byte(content), 0600); err != nil {
-		return err
+// instanceToken has the brain server issue a token for an instance.
+func instanceToken(info brain.InstanceInfo) (string, error) {
+	issuer := getTokenIssuer()
+	if issuer == nil {
+		return "", errors.New("brain server is not running")
 	}
-	log.InfoLog.Printf("MCP config: wrote %s", mcpPath)
-	return nil
+	return issuer.IssueToken(info)
 }
 
 // findMCPBinary locates the hivemind-mcp binary. It checks:
@@ -128,12 +172,13 @@ func findMCPBinary() (string, error) {
 	return "", fmt.Errorf("hivemind-mcp not found")
 }
 
-// isClaudeProgram returns true if the program string refers to Claude Code.
-// This duplicates the unexported function in session/tmux to avoid exporting it.
-func isClaudeProgram(program string) bool {
-	parts := strings.Fields(program)
-	if len(parts) == 0 {
-		return false
+// brainInstanceInfo describes the instance to the brain server's permission
+// policy.
+func (i *Instance) brainInstanceInfo() brain.InstanceInfo {
+	return brain.InstanceInfo{
+		Title:       i.Title,
+		RepoPath:    i.Path,
+		Role:        i.Role,
+		ParentTitle: i.ParentTitle,
 	}
-	return filepath.Base(parts[0]) == "claude"
 }
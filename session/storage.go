@@ -27,6 +27,9 @@ type InstanceData struct {
 	Program   string          `json:"program"`
 	Worktree  GitWorktreeData `json:"worktree"`
 	DiffStats DiffStatsData   `json:"diff_stats"`
+	Usage     TokenUsage      `json:"usage"`
+	// BudgetPaused mirrors Instance.BudgetPaused.
+	BudgetPaused bool `json:"budget_paused,omitempty"`
 }
 
 // GitWorktreeData represents the serializable data of a GitWorktree
@@ -0,0 +1,92 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ByteMirror/hivemind/session/agent"
+)
+
+// maxPromptLines caps how many lines above the options are taken as the
+// prompt text.
+const maxPromptLines = 12
+
+// DetectPermissionPrompt reports whether pane content shows program's
+// permission prompt and returns the prompt text: the question and what it is
+// about, without the answer options or box drawing.
+func DetectPermissionPrompt(program, content string) (string, bool) {
+	marker := agent.For(program).PermissionMarker()
+	if marker == "" || !strings.Contains(content, marker) {
+		return "", false
+	}
+
+	lines := strings.Split(stripANSI(content), "\n")
+	end := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], marker) {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		// The marker was split by escape sequences; the prompt is there but
+		// its text can't be located.
+		return "", true
+	}
+
+	// Walk up from the marker to the top of the prompt box, a horizontal rule
+	// or a blank line outside a box, collecting everything that is not an
+	// answer option.
+	var text []string
+	for i := end; i >= 0 && end-i < maxPromptLines; i-- {
+		raw := strings.TrimSpace(lines[i])
+		if isPromptBorder(raw) || (raw == "" && len(text) > 0) {
+			break
+		}
+		line := trimBoxEdges(raw)
+		if line != "" && !isPromptOption(line) {
+			text = append(text, line)
+		}
+	}
+
+	for i, j := 0, len(text)-1; i < j; i, j = i+1, j-1 {
+		text[i], text[j] = text[j], text[i]
+	}
+	return strings.Join(text, "\n"), true
+}
+
+// isPromptBorder reports whether line is the top or bottom of a box or a
+// horizontal rule.
+func isPromptBorder(line string) bool {
+	if strings.HasPrefix(line, "╭") || strings.HasPrefix(line, "╰") || strings.HasPrefix(line, "┌") || strings.HasPrefix(line, "└") {
+		return true
+	}
+	return len(line) > 0 && strings.Trim(line, "─━-") == ""
+}
+
+// trimBoxEdges removes the side borders of a box line.
+func trimBoxEdges(line string) string {
+	line = strings.TrimPrefix(line, "│")
+	line = strings.TrimSuffix(line, "│")
+	return strings.TrimSpace(line)
+}
+
+// isPromptOption reports whether line is a numbered answer option such as
+// "❯ 1. Yes" or "2. No".
+func isPromptOption(line string) bool {
+	line = strings.TrimLeftFunc(line, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '❯' || r == '>' || r == '●' || r == '○'
+	})
+	digits := len(line) - len(strings.TrimLeftFunc(line, unicode.IsDigit))
+	return digits > 0 && strings.HasPrefix(line[digits:], ".")
+}
+
+// AnswerPermission answers a permission prompt detected by HasUpdated:
+// approve selects the prompt's default "yes" option, deny declines it.
+func (t *TmuxSession) AnswerPermission(approve bool) error {
+	if _, err := t.ptmx.Write(t.adapter.PermissionKeys(approve)); err != nil {
+		return fmt.Errorf("error answering permission prompt: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package tmux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectPermissionPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		content string
+		found   bool
+		text    string
+	}{
+		{
+			name:    "claude box",
+			program: "claude --model opus",
+			content: "earlier output\n" +
+				"╭──────────────────────────────────────────────╮\n" +
+				"│ Bash command                                 │\n" +
+				"│                                              │\n" +
+				"│   \x1b[1mrm -rf build\x1b[0m                             │\n" +
+				"│   Remove the build directory                 │\n" +
+				"│                                              │\n" +
+				"│ Do you want to proceed?                      │\n" +
+				"│ ❯ 1. Yes                                     │\n" +
+				"│   2. Yes, and don't ask again for rm commands│\n" +
+				"│   3. No, and tell Claude what to do differently (esc) │\n" +
+				"╰──────────────────────────────────────────────╯\n",
+			found: true,
+			text:  "Bash command\nrm -rf build\nRemove the build directory\nDo you want to proceed?",
+		},
+		{
+			name:    "aider",
+			program: "aider --model gpt-4",
+			content: "Added foo.py\n\nmake test\nRun shell command? (Y)es/(N)o/(D)on't ask again [Yes]:",
+			found:   true,
+			text:    "make test\nRun shell command? (Y)es/(N)o/(D)on't ask again [Yes]:",
+		},
+		{
+			name:    "gemini",
+			program: "gemini",
+			content: "╭────────╮\n│ Shell ls -la │\n│ Allow execution? │\n│ ● 1. Yes, allow once │\n│   2. No (esc) │\n╰────────╯",
+			found:   true,
+			text:    "Shell ls -la\nAllow execution?",
+		},
+		{
+			name:    "no prompt",
+			program: "claude",
+			content: "Thinking…",
+		},
+		{
+			name:    "unknown program",
+			program: "bash",
+			content: "No, and tell Claude what to do differently",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, found := DetectPermissionPrompt(tt.program, tt.content)
+			require.Equal(t, tt.found, found)
+			require.Equal(t, tt.text, text)
+		})
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -15,13 +14,9 @@ import (
 
 	"github.com/ByteMirror/hivemind/cmd"
 	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/agent"
 )
 
-const ProgramClaude = "claude"
-
-const ProgramAider = "aider"
-const ProgramGemini = "gemini"
-
 // TmuxSession represents a managed tmux session
 type TmuxSession struct {
 	// Initialized by NewTmuxSession
@@ -29,11 +24,14 @@ type TmuxSession struct {
 	// The name of the tmux session and the sanitized name used for tmux commands.
 	sanitizedName string
 	program       string
+	// adapter drives the program-specific parts of the session.
+	adapter agent.AgentAdapter
 	// ptyFactory is used to create a PTY for the tmux session.
 	ptyFactory PtyFactory
 	// cmdExec is used to execute commands in the tmux session.
 	cmdExec cmd.Executor
-	// skipPermissions appends --dangerously-skip-permissions to Claude commands
+	// skipPermissions appends the agent's flags for running without
+	// permission prompts.
 	skipPermissions bool
 	// AppendArgs holds additional arguments appended to the program's argument list
 	// during Start(). Each element is a separate arg — no shell splitting is done.
@@ -95,6 +93,7 @@ func newTmuxSession(name string, program string, skipPermissions bool, ptyFactor
 	return &TmuxSession{
 		sanitizedName:   toHivemindTmuxName(name),
 		program:         program,
+		adapter:         agent.For(program),
 		skipPermissions: skipPermissions,
 		ptyFactory:      ptyFactory,
 		cmdExec:         cmdExec,
@@ -107,18 +106,11 @@ func (t *TmuxSession) reportProgress(stage int, desc string) {
 	}
 }
 
-// isClaudeProgram returns true if the program string refers to Claude Code.
-func isClaudeProgram(program string) bool {
-	parts := strings.Fields(program)
-	if len(parts) == 0 {
-		return false
-	}
-	return filepath.Base(parts[0]) == ProgramClaude
-}
-
 type statusMonitor struct {
 	// Store hashes to save memory.
 	prevOutputHash []byte
+	// prompt is the permission prompt text seen by the last HasUpdated call.
+	prompt string
 }
 
 func newStatusMonitor() *statusMonitor {
@@ -145,9 +137,7 @@ func (t *TmuxSession) Start(workDir string) error {
 	// tmux interprets a single program argument as a shell command, so we
 	// pass each part separately to avoid metacharacter expansion.
 	programParts := strings.Fields(t.program)
-	if t.skipPermissions && isClaudeProgram(t.program) {
-		programParts = append(programParts, "--dangerously-skip-permissions")
-	}
+	programParts = append(programParts, t.adapter.LaunchArgs(t.skipPermissions)...)
 	if len(t.AppendArgs) > 0 {
 		programParts = append(programParts, t.AppendArgs...)
 	}
@@ -229,58 +219,42 @@ func (t *TmuxSession) Start(workDir string) error {
 		return fmt.Errorf("error restoring tmux session: %w", err)
 	}
 
-	if strings.HasSuffix(t.program, ProgramClaude) || strings.HasSuffix(t.program, ProgramAider) || strings.HasSuffix(t.program, ProgramGemini) {
+	if screen := t.adapter.StartupScreen(t.skipPermissions); screen != nil {
 		t.reportProgress(4, "Waiting for program to start...")
+		t.awaitStartup(screen)
+	}
+	return nil
+}
 
-		isClaude := isClaudeProgram(t.program)
-
-		var searchString string
-		var tapFunc func() error
-		var maxWaitTime time.Duration
-
-		if isClaude {
-			searchString = "Do you trust the files in this folder?"
-			tapFunc = t.TapEnter
-			maxWaitTime = 15 * time.Second
-			if t.skipPermissions {
-				maxWaitTime = 10 * time.Second
-			}
-		} else {
-			searchString = "Open documentation url for more info"
-			tapFunc = t.TapDAndEnter
-			maxWaitTime = 45 * time.Second
+// awaitStartup waits for the program to start, dismissing its startup screen
+// if it shows one.
+func (t *TmuxSession) awaitStartup(screen *agent.StartupScreen) {
+	startTime := time.Now()
+	for time.Since(startTime) < screen.MaxWait {
+		time.Sleep(250 * time.Millisecond)
+		content, err := t.CapturePaneContent()
+		if err != nil {
+			continue
 		}
 
-		startTime := time.Now()
-		for time.Since(startTime) < maxWaitTime {
-			time.Sleep(250 * time.Millisecond)
-			content, err := t.CapturePaneContent()
-			if err != nil {
-				continue
-			}
-
-			clean := strings.TrimSpace(stripANSI(content))
-			if len(clean) == 0 {
-				continue // Program hasn't produced output yet
-			}
+		clean := strings.TrimSpace(stripANSI(content))
+		if len(clean) == 0 {
+			continue // Program hasn't produced output yet
+		}
 
-			// When skipPermissions is active, skip trust prompt matching —
-			// just wait for substantial output indicating the program started.
-			if !(isClaude && t.skipPermissions) && strings.Contains(clean, searchString) {
-				if err := tapFunc(); err != nil {
-					log.ErrorLog.Printf("could not dismiss prompt: %v", err)
-				}
-				break
+		if screen.Text != "" && strings.Contains(clean, screen.Text) {
+			if _, err := t.ptmx.Write(screen.Keys); err != nil {
+				log.ErrorLog.Printf("could not dismiss prompt: %v", err)
 			}
+			return
+		}
 
-			// Substantial content without the prompt means the program
-			// started and moved past any trust screen (or never showed one).
-			if len(clean) > 100 {
-				break
-			}
+		// Substantial content without the prompt means the program
+		// started and moved past any trust screen (or never showed one).
+		if len(clean) > 100 {
+			return
 		}
 	}
-	return nil
 }
 
 // Restore attaches to an existing session and restores the window size
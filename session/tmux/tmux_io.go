@@ -52,7 +52,7 @@ func (t *TmuxSession) SendTextViaTmux(text string) error {
 }
 
 // HasUpdated checks if the tmux pane content has changed since the last tick. It also returns true if
-// the tmux pane has a prompt for aider or claude code.
+// the tmux pane has a permission prompt for claude, aider or gemini; see PermissionPrompt for its text.
 func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
 	content, err := t.CapturePaneContent()
 	if err != nil {
@@ -60,14 +60,7 @@ func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
 		return false, false
 	}
 
-	// Only set hasPrompt for claude and aider. Use these strings to check for a prompt.
-	if t.program == ProgramClaude {
-		hasPrompt = strings.Contains(content, "No, and tell Claude what to do differently")
-	} else if strings.HasPrefix(t.program, ProgramAider) {
-		hasPrompt = strings.Contains(content, "(Y)es/(N)o/(D)on't ask again")
-	} else if strings.HasPrefix(t.program, ProgramGemini) {
-		hasPrompt = strings.Contains(content, "Yes, allow once")
-	}
+	t.monitor.prompt, hasPrompt = DetectPermissionPrompt(t.program, content)
 
 	newHash := t.monitor.hash(content)
 	if !bytes.Equal(newHash, t.monitor.prevOutputHash) {
@@ -77,6 +70,12 @@ func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
 	return false, hasPrompt
 }
 
+// PermissionPrompt returns the text of the permission prompt seen by the last
+// HasUpdated call, or "" if there was none.
+func (t *TmuxSession) PermissionPrompt() string {
+	return t.monitor.prompt
+}
+
 // CapturePaneContent captures the content of the tmux pane
 func (t *TmuxSession) CapturePaneContent() (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
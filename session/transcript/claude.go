@@ -0,0 +1,226 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Claude is the format of Claude Code's transcripts, kept per project in
+// ~/.claude/projects/<project>/<session>.jsonl.
+var Claude Format = claudeFormat{}
+
+type claudeFormat struct{}
+
+func (claudeFormat) Name() string { return "claude" }
+
+// claudeProjectRe matches the characters Claude Code replaces with "-" in
+// project directory names.
+var claudeProjectRe = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// claudeProjectsDir returns the directory Claude Code keeps transcripts in.
+func claudeProjectsDir() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude", "projects"), nil
+}
+
+func (claudeFormat) Locate(workDir string, since time.Time, exclude func(string) bool) (string, error) {
+	root, err := claudeProjectsDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, claudeProjectRe.ReplaceAllString(workDir, "-"))
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	return newest(paths, since, exclude), nil
+}
+
+// newest returns the most recently modified of paths written since the given
+// time and not excluded, or "".
+func newest(paths []string, since time.Time, exclude func(string) bool) string {
+	var best string
+	var bestTime time.Time
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || info.ModTime().Before(since) || exclude(p) {
+			continue
+		}
+		if best == "" || info.ModTime().After(bestTime) {
+			best, bestTime = p, info.ModTime()
+		}
+	}
+	return best
+}
+
+func (claudeFormat) NewParser() Parser { return &claudeParser{} }
+
+// claudeParser parses one Claude Code transcript. Claude writes each content
+// block of a message as its own entry, repeating the message's usage, so
+// usage is counted once per message ID.
+type claudeParser struct {
+	msgID    string
+	msgUsage Usage
+}
+
+type claudeEntry struct {
+	Type        string        `json:"type"`
+	IsSidechain bool          `json:"isSidechain"`
+	IsMeta      bool          `json:"isMeta"`
+	Timestamp   time.Time     `json:"timestamp"`
+	Message     claudeMessage `json:"message"`
+}
+
+type claudeMessage struct {
+	ID         string          `json:"id"`
+	Model      string          `json:"model"`
+	Content    json.RawMessage `json:"content"`
+	StopReason string          `json:"stop_reason"`
+	Usage      *struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	} `json:"usage"`
+}
+
+type claudeBlock struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+}
+
+func (p *claudeParser) Apply(line []byte, s *State) {
+	var e claudeEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return
+	}
+	switch e.Type {
+	case "user":
+		p.applyUser(e, s)
+	case "assistant":
+		p.applyAssistant(e, s)
+	}
+}
+
+func (p *claudeParser) applyUser(e claudeEntry, s *State) {
+	// A plain string is a prompt typed by the user.
+	var text string
+	if json.Unmarshal(e.Message.Content, &text) == nil {
+		if !e.IsMeta && !e.IsSidechain {
+			s.startTurn()
+		}
+		return
+	}
+	var blocks []claudeBlock
+	if json.Unmarshal(e.Message.Content, &blocks) != nil {
+		return
+	}
+	prompt := false
+	for _, b := range blocks {
+		switch b.Type {
+		case "tool_result":
+			s.finishToolCall(b.ToolUseID)
+		case "text", "image":
+			prompt = true
+		}
+	}
+	if prompt && !e.IsMeta && !e.IsSidechain {
+		s.startTurn()
+	}
+}
+
+func (p *claudeParser) applyAssistant(e claudeEntry, s *State) {
+	if u := e.Message.Usage; u != nil {
+		usage := Usage{
+			InputTokens:      u.InputTokens,
+			OutputTokens:     u.OutputTokens,
+			CacheReadTokens:  u.CacheReadInputTokens,
+			CacheWriteTokens: u.CacheCreationInputTokens,
+		}
+		if e.Message.ID != "" && e.Message.ID == p.msgID {
+			// A later block of the same message: count only what changed.
+			s.addUsage(e.Message.Model, e.Timestamp, usage.Sub(p.msgUsage))
+		} else {
+			s.addUsage(e.Message.Model, e.Timestamp, usage)
+		}
+		p.msgID, p.msgUsage = e.Message.ID, usage
+	}
+
+	var blocks []claudeBlock
+	if json.Unmarshal(e.Message.Content, &blocks) == nil {
+		for _, b := range blocks {
+			if b.Type != "tool_use" {
+				continue
+			}
+			tc := claudeToolCall(b)
+			tc.At = e.Timestamp
+			s.addToolCall(tc)
+			if tc.Action == ActionEditing && tc.Detail != "" {
+				s.addEdit(tc.Detail)
+			}
+		}
+	}
+	if e.Message.StopReason == "end_turn" && !e.IsSidechain {
+		s.InTurn = false
+	}
+}
+
+// claudeToolCall maps a tool_use block to a ToolCall.
+func claudeToolCall(b claudeBlock) ToolCall {
+	var input struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Path         string `json:"path"`
+		Command      string `json:"command"`
+		Pattern      string `json:"pattern"`
+		URL          string `json:"url"`
+		Query        string `json:"query"`
+		Description  string `json:"description"`
+	}
+	_ = json.Unmarshal(b.Input, &input)
+
+	tc := ToolCall{ID: b.ID, Name: b.Name}
+	switch b.Name {
+	case "Edit", "MultiEdit", "Write", "NotebookEdit":
+		tc.Action, tc.File = ActionEditing, true
+		tc.Detail = firstNonEmpty(input.FilePath, input.NotebookPath)
+	case "Read":
+		tc.Action, tc.File = ActionReading, true
+		tc.Detail = input.FilePath
+	case "Bash":
+		tc.Action = ActionRunning
+		tc.Detail = strings.TrimSpace(input.Command)
+	case "Grep", "Glob":
+		tc.Action = ActionSearching
+		tc.Detail = input.Pattern
+	case "WebFetch", "WebSearch":
+		tc.Action = ActionReading
+		tc.Detail = firstNonEmpty(input.URL, input.Query)
+	default:
+		tc.Action = ActionWorking
+		tc.Detail = firstNonEmpty(input.Description, b.Name)
+	}
+	return tc
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
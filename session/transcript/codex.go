@@ -0,0 +1,209 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Codex is the format of Codex's session rollouts, kept by date in
+// ~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl.
+var Codex Format = codexFormat{}
+
+type codexFormat struct{}
+
+func (codexFormat) Name() string { return "codex" }
+
+// codexMaxDays bounds how many days of rollouts Locate looks through.
+const codexMaxDays = 31
+
+// codexSessionsDir returns the directory Codex keeps rollouts in.
+func codexSessionsDir() (string, error) {
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return filepath.Join(dir, "sessions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codex", "sessions"), nil
+}
+
+// Locate looks at the rollouts of each day since the given time and picks
+// the newest whose session ran in workDir.
+func (codexFormat) Locate(workDir string, since time.Time, exclude func(string) bool) (string, error) {
+	root, err := codexSessionsDir()
+	if err != nil {
+		return "", err
+	}
+	// Rollouts are filed under the local date the session started, which is
+	// at most a day before since when a session is resumed.
+	start := since
+	if oldest := time.Now().AddDate(0, 0, -codexMaxDays); start.Before(oldest) {
+		start = oldest
+	}
+	y, m, d := start.Local().Date()
+	var paths []string
+	for day := time.Date(y, m, d-1, 0, 0, 0, 0, time.Local); !day.After(time.Now()); day = day.AddDate(0, 0, 1) {
+		matches, err := filepath.Glob(filepath.Join(root, day.Format("2006/01/02"), "rollout-*.jsonl"))
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, matches...)
+	}
+	return newest(paths, since, func(p string) bool {
+		return exclude(p) || codexSessionDir(p) != filepath.Clean(workDir)
+	}), nil
+}
+
+// codexSessionDir returns the working directory recorded in a rollout's
+// session_meta line.
+func codexSessionDir(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return ""
+	}
+	var e struct {
+		Type    string `json:"type"`
+		Payload struct {
+			Cwd string `json:"cwd"`
+		} `json:"payload"`
+	}
+	if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Type != "session_meta" {
+		return ""
+	}
+	return filepath.Clean(e.Payload.Cwd)
+}
+
+func (codexFormat) NewParser() Parser { return &codexParser{} }
+
+// codexParser parses one Codex rollout. Codex reports cumulative token
+// counts, so usage is added as the difference to the previous count.
+type codexParser struct {
+	model string
+	total Usage
+}
+
+type codexEntry struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type codexPayload struct {
+	Type      string `json:"type"`
+	Model     string `json:"model"`
+	Name      string `json:"name"`
+	CallID    string `json:"call_id"`
+	Arguments string `json:"arguments"`
+	Input     string `json:"input"`
+	Info      *struct {
+		Total struct {
+			InputTokens       int64 `json:"input_tokens"`
+			CachedInputTokens int64 `json:"cached_input_tokens"`
+			OutputTokens      int64 `json:"output_tokens"`
+		} `json:"total_token_usage"`
+	} `json:"info"`
+}
+
+// codexPatchFileRe finds the files an apply_patch call touches.
+var codexPatchFileRe = regexp.MustCompile(`(?m)^\*\*\* (?:Add|Update|Delete) File: (.+)$`)
+
+func (p *codexParser) Apply(line []byte, s *State) {
+	var e codexEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return
+	}
+	var pl codexPayload
+	if err := json.Unmarshal(e.Payload, &pl); err != nil {
+		return
+	}
+	switch e.Type {
+	case "turn_context":
+		p.model = pl.Model
+	case "event_msg":
+		switch pl.Type {
+		case "task_started":
+			s.startTurn()
+		case "task_complete", "turn_aborted":
+			s.InTurn = false
+		case "token_count":
+			if pl.Info == nil {
+				return
+			}
+			// Cached input is part of the input count.
+			total := Usage{
+				InputTokens:     pl.Info.Total.InputTokens - pl.Info.Total.CachedInputTokens,
+				OutputTokens:    pl.Info.Total.OutputTokens,
+				CacheReadTokens: pl.Info.Total.CachedInputTokens,
+			}
+			s.addUsage(p.model, e.Timestamp, total.Sub(p.total))
+			p.total = total
+		}
+	case "response_item":
+		switch pl.Type {
+		case "function_call", "custom_tool_call":
+			tc := codexToolCall(pl)
+			tc.At = e.Timestamp
+			s.addToolCall(tc)
+			if pl.Name == "apply_patch" {
+				for _, m := range codexPatchFileRe.FindAllStringSubmatch(pl.Input+pl.Arguments, -1) {
+					s.addEdit(strings.TrimSpace(m[1]))
+				}
+			}
+		case "function_call_output", "custom_tool_call_output":
+			s.finishToolCall(pl.CallID)
+		}
+	}
+}
+
+// codexToolCall maps a function call to a ToolCall.
+func codexToolCall(pl codexPayload) ToolCall {
+	tc := ToolCall{ID: pl.CallID, Name: pl.Name}
+	switch pl.Name {
+	case "shell", "exec_command", "local_shell":
+		var args struct {
+			Command json.RawMessage `json:"command"`
+			Cmd     string          `json:"cmd"`
+		}
+		_ = json.Unmarshal([]byte(pl.Arguments), &args)
+		tc.Action = ActionRunning
+		tc.Detail = firstNonEmpty(codexCommand(args.Command), args.Cmd)
+	case "apply_patch":
+		tc.Action, tc.File = ActionEditing, true
+		if m := codexPatchFileRe.FindStringSubmatch(pl.Input + pl.Arguments); m != nil {
+			tc.Detail = strings.TrimSpace(m[1])
+		}
+	default:
+		tc.Action = ActionWorking
+		tc.Detail = pl.Name
+	}
+	return tc
+}
+
+// codexCommand returns the command of a shell call, given as a string or as
+// an argv like ["bash", "-lc", "go test ./..."].
+func codexCommand(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var argv []string
+	if json.Unmarshal(raw, &argv) != nil || len(argv) == 0 {
+		return ""
+	}
+	if len(argv) == 3 && (argv[1] == "-lc" || argv[1] == "-c") {
+		return argv[2]
+	}
+	return strings.Join(argv, " ")
+}
@@ -0,0 +1,359 @@
+// Package transcript follows the session logs agent programs write, such as
+// Claude Code's JSONL transcripts, and derives what the agent is doing from
+// them: tool calls, files edited, token usage and turn boundaries.
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actions of tool calls, matching the activity names shown in the list.
+const (
+	ActionEditing   = "editing"
+	ActionReading   = "reading"
+	ActionRunning   = "running"
+	ActionSearching = "searching"
+	ActionWorking   = "working"
+)
+
+// maxToolCalls caps how many recent tool calls State keeps.
+const maxToolCalls = 20
+
+// relocateInterval is how often a Reader looks for a newer transcript, which
+// agents start when a conversation is cleared.
+const relocateInterval = 10 * time.Second
+
+// ToolCall is a tool the agent called.
+type ToolCall struct {
+	ID   string
+	Name string
+	// Action is one of the Action constants.
+	Action string
+	// Detail is the command, pattern or file the call is about.
+	Detail string
+	// File is set when Detail is a file path.
+	File bool
+	// Done is set once the tool's result is in the transcript.
+	Done bool
+	At   time.Time
+}
+
+// Usage counts model tokens.
+type Usage struct {
+	InputTokens      int64 `json:"input_tokens"`
+	OutputTokens     int64 `json:"output_tokens"`
+	CacheReadTokens  int64 `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int64 `json:"cache_write_tokens,omitempty"`
+}
+
+// Add returns the sum of u and o.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		InputTokens:      u.InputTokens + o.InputTokens,
+		OutputTokens:     u.OutputTokens + o.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens + o.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens + o.CacheWriteTokens,
+	}
+}
+
+// Sub returns u minus o.
+func (u Usage) Sub(o Usage) Usage {
+	return Usage{
+		InputTokens:      u.InputTokens - o.InputTokens,
+		OutputTokens:     u.OutputTokens - o.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens - o.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens - o.CacheWriteTokens,
+	}
+}
+
+// Total returns the number of tokens of all kinds.
+func (u Usage) Total() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// State is what a transcript says about the agent so far.
+type State struct {
+	// ToolCalls are the most recent tool calls, oldest first.
+	ToolCalls []ToolCall
+	// FilesEdited lists the files the agent edited, in first-edit order.
+	FilesEdited []string
+	// Usage is the token usage of all models.
+	Usage Usage
+	// UsageByModel splits Usage by model name.
+	UsageByModel map[string]Usage
+	// Turns counts the user turns started.
+	Turns int
+	// InTurn is set while the agent is working on a turn.
+	InTurn    bool
+	UpdatedAt time.Time
+
+	// countSince skips the usage of entries written before it, which was
+	// counted by an earlier reader.
+	countSince time.Time
+}
+
+// LastToolCall returns the most recent tool call, or nil.
+func (s *State) LastToolCall() *ToolCall {
+	if len(s.ToolCalls) == 0 {
+		return nil
+	}
+	return &s.ToolCalls[len(s.ToolCalls)-1]
+}
+
+// startTurn records the start of a user turn.
+func (s *State) startTurn() {
+	s.Turns++
+	s.InTurn = true
+}
+
+func (s *State) addToolCall(tc ToolCall) {
+	s.ToolCalls = append(s.ToolCalls, tc)
+	if len(s.ToolCalls) > maxToolCalls {
+		s.ToolCalls = append([]ToolCall(nil), s.ToolCalls[len(s.ToolCalls)-maxToolCalls:]...)
+	}
+}
+
+// finishToolCall marks the call with id done.
+func (s *State) finishToolCall(id string) {
+	for i := len(s.ToolCalls) - 1; i >= 0; i-- {
+		if s.ToolCalls[i].ID == id {
+			s.ToolCalls[i].Done = true
+			return
+		}
+	}
+}
+
+func (s *State) addEdit(path string) {
+	for _, f := range s.FilesEdited {
+		if f == path {
+			return
+		}
+	}
+	s.FilesEdited = append(s.FilesEdited, path)
+}
+
+// addUsage counts the usage of an entry written at the given time, which is
+// zero if the transcript doesn't say.
+func (s *State) addUsage(model string, at time.Time, u Usage) {
+	if !at.IsZero() && !at.After(s.countSince) {
+		return
+	}
+	s.Usage = s.Usage.Add(u)
+	if s.UsageByModel == nil {
+		s.UsageByModel = make(map[string]Usage)
+	}
+	s.UsageByModel[model] = s.UsageByModel[model].Add(u)
+}
+
+// clone returns a deep copy of s.
+func (s *State) clone() State {
+	c := *s
+	c.ToolCalls = append([]ToolCall(nil), s.ToolCalls...)
+	c.FilesEdited = append([]string(nil), s.FilesEdited...)
+	c.UsageByModel = make(map[string]Usage, len(s.UsageByModel))
+	for m, u := range s.UsageByModel {
+		c.UsageByModel[m] = u
+	}
+	return c
+}
+
+// Format is an agent's transcript format.
+type Format interface {
+	// Name identifies the format, e.g. "claude".
+	Name() string
+	// Locate returns the newest transcript of a session running in workDir
+	// that was written since the given time, skipping paths in exclude. It
+	// returns "" if there is none yet.
+	Locate(workDir string, since time.Time, exclude func(path string) bool) (string, error)
+	// NewParser returns a parser for one transcript file.
+	NewParser() Parser
+}
+
+// Parser applies the lines of one transcript file to a State.
+type Parser interface {
+	Apply(line []byte, s *State)
+}
+
+var formats = map[string]Format{
+	"claude": Claude,
+	"codex":  Codex,
+}
+
+// FormatNamed returns the format called name.
+func FormatNamed(name string) (Format, error) {
+	if f, ok := formats[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown transcript format %q", name)
+}
+
+// claims maps transcript paths to the Reader following them, so agents
+// sharing a worktree don't follow the same transcript.
+var (
+	claimsMu sync.Mutex
+	claims   = make(map[string]*Reader)
+)
+
+// Reader follows the transcript of one agent session. Safe for concurrent
+// use.
+type Reader struct {
+	format  Format
+	workDir string
+	since   time.Time
+
+	mu         sync.Mutex
+	path       string
+	offset     int64
+	partial    []byte
+	parser     Parser
+	lastLocate time.Time
+	state      State
+	// cursors holds how far transcripts followed before were read, so
+	// switching back to one doesn't count its entries again.
+	cursors map[string]cursor
+}
+
+// cursor is a Reader's position in a transcript.
+type cursor struct {
+	offset  int64
+	partial []byte
+	parser  Parser
+}
+
+// NewReader creates a Reader for the agent session in workDir that started at
+// since.
+func NewReader(format Format, workDir string, since time.Time) *Reader {
+	return &Reader{format: format, workDir: workDir, since: since}
+}
+
+// Poll reads what was appended to the transcript since the last call. It
+// reports whether the state changed.
+func (r *Reader) Poll() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.path == "" || time.Since(r.lastLocate) > relocateInterval {
+		if err := r.locate(); err != nil {
+			return false, err
+		}
+	}
+	if r.path == "" {
+		return false, nil
+	}
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		path := r.path
+		r.switchTo("")
+		delete(r.cursors, path)
+		return false, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return false, err
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	r.offset += int64(len(data))
+
+	data = append(r.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		r.partial = data
+		return false, nil
+	}
+	r.partial = append([]byte(nil), data[end+1:]...)
+	for _, line := range bytes.Split(data[:end], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			r.parser.Apply(line, &r.state)
+		}
+	}
+	r.state.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// locate looks for the newest transcript and switches to it if it changed.
+func (r *Reader) locate() error {
+	r.lastLocate = time.Now()
+	path, err := r.format.Locate(r.workDir, r.since, func(p string) bool {
+		claimsMu.Lock()
+		defer claimsMu.Unlock()
+		owner, ok := claims[p]
+		return ok && owner != r
+	})
+	if err != nil {
+		return err
+	}
+	if path != "" && path != r.path {
+		r.switchTo(path)
+	}
+	return nil
+}
+
+// switchTo starts following path from where the reader left it, or from its
+// beginning if it wasn't followed before. State carries over, so usage keeps
+// adding up across transcripts.
+func (r *Reader) switchTo(path string) {
+	claimsMu.Lock()
+	if r.path != "" && claims[r.path] == r {
+		delete(claims, r.path)
+	}
+	if path != "" {
+		claims[path] = r
+	}
+	claimsMu.Unlock()
+
+	if r.path != "" {
+		if r.cursors == nil {
+			r.cursors = make(map[string]cursor)
+		}
+		r.cursors[r.path] = cursor{offset: r.offset, partial: r.partial, parser: r.parser}
+	}
+	r.path = path
+	if c, ok := r.cursors[path]; ok {
+		r.offset, r.partial, r.parser = c.offset, c.partial, c.parser
+	} else {
+		r.offset, r.partial, r.parser = 0, nil, r.format.NewParser()
+	}
+	r.state.InTurn = false
+}
+
+// CountUsageSince makes the reader skip the usage of entries written at or
+// before t, such as usage already counted before a restart. Call it before
+// the first Poll.
+func (r *Reader) CountUsageSince(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.countSince = t
+}
+
+// Path returns the transcript being followed, or "" if none was found yet.
+func (r *Reader) Path() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.path
+}
+
+// State returns a copy of the state read so far.
+func (r *Reader) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.clone()
+}
+
+// Close stops following the transcript.
+func (r *Reader) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.switchTo("")
+}
@@ -0,0 +1,176 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const claudeTranscript = `{"type":"user","message":{"role":"user","content":"fix the failing test"},"timestamp":"2026-01-02T10:00:00Z"}
+{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","content":[{"type":"thinking","thinking":"..."}],"usage":{"input_tokens":100,"output_tokens":5,"cache_read_input_tokens":1000,"cache_creation_input_tokens":200}},"timestamp":"2026-01-02T10:00:01Z"}
+{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"tu_1","name":"Bash","input":{"command":"go test ./..."}}],"usage":{"input_tokens":100,"output_tokens":40,"cache_read_input_tokens":1000,"cache_creation_input_tokens":200}},"timestamp":"2026-01-02T10:00:02Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"FAIL"}]},"timestamp":"2026-01-02T10:00:05Z"}
+{"type":"assistant","message":{"id":"msg_2","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"tu_2","name":"Edit","input":{"file_path":"/repo/auth.go","old_string":"a","new_string":"b"}}],"usage":{"input_tokens":10,"output_tokens":20}},"timestamp":"2026-01-02T10:00:06Z"}
+`
+
+const claudeTurnEnd = `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_2","content":"ok"}]}}
+{"type":"assistant","message":{"id":"msg_3","model":"claude-haiku-4-5","content":[{"type":"text","text":"Fixed."}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":2}}}
+`
+
+func applyAll(p Parser, lines string) State {
+	var s State
+	for _, line := range strings.Split(strings.TrimSpace(lines), "\n") {
+		p.Apply([]byte(line), &s)
+	}
+	return s
+}
+
+func TestClaudeParser(t *testing.T) {
+	s := applyAll(Claude.NewParser(), claudeTranscript+claudeTurnEnd)
+
+	assert.Equal(t, 1, s.Turns)
+	assert.False(t, s.InTurn)
+	require.Len(t, s.ToolCalls, 2)
+	assert.Equal(t, ToolCall{ID: "tu_1", Name: "Bash", Action: ActionRunning, Detail: "go test ./...", Done: true,
+		At: time.Date(2026, 1, 2, 10, 0, 2, 0, time.UTC)}, s.ToolCalls[0])
+	assert.Equal(t, ActionEditing, s.ToolCalls[1].Action)
+	assert.True(t, s.ToolCalls[1].Done)
+	assert.Equal(t, []string{"/repo/auth.go"}, s.FilesEdited)
+
+	// msg_1's usage is counted once, with its final output count.
+	assert.Equal(t, Usage{InputTokens: 111, OutputTokens: 62, CacheReadTokens: 1000, CacheWriteTokens: 200}, s.Usage)
+	assert.Equal(t, Usage{InputTokens: 1, OutputTokens: 2}, s.UsageByModel["claude-haiku-4-5"])
+
+	// Usage counted before a restart is skipped; msg_2 and the untimed msg_3 are not.
+	p := Claude.NewParser()
+	s = State{countSince: time.Date(2026, 1, 2, 10, 0, 5, 0, time.UTC)}
+	for _, line := range strings.Split(strings.TrimSpace(claudeTranscript+claudeTurnEnd), "\n") {
+		p.Apply([]byte(line), &s)
+	}
+	assert.Equal(t, Usage{InputTokens: 11, OutputTokens: 22}, s.Usage)
+}
+
+func TestCodexParser(t *testing.T) {
+	rollout := `{"type":"session_meta","payload":{"id":"s1","cwd":"/repo"}}
+{"type":"turn_context","payload":{"cwd":"/repo","model":"gpt-5-codex"}}
+{"type":"event_msg","payload":{"type":"task_started"}}
+{"type":"response_item","timestamp":"2026-01-02T10:00:00Z","payload":{"type":"function_call","name":"shell","call_id":"c1","arguments":"{\"command\":[\"bash\",\"-lc\",\"make test\"]}"}}
+{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":50}}}}
+{"type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"ok"}}
+{"type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","call_id":"c2","input":"*** Begin Patch\n*** Update File: src/app.py\n@@\n-a\n+b\n*** Add File: src/new.py\n+x\n*** End Patch"}}
+{"type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1500,"cached_input_tokens":600,"output_tokens":80}}}}
+`
+	s := applyAll(Codex.NewParser(), rollout)
+
+	assert.Equal(t, 1, s.Turns)
+	assert.True(t, s.InTurn)
+	require.Len(t, s.ToolCalls, 2)
+	assert.Equal(t, "make test", s.ToolCalls[0].Detail)
+	assert.True(t, s.ToolCalls[0].Done)
+	assert.Equal(t, ActionEditing, s.ToolCalls[1].Action)
+	assert.Equal(t, "src/app.py", s.ToolCalls[1].Detail)
+	assert.Equal(t, []string{"src/app.py", "src/new.py"}, s.FilesEdited)
+	assert.Equal(t, Usage{InputTokens: 900, OutputTokens: 80, CacheReadTokens: 600}, s.UsageByModel["gpt-5-codex"])
+
+	s = applyAll(Codex.NewParser(), rollout+`{"type":"event_msg","payload":{"type":"task_complete"}}`)
+	assert.False(t, s.InTurn)
+}
+
+func TestReaderTailsClaudeTranscript(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+	workDir := "/home/me/.hivemind/worktrees/api_1"
+	projectDir := filepath.Join(configDir, "projects", "-home-me--hivemind-worktrees-api-1")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	r := NewReader(Claude, workDir, time.Now().Add(-time.Minute))
+	defer r.Close()
+	changed, err := r.Poll()
+	require.NoError(t, err)
+	assert.False(t, changed, "no transcript yet")
+
+	path := filepath.Join(projectDir, "session.jsonl")
+	// The last line is incomplete and must wait for the rest.
+	cut := strings.LastIndex(strings.TrimSuffix(claudeTranscript, "\n"), "\n") + 20
+	require.NoError(t, os.WriteFile(path, []byte(claudeTranscript[:cut]), 0644))
+	r.lastLocate = time.Time{}
+	changed, err = r.Poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, path, r.Path())
+	assert.Len(t, r.State().ToolCalls, 1)
+	assert.True(t, r.State().InTurn)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(claudeTranscript[cut:] + claudeTurnEnd)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = r.Poll()
+	require.NoError(t, err)
+	state := r.State()
+	assert.Len(t, state.ToolCalls, 2)
+	assert.False(t, state.InTurn)
+	assert.Equal(t, int64(62), state.Usage.OutputTokens)
+
+	// A second reader for the same directory doesn't take the claimed file.
+	other := NewReader(Claude, workDir, time.Now().Add(-time.Minute))
+	defer other.Close()
+	_, err = other.Poll()
+	require.NoError(t, err)
+	assert.Empty(t, other.Path())
+}
+
+func TestReaderSwitchingBackDoesNotRecount(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+	workDir := "/home/me/repo"
+	projectDir := filepath.Join(configDir, "projects", "-home-me-repo")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	touch := func(path string, at time.Time) {
+		require.NoError(t, os.Chtimes(path, at, at))
+	}
+	now := time.Now()
+	first := filepath.Join(projectDir, "first.jsonl")
+	second := filepath.Join(projectDir, "second.jsonl")
+	require.NoError(t, os.WriteFile(first, []byte(claudeTranscript), 0644))
+	touch(first, now)
+
+	r := NewReader(Claude, workDir, now.Add(-time.Minute))
+	defer r.Close()
+	_, err := r.Poll()
+	require.NoError(t, err)
+	want := r.State().Usage
+
+	require.NoError(t, os.WriteFile(second, []byte(claudeTurnEnd), 0644))
+	touch(second, now.Add(time.Second))
+	r.lastLocate = time.Time{}
+	_, err = r.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, second, r.Path())
+	want = want.Add(Usage{InputTokens: 1, OutputTokens: 2})
+	assert.Equal(t, want, r.State().Usage)
+
+	// Back to the first transcript: only what was appended is counted.
+	touch(first, now.Add(2*time.Second))
+	r.lastLocate = time.Time{}
+	_, err = r.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, first, r.Path())
+	assert.Equal(t, want, r.State().Usage)
+}
+
+func TestFormatNamed(t *testing.T) {
+	f, err := FormatNamed("codex")
+	require.NoError(t, err)
+	assert.Equal(t, "codex", f.Name())
+	_, err = FormatNamed("nope")
+	assert.Error(t, err)
+}
@@ -0,0 +1,337 @@
+package session
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ByteMirror/hivemind/config"
+	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session/transcript"
+)
+
+// TokenUsage is the model usage of an instance, or of a group of instances.
+type TokenUsage struct {
+	// Tokens is the usage of all models.
+	Tokens transcript.Usage `json:"tokens"`
+	// ByModel splits Tokens by model name.
+	ByModel map[string]transcript.Usage `json:"by_model,omitempty"`
+	// CostUSD is the estimated cost in US dollars of the models with a known
+	// price. When Unpriced is not empty it is only a lower bound.
+	CostUSD float64 `json:"cost_usd"`
+	// Unpriced lists the models used whose price is not known.
+	Unpriced []string `json:"unpriced,omitempty"`
+	// UpdatedAt is when the agent's transcript was last read. Usage written
+	// before it has been counted.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Add returns the sum of u and o.
+func (u TokenUsage) Add(o TokenUsage) TokenUsage {
+	sum := TokenUsage{
+		Tokens:    u.Tokens.Add(o.Tokens),
+		CostUSD:   u.CostUSD + o.CostUSD,
+		UpdatedAt: u.UpdatedAt,
+	}
+	if o.UpdatedAt.After(sum.UpdatedAt) {
+		sum.UpdatedAt = o.UpdatedAt
+	}
+	if len(u.Unpriced)+len(o.Unpriced) > 0 {
+		sum.Unpriced = append(append([]string{}, u.Unpriced...), o.Unpriced...)
+		sort.Strings(sum.Unpriced)
+		sum.Unpriced = slices.Compact(sum.Unpriced)
+	}
+	if len(u.ByModel)+len(o.ByModel) > 0 {
+		sum.ByModel = make(map[string]transcript.Usage, len(u.ByModel)+len(o.ByModel))
+		for m, mu := range u.ByModel {
+			sum.ByModel[m] = mu
+		}
+		for m, mu := range o.ByModel {
+			sum.ByModel[m] = sum.ByModel[m].Add(mu)
+		}
+	}
+	return sum
+}
+
+// CostText formats the estimated cost for display. When some models have no
+// known price, the cost is shown as a lower bound, or as unknown if no model
+// has one.
+func (u TokenUsage) CostText() string {
+	switch {
+	case len(u.Unpriced) == 0:
+		return FormatCost(u.CostUSD)
+	case u.CostUSD == 0:
+		return "$?"
+	}
+	return ">" + FormatCost(u.CostUSD)
+}
+
+// OverBudget reports whether u exceeds a limit of the budget. The cost limit
+// only counts models with a known price; see Unpriced.
+func (u TokenUsage) OverBudget(b *config.BudgetConfig) bool {
+	if b == nil {
+		return false
+	}
+	return (b.MaxCostUSD > 0 && u.CostUSD > b.MaxCostUSD) ||
+		(b.MaxTokens > 0 && u.Tokens.Total() > b.MaxTokens)
+}
+
+// EnforceBudget pauses the instance if it is running and its usage exceeds
+// b. An instance already paused over budget once is left alone, as the user
+// resumed it anyway. It reports whether it paused the instance.
+func (i *Instance) EnforceBudget(b *config.BudgetConfig) (bool, error) {
+	if !i.Started() || i.Paused() || i.Status == Loading || i.BudgetPaused || !i.Usage.OverBudget(b) {
+		return false, nil
+	}
+	i.BudgetPaused = true
+
+	// Set Loading immediately to prevent a concurrent status update from
+	// overwriting the status while Pause() is running.
+	prevStatus := i.Status
+	i.SetStatus(Loading)
+	i.LoadingMessage = "Pausing..."
+	if err := i.Pause(); err != nil {
+		i.SetStatus(prevStatus)
+		return false, err
+	}
+	log.InfoLog.Printf("paused %s: usage %s tokens, %s over budget", i.Title,
+		FormatTokens(i.Usage.Tokens.Total()), i.Usage.CostText())
+	return true, nil
+}
+
+// usageFromTranscript converts what a transcript says into a TokenUsage.
+func usageFromTranscript(s transcript.State) TokenUsage {
+	u := TokenUsage{Tokens: s.Usage, ByModel: s.UsageByModel, UpdatedAt: s.UpdatedAt}
+	for model, mu := range s.UsageByModel {
+		u.CostUSD += EstimateCost(model, mu)
+		if _, ok := modelPrice(model); !ok && mu.Total() > 0 {
+			u.Unpriced = append(u.Unpriced, model)
+		}
+	}
+	sort.Strings(u.Unpriced)
+	return u
+}
+
+// UpdateUsage counts the tokens the agent used, as its transcript reports
+// them, on top of the usage of earlier runs.
+func (i *Instance) UpdateUsage() {
+	if r := i.pollTranscript(); r != nil {
+		i.Usage = i.usageBase.Add(usageFromTranscript(r.State()))
+	}
+}
+
+// ModelPrice is what a model costs in US dollars per million tokens.
+type ModelPrice struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// modelPrices are list prices by model name prefix. The longest matching
+// prefix applies.
+var modelPrices = map[string]ModelPrice{
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.4, CacheRead: 0.005},
+	"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.5},
+	"o3":                {Input: 2, Output: 8, CacheRead: 0.5},
+	"o4-mini":           {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10, CacheRead: 0.31},
+	"gemini-2.5-flash":  {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+}
+
+// modelPrice returns the price of a model, and whether it is known.
+func modelPrice(model string) (ModelPrice, bool) {
+	var price ModelPrice
+	best := -1
+	for prefix, p := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			price, best = p, len(prefix)
+		}
+	}
+	return price, best >= 0
+}
+
+// EstimateCost returns the cost in US dollars of the usage of a model, or 0
+// if its price is not known.
+func EstimateCost(model string, u transcript.Usage) float64 {
+	price, _ := modelPrice(model)
+	return (float64(u.InputTokens)*price.Input +
+		float64(u.OutputTokens)*price.Output +
+		float64(u.CacheReadTokens)*price.CacheRead +
+		float64(u.CacheWriteTokens)*price.CacheWrite) / 1e6
+}
+
+// FormatTokens formats a token count for display, e.g. "12.3k".
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// FormatCost formats a cost in US dollars for display.
+func FormatCost(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// UsageGroup is the usage of the instances sharing a topic, workflow,
+// automation or model.
+type UsageGroup struct {
+	Name      string     `json:"name"`
+	Instances int        `json:"instances,omitempty"`
+	Usage     TokenUsage `json:"usage"`
+}
+
+// UsageReport rolls the usage of instances up by topic, workflow,
+// automation and model.
+type UsageReport struct {
+	Total       TokenUsage   `json:"total"`
+	Instances   []UsageGroup `json:"instances"`
+	Topics      []UsageGroup `json:"topics"`
+	Workflows   []UsageGroup `json:"workflows"`
+	Automations []UsageGroup `json:"automations"`
+	Models      []UsageGroup `json:"models"`
+}
+
+// NewUsageReport builds a report of the instances' usage. workflows maps
+// workflow IDs to the titles of the instances their tasks ran in, and
+// automations maps automation IDs to their names.
+func NewUsageReport(instances []InstanceData, workflows map[string][]string, automations map[string]string) UsageReport {
+	var r UsageReport
+	byTitle := make(map[string]TokenUsage, len(instances))
+	topics := make(map[string]*UsageGroup)
+	autos := make(map[string]*UsageGroup)
+	add := func(groups map[string]*UsageGroup, name string, u TokenUsage) {
+		g := groups[name]
+		if g == nil {
+			g = &UsageGroup{Name: name}
+			groups[name] = g
+		}
+		g.Instances++
+		g.Usage = g.Usage.Add(u)
+	}
+
+	for _, data := range instances {
+		u := data.Usage
+		if u.Tokens.Total() == 0 {
+			continue
+		}
+		byTitle[data.Title] = u
+		r.Total = r.Total.Add(u)
+		r.Instances = append(r.Instances, UsageGroup{Name: data.Title, Usage: u})
+		if data.TopicName != "" {
+			add(topics, data.TopicName, u)
+		}
+		if data.AutomationID != "" {
+			name := automations[data.AutomationID]
+			if name == "" {
+				name = data.AutomationID
+			}
+			add(autos, name, u)
+		}
+	}
+
+	wfs := make(map[string]*UsageGroup)
+	for id, titles := range workflows {
+		seen := make(map[string]bool, len(titles))
+		for _, title := range titles {
+			if u, ok := byTitle[title]; ok && !seen[title] {
+				seen[title] = true
+				add(wfs, id, u)
+			}
+		}
+	}
+
+	for model, mu := range r.Total.ByModel {
+		u := TokenUsage{
+			Tokens:  mu,
+			ByModel: map[string]transcript.Usage{model: mu},
+			CostUSD: EstimateCost(model, mu),
+		}
+		if _, ok := modelPrice(model); !ok {
+			u.Unpriced = []string{model}
+		}
+		r.Models = append(r.Models, UsageGroup{Name: model, Usage: u})
+	}
+
+	sortUsageGroups(r.Instances)
+	r.Topics = sortedUsageGroups(topics)
+	r.Workflows = sortedUsageGroups(wfs)
+	r.Automations = sortedUsageGroups(autos)
+	sortUsageGroups(r.Models)
+	return r
+}
+
+func sortedUsageGroups(groups map[string]*UsageGroup) []UsageGroup {
+	list := make([]UsageGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, *g)
+	}
+	sortUsageGroups(list)
+	return list
+}
+
+// sortUsageGroups sorts the most expensive groups first.
+func sortUsageGroups(groups []UsageGroup) {
+	sort.Slice(groups, func(a, b int) bool {
+		ua, ub := groups[a].Usage, groups[b].Usage
+		if ua.CostUSD != ub.CostUSD {
+			return ua.CostUSD > ub.CostUSD
+		}
+		if ua.Tokens.Total() != ub.Tokens.Total() {
+			return ua.Tokens.Total() > ub.Tokens.Total()
+		}
+		return groups[a].Name < groups[b].Name
+	})
+}
+
+// String formats the report as a plain-text table.
+func (r UsageReport) String() string {
+	var b strings.Builder
+	row := func(name string, u TokenUsage) {
+		if len(name) > 32 {
+			name = name[:31] + "…"
+		}
+		fmt.Fprintf(&b, "  %-32s %10s %10s\n", name, FormatTokens(u.Tokens.Total()), u.CostText())
+	}
+	fmt.Fprintf(&b, "  %-32s %10s %10s\n", "", "tokens", "cost")
+	row("Total", r.Total)
+	for _, section := range []struct {
+		title  string
+		groups []UsageGroup
+	}{
+		{"Instances", r.Instances},
+		{"Topics", r.Topics},
+		{"Workflows", r.Workflows},
+		{"Automations", r.Automations},
+		{"Models", r.Models},
+	} {
+		if len(section.groups) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s\n", section.title)
+		for _, g := range section.groups {
+			row(g.Name, g.Usage)
+		}
+	}
+	if len(r.Total.Unpriced) > 0 {
+		fmt.Fprintf(&b, "\nNo price is known for %s; their cost is not included.\n", strings.Join(r.Total.Unpriced, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ByteMirror/hivemind/config"
+	"github.com/ByteMirror/hivemind/session/transcript"
+)
+
+func usageOf(model string, in, out int64) TokenUsage {
+	u := transcript.Usage{InputTokens: in, OutputTokens: out}
+	return TokenUsage{
+		Tokens:  u,
+		ByModel: map[string]transcript.Usage{model: u},
+		CostUSD: EstimateCost(model, u),
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	u := transcript.Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 2_000_000}
+
+	// claude-sonnet-4-5: 3 + 1.5 + 0.6
+	if got := EstimateCost("claude-sonnet-4-5-20250929", u); math.Abs(got-5.1) > 1e-9 {
+		t.Errorf("expected $5.10, got %v", got)
+	}
+	// The longest prefix wins: opus 4.5 is cheaper than opus 4.
+	if got := EstimateCost("claude-opus-4-5", u); math.Abs(got-8.5) > 1e-9 {
+		t.Errorf("expected $8.50, got %v", got)
+	}
+	if got := EstimateCost("local-model", u); got != 0 {
+		t.Errorf("expected no cost for an unknown model, got %v", got)
+	}
+}
+
+func TestNewUsageReport(t *testing.T) {
+	instances := []InstanceData{
+		{Title: "api", TopicName: "auth", Usage: usageOf("claude-sonnet-4-5", 1_000_000, 0)},
+		{Title: "web", TopicName: "auth", Usage: usageOf("gpt-5-codex", 2_000_000, 0)},
+		{Title: "nightly", AutomationID: "a1", Usage: usageOf("claude-sonnet-4-5", 0, 100_000)},
+		{Title: "idle", TopicName: "auth"},
+	}
+	workflows := map[string][]string{"wf-1": {"api", "web", "api", "gone"}}
+	r := NewUsageReport(instances, workflows, map[string]string{"a1": "Nightly review"})
+
+	if r.Total.Tokens.Total() != 3_100_000 {
+		t.Errorf("expected 3.1M tokens in total, got %d", r.Total.Tokens.Total())
+	}
+	if len(r.Instances) != 3 || r.Instances[0].Name != "api" {
+		t.Errorf("expected instances with usage, most expensive first, got %+v", r.Instances)
+	}
+	if len(r.Topics) != 1 || r.Topics[0].Instances != 2 || r.Topics[0].Usage.Tokens.InputTokens != 3_000_000 {
+		t.Errorf("unexpected topic rollup: %+v", r.Topics)
+	}
+	if len(r.Workflows) != 1 || r.Workflows[0].Instances != 2 {
+		t.Errorf("expected each workflow instance counted once, got %+v", r.Workflows)
+	}
+	if len(r.Automations) != 1 || r.Automations[0].Name != "Nightly review" {
+		t.Errorf("expected the automation by name, got %+v", r.Automations)
+	}
+	if len(r.Models) != 2 || r.Models[0].Name != "claude-sonnet-4-5" || r.Models[0].Usage.Tokens.Total() != 1_100_000 {
+		t.Errorf("unexpected model rollup: %+v", r.Models)
+	}
+}
+
+func TestOverBudget(t *testing.T) {
+	u := usageOf("claude-sonnet-4-5", 1_000_000, 0) // $3
+
+	if u.OverBudget(nil) {
+		t.Error("no budget is never exceeded")
+	}
+	if !u.OverBudget(&config.BudgetConfig{MaxCostUSD: 2}) {
+		t.Error("expected $3 to exceed a $2 budget")
+	}
+	if u.OverBudget(&config.BudgetConfig{MaxCostUSD: 5, MaxTokens: 2_000_000}) {
+		t.Error("expected usage within both limits")
+	}
+	if !u.OverBudget(&config.BudgetConfig{MaxTokens: 500_000}) {
+		t.Error("expected 1M tokens to exceed a 500k budget")
+	}
+}
+
+func TestUnpricedModels(t *testing.T) {
+	u := usageFromTranscript(transcript.State{
+		Usage: transcript.Usage{InputTokens: 2_000_000},
+		UsageByModel: map[string]transcript.Usage{
+			"claude-sonnet-4-5": {InputTokens: 1_000_000},
+			"local-model":       {InputTokens: 1_000_000},
+		},
+	})
+	if len(u.Unpriced) != 1 || u.Unpriced[0] != "local-model" {
+		t.Fatalf("expected local-model to be unpriced, got %v", u.Unpriced)
+	}
+	if got := u.CostText(); got != ">$3.00" {
+		t.Errorf("expected the cost to be shown as a lower bound, got %q", got)
+	}
+
+	sum := u.Add(usageOf("local-model", 10, 0)).Add(usageOf("gpt-5", 0, 0))
+	if len(sum.Unpriced) != 1 {
+		t.Errorf("expected unpriced models to be merged, got %v", sum.Unpriced)
+	}
+	if got := (TokenUsage{Unpriced: []string{"local-model"}}).CostText(); got != "$?" {
+		t.Errorf("expected an unknown cost, got %q", got)
+	}
+	if got := usageOf("gpt-5", 1_000_000, 0).CostText(); got != "$1.25" {
+		t.Errorf("expected a known cost, got %q", got)
+	}
+}
+
+func TestEnforceBudgetSkipsInstances(t *testing.T) {
+	budget := &config.BudgetConfig{MaxTokens: 500_000}
+	inst := &Instance{Title: "api", Status: Running, Usage: usageOf("claude-sonnet-4-5", 1_000_000, 0)}
+
+	if paused, err := inst.EnforceBudget(budget); paused || err != nil {
+		t.Errorf("an instance that isn't started should be left alone, got %v, %v", paused, err)
+	}
+
+	inst.started.Store(true)
+	inst.BudgetPaused = true
+	if paused, err := inst.EnforceBudget(budget); paused || err != nil {
+		t.Errorf("an instance resumed over budget should be left alone, got %v, %v", paused, err)
+	}
+	if !inst.ToInstanceData().BudgetPaused {
+		t.Error("BudgetPaused should be saved")
+	}
+}
@@ -296,6 +296,23 @@ func (l *List) SelectInstanceByRef(instance *session.Instance) {
 	}
 }
 
+// RevealInstance selects an instance in the filtered list, expanding its
+// parent's children first if it is a brain-spawned child. Returns false if the
+// instance is not visible under the current filter.
+func (l *List) RevealInstance(instance *session.Instance) bool {
+	if instance.ParentTitle != "" && !l.childExpanded[instance.ParentTitle] {
+		l.childExpanded[instance.ParentTitle] = true
+		l.rebuildFilteredItems()
+	}
+	for i, item := range l.items {
+		if item == instance {
+			l.selectedIdx = i
+			return true
+		}
+	}
+	return false
+}
+
 // ToggleExpanded toggles the sub-agent tree for the currently selected instance.
 // It first tries tmux sub-agents, then brain-spawned children.
 // Returns true if the toggle was meaningful.
@@ -114,6 +114,10 @@ func (r *InstanceRenderer) Render(i *session.Instance, selected bool, focused bo
 	if i.AutoYes {
 		skipPermsIndicator += " \uf00c"
 	}
+	// Warn when another instance is changing the same files.
+	if len(i.OverlapsWith) > 0 {
+		skipPermsIndicator += " \uf071"
+	}
 
 	titleContent := fmt.Sprintf("%s %s%s", prefix, titleText, skipPermsIndicator)
 	// Build title line: content + spaces + status icon, all fitting within r.width
@@ -259,7 +263,8 @@ func (r *InstanceRenderer) Render(i *session.Instance, selected bool, focused bo
 	// Each segment gets the row background explicitly so ANSI resets between
 	// styled spans don't create black gaps on highlighted rows.
 	var thirdLine string
-	hasResource := i.Status != session.Paused && i.MemMB > 0
+	resource := resourceText(i)
+	hasResource := resource != ""
 	hasRole := i.Role != ""
 	if hasResource || diff != "" || hasRole {
 		bg := descS.GetBackground()
@@ -269,9 +274,7 @@ func (r *InstanceRenderer) Render(i *session.Instance, selected bool, focused bo
 		var leftRendered string
 		var leftWidth int
 		if hasResource {
-			cpuText := fmt.Sprintf("\U000f0d46 %.0f%%", i.CPUPercent)
-			memText := fmt.Sprintf("\uefc5 %.0fM", i.MemMB)
-			resourceRaw := fmt.Sprintf("%s %s  %s", strings.Repeat(" ", len(prefix)), cpuText, memText)
+			resourceRaw := fmt.Sprintf("%s %s", strings.Repeat(" ", len(prefix)), resource)
 			leftWidth = runewidth.StringWidth(resourceRaw)
 			leftRendered = resourceStyle.Background(bg).Render(resourceRaw)
 
@@ -318,9 +321,7 @@ func (r *InstanceRenderer) Render(i *session.Instance, selected bool, focused bo
 				maxLeft = 4
 			}
 			// Rebuild resource text truncated to maxLeft.
-			cpuText := fmt.Sprintf("\U000f0d46 %.0f%%", i.CPUPercent)
-			memText := fmt.Sprintf("\uefc5 %.0fM", i.MemMB)
-			resourceRaw := fmt.Sprintf("%s %s  %s", strings.Repeat(" ", len(prefix)), cpuText, memText)
+			resourceRaw := fmt.Sprintf("%s %s", strings.Repeat(" ", len(prefix)), resource)
 			resourceRaw = runewidth.Truncate(resourceRaw, maxLeft, "...")
 			leftWidth = runewidth.StringWidth(resourceRaw)
 			leftRendered = resourceStyle.Background(bg).Render(resourceRaw)
@@ -495,6 +496,23 @@ func (l *List) buildScrollbar() string {
 	return strings.Join(lines, "\n")
 }
 
+// resourceText returns the CPU, memory and model usage shown on an instance's
+// third line, or "" if there is none.
+func resourceText(i *session.Instance) string {
+	var parts []string
+	if i.Status != session.Paused && i.MemMB > 0 {
+		parts = append(parts, fmt.Sprintf("\U000f0d46 %.0f%%", i.CPUPercent), fmt.Sprintf("\uefc5 %.0fM", i.MemMB))
+	}
+	if tokens := i.Usage.Tokens.Total(); tokens > 0 {
+		usage := session.FormatTokens(tokens) + " tok"
+		if i.Usage.CostUSD > 0 || len(i.Usage.Unpriced) > 0 {
+			usage += " " + i.Usage.CostText()
+		}
+		parts = append(parts, usage)
+	}
+	return strings.Join(parts, "  ")
+}
+
 // itemHeight returns the rendered row count for an instance entry.
 // Title style has Padding(1,0) top, desc style has Padding(0,1) bottom.
 // 2-line item (title+branch) = 4 rows; 3-line (with resource) = 6 rows.
@@ -502,7 +520,7 @@ func (l *List) buildScrollbar() string {
 func (l *List) itemHeight(idx int) int {
 	inst := l.items[idx]
 	base := 4 // title (1 pad top + 1 content) + branch (1 content + 1 pad bottom)
-	hasResource := inst.Status != session.Paused && inst.MemMB > 0
+	hasResource := resourceText(inst) != ""
 	stat := inst.GetDiffStats()
 	hasDiff := stat != nil && stat.Error == nil && !stat.IsEmpty()
 	hasRole := inst.Role != ""
@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 
 	"github.com/ByteMirror/hivemind/memory"
 )
@@ -1063,7 +1064,9 @@ func (b *MemoryBrowser) renderList(width int) string {
 			listTitle = "Memory Files (git)"
 		}
 	}
-	sb.WriteString(browserTitleStyle.Render(listTitle) + "\n\n")
+	sb.WriteString(browserTitleStyle.Render(listTitle) + "\n")
+	// The re-index status takes the blank line under the title.
+	sb.WriteString(browserFileMtimeStyle.Render(runewidth.Truncate(b.reindexStatus(), innerW, "…")) + "\n")
 
 	if len(b.files) == 0 {
 		sb.WriteString(browserFileMtimeStyle.Render("(no memory files)"))
@@ -1193,6 +1196,23 @@ func (b *MemoryBrowser) renderContent(width int) string {
 	return borderSt.Width(width - 2).Render(full)
 }
 
+// reindexStatus describes the re-embedding of the store after an embedding
+// provider change, or returns "" when the vectors are current.
+func (b *MemoryBrowser) reindexStatus() string {
+	p := b.mgr.ReindexProgress()
+	switch {
+	case p.Running && p.Total > 0:
+		return fmt.Sprintf("Re-indexing %d/%d (%d%%)", p.Done, p.Total, 100*p.Done/p.Total)
+	case p.Running:
+		return "Re-indexing..."
+	case p.Err != nil:
+		return "Re-index failed: " + p.Err.Error()
+	case p.Done < p.Total:
+		return fmt.Sprintf("%d chunks need re-indexing", p.Total-p.Done)
+	}
+	return ""
+}
+
 func (b *MemoryBrowser) renderHint() string {
 	if b.editing {
 		return browserHintStyle.Render("  [ctrl+s] save  [esc] cancel edit")
@@ -0,0 +1,222 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var inboxTextStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#dddddd")).
+	PaddingLeft(2)
+
+var inboxChoiceStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#7EC8D8")).
+	PaddingLeft(2)
+
+// InboxAction tells the caller what to do after a key press in the inbox.
+type InboxAction int
+
+const (
+	// InboxNone means the inbox handled the key itself.
+	InboxNone InboxAction = iota
+	// InboxClose means the inbox should be closed.
+	InboxClose
+	// InboxAnswer means the user picked one of the selected item's choices;
+	// see Answer.
+	InboxAnswer
+	// InboxCompose means the user wants to type a free-form answer to the
+	// selected item.
+	InboxCompose
+	// InboxApprove means the user approved the selected permission prompt.
+	InboxApprove
+	// InboxDeny means the user denied the selected permission prompt.
+	InboxDeny
+)
+
+// InboxKind is the kind of thing an inbox item asks of the user.
+type InboxKind int
+
+const (
+	// InboxQuestion is a question asked with ask_user.
+	InboxQuestion InboxKind = iota
+	// InboxPermission is a permission prompt an agent is blocked on.
+	InboxPermission
+)
+
+// InboxItem is something waiting for the user.
+type InboxItem struct {
+	// ID identifies the item to the caller; it is not displayed.
+	ID      string
+	Kind    InboxKind
+	From    string
+	Text    string
+	Choices []string
+	Since   time.Time
+}
+
+// Inbox is the queue of items that need the user's attention. It lets them
+// answer questions and approve or deny permission prompts one at a time.
+type Inbox struct {
+	items       []InboxItem
+	selectedIdx int
+	answer      string
+	width       int
+}
+
+// NewInbox creates an inbox showing items.
+func NewInbox(items []InboxItem) *Inbox {
+	in := &Inbox{width: 70}
+	in.SetItems(items)
+	return in
+}
+
+// SetItems replaces the items, keeping the selected item selected if it is
+// still present.
+func (in *Inbox) SetItems(items []InboxItem) {
+	var selID string
+	if sel := in.Selected(); sel != nil {
+		selID = sel.ID
+	}
+	in.items = items
+	in.selectedIdx = 0
+	for i, item := range items {
+		if item.ID == selID {
+			in.selectedIdx = i
+			break
+		}
+	}
+}
+
+// SetWidth sets the rendered width.
+func (in *Inbox) SetWidth(width int) {
+	in.width = width
+}
+
+// Len returns the number of items.
+func (in *Inbox) Len() int {
+	return len(in.items)
+}
+
+// Selected returns the highlighted item, or nil if the inbox is empty.
+func (in *Inbox) Selected() *InboxItem {
+	if in.selectedIdx < 0 || in.selectedIdx >= len(in.items) {
+		return nil
+	}
+	return &in.items[in.selectedIdx]
+}
+
+// Answer returns the choice picked by the last InboxAnswer action.
+func (in *Inbox) Answer() string {
+	return in.answer
+}
+
+// HandleKeyPress processes one key event and reports what the caller should do.
+func (in *Inbox) HandleKeyPress(msg tea.KeyMsg) InboxAction {
+	switch key := msg.String(); key {
+	case "esc", "q":
+		return InboxClose
+	case "up", "k":
+		if in.selectedIdx > 0 {
+			in.selectedIdx--
+		}
+	case "down", "j":
+		if in.selectedIdx < len(in.items)-1 {
+			in.selectedIdx++
+		}
+	case "enter", "a":
+		if sel := in.Selected(); sel != nil && sel.Kind == InboxQuestion {
+			return InboxCompose
+		}
+	case "y":
+		if sel := in.Selected(); sel != nil && sel.Kind == InboxPermission {
+			return InboxApprove
+		}
+	case "n":
+		if sel := in.Selected(); sel != nil && sel.Kind == InboxPermission {
+			return InboxDeny
+		}
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		sel := in.Selected()
+		n := int(key[0] - '1')
+		if sel != nil && n < len(sel.Choices) {
+			in.answer = sel.Choices[n]
+			return InboxAnswer
+		}
+	}
+	return InboxNone
+}
+
+// Render returns the inbox box.
+func (in *Inbox) Render() string {
+	innerWidth := in.width - 8 // borders + padding
+	if innerWidth < 20 {
+		innerWidth = 20
+	}
+
+	var b strings.Builder
+	b.WriteString(pickerTitleStyle.Render(fmt.Sprintf("Needs attention (%d)", len(in.items))))
+	b.WriteString("\n")
+
+	if len(in.items) == 0 {
+		b.WriteString(pickerHintStyle.Render("Nothing needs attention."))
+	}
+	for i, item := range in.items {
+		verb := "asks"
+		if item.Kind == InboxPermission {
+			verb = "needs permission"
+		}
+		header := fmt.Sprintf("%s %s · %s ago", item.From, verb, formatInboxAge(time.Since(item.Since)))
+		if i != in.selectedIdx {
+			line := ansi.Truncate(header+": "+firstLine(item.Text), innerWidth-4, "…")
+			b.WriteString(pickerItemStyle.Width(innerWidth).Render("  " + line))
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(pickerSelectedItemStyle.Width(innerWidth).Render("▸ " + header))
+		b.WriteString("\n")
+		b.WriteString(inboxTextStyle.Width(innerWidth).Render(item.Text))
+		b.WriteString("\n")
+		for n, choice := range item.Choices {
+			if n >= 9 {
+				break
+			}
+			b.WriteString(inboxChoiceStyle.Width(innerWidth).Render(fmt.Sprintf("%d) %s", n+1, choice)))
+			b.WriteString("\n")
+		}
+	}
+
+	hint := "↑↓ navigate • enter answer • esc close"
+	if sel := in.Selected(); sel != nil && sel.Kind == InboxPermission {
+		hint = "↑↓ navigate • y approve • n deny • esc close"
+	} else if sel != nil && len(sel.Choices) > 0 {
+		hint = "↑↓ navigate • 1-9 choose • enter answer freely • esc close"
+	}
+	b.WriteString(pickerHintStyle.Render(hint))
+
+	return pickerBorderStyle.Width(in.width).Render(b.String())
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + "…"
+	}
+	return s
+}
+
+// formatInboxAge renders a duration as a short age like "45s", "12m" or "3h".
+func formatInboxAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+}
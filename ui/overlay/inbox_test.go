@@ -0,0 +1,70 @@
+package overlay
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inboxKey(s string) tea.KeyMsg {
+	switch s {
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestInboxChoices(t *testing.T) {
+	in := NewInbox([]InboxItem{
+		{ID: "q-1", From: "api", Text: "Which database?", Choices: []string{"postgres", "sqlite"}, Since: time.Now()},
+		{ID: "q-2", From: "web", Text: "Ship it?", Since: time.Now()},
+	})
+
+	assert.Equal(t, InboxNone, in.HandleKeyPress(inboxKey("3")), "out-of-range choice is ignored")
+	require.Equal(t, InboxAnswer, in.HandleKeyPress(inboxKey("2")))
+	assert.Equal(t, "sqlite", in.Answer())
+
+	in.HandleKeyPress(inboxKey("down"))
+	assert.Equal(t, "q-2", in.Selected().ID)
+	assert.Equal(t, InboxNone, in.HandleKeyPress(inboxKey("1")), "item without choices has nothing to pick")
+	assert.Equal(t, InboxCompose, in.HandleKeyPress(inboxKey("enter")))
+	assert.Equal(t, InboxClose, in.HandleKeyPress(inboxKey("esc")))
+}
+
+func TestInboxSetItemsKeepsSelection(t *testing.T) {
+	in := NewInbox([]InboxItem{{ID: "a"}, {ID: "b"}})
+	in.HandleKeyPress(inboxKey("down"))
+
+	in.SetItems([]InboxItem{{ID: "new"}, {ID: "a"}, {ID: "b"}})
+	assert.Equal(t, "b", in.Selected().ID)
+
+	in.SetItems([]InboxItem{{ID: "a"}})
+	assert.Equal(t, "a", in.Selected().ID, "selection falls back to the first item")
+
+	in.SetItems(nil)
+	assert.Nil(t, in.Selected())
+	assert.Equal(t, InboxNone, in.HandleKeyPress(inboxKey("enter")))
+	assert.Contains(t, in.Render(), "Nothing needs attention")
+}
+
+func TestInboxPermissionItems(t *testing.T) {
+	in := NewInbox([]InboxItem{
+		{ID: "api", Kind: InboxPermission, From: "api", Text: "rm -rf build", Since: time.Now()},
+		{ID: "q-1", From: "web", Text: "Ship it?", Since: time.Now()},
+	})
+
+	assert.Equal(t, InboxNone, in.HandleKeyPress(inboxKey("enter")), "permission prompts are not answered freely")
+	assert.Equal(t, InboxApprove, in.HandleKeyPress(inboxKey("y")))
+	assert.Equal(t, InboxDeny, in.HandleKeyPress(inboxKey("n")))
+	assert.Contains(t, in.Render(), "api needs permission")
+
+	in.HandleKeyPress(inboxKey("down"))
+	assert.Equal(t, InboxNone, in.HandleKeyPress(inboxKey("y")), "questions cannot be approved")
+}
@@ -83,6 +83,21 @@ type SidebarItem struct {
 	RepoPath        string // repo path this item belongs to (for multi-repo disambiguation)
 }
 
+// sidebarLeaseStyle renders file lease lines below the topics.
+var sidebarLeaseStyle = lipgloss.NewStyle().
+	Padding(0, 1).
+	Foreground(lipgloss.AdaptiveColor{Light: "#555555", Dark: "#aaaaaa"})
+
+// maxSidebarLeases caps the file lease lines shown before collapsing the rest.
+const maxSidebarLeases = 8
+
+// FileLeaseEntry is a file lease shown in the sidebar.
+type FileLeaseEntry struct {
+	Pattern   string
+	Holder    string
+	Exclusive bool
+}
+
 // Sidebar is the left-most panel showing topics and search.
 type Sidebar struct {
 	items         []SidebarItem
@@ -93,6 +108,9 @@ type Sidebar struct {
 	searchActive bool
 	searchQuery  string
 
+	// leases are the file leases held by agents in the selected topic.
+	leases []FileLeaseEntry
+
 	repoName    string // current repo name shown at bottom
 	repoHovered bool   // true when mouse is hovering over the repo button
 
@@ -126,6 +144,11 @@ func (s *Sidebar) IsFocused() bool {
 	return s.focused
 }
 
+// SetFileLeases sets the file leases listed below the topics.
+func (s *Sidebar) SetFileLeases(leases []FileLeaseEntry) {
+	s.leases = leases
+}
+
 // SetRepoName sets the current repo name displayed at the bottom of the sidebar.
 func (s *Sidebar) SetRepoName(name string) {
 	s.repoName = name
@@ -620,6 +643,21 @@ func (s *Sidebar) String() string {
 		b.WriteString("\n")
 	}
 
+	if len(s.leases) > 0 && !s.searchActive {
+		b.WriteString("\n")
+		b.WriteString(sectionHeaderStyle.Render("── Files ──"))
+		b.WriteString("\n")
+		for i, l := range s.leases {
+			if i == maxSidebarLeases {
+				b.WriteString(sidebarLeaseStyle.Width(itemWidth).Render(fmt.Sprintf(" +%d more", len(s.leases)-i)))
+				b.WriteString("\n")
+				break
+			}
+			b.WriteString(sidebarLeaseStyle.Width(itemWidth).Render(leaseLine(l, itemWidth-2)))
+			b.WriteString("\n")
+		}
+	}
+
 	// Build repo indicator as a clickable dropdown button at the bottom.
 	var repoSection string
 	if s.repoName != "" {
@@ -689,3 +727,26 @@ func (s *Sidebar) String() string {
 	bordered := borderStyle.Width(innerWidth).Height(borderHeight).Render(innerContent)
 	return lipgloss.Place(s.width, s.height, lipgloss.Left, lipgloss.Top, bordered)
 }
+
+// leaseLine formats a lease as "<lock> pattern holder", truncating the
+// pattern so the holder stays visible.
+func leaseLine(l FileLeaseEntry, width int) string {
+	icon := " "
+	if l.Exclusive {
+		icon = "\uf023"
+	}
+	holder := runewidth.Truncate(l.Holder, width/2, "…")
+	maxPattern := width - 3 - runewidth.StringWidth(holder)
+	if maxPattern < 3 {
+		maxPattern = 3
+	}
+	pattern := l.Pattern
+	if runewidth.StringWidth(pattern) > maxPattern {
+		pattern = "…" + runewidth.TruncateLeft(pattern, runewidth.StringWidth(pattern)-maxPattern+1, "")
+	}
+	gap := width - 2 - runewidth.StringWidth(pattern) - runewidth.StringWidth(holder)
+	if gap < 1 {
+		gap = 1
+	}
+	return icon + " " + pattern + strings.Repeat(" ", gap) + holder
+}
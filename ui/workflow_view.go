@@ -0,0 +1,608 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/ByteMirror/hivemind/brain"
+)
+
+// WorkflowViewAction tells the caller what to do after a key press in the workflow view.
+type WorkflowViewAction int
+
+const (
+	// WorkflowViewNone means the view handled the key itself.
+	WorkflowViewNone WorkflowViewAction = iota
+	// WorkflowViewClose means the view should be closed.
+	WorkflowViewClose
+	// WorkflowViewJump means the caller should select the selected task's instance.
+	WorkflowViewJump
+	// WorkflowViewRetry means the caller should retry the selected task.
+	WorkflowViewRetry
+	// WorkflowViewCancel means the caller should cancel the selected workflow.
+	WorkflowViewCancel
+)
+
+// WorkflowEntry is a workflow together with the repository it runs in.
+type WorkflowEntry struct {
+	RepoPath string
+	Workflow *brain.Workflow
+}
+
+const (
+	workflowBoxWidth   = 26
+	workflowBoxGap     = 3
+	workflowMarginLeft = 2
+	// workflowDetailHeight is the number of lines reserved for the selected task's details.
+	workflowDetailHeight = 9
+)
+
+// WorkflowView is a full-screen view that draws a workflow DAG as layers of
+// task boxes connected by box-drawing edges.
+type WorkflowView struct {
+	entries []WorkflowEntry
+	wfIdx   int
+
+	// levels holds the selected workflow's tasks grouped by topological depth.
+	levels   [][]*brain.WorkflowTask
+	selLevel int
+	selCol   int
+
+	scrollY, scrollX int
+	confirmCancel    bool
+	width, height    int
+}
+
+// NewWorkflowView creates a view over the given workflows. The most recently
+// defined workflow is selected.
+func NewWorkflowView(entries []WorkflowEntry) *WorkflowView {
+	v := &WorkflowView{}
+	v.SetWorkflows(entries)
+	v.wfIdx = len(v.entries) - 1
+	if v.wfIdx < 0 {
+		v.wfIdx = 0
+	}
+	v.rebuildLevels()
+	return v
+}
+
+// SetWorkflows replaces the displayed workflows, keeping the current workflow
+// and task selected when they still exist.
+func (v *WorkflowView) SetWorkflows(entries []WorkflowEntry) {
+	var wfID, taskID string
+	if e := v.SelectedWorkflow(); e != nil {
+		wfID = e.Workflow.ID
+	}
+	if t := v.SelectedTask(); t != nil {
+		taskID = t.ID
+	}
+
+	sorted := append([]WorkflowEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Workflow.ID < sorted[j].Workflow.ID
+	})
+	v.entries = sorted
+
+	v.wfIdx = 0
+	for i, e := range v.entries {
+		if e.Workflow.ID == wfID {
+			v.wfIdx = i
+			break
+		}
+	}
+	v.rebuildLevels()
+	v.selectTask(taskID)
+}
+
+// SetSize sets the view dimensions.
+func (v *WorkflowView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
+
+// SelectedWorkflow returns the workflow currently shown, or nil if there is none.
+func (v *WorkflowView) SelectedWorkflow() *WorkflowEntry {
+	if v.wfIdx < 0 || v.wfIdx >= len(v.entries) {
+		return nil
+	}
+	return &v.entries[v.wfIdx]
+}
+
+// SelectedTask returns the highlighted task, or nil if there is none.
+func (v *WorkflowView) SelectedTask() *brain.WorkflowTask {
+	if v.selLevel < 0 || v.selLevel >= len(v.levels) {
+		return nil
+	}
+	level := v.levels[v.selLevel]
+	if v.selCol < 0 || v.selCol >= len(level) {
+		return nil
+	}
+	return level[v.selCol]
+}
+
+// HandleKeyPress processes one key event and reports what the caller should do.
+func (v *WorkflowView) HandleKeyPress(msg tea.KeyMsg) WorkflowViewAction {
+	if v.confirmCancel {
+		switch msg.String() {
+		case "y":
+			v.confirmCancel = false
+			return WorkflowViewCancel
+		case "n", "esc":
+			v.confirmCancel = false
+		}
+		return WorkflowViewNone
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		return WorkflowViewClose
+	case "up", "k":
+		v.moveLevel(-1)
+	case "down", "j":
+		v.moveLevel(1)
+	case "left", "h":
+		if v.selCol > 0 {
+			v.selCol--
+		}
+	case "right", "l":
+		if v.selLevel < len(v.levels) && v.selCol < len(v.levels[v.selLevel])-1 {
+			v.selCol++
+		}
+	case "tab", "]":
+		v.cycleWorkflow(1)
+	case "shift+tab", "[":
+		v.cycleWorkflow(-1)
+	case "enter":
+		if t := v.SelectedTask(); t != nil && t.AssignedTo != "" {
+			return WorkflowViewJump
+		}
+	case "r":
+		if t := v.SelectedTask(); t != nil && retryableTask(t) {
+			return WorkflowViewRetry
+		}
+	case "x":
+		if e := v.SelectedWorkflow(); e != nil && e.Workflow.Status == brain.WorkflowRunning {
+			v.confirmCancel = true
+		}
+	}
+	return WorkflowViewNone
+}
+
+// ScrollUp scrolls the graph up by n lines.
+func (v *WorkflowView) ScrollUp(n int) {
+	v.scrollY -= n
+	if v.scrollY < 0 {
+		v.scrollY = 0
+	}
+}
+
+// ScrollDown scrolls the graph down by n lines.
+func (v *WorkflowView) ScrollDown(n int) {
+	v.scrollY += n
+}
+
+// Render returns the full-screen view.
+func (v *WorkflowView) Render() string {
+	innerW := v.width - 4
+	if innerW < 20 {
+		innerW = 20
+	}
+	graphH := v.height - workflowDetailHeight - 6
+	if graphH < 3 {
+		graphH = 3
+	}
+
+	var sb strings.Builder
+	sb.WriteString(v.renderHeader(innerW) + "\n\n")
+
+	if len(v.entries) == 0 {
+		sb.WriteString(browserDescStyle.Render("No workflows defined. Agents create them with the define_workflow tool."))
+	} else {
+		sb.WriteString(v.renderGraph(innerW, graphH) + "\n")
+		sb.WriteString(v.renderDetails(innerW))
+	}
+
+	content := lipgloss.NewStyle().Width(innerW).Height(v.height - 3).Render(sb.String())
+	return lipgloss.JoinVertical(lipgloss.Left,
+		browserListFocusedStyle.Width(v.width-2).Render(content),
+		v.renderHint(),
+	)
+}
+
+// --- private helpers ---
+
+func retryableTask(t *brain.WorkflowTask) bool {
+	switch t.Status {
+	case brain.TaskFailed, brain.TaskSkipped, brain.TaskCancelled:
+		return true
+	}
+	return false
+}
+
+func (v *WorkflowView) cycleWorkflow(delta int) {
+	if len(v.entries) == 0 {
+		return
+	}
+	v.wfIdx = (v.wfIdx + delta + len(v.entries)) % len(v.entries)
+	v.rebuildLevels()
+	v.selLevel, v.selCol = 0, 0
+	v.scrollY, v.scrollX = 0, 0
+}
+
+func (v *WorkflowView) moveLevel(delta int) {
+	next := v.selLevel + delta
+	if next < 0 || next >= len(v.levels) {
+		return
+	}
+	v.selLevel = next
+	if v.selCol >= len(v.levels[next]) {
+		v.selCol = len(v.levels[next]) - 1
+	}
+}
+
+// selectTask moves the selection to the task with the given ID, or clamps the
+// current selection when it is not found.
+func (v *WorkflowView) selectTask(taskID string) {
+	for li, level := range v.levels {
+		for ci, t := range level {
+			if t.ID == taskID {
+				v.selLevel, v.selCol = li, ci
+				return
+			}
+		}
+	}
+	if v.selLevel >= len(v.levels) {
+		v.selLevel = len(v.levels) - 1
+	}
+	if v.selLevel < 0 {
+		v.selLevel = 0
+	}
+	if v.selLevel < len(v.levels) && v.selCol >= len(v.levels[v.selLevel]) {
+		v.selCol = len(v.levels[v.selLevel]) - 1
+	}
+	if v.selCol < 0 {
+		v.selCol = 0
+	}
+}
+
+// rebuildLevels groups the selected workflow's tasks by topological depth.
+func (v *WorkflowView) rebuildLevels() {
+	v.levels = nil
+	e := v.SelectedWorkflow()
+	if e == nil {
+		return
+	}
+	byID := make(map[string]*brain.WorkflowTask, len(e.Workflow.Tasks))
+	for _, t := range e.Workflow.Tasks {
+		byID[t.ID] = t
+	}
+
+	ids, err := brain.ValidateWorkflow(e.Workflow.Tasks)
+	if err != nil {
+		// Stored workflows are validated on definition; fall back to a flat row.
+		v.levels = [][]*brain.WorkflowTask{e.Workflow.Tasks}
+		return
+	}
+	for _, level := range ids {
+		row := make([]*brain.WorkflowTask, 0, len(level))
+		for _, id := range level {
+			row = append(row, byID[id])
+		}
+		v.levels = append(v.levels, row)
+	}
+}
+
+var (
+	workflowHeaderStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#F0A868")).
+				Bold(true)
+
+	workflowEdgeStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#555555"))
+
+	workflowLabelStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#777777"))
+
+	workflowErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#de613e"))
+)
+
+// workflowStatusColor returns the color used for a task or workflow status.
+func workflowStatusColor(status string) lipgloss.Color {
+	switch status {
+	case string(brain.TaskRunning):
+		return lipgloss.Color("#74C0FC")
+	case string(brain.TaskDone):
+		return lipgloss.Color("#51bd73")
+	case string(brain.TaskFailed):
+		return lipgloss.Color("#de613e")
+	case string(brain.TaskSkipped), string(brain.TaskCancelled):
+		return lipgloss.Color("#555555")
+	default:
+		return lipgloss.Color("#888888")
+	}
+}
+
+// workflowStatusIcon returns a one-cell glyph for a task status.
+func workflowStatusIcon(status brain.TaskStatus) string {
+	switch status {
+	case brain.TaskRunning:
+		return "◐"
+	case brain.TaskDone:
+		return "✓"
+	case brain.TaskFailed:
+		return "✗"
+	case brain.TaskSkipped, brain.TaskCancelled:
+		return "⊘"
+	default:
+		return "○"
+	}
+}
+
+func (v *WorkflowView) renderHeader(width int) string {
+	e := v.SelectedWorkflow()
+	if e == nil {
+		return workflowHeaderStyle.Render("Workflows")
+	}
+	wf := e.Workflow
+
+	done := 0
+	for _, t := range wf.Tasks {
+		if t.Status == brain.TaskDone {
+			done++
+		}
+	}
+	status := lipgloss.NewStyle().Foreground(workflowStatusColor(string(wf.Status))).Render(string(wf.Status))
+	title := fmt.Sprintf("Workflow %s (%d/%d)", wf.ID, v.wfIdx+1, len(v.entries))
+	meta := fmt.Sprintf("  %s · %d/%d done · %s", status, done, len(wf.Tasks), filepath.Base(e.RepoPath))
+	if wf.CreatedBy != "" {
+		meta += " · by " + wf.CreatedBy
+	}
+	return ansi.Truncate(workflowHeaderStyle.Render(title)+workflowLabelStyle.Render(meta), width, "…")
+}
+
+// boxCenter returns the x coordinate of the middle of the box in column col.
+func boxCenter(col int) int {
+	return workflowMarginLeft + col*(workflowBoxWidth+workflowBoxGap) + workflowBoxWidth/2
+}
+
+func (v *WorkflowView) renderGraph(width, height int) string {
+	var lines []string
+	selTop, selBottom := 0, 0
+
+	for li, level := range v.levels {
+		if li > 0 {
+			lines = append(lines, v.renderEdges(v.levels[li-1], level)...)
+		}
+		if li == v.selLevel {
+			selTop = len(lines)
+		}
+		lines = append(lines, v.renderLevel(li, level)...)
+		if li == v.selLevel {
+			selBottom = len(lines)
+		}
+	}
+
+	// Keep the selected box on screen.
+	if selTop < v.scrollY {
+		v.scrollY = selTop
+	}
+	if selBottom > v.scrollY+height {
+		v.scrollY = selBottom - height
+	}
+	if maxScroll := len(lines) - height; v.scrollY > maxScroll {
+		v.scrollY = maxScroll
+	}
+	if v.scrollY < 0 {
+		v.scrollY = 0
+	}
+	left := boxCenter(v.selCol) - workflowBoxWidth/2 - workflowMarginLeft
+	right := left + workflowBoxWidth + 2*workflowMarginLeft
+	if left < v.scrollX {
+		v.scrollX = left
+	}
+	if right > v.scrollX+width {
+		v.scrollX = right - width
+	}
+	if v.scrollX < 0 {
+		v.scrollX = 0
+	}
+
+	end := v.scrollY + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	visible := make([]string, 0, height)
+	for _, line := range lines[v.scrollY:end] {
+		visible = append(visible, ansi.Cut(line, v.scrollX, v.scrollX+width))
+	}
+	for len(visible) < height {
+		visible = append(visible, "")
+	}
+	return strings.Join(visible, "\n")
+}
+
+// renderLevel draws one row of task boxes.
+func (v *WorkflowView) renderLevel(li int, level []*brain.WorkflowTask) []string {
+	boxes := make([]string, 0, 2*len(level)+1)
+	boxes = append(boxes, strings.Repeat(" ", workflowMarginLeft))
+	for ci, t := range level {
+		if ci > 0 {
+			boxes = append(boxes, strings.Repeat(" ", workflowBoxGap))
+		}
+		boxes = append(boxes, renderTaskBox(t, li == v.selLevel && ci == v.selCol))
+	}
+	return strings.Split(lipgloss.JoinHorizontal(lipgloss.Top, boxes...), "\n")
+}
+
+// renderTaskBox draws a single task: ID, status, and assigned instance.
+func renderTaskBox(t *brain.WorkflowTask, selected bool) string {
+	innerW := workflowBoxWidth - 4
+	color := workflowStatusColor(string(t.Status))
+
+	status := workflowStatusIcon(t.Status) + " " + string(t.Status)
+	if t.MaxRetries > 0 {
+		status += fmt.Sprintf(" %d/%d", t.Attempts, t.MaxRetries+1)
+	}
+	instance := "—"
+	if t.AssignedTo != "" {
+		instance = "→ " + t.AssignedTo
+	}
+
+	body := strings.Join([]string{
+		lipgloss.NewStyle().Bold(true).Render(ansi.Truncate(t.ID, innerW, "…")),
+		lipgloss.NewStyle().Foreground(color).Render(ansi.Truncate(status, innerW, "…")),
+		workflowLabelStyle.Render(ansi.Truncate(instance, innerW, "…")),
+	}, "\n")
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color).
+		Padding(0, 1).
+		Width(workflowBoxWidth - 2)
+	if selected {
+		style = style.Border(lipgloss.ThickBorder()).BorderForeground(lipgloss.Color("#F0A868"))
+	}
+	return style.Render(body)
+}
+
+// renderEdges draws the connectors between two adjacent levels: a bus line
+// joining each upper task to the lower tasks that depend on it. Dependencies
+// that skip levels are listed in the task details instead.
+func (v *WorkflowView) renderEdges(upper, lower []*brain.WorkflowTask) []string {
+	upperCol := make(map[string]int, len(upper))
+	for ci, t := range upper {
+		upperCol[t.ID] = ci
+	}
+
+	ups := make(map[int]bool)
+	downs := make(map[int]bool)
+	for ci, t := range lower {
+		for _, dep := range t.DependsOn {
+			if uc, ok := upperCol[dep]; ok {
+				ups[boxCenter(uc)] = true
+				downs[boxCenter(ci)] = true
+			}
+		}
+	}
+	if len(ups) == 0 {
+		return []string{""}
+	}
+
+	minX, maxX := -1, -1
+	for _, set := range []map[int]bool{ups, downs} {
+		for x := range set {
+			if minX < 0 || x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+		}
+	}
+
+	top := []rune(strings.Repeat(" ", maxX+1))
+	mid := []rune(strings.Repeat(" ", maxX+1))
+	bottom := []rune(strings.Repeat(" ", maxX+1))
+	for x := minX; x <= maxX; x++ {
+		mid[x] = '─'
+	}
+	for x := range ups {
+		top[x] = '│'
+	}
+	for x := range downs {
+		bottom[x] = '▼'
+	}
+	for x := minX; x <= maxX; x++ {
+		up, down := ups[x], downs[x]
+		switch {
+		case minX == maxX:
+			mid[x] = '│'
+		case x == minX:
+			mid[x] = pickRune(up, down, '├', '└', '┌')
+		case x == maxX:
+			mid[x] = pickRune(up, down, '┤', '┘', '┐')
+		case up || down:
+			mid[x] = pickRune(up, down, '┼', '┴', '┬')
+		}
+	}
+
+	return []string{
+		workflowEdgeStyle.Render(string(top)),
+		workflowEdgeStyle.Render(string(mid)),
+		workflowEdgeStyle.Render(string(bottom)),
+	}
+}
+
+// pickRune selects a junction glyph for a bus position with an edge coming
+// from above (up), going below (down), or both.
+func pickRune(up, down bool, both, upOnly, downOnly rune) rune {
+	switch {
+	case up && down:
+		return both
+	case up:
+		return upOnly
+	default:
+		return downOnly
+	}
+}
+
+// renderDetails describes the selected task.
+func (v *WorkflowView) renderDetails(width int) string {
+	t := v.SelectedTask()
+	if t == nil {
+		return ""
+	}
+
+	field := func(label, value string) string {
+		return ansi.Truncate(workflowLabelStyle.Render(fmt.Sprintf("%-9s", label))+value, width, "…")
+	}
+
+	lines := []string{
+		workflowEdgeStyle.Render(strings.Repeat("─", width)),
+		field("task", t.ID+"  "+t.Title),
+		field("status", lipgloss.NewStyle().Foreground(workflowStatusColor(string(t.Status))).Render(string(t.Status))+
+			fmt.Sprintf("  (attempt %d of %d)", t.Attempts, t.MaxRetries+1)),
+	}
+	if t.AssignedTo != "" {
+		instance := t.AssignedTo
+		if t.Branch != "" {
+			instance += "  on " + t.Branch
+		}
+		lines = append(lines, field("instance", instance))
+	}
+	if len(t.DependsOn) > 0 {
+		lines = append(lines, field("needs", strings.Join(t.DependsOn, ", ")))
+	}
+	if t.Role != "" {
+		lines = append(lines, field("role", t.Role))
+	}
+	if len(t.Outputs) > 0 {
+		data, _ := json.Marshal(t.Outputs)
+		lines = append(lines, field("outputs", string(data)))
+	}
+	if t.Error != "" {
+		lines = append(lines, field("error", workflowErrorStyle.Render(t.Error)))
+	}
+	if v.confirmCancel {
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF6B6B")).
+			Bold(true).
+			Render(fmt.Sprintf("Cancel the rest of workflow %s? [y/n]", v.SelectedWorkflow().Workflow.ID)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (v *WorkflowView) renderHint() string {
+	if v.confirmCancel {
+		return browserHintStyle.Render("  [y] cancel workflow  [n] keep running")
+	}
+	return browserHintStyle.Render("  [arrows/hjkl] select task  [enter] jump to instance  [r] retry task  [x] cancel workflow  [tab] next workflow  [esc] close")
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ByteMirror/hivemind/brain"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func testWorkflowEntries() []WorkflowEntry {
+	return []WorkflowEntry{
+		{RepoPath: "/repo", Workflow: &brain.Workflow{
+			ID:     "wf-1",
+			Status: brain.WorkflowDone,
+			Tasks:  []*brain.WorkflowTask{{ID: "old", Title: "old task", Status: brain.TaskDone}},
+		}},
+		{RepoPath: "/repo", Workflow: &brain.Workflow{
+			ID:     "wf-2",
+			Status: brain.WorkflowRunning,
+			Tasks: []*brain.WorkflowTask{
+				{ID: "design", Title: "design", Status: brain.TaskDone, AssignedTo: "design"},
+				{ID: "api", Title: "build api", Status: brain.TaskRunning, AssignedTo: "api", DependsOn: []string{"design"}},
+				{ID: "ui", Title: "build ui", Status: brain.TaskFailed, Error: "tests failed", DependsOn: []string{"design"}},
+				{ID: "ship", Title: "ship", Status: brain.TaskPending, DependsOn: []string{"api", "ui"}},
+			},
+		}},
+	}
+}
+
+func workflowKey(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestWorkflowView_SelectsLatestWorkflow(t *testing.T) {
+	v := NewWorkflowView(testWorkflowEntries())
+	if e := v.SelectedWorkflow(); e == nil || e.Workflow.ID != "wf-2" {
+		t.Fatalf("expected the latest workflow selected, got %+v", e)
+	}
+	if task := v.SelectedTask(); task == nil || task.ID != "design" {
+		t.Fatalf("expected the root task selected, got %+v", task)
+	}
+
+	v.HandleKeyPress(workflowKey("tab"))
+	if e := v.SelectedWorkflow(); e.Workflow.ID != "wf-1" {
+		t.Errorf("expected tab to cycle to wf-1, got %s", e.Workflow.ID)
+	}
+}
+
+func TestWorkflowView_RenderDrawsLevelsAndEdges(t *testing.T) {
+	v := NewWorkflowView(testWorkflowEntries())
+	v.SetSize(120, 50)
+	out := ansi.Strip(v.Render())
+
+	for _, want := range []string{"design", "api", "ui", "ship", "✗ failed", "▼", "├", "┘"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected render to contain %q", want)
+		}
+	}
+
+	// Levels are drawn top to bottom in dependency order.
+	design := strings.Index(out, "design")
+	api := strings.Index(out, "◐ running")
+	ship := strings.Index(out, "ship")
+	if !(design < api && api < ship) {
+		t.Errorf("expected design above api above ship, got offsets %d, %d, %d", design, api, ship)
+	}
+}
+
+func TestWorkflowView_Actions(t *testing.T) {
+	v := NewWorkflowView(testWorkflowEntries())
+
+	if got := v.HandleKeyPress(workflowKey("r")); got != WorkflowViewNone {
+		t.Errorf("retrying a done task should do nothing, got %v", got)
+	}
+	if got := v.HandleKeyPress(workflowKey("enter")); got != WorkflowViewJump {
+		t.Errorf("enter on an assigned task should jump, got %v", got)
+	}
+
+	v.HandleKeyPress(workflowKey("down"))
+	v.HandleKeyPress(workflowKey("right"))
+	if task := v.SelectedTask(); task == nil || task.ID != "ui" {
+		t.Fatalf("expected ui selected, got %+v", task)
+	}
+	if got := v.HandleKeyPress(workflowKey("enter")); got != WorkflowViewNone {
+		t.Errorf("enter on an unassigned task should do nothing, got %v", got)
+	}
+	if got := v.HandleKeyPress(workflowKey("r")); got != WorkflowViewRetry {
+		t.Errorf("r on a failed task should retry, got %v", got)
+	}
+	if got := v.HandleKeyPress(workflowKey("esc")); got != WorkflowViewClose {
+		t.Errorf("esc should close, got %v", got)
+	}
+}
+
+func TestWorkflowView_CancelRequiresConfirmation(t *testing.T) {
+	v := NewWorkflowView(testWorkflowEntries())
+	v.SetSize(120, 50)
+
+	if got := v.HandleKeyPress(workflowKey("x")); got != WorkflowViewNone {
+		t.Fatalf("x should ask for confirmation first, got %v", got)
+	}
+	if got := v.HandleKeyPress(workflowKey("n")); got != WorkflowViewNone {
+		t.Fatalf("n should dismiss the confirmation, got %v", got)
+	}
+	if got := v.HandleKeyPress(workflowKey("esc")); got != WorkflowViewClose {
+		t.Fatalf("esc after dismissing should close, got %v", got)
+	}
+
+	v.HandleKeyPress(workflowKey("x"))
+	if got := v.HandleKeyPress(workflowKey("y")); got != WorkflowViewCancel {
+		t.Errorf("y should confirm the cancel, got %v", got)
+	}
+
+	// Finished workflows cannot be cancelled.
+	v.HandleKeyPress(workflowKey("tab"))
+	v.HandleKeyPress(workflowKey("x"))
+	if got := v.HandleKeyPress(workflowKey("y")); got != WorkflowViewNone {
+		t.Errorf("cancelling a finished workflow should do nothing, got %v", got)
+	}
+}
+
+func TestWorkflowView_SetWorkflowsKeepsSelection(t *testing.T) {
+	entries := testWorkflowEntries()
+	v := NewWorkflowView(entries)
+	v.HandleKeyPress(workflowKey("down"))
+	v.HandleKeyPress(workflowKey("right"))
+
+	v.SetWorkflows(testWorkflowEntries())
+	if e := v.SelectedWorkflow(); e.Workflow.ID != "wf-2" {
+		t.Errorf("expected wf-2 to stay selected, got %s", e.Workflow.ID)
+	}
+	if task := v.SelectedTask(); task == nil || task.ID != "ui" {
+		t.Errorf("expected ui to stay selected, got %+v", task)
+	}
+}
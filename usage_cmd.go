@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ByteMirror/hivemind/config"
+	"github.com/ByteMirror/hivemind/log"
+	"github.com/ByteMirror/hivemind/session"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageJSON bool
+
+	usageCmd = &cobra.Command{
+		Use:          "usage",
+		Short:        "Report model tokens and estimated cost per instance, topic, workflow and automation",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			var instances []session.InstanceData
+			if err := json.Unmarshal(config.LoadState().GetInstances(), &instances); err != nil {
+				return fmt.Errorf("failed to read instances: %w", err)
+			}
+
+			automations := make(map[string]string)
+			autos, err := config.LoadAutomations()
+			if err != nil {
+				return err
+			}
+			for _, a := range autos {
+				automations[a.ID] = a.Name
+			}
+
+			report := session.NewUsageReport(instances, usageWorkflows(instances), automations)
+			if usageJSON {
+				return printJSON(report)
+			}
+			if report.Total.Tokens.Total() == 0 {
+				fmt.Println("No token usage recorded yet.")
+				return nil
+			}
+			fmt.Print(report.String())
+			return nil
+		},
+	}
+)
+
+// usageWorkflows maps the workflows of the instances' repositories to the
+// instances their tasks ran in. Workflows are only known while hivemind
+// runs; without it the report has none.
+func usageWorkflows(instances []session.InstanceData) map[string][]string {
+	client, err := brainClient()
+	if err != nil {
+		return nil
+	}
+	workflows := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, data := range instances {
+		repoPath := data.Worktree.RepoPath
+		if repoPath == "" || seen[repoPath] {
+			continue
+		}
+		seen[repoPath] = true
+		list, err := client.ListWorkflows(repoPath, "")
+		if err != nil {
+			continue
+		}
+		for _, wf := range list {
+			for _, task := range wf.Tasks {
+				if task.AssignedTo != "" {
+					workflows[wf.ID] = append(workflows[wf.ID], task.AssignedTo)
+				}
+			}
+		}
+	}
+	return workflows
+}
+
+func init() {
+	usageCmd.Flags().BoolVar(&usageJSON, "json", false, "Print machine-readable JSON")
+}
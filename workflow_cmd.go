@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ByteMirror/hivemind/brain"
+	"github.com/ByteMirror/hivemind/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	workflowParams []string
+
+	workflowCmd = &cobra.Command{
+		Use:   "workflow",
+		Short: "Run workflows defined in .hivemind/workflows/",
+	}
+
+	workflowListCmd = &cobra.Command{
+		Use:          "list",
+		Short:        "List the workflow definitions in this repository",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := filepath.Abs(".")
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			defs, err := brain.LoadWorkflowDefinitions(repoPath)
+			if err != nil {
+				return err
+			}
+			if len(defs) == 0 {
+				fmt.Printf("No workflows found in %s\n", filepath.Join(repoPath, brain.WorkflowsDir))
+				return nil
+			}
+			for _, def := range defs {
+				fmt.Printf("%s\t%s\n", def.Name, def.Description)
+				for _, p := range def.Params {
+					var notes []string
+					if p.Required {
+						notes = append(notes, "required")
+					}
+					if p.Default != "" {
+						notes = append(notes, "default "+p.Default)
+					}
+					line := "  --param " + p.Name + "=..."
+					if len(notes) > 0 {
+						line += " (" + strings.Join(notes, ", ") + ")"
+					}
+					if p.Description != "" {
+						line += "  " + p.Description
+					}
+					fmt.Println(line)
+				}
+			}
+			return nil
+		},
+	}
+
+	workflowRunCmd = &cobra.Command{
+		Use:          "run <name>",
+		Short:        "Start a workflow on the running hivemind instance",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := filepath.Abs(".")
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			params, err := parseParamFlags(workflowParams)
+			if err != nil {
+				return err
+			}
+
+			def, err := brain.LoadWorkflowDefinition(repoPath, args[0])
+			if err != nil {
+				return err
+			}
+			tasks, err := def.Instantiate(params)
+			if err != nil {
+				return err
+			}
+
+			client, err := brainClient()
+			if err != nil {
+				return err
+			}
+			result, err := client.DefineWorkflow(repoPath, "", tasks)
+			if err != nil {
+				return fmt.Errorf("failed to start workflow: %w", err)
+			}
+
+			fmt.Printf("Started workflow %s (%s) with %d tasks\n", def.Name, result.WorkflowID, len(tasks))
+			if len(result.Triggered) > 0 {
+				fmt.Printf("Spawned: %s\n", strings.Join(result.Triggered, ", "))
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	workflowRunCmd.Flags().StringArrayVar(&workflowParams, "param", nil,
+		"Workflow parameter as key=value (repeatable)")
+	workflowCmd.AddCommand(workflowListCmd)
+	workflowCmd.AddCommand(workflowRunCmd)
+}
+
+// parseParamFlags turns repeated key=value flags into a map.
+func parseParamFlags(flags []string) (map[string]string, error) {
+	params := make(map[string]string, len(flags))
+	for _, f := range flags {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --param %q (use key=value)", f)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+// brainClient connects to the brain server of a running TUI or daemon.
+func brainClient() (*brain.Client, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	client := brain.NewClient(filepath.Join(configDir, "hivemind.sock"))
+	if err := client.Ping(); err != nil {
+		return nil, fmt.Errorf("hivemind is not running (start it in this repository first): %w", err)
+	}
+	// The CLI acts as the user rather than an instance, so it sends the
+	// operator key and no instance ID.
+	key, err := brain.LoadOperatorKey(configDir)
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken("", key)
+	return client, nil
+}